@@ -2,16 +2,31 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"database/sql"
+	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
 
 	"github.com/sirupsen/logrus"
+	_ "modernc.org/sqlite"
 
+	"rakia-tech-test/internal/application/idempotency"
+	"rakia-tech-test/internal/application/jwt"
+	"rakia-tech-test/internal/application/ratelimit"
+	"rakia-tech-test/internal/application/scheduler"
+	"rakia-tech-test/internal/application/search"
 	"rakia-tech-test/internal/application/services"
+	"rakia-tech-test/internal/domain/clock"
+	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/repositories"
 	"rakia-tech-test/internal/infrastructure/loader"
 	memory_repositories "rakia-tech-test/internal/infrastructure/repositories"
 	"rakia-tech-test/internal/interfaces/rest"
@@ -28,18 +43,104 @@ func main() {
 		}
 	}
 
-	postRepo := memory_repositories.NewMemoryPostRepository()
+	// baseCtx is the parent of every request context. It is cancelled when the
+	// graceful shutdown runs out of time, which aborts in-flight work.
+	baseCtx, cancelBase := context.WithCancel(context.Background())
+	defer cancelBase()
 
-	dataLoader := loader.NewDataLoader(postRepo, logger)
-	if err := dataLoader.LoadFromFile("blog_data.json"); err != nil {
-		logger.WithError(err).Warn("Failed to load initial data, starting with empty repository")
+	store, err := newStorage(baseCtx, logger)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to open storage")
 	}
 
-	postService := services.NewPostService(postRepo, logger)
+	// Every write goes through the indexed repository, which keeps the
+	// search index in sync with the storage, and the scheduled repository,
+	// which keeps track of the pending publications.
+	searchIndex := search.NewIndex()
+	indexedRepo, err := search.NewIndexedRepository(baseCtx, store.posts, searchIndex)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to build search index")
+	}
+	publications := scheduler.NewQueue()
+	postRepo, err := scheduler.NewScheduledRepository(baseCtx, indexedRepo, publications)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to load scheduled publications")
+	}
+
+	// Durable storage keeps the posts written through the API, so the sample
+	// data is only used to seed an empty repository. Authors are kept in
+	// memory and rebuilt from the stored posts otherwise.
+	authorRepo := memory_repositories.NewMemoryAuthorRepository()
+	dataLoader := loader.NewDataLoader(postRepo, authorRepo, clock.System{}, logger)
+	if existing, err := postRepo.GetAll(baseCtx); err == nil && len(existing) == 0 {
+		if err := dataLoader.LoadFromFile(baseCtx, "blog_data.json"); err != nil {
+			logger.WithError(err).Warn("Failed to load initial data, starting with empty repository")
+		}
+	} else if err := dataLoader.LinkAuthors(baseCtx); err != nil {
+		logger.WithError(err).Fatal("Failed to link posts to their authors")
+	}
+
+	// Users and API keys are kept in memory.
+	userRepo := memory_repositories.NewMemoryUserRepository()
+	authService, err := newAuthService(baseCtx, userRepo, logger)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to set up authentication")
+	}
+	apiKeyService := services.NewAPIKeyService(memory_repositories.NewMemoryAPIKeyRepository(), userRepo, clock.System{}, logger)
+
+	commentRepo := memory_repositories.NewMemoryCommentRepository()
+	postService := services.NewPostService(postRepo, store.revisions, authorRepo, commentRepo, clock.System{}, logger)
+	authorService := services.NewAuthorService(authorRepo, postService, clock.System{}, logger)
+	commentService := services.NewCommentService(commentRepo, postService, clock.System{}, logger)
+	searchService := services.NewSearchService(searchIndex, postRepo, logger)
+
+	trashRetention, err := time.ParseDuration(getEnv("TRASH_RETENTION", "720h"))
+	if err != nil {
+		logger.WithError(err).Fatal("Invalid TRASH_RETENTION")
+	}
+	purgeInterval, err := time.ParseDuration(getEnv("TRASH_PURGE_INTERVAL", "1h"))
+	if err != nil || purgeInterval <= 0 {
+		logger.WithField("value", os.Getenv("TRASH_PURGE_INTERVAL")).Fatal("Invalid TRASH_PURGE_INTERVAL")
+	}
+
+	purgerCtx, stopPurger := context.WithCancel(baseCtx)
+	purgerDone := make(chan struct{})
+	go func() {
+		defer close(purgerDone)
+		services.NewTrashPurger(postService, trashRetention, purgeInterval, logger).Run(purgerCtx)
+	}()
+
+	schedulerCtx, stopScheduler := context.WithCancel(baseCtx)
+	schedulerDone := make(chan struct{})
+	go func() {
+		defer close(schedulerDone)
+		scheduler.NewScheduler(publications, postService, clock.System{}, logger).Run(schedulerCtx)
+	}()
 
 	postHandler := rest.NewPostHandler(postService, logger)
+	authorHandler := rest.NewAuthorHandler(authorService, logger)
+	commentHandler := rest.NewCommentHandler(commentService, logger)
+	searchHandler := rest.NewSearchHandler(searchService, logger)
+	authHandler := rest.NewAuthHandler(authService, logger)
+	apiKeyHandler := rest.NewAPIKeyHandler(apiKeyService, logger)
 
-	r := rest.SetupRouter(postHandler, logger)
+	rateLimiter, err := newRateLimiter()
+	if err != nil {
+		logger.WithError(err).Fatal("Invalid rate limits")
+	}
+
+	idempotencyTTL, err := time.ParseDuration(getEnv("IDEMPOTENCY_TTL", "24h"))
+	if err != nil || idempotencyTTL <= 0 {
+		logger.WithField("value", os.Getenv("IDEMPOTENCY_TTL")).Fatal("Invalid IDEMPOTENCY_TTL")
+	}
+	idempotencyStore := idempotency.NewStore(idempotencyTTL, clock.System{})
+
+	r := rest.SetupRouter(postHandler, authorHandler, commentHandler, searchHandler, authHandler, apiKeyHandler, rateLimiter, idempotencyStore, logger)
+	if proxies := os.Getenv("TRUSTED_PROXIES"); proxies != "" {
+		if err := r.SetTrustedProxies(strings.Split(proxies, ",")); err != nil {
+			logger.WithError(err).Fatal("Invalid TRUSTED_PROXIES")
+		}
+	}
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -49,6 +150,9 @@ func main() {
 	srv := &http.Server{
 		Addr:    ":" + port,
 		Handler: r,
+		BaseContext: func(net.Listener) context.Context {
+			return baseCtx
+		},
 	}
 
 	var wg sync.WaitGroup
@@ -70,9 +174,19 @@ func main() {
 
 		if err := srv.Shutdown(ctx); err != nil {
 			logger.WithError(err).Error("HTTP Server Shutdown Error")
+			cancelBase()
 		} else {
 			logger.Info("Server shutdown completed successfully")
 		}
+
+		stopPurger()
+		stopScheduler()
+		<-purgerDone
+		<-schedulerDone
+
+		if err := store.close(); err != nil {
+			logger.WithError(err).Error("Failed to close storage")
+		}
 	}()
 
 	logger.WithField("port", port).Info("Starting server")
@@ -84,3 +198,150 @@ func main() {
 	wg.Wait()
 	logger.Info("Server stopped")
 }
+
+// storage bundles the repositories selected by STORAGE_DRIVER with a
+// function releasing their resources.
+type storage struct {
+	posts     repositories.PostRepository
+	revisions repositories.RevisionRepository
+	close     func() error
+}
+
+// newStorage builds the repositories selected by STORAGE_DRIVER. The file
+// driver persists posts only; their revision history is kept in memory.
+func newStorage(ctx context.Context, logger *logrus.Logger) (*storage, error) {
+	switch driver := getEnv("STORAGE_DRIVER", "memory"); driver {
+	case "memory":
+		return &storage{
+			posts:     memory_repositories.NewMemoryPostRepository(),
+			revisions: memory_repositories.NewMemoryRevisionRepository(),
+			close:     func() error { return nil },
+		}, nil
+	case "file":
+		dataDir := getEnv("DATA_DIR", "data")
+		compactEvery, err := strconv.Atoi(getEnv("WAL_COMPACT_EVERY", strconv.Itoa(memory_repositories.DefaultCompactEvery)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid WAL_COMPACT_EVERY: %w", err)
+		}
+
+		repo, err := memory_repositories.NewFilePostRepository(dataDir, compactEvery)
+		if err != nil {
+			return nil, err
+		}
+
+		logger.WithField("data_dir", dataDir).Info("Using file-backed post repository")
+		return &storage{
+			posts:     repo,
+			revisions: memory_repositories.NewMemoryRevisionRepository(),
+			close:     repo.Close,
+		}, nil
+	case "sql":
+		dbDriver := getEnv("DATABASE_DRIVER", "sqlite")
+		dsn := getEnv("DATABASE_DSN", "file:blog.db?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+
+		db, err := sql.Open(dbDriver, dsn)
+		if err != nil {
+			return nil, err
+		}
+		if err := db.PingContext(ctx); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("connect to database: %w", err)
+		}
+
+		repo, err := memory_repositories.NewSQLPostRepository(ctx, db)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		revisionRepo, err := memory_repositories.NewSQLRevisionRepository(ctx, db)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+
+		logger.WithField("database_driver", dbDriver).Info("Using SQL post repository")
+		return &storage{
+			posts:     repo,
+			revisions: revisionRepo,
+			close:     db.Close,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown STORAGE_DRIVER %q", driver)
+	}
+}
+
+// newAuthService sets up token authentication. Tokens are signed with
+// JWT_SECRET; without it a random key is used, so tokens do not survive a
+// restart. userRepo is seeded with the account named by AUTH_USERNAME and
+// AUTH_PASSWORD when both are set, with the role AUTH_ROLE (default admin).
+func newAuthService(ctx context.Context, userRepo repositories.UserRepository, logger *logrus.Logger) (*services.AuthService, error) {
+	secret := []byte(os.Getenv("JWT_SECRET"))
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+		logger.Warn("JWT_SECRET is not set, tokens will not survive a restart")
+	}
+
+	accessTTL, err := time.ParseDuration(getEnv("ACCESS_TOKEN_TTL", services.DefaultAccessTokenTTL.String()))
+	if err != nil || accessTTL <= 0 {
+		return nil, fmt.Errorf("invalid ACCESS_TOKEN_TTL %q", os.Getenv("ACCESS_TOKEN_TTL"))
+	}
+	refreshTTL, err := time.ParseDuration(getEnv("REFRESH_TOKEN_TTL", services.DefaultRefreshTokenTTL.String()))
+	if err != nil || refreshTTL <= 0 {
+		return nil, fmt.Errorf("invalid REFRESH_TOKEN_TTL %q", os.Getenv("REFRESH_TOKEN_TTL"))
+	}
+
+	authService := services.NewAuthService(userRepo, jwt.NewSigner(secret), accessTTL, refreshTTL, clock.System{}, logger)
+
+	username, password := os.Getenv("AUTH_USERNAME"), os.Getenv("AUTH_PASSWORD")
+	if username != "" && password != "" {
+		role := entities.Role(getEnv("AUTH_ROLE", string(entities.RoleAdmin)))
+		if _, err := authService.CreateUser(ctx, username, getEnv("AUTH_NAME", username), role, password); err != nil {
+			return nil, fmt.Errorf("create user %q: %w", username, err)
+		}
+	} else {
+		logger.Warn("AUTH_USERNAME and AUTH_PASSWORD are not set, nobody can sign in")
+	}
+
+	return authService, nil
+}
+
+// newRateLimiter sets up the request budgets of every client:
+// RATE_LIMIT_READS reads and RATE_LIMIT_WRITES writes per
+// RATE_LIMIT_PERIOD. A budget of 0 turns its limit off.
+func newRateLimiter() (*rest.RateLimiter, error) {
+	period, err := time.ParseDuration(getEnv("RATE_LIMIT_PERIOD", "1m"))
+	if err != nil || period <= 0 {
+		return nil, fmt.Errorf("invalid RATE_LIMIT_PERIOD %q", os.Getenv("RATE_LIMIT_PERIOD"))
+	}
+
+	limiter := func(key, fallback string) (*ratelimit.Limiter, error) {
+		requests, err := strconv.Atoi(getEnv(key, fallback))
+		if err != nil || requests < 0 {
+			return nil, fmt.Errorf("invalid %s %q", key, os.Getenv(key))
+		}
+		if requests == 0 {
+			return nil, nil
+		}
+		return ratelimit.NewLimiter(ratelimit.Limit{Requests: requests, Period: period}, clock.System{}), nil
+	}
+
+	reads, err := limiter("RATE_LIMIT_READS", "300")
+	if err != nil {
+		return nil, err
+	}
+	writes, err := limiter("RATE_LIMIT_WRITES", "60")
+	if err != nil {
+		return nil, err
+	}
+	return rest.NewRateLimiter(reads, writes), nil
+}
+
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,245 @@
+// Package diff computes line and word level differences between two texts.
+package diff
+
+import (
+	"regexp"
+	"strings"
+)
+
+type Op string
+
+const (
+	Equal  Op = "equal"
+	Insert Op = "insert"
+	Delete Op = "delete"
+)
+
+// Edit is one run of text that is kept, inserted or deleted. Concatenating
+// the Equal and Delete edits yields the old text; Equal and Insert edits
+// yield the new one.
+type Edit struct {
+	Op   Op     `json:"op"`
+	Text string `json:"text"`
+}
+
+// Mode selects the unit a diff is computed over.
+type Mode string
+
+const (
+	LineMode Mode = "line"
+	WordMode Mode = "word"
+)
+
+var wordPattern = regexp.MustCompile(`\s+|\S+`)
+
+// Lines diffs a and b line by line. Every line keeps its trailing newline.
+func Lines(a, b string) []Edit {
+	return compute(splitLines(a), splitLines(b))
+}
+
+// Words diffs a and b word by word. Runs of whitespace are tokens of their
+// own, so whitespace changes show up in the result as well.
+func Words(a, b string) []Edit {
+	return compute(wordPattern.FindAllString(a, -1), wordPattern.FindAllString(b, -1))
+}
+
+// Compute diffs a and b in the given mode. It reports false for an unknown
+// mode.
+func Compute(mode Mode, a, b string) ([]Edit, bool) {
+	switch mode {
+	case LineMode:
+		return Lines(a, b), true
+	case WordMode:
+		return Words(a, b), true
+	default:
+		return nil, false
+	}
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// maxCost bounds the work of one diff, counted in diagonals explored by
+// the Myers search. Parts of the texts still unmatched when it runs out are
+// reported as deleted and inserted whole, so very different texts cost
+// bounded time at the price of a longer edit script.
+const maxCost = 1 << 22
+
+// compute runs the linear space variant of the Myers algorithm: it finds
+// the middle snake of a shortest edit script and recurses on both sides of
+// it. That takes O((n+m)d) time and O(n+m) space for n and m tokens and d
+// differences.
+func compute(a, b []string) []Edit {
+	d := &differ{a: a, b: b, budget: maxCost, edits: []Edit{}}
+	d.diff(0, len(a), 0, len(b))
+	return d.edits
+}
+
+// differ builds the edit script of a and b.
+type differ struct {
+	a, b   []string
+	budget int
+	edits  []Edit
+}
+
+// diff appends the edits turning a[a0:a1] into b[b0:b1].
+func (d *differ) diff(a0, a1, b0, b1 int) {
+	prefix := 0
+	for a0+prefix < a1 && b0+prefix < b1 && d.a[a0+prefix] == d.b[b0+prefix] {
+		prefix++
+	}
+	d.emit(Equal, d.a[a0:a0+prefix])
+	a0, b0 = a0+prefix, b0+prefix
+
+	suffix := 0
+	for a1-suffix > a0 && b1-suffix > b0 && d.a[a1-suffix-1] == d.b[b1-suffix-1] {
+		suffix++
+	}
+	a1, b1 = a1-suffix, b1-suffix
+
+	if x, y, ok := d.middleSnake(a0, a1, b0, b1); ok {
+		d.diff(a0, x, b0, y)
+		d.diff(x, a1, y, b1)
+	} else {
+		d.emit(Delete, d.a[a0:a1])
+		d.emit(Insert, d.b[b0:b1])
+	}
+
+	d.emit(Equal, d.a[a1:a1+suffix])
+}
+
+// middleSnake searches for a shortest edit script of a[a0:a1] and
+// b[b0:b1] from both ends at once, and returns a point on the path where
+// the two searches meet. The parts before and after it are both smaller
+// than the whole. It reports false when there is nothing to split, and when
+// the budget runs out.
+func (d *differ) middleSnake(a0, a1, b0, b1 int) (int, int, bool) {
+	n, m := a1-a0, b1-b0
+	if n == 0 || m == 0 {
+		return 0, 0, false
+	}
+
+	// forward[offset+k] is how far the forward search got on diagonal k,
+	// x-y=k, and backward[offset+k] how far the backward search got from
+	// the ends on diagonal k of the reversed texts. -1 marks diagonals not
+	// reached yet.
+	maxD := (n + m + 1) / 2
+	offset := maxD
+	forward := make([]int, 2*maxD+2)
+	backward := make([]int, 2*maxD+2)
+	for i := range forward {
+		forward[i], backward[i] = -1, -1
+	}
+	forward[offset+1], backward[offset+1] = 0, 0
+
+	// When delta is odd the searches can only meet on a forward step,
+	// otherwise on a backward one. Diagonal k of the backward search is
+	// diagonal delta-k of the forward one. The start and end counters trim
+	// diagonals that ran off the edges of the texts.
+	delta := n - m
+	odd := delta%2 != 0
+	var forwardStart, forwardEnd, backwardStart, backwardEnd int
+	for step := 0; step < maxD; step++ {
+		d.budget -= 2*step + 1
+		if d.budget < 0 {
+			return 0, 0, false
+		}
+
+		for k := -step + forwardStart; k <= step-forwardEnd; k += 2 {
+			var x int
+			if k == -step || (k != step && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && d.a[a0+x] == d.b[b0+y] {
+				x++
+				y++
+			}
+			forward[offset+k] = x
+
+			switch {
+			case x > n:
+				forwardEnd += 2
+			case y > m:
+				forwardStart += 2
+			case odd:
+				if j := offset + delta - k; j >= 0 && j < len(backward) && backward[j] != -1 && x >= n-backward[j] {
+					return d.split(a0, a1, b0, b1, x, y)
+				}
+			}
+		}
+
+		for k := -step + backwardStart; k <= step-backwardEnd; k += 2 {
+			var x int
+			if k == -step || (k != step && backward[offset+k-1] < backward[offset+k+1]) {
+				x = backward[offset+k+1]
+			} else {
+				x = backward[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && d.a[a1-x-1] == d.b[b1-y-1] {
+				x++
+				y++
+			}
+			backward[offset+k] = x
+
+			switch {
+			case x > n:
+				backwardEnd += 2
+			case y > m:
+				backwardStart += 2
+			case !odd:
+				if j := offset + delta - k; j >= 0 && j < len(forward) && forward[j] != -1 {
+					fx := forward[j]
+					if fx >= n-x {
+						return d.split(a0, a1, b0, b1, fx, offset+fx-j)
+					}
+				}
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// split turns the meeting point x, y of middleSnake, relative to a0 and
+// b0, into a split point. It reports false for a point at either end,
+// which would not make the problem any smaller.
+func (d *differ) split(a0, a1, b0, b1, x, y int) (int, int, bool) {
+	if (x == 0 && y == 0) || (a0+x == a1 && b0+y == b1) {
+		return 0, 0, false
+	}
+	return a0 + x, b0 + y, true
+}
+
+// emit appends the edits of tokens. Neighbouring edits are merged, and
+// within a run of changes deletions come before insertions.
+func (d *differ) emit(op Op, tokens []string) {
+	if len(tokens) == 0 {
+		return
+	}
+	text := strings.Join(tokens, "")
+
+	last := len(d.edits) - 1
+	switch {
+	case last >= 0 && d.edits[last].Op == op:
+		d.edits[last].Text += text
+	case op == Delete && last >= 0 && d.edits[last].Op == Insert:
+		if last > 0 && d.edits[last-1].Op == Delete {
+			d.edits[last-1].Text += text
+		} else {
+			d.edits = append(d.edits[:last], Edit{Op: Delete, Text: text}, d.edits[last])
+		}
+	default:
+		d.edits = append(d.edits, Edit{Op: op, Text: text})
+	}
+}
@@ -0,0 +1,188 @@
+package diff
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     string
+		expected []Edit
+	}{
+		{
+			name:     "identical",
+			a:        "one\ntwo\n",
+			b:        "one\ntwo\n",
+			expected: []Edit{{Op: Equal, Text: "one\ntwo\n"}},
+		},
+		{
+			name:     "both empty",
+			a:        "",
+			b:        "",
+			expected: []Edit{},
+		},
+		{
+			name:     "from empty",
+			a:        "",
+			b:        "one\n",
+			expected: []Edit{{Op: Insert, Text: "one\n"}},
+		},
+		{
+			name: "changed middle line",
+			a:    "one\ntwo\nthree\n",
+			b:    "one\n2\nthree\n",
+			expected: []Edit{
+				{Op: Equal, Text: "one\n"},
+				{Op: Delete, Text: "two\n"},
+				{Op: Insert, Text: "2\n"},
+				{Op: Equal, Text: "three\n"},
+			},
+		},
+		{
+			name: "last line without newline",
+			a:    "one\ntwo",
+			b:    "one\ntwo\nthree",
+			expected: []Edit{
+				{Op: Equal, Text: "one\n"},
+				{Op: Delete, Text: "two"},
+				{Op: Insert, Text: "two\nthree"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Lines(tt.a, tt.b))
+		})
+	}
+}
+
+func TestWords(t *testing.T) {
+	edits := Words("the quick brown fox", "the slow brown fox jumps")
+
+	assert.Equal(t, []Edit{
+		{Op: Equal, Text: "the "},
+		{Op: Delete, Text: "quick"},
+		{Op: Insert, Text: "slow"},
+		{Op: Equal, Text: " brown fox"},
+		{Op: Insert, Text: " jumps"},
+	}, edits)
+}
+
+func TestCompute_ReconstructsBothSides(t *testing.T) {
+	a := "Lorem ipsum dolor sit amet,\nconsectetur adipiscing elit.\nSed do eiusmod tempor.\n"
+	b := "Lorem ipsum sit amet,\nconsectetur elit, sed.\nUt enim ad minim.\nSed do eiusmod tempor.\n"
+
+	for _, mode := range []Mode{LineMode, WordMode} {
+		edits, ok := Compute(mode, a, b)
+		assert.True(t, ok)
+
+		var oldText, newText strings.Builder
+		for _, edit := range edits {
+			if edit.Op != Insert {
+				oldText.WriteString(edit.Text)
+			}
+			if edit.Op != Delete {
+				newText.WriteString(edit.Text)
+			}
+		}
+		assert.Equal(t, a, oldText.String(), "mode %s", mode)
+		assert.Equal(t, b, newText.String(), "mode %s", mode)
+	}
+
+	_, ok := Compute("char", a, b)
+	assert.False(t, ok)
+}
+
+func TestCompute_DifferentTexts(t *testing.T) {
+	words := func(prefix string, n int) string {
+		var text strings.Builder
+		for i := 0; i < n; i++ {
+			text.WriteString(prefix + strconv.Itoa(i) + " ")
+			if i%10 == 9 {
+				text.WriteString("\n")
+			}
+		}
+		return text.String()
+	}
+
+	for _, size := range []int{3, 100, 20000} {
+		a, b := words("old", size), words("new", size)
+		for _, mode := range []Mode{LineMode, WordMode} {
+			start := time.Now()
+			edits, _ := Compute(mode, a, b)
+			assert.Less(t, time.Since(start), 2*time.Second, "%d words in mode %s", size, mode)
+
+			var oldText, newText strings.Builder
+			for _, edit := range edits {
+				if edit.Op != Insert {
+					oldText.WriteString(edit.Text)
+				}
+				if edit.Op != Delete {
+					newText.WriteString(edit.Text)
+				}
+			}
+			assert.Equal(t, a, oldText.String(), "%d words in mode %s", size, mode)
+			assert.Equal(t, b, newText.String(), "%d words in mode %s", size, mode)
+		}
+	}
+}
+
+func TestCompute_Minimal(t *testing.T) {
+	a := strings.Split("a b c a b b a", " ")
+	b := strings.Split("c b a b a c", " ")
+
+	changes := 0
+	for _, edit := range compute(a, b) {
+		if edit.Op != Equal {
+			changes += len(edit.Text)
+		}
+	}
+	assert.Equal(t, 5, changes, "the shortest edit script has 5 edits")
+}
+
+func TestCompute_MatchesLongestCommonSubsequence(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	tokens := func() []string {
+		out := make([]string, random.Intn(30))
+		for i := range out {
+			out[i] = string(rune('a' + random.Intn(4)))
+		}
+		return out
+	}
+
+	for i := 0; i < 500; i++ {
+		a, b := tokens(), tokens()
+
+		// lcs[i][j] is the length of the longest common subsequence of
+		// a[i:] and b[j:].
+		lcs := make([][]int, len(a)+1)
+		for i := range lcs {
+			lcs[i] = make([]int, len(b)+1)
+		}
+		for i := len(a) - 1; i >= 0; i-- {
+			for j := len(b) - 1; j >= 0; j-- {
+				if a[i] == b[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else {
+					lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+				}
+			}
+		}
+
+		equal := 0
+		for _, edit := range compute(a, b) {
+			if edit.Op == Equal {
+				equal += len(edit.Text)
+			}
+		}
+		assert.Equal(t, lcs[0][0], equal, "%q to %q", a, b)
+	}
+}
@@ -0,0 +1,145 @@
+// Package idempotency lets clients retry requests safely. A client tags a
+// request with a key of its choosing; the response to the first request
+// with the key is stored and replayed to every retry, so the request takes
+// effect once however often it is sent.
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"rakia-tech-test/internal/domain/clock"
+)
+
+// ErrKeyReused means the key was first used for a different request.
+var ErrKeyReused = errors.New("idempotency key was already used for a different request")
+
+// Response is a stored response, replayed to retries.
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// entry is the state of one key. Until done is closed the first request
+// with the key is still in progress, and response is nil.
+type entry struct {
+	fingerprint string
+	done        chan struct{}
+	response    *Response
+	expiresAt   time.Time
+}
+
+// Store holds the responses to keyed requests for TTL after they complete.
+// Expired responses are evicted once per TTL. It is safe for concurrent
+// use.
+type Store struct {
+	ttl       time.Duration
+	clock     clock.Clock
+	entries   map[string]*entry
+	lastSweep time.Time
+	mutex     sync.Mutex
+}
+
+func NewStore(ttl time.Duration, clock clock.Clock) *Store {
+	return &Store{
+		ttl:       ttl,
+		clock:     clock,
+		entries:   make(map[string]*entry),
+		lastSweep: clock.Now(),
+	}
+}
+
+// Begin claims key for a request identified by fingerprint, such as a hash
+// of its body. It returns the stored response when the key has already
+// been used for the same request. Otherwise it returns nil, and the caller
+// handles the request and has to call Complete or Release afterwards.
+//
+// While a request with the key is in progress, Begin waits for it to end,
+// or for ctx to be done. Keys used for a different fingerprint fail with
+// ErrKeyReused.
+func (s *Store) Begin(ctx context.Context, key, fingerprint string) (*Response, error) {
+	for {
+		s.mutex.Lock()
+		now := s.clock.Now()
+		s.sweep(now)
+
+		e, ok := s.entries[key]
+		if ok && e.response != nil && !now.Before(e.expiresAt) {
+			ok = false
+		}
+		if !ok {
+			s.entries[key] = &entry{fingerprint: fingerprint, done: make(chan struct{})}
+			s.mutex.Unlock()
+			return nil, nil
+		}
+		response := e.response
+		s.mutex.Unlock()
+
+		if e.fingerprint != fingerprint {
+			return nil, ErrKeyReused
+		}
+		if response != nil {
+			return response, nil
+		}
+
+		// The request in progress either completes, and its response is
+		// replayed, or is released, and the key is claimed again.
+		select {
+		case <-e.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Complete stores the response to the request that claimed key, for
+// retries within TTL.
+func (s *Store) Complete(key string, response *Response) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if e, ok := s.entries[key]; ok && e.response == nil {
+		e.response = response
+		e.expiresAt = s.clock.Now().Add(s.ttl)
+		close(e.done)
+	}
+}
+
+// Release gives up the claim on key without a response, for requests that
+// failed, so a retry runs again.
+func (s *Store) Release(key string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if e, ok := s.entries[key]; ok && e.response == nil {
+		delete(s.entries, key)
+		close(e.done)
+	}
+}
+
+// Len returns the number of keys held.
+func (s *Store) Len() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return len(s.entries)
+}
+
+// sweep evicts the expired responses, at most once per TTL. The caller
+// must hold the mutex.
+func (s *Store) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < s.ttl {
+		return
+	}
+	s.lastSweep = now
+
+	for key, e := range s.entries {
+		if e.response != nil && !now.Before(e.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+}
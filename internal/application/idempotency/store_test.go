@@ -0,0 +1,116 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"rakia-tech-test/internal/domain/clock"
+)
+
+func newStore() (*Store, *clock.Fake) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	return NewStore(time.Hour, fake), fake
+}
+
+func TestStore_Replay(t *testing.T) {
+	store, fake := newStore()
+	ctx := context.Background()
+
+	replay, err := store.Begin(ctx, "key", "body")
+	require.NoError(t, err)
+	assert.Nil(t, replay, "the first request is handled")
+	response := &Response{Status: 201, Body: []byte("created")}
+	store.Complete("key", response)
+
+	replay, err = store.Begin(ctx, "key", "body")
+	require.NoError(t, err)
+	assert.Equal(t, response, replay)
+	_, err = store.Begin(ctx, "key", "other body")
+	assert.ErrorIs(t, err, ErrKeyReused)
+
+	fake.Advance(time.Hour)
+	replay, err = store.Begin(ctx, "key", "other body")
+	require.NoError(t, err)
+	assert.Nil(t, replay, "expired keys can be used again")
+}
+
+func TestStore_Release(t *testing.T) {
+	store, _ := newStore()
+	ctx := context.Background()
+
+	_, err := store.Begin(ctx, "key", "body")
+	require.NoError(t, err)
+	store.Release("key")
+
+	replay, err := store.Begin(ctx, "key", "body")
+	require.NoError(t, err)
+	assert.Nil(t, replay, "failed requests run again")
+}
+
+func TestStore_EvictsExpiredResponses(t *testing.T) {
+	store, fake := newStore()
+	ctx := context.Background()
+
+	for _, key := range []string{"a", "b", "c"} {
+		_, err := store.Begin(ctx, key, "body")
+		require.NoError(t, err)
+		store.Complete(key, &Response{Status: 201})
+	}
+	_, err := store.Begin(ctx, "in progress", "body")
+	require.NoError(t, err)
+	assert.Equal(t, 4, store.Len())
+
+	fake.Advance(time.Hour)
+	_, err = store.Begin(ctx, "new", "body")
+	require.NoError(t, err)
+	assert.Equal(t, 2, store.Len(), "requests in progress are kept")
+}
+
+func TestStore_ConcurrentRequests(t *testing.T) {
+	store, _ := newStore()
+
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	handled := 0
+	replays := make([]*Response, 20)
+	for i := range replays {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			replay, err := store.Begin(context.Background(), "key", "body")
+			assert.NoError(t, err)
+			if replay == nil {
+				mutex.Lock()
+				handled++
+				mutex.Unlock()
+				time.Sleep(10 * time.Millisecond)
+				replay = &Response{Status: 201}
+				store.Complete("key", replay)
+			}
+			replays[i] = replay
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, handled)
+	for _, replay := range replays {
+		assert.Same(t, replays[0], replay)
+	}
+}
+
+func TestStore_WaitHonorsContext(t *testing.T) {
+	store, _ := newStore()
+
+	_, err := store.Begin(context.Background(), "key", "body")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = store.Begin(ctx, "key", "body")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,52 @@
+package jwt
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var now = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func TestSigner_RoundTrip(t *testing.T) {
+	signer := NewSigner([]byte("secret"))
+	claims := Claims{Subject: "42", Use: "access", ID: "abc", IssuedAt: now.Unix(), ExpiresAt: now.Add(time.Minute).Unix()}
+
+	token, err := signer.Sign(claims)
+	require.NoError(t, err)
+	assert.Equal(t, 3, len(strings.Split(token, ".")))
+
+	verified, err := signer.Verify(token, now)
+	require.NoError(t, err)
+	assert.Equal(t, claims, verified)
+
+	_, err = signer.Verify(token, now.Add(time.Minute))
+	assert.ErrorIs(t, err, ErrExpired)
+}
+
+func TestSigner_RejectsTampering(t *testing.T) {
+	signer := NewSigner([]byte("secret"))
+	token, err := signer.Sign(Claims{Subject: "42", ExpiresAt: now.Add(time.Minute).Unix()})
+	require.NoError(t, err)
+	parts := strings.Split(token, ".")
+
+	_, err = NewSigner([]byte("other")).Verify(token, now)
+	assert.ErrorIs(t, err, ErrInvalidSignature, "signed with another key")
+
+	forged := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"1","exp":9999999999}`))
+	_, err = signer.Verify(parts[0]+"."+forged+"."+parts[2], now)
+	assert.ErrorIs(t, err, ErrInvalidSignature, "payload swapped")
+
+	none := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`))
+	_, err = signer.Verify(none+"."+parts[1]+".", now)
+	assert.ErrorIs(t, err, ErrMalformed, "alg none")
+
+	for _, malformed := range []string{"", "a.b", "a.b.c.d", parts[0] + "." + parts[1] + ".!!"} {
+		_, err = signer.Verify(malformed, now)
+		assert.ErrorIs(t, err, ErrMalformed, malformed)
+	}
+}
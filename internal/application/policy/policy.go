@@ -0,0 +1,92 @@
+// Package policy decides which callers may read and change which posts. The
+// role of the caller sets how far they reach: readers change no post,
+// authors only their own, and editors and admins every post. Published posts
+// are open to everyone, but only callers who reach a post read it in the
+// other states. A post belongs to the author it names, matched like author
+// names are. Comments belong to the caller who wrote them, and only editors
+// and admins change the comments of others.
+package policy
+
+import (
+	"rakia-tech-test/internal/application/principal"
+	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/errdefs"
+	"rakia-tech-test/internal/domain/repositories"
+)
+
+var (
+	// ErrReadOnly is returned to callers whose role does not write posts.
+	ErrReadOnly = errdefs.New(errdefs.ErrForbidden, "your role does not allow writing posts")
+	// ErrNotOwner is returned to authors acting on the posts of others.
+	ErrNotOwner = errdefs.New(errdefs.ErrForbidden, "only editors may change the posts of other authors")
+	// ErrNotCommenter is returned to callers acting on the comments of
+	// others.
+	ErrNotCommenter = errdefs.New(errdefs.ErrForbidden, "only editors may change the comments of others")
+)
+
+// reach is how many posts a role may change.
+type reach int
+
+const (
+	reachNone reach = iota
+	reachOwn
+	reachAll
+)
+
+var reaches = map[entities.Role]reach{
+	entities.RoleReader: reachNone,
+	entities.RoleAuthor: reachOwn,
+	entities.RoleEditor: reachAll,
+	entities.RoleAdmin:  reachAll,
+}
+
+// Authorize returns nil if p may change a post written by author, and
+// ErrReadOnly or ErrNotOwner otherwise. Changes include creating, updating,
+// deleting and restoring the post and moving it along the workflow; for a
+// new post, or one given to another author, author is the one the post
+// names afterwards. Unknown roles may change nothing.
+func Authorize(p principal.Principal, author string) error {
+	switch reaches[p.Role] {
+	case reachAll:
+		return nil
+	case reachOwn:
+		if Owns(p, author) {
+			return nil
+		}
+		return ErrNotOwner
+	default:
+		return ErrReadOnly
+	}
+}
+
+// AuthorizeComment returns nil if p may change a comment written by author,
+// and ErrNotCommenter otherwise. Everyone comments, readers included, so
+// every known role may edit and delete their own comments; editors and
+// admins may change all of them.
+func AuthorizeComment(p principal.Principal, author string) error {
+	reach, known := reaches[p.Role]
+	if reach == reachAll || known && Owns(p, author) {
+		return nil
+	}
+	return ErrNotCommenter
+}
+
+// CanRead reports whether p may read post. Anonymous callers, with the zero
+// Principal, only read published posts.
+func CanRead(p principal.Principal, post *entities.Post) bool {
+	return post.IsPublished() || reaches[p.Role] == reachAll || Owns(p, post.Author)
+}
+
+// Viewer returns the listing filter selecting the posts p may read, or nil
+// when p reads every post.
+func Viewer(p principal.Principal) *repositories.Viewer {
+	if reaches[p.Role] == reachAll {
+		return nil
+	}
+	return &repositories.Viewer{Author: p.Name}
+}
+
+// Owns reports whether author names the caller.
+func Owns(p principal.Principal, author string) bool {
+	return p.Name != "" && entities.AuthorNameKey(author) == entities.AuthorNameKey(p.Name)
+}
@@ -0,0 +1,115 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"rakia-tech-test/internal/application/principal"
+	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/errdefs"
+)
+
+func TestAuthorize(t *testing.T) {
+	caller := func(role entities.Role) principal.Principal {
+		return principal.Principal{UserID: 1, Name: "Jane Doe", Role: role}
+	}
+
+	testCases := []struct {
+		name    string
+		caller  principal.Principal
+		author  string
+		wantErr error
+	}{
+		{name: "reader on own post", caller: caller(entities.RoleReader), author: "Jane Doe", wantErr: ErrReadOnly},
+		{name: "reader on other post", caller: caller(entities.RoleReader), author: "John Roe", wantErr: ErrReadOnly},
+		{name: "author on own post", caller: caller(entities.RoleAuthor), author: "Jane Doe"},
+		{name: "author on own post spelled differently", caller: caller(entities.RoleAuthor), author: " jane  DOE "},
+		{name: "author on other post", caller: caller(entities.RoleAuthor), author: "John Roe", wantErr: ErrNotOwner},
+		{name: "author on post without author", caller: caller(entities.RoleAuthor), author: "", wantErr: ErrNotOwner},
+		{name: "author without name", caller: principal.Principal{UserID: 1, Role: entities.RoleAuthor}, author: "", wantErr: ErrNotOwner},
+		{name: "editor on own post", caller: caller(entities.RoleEditor), author: "Jane Doe"},
+		{name: "editor on other post", caller: caller(entities.RoleEditor), author: "John Roe"},
+		{name: "admin on other post", caller: caller(entities.RoleAdmin), author: "John Roe"},
+		{name: "API key of an author on own post", caller: principal.Principal{UserID: 1, Name: "Jane Doe", Role: entities.RoleAuthor, APIKeyID: 7}, author: "Jane Doe"},
+		{name: "API key of an author on other post", caller: principal.Principal{UserID: 1, Name: "Jane Doe", Role: entities.RoleAuthor, APIKeyID: 7}, author: "John Roe", wantErr: ErrNotOwner},
+		{name: "unknown role", caller: caller("owner"), author: "Jane Doe", wantErr: ErrReadOnly},
+		{name: "no role", caller: caller(""), author: "Jane Doe", wantErr: ErrReadOnly},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Authorize(tc.caller, tc.author)
+			if tc.wantErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tc.wantErr)
+			assert.ErrorIs(t, err, errdefs.ErrForbidden)
+		})
+	}
+}
+
+func TestAuthorizeComment(t *testing.T) {
+	caller := func(role entities.Role) principal.Principal {
+		return principal.Principal{UserID: 1, Name: "Jane Doe", Role: role}
+	}
+	testCases := []struct {
+		name    string
+		caller  principal.Principal
+		author  string
+		wantErr bool
+	}{
+		{name: "reader on own comment", caller: caller(entities.RoleReader), author: "Jane Doe"},
+		{name: "reader on own comment spelled differently", caller: caller(entities.RoleReader), author: " jane  DOE "},
+		{name: "reader on other comment", caller: caller(entities.RoleReader), author: "John Roe", wantErr: true},
+		{name: "author on other comment", caller: caller(entities.RoleAuthor), author: "John Roe", wantErr: true},
+		{name: "caller without name", caller: principal.Principal{UserID: 1, Role: entities.RoleReader}, author: "", wantErr: true},
+		{name: "editor on other comment", caller: caller(entities.RoleEditor), author: "John Roe"},
+		{name: "admin on other comment", caller: caller(entities.RoleAdmin), author: "John Roe"},
+		{name: "unknown role on own comment", caller: caller("owner"), author: "Jane Doe", wantErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := AuthorizeComment(tc.caller, tc.author)
+			if !tc.wantErr {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrNotCommenter)
+			assert.ErrorIs(t, err, errdefs.ErrForbidden)
+		})
+	}
+}
+
+func TestCanRead(t *testing.T) {
+	draft := &entities.Post{Author: "Jane Doe", Status: entities.StatusDraft}
+	published := &entities.Post{Author: "John Roe", Status: entities.StatusPublished}
+	caller := func(name string, role entities.Role) principal.Principal {
+		return principal.Principal{UserID: 1, Name: name, Role: role}
+	}
+
+	testCases := []struct {
+		name   string
+		caller principal.Principal
+		post   *entities.Post
+		want   bool
+	}{
+		{name: "anonymous on published post", caller: principal.Principal{}, post: published, want: true},
+		{name: "anonymous on draft", caller: principal.Principal{}, post: draft},
+		{name: "author on own draft", caller: caller("Jane Doe", entities.RoleAuthor), post: draft, want: true},
+		{name: "author on own draft spelled differently", caller: caller("jane  doe", entities.RoleAuthor), post: draft, want: true},
+		{name: "author on draft of other", caller: caller("John Roe", entities.RoleAuthor), post: draft},
+		{name: "reader on draft of other", caller: caller("John Roe", entities.RoleReader), post: draft},
+		{name: "editor on draft of other", caller: caller("John Roe", entities.RoleEditor), post: draft, want: true},
+		{name: "admin on draft of other", caller: caller("John Roe", entities.RoleAdmin), post: draft, want: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, CanRead(tc.caller, tc.post))
+
+			// Listings agree with CanRead.
+			viewer := Viewer(tc.caller)
+			assert.Equal(t, tc.want, viewer == nil || viewer.Sees(tc.post))
+		})
+	}
+}
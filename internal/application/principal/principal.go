@@ -0,0 +1,53 @@
+// Package principal carries the identity of the caller behind a request in a
+// context.Context, so the application layer can decide what the caller may
+// see.
+package principal
+
+import (
+	"context"
+
+	"rakia-tech-test/internal/domain/entities"
+)
+
+// Principal is an authenticated caller. Name is the author name the caller
+// writes under and Role decides which posts the caller may change.
+type Principal struct {
+	UserID int
+	Name   string
+	Role   entities.Role
+	// APIKeyID is the API key the caller authenticated with, acting for
+	// the user UserID, or zero for users who signed in themselves.
+	APIKeyID int
+	// Scopes are the scopes of the API key. They are nil for users who
+	// signed in themselves, who are not limited by scopes.
+	Scopes []entities.Scope
+}
+
+// HasScope reports whether the caller may do what scope allows. Users who
+// signed in themselves hold every scope but admin, which only admins hold;
+// API keys hold the scopes they were issued with.
+func (p Principal) HasScope(scope entities.Scope) bool {
+	if p.APIKeyID == 0 {
+		return scope != entities.ScopeAdmin || p.Role == entities.RoleAdmin
+	}
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying p.
+func NewContext(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// FromContext returns the principal stored in ctx and false for anonymous
+// requests.
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(contextKey{}).(Principal)
+	return p, ok
+}
@@ -0,0 +1,133 @@
+// Package ratelimit keeps clients to a budget of requests with one token
+// bucket per client. A bucket holds up to Limit.Requests tokens and refills
+// at Limit.Requests per Limit.Period; every request takes a token, and
+// requests finding the bucket empty are refused.
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"rakia-tech-test/internal/domain/clock"
+)
+
+// Limit is a budget of requests: bursts of up to Requests, sustained at
+// Requests per Period.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// Decision is the outcome of Allow, with what the client needs to know to
+// pace itself.
+type Decision struct {
+	Allowed bool
+	// Limit is the size of the bucket and Remaining the whole tokens left
+	// in it after the request.
+	Limit     int
+	Remaining int
+	// ResetAfter is how long the bucket takes to fill up again.
+	ResetAfter time.Duration
+	// RetryAfter is how long a refused client has to wait for the next
+	// token. It is zero for allowed requests.
+	RetryAfter time.Duration
+}
+
+// bucket is the token bucket of one client as of updated.
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// Limiter holds a bucket for every client seen lately. Buckets that have
+// filled up again are no different from new ones, so they are evicted once
+// per Period, which keeps memory bounded by the number of clients active
+// within a period. It is safe for concurrent use.
+type Limiter struct {
+	limit     Limit
+	clock     clock.Clock
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	mutex     sync.Mutex
+}
+
+func NewLimiter(limit Limit, clock clock.Clock) *Limiter {
+	return &Limiter{
+		limit:     limit,
+		clock:     clock,
+		buckets:   make(map[string]*bucket),
+		lastSweep: clock.Now(),
+	}
+}
+
+// Limit returns the budget every client is held to.
+func (l *Limiter) Limit() Limit {
+	return l.limit
+}
+
+// Allow takes a token from the bucket of the client identified by key, if
+// there is one left.
+func (l *Limiter) Allow(key string) Decision {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := l.clock.Now()
+	l.sweep(now)
+
+	capacity := float64(l.limit.Requests)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, updated: now}
+		l.buckets[key] = b
+	}
+	if elapsed := now.Sub(b.updated); elapsed > 0 {
+		b.tokens = math.Min(capacity, b.tokens+elapsed.Seconds()*l.rate())
+		b.updated = now
+	}
+
+	decision := Decision{Limit: l.limit.Requests}
+	if b.tokens >= 1 {
+		b.tokens--
+		decision.Allowed = true
+	} else {
+		decision.RetryAfter = l.timeFor(1 - b.tokens)
+	}
+	decision.Remaining = int(b.tokens)
+	decision.ResetAfter = l.timeFor(capacity - b.tokens)
+	return decision
+}
+
+// Len returns the number of buckets held.
+func (l *Limiter) Len() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return len(l.buckets)
+}
+
+// sweep evicts the buckets that have filled up again, at most once per
+// Period. The caller must hold the mutex.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.limit.Period {
+		return
+	}
+	l.lastSweep = now
+
+	capacity := float64(l.limit.Requests)
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.updated).Seconds()*l.rate() >= capacity {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// rate is the refill rate, in tokens per second.
+func (l *Limiter) rate() float64 {
+	return float64(l.limit.Requests) / l.limit.Period.Seconds()
+}
+
+// timeFor returns how long the bucket takes to gain tokens.
+func (l *Limiter) timeFor(tokens float64) time.Duration {
+	return time.Duration(math.Ceil(tokens / l.rate() * float64(time.Second)))
+}
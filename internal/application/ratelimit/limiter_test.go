@@ -0,0 +1,95 @@
+package ratelimit
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"rakia-tech-test/internal/domain/clock"
+)
+
+func newLimiter(requests int, period time.Duration) (*Limiter, *clock.Fake) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	return NewLimiter(Limit{Requests: requests, Period: period}, fake), fake
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	limiter, fake := newLimiter(3, 3*time.Second)
+
+	for remaining := 2; remaining >= 0; remaining-- {
+		decision := limiter.Allow("client")
+		assert.True(t, decision.Allowed)
+		assert.Equal(t, 3, decision.Limit)
+		assert.Equal(t, remaining, decision.Remaining)
+		assert.Zero(t, decision.RetryAfter)
+	}
+
+	refused := limiter.Allow("client")
+	assert.False(t, refused.Allowed)
+	assert.Equal(t, 0, refused.Remaining)
+	assert.Equal(t, time.Second, refused.RetryAfter)
+	assert.Equal(t, 3*time.Second, refused.ResetAfter)
+	assert.True(t, limiter.Allow("other").Allowed, "clients have buckets of their own")
+
+	// The bucket refills at one token per second.
+	fake.Advance(500 * time.Millisecond)
+	refused = limiter.Allow("client")
+	assert.False(t, refused.Allowed)
+	assert.Equal(t, 500*time.Millisecond, refused.RetryAfter)
+	fake.Advance(500 * time.Millisecond)
+	allowed := limiter.Allow("client")
+	assert.True(t, allowed.Allowed)
+	assert.Equal(t, 0, allowed.Remaining)
+
+	// Buckets never hold more than the limit.
+	fake.Advance(time.Hour)
+	allowed = limiter.Allow("client")
+	assert.Equal(t, 2, allowed.Remaining)
+	assert.Equal(t, time.Second, allowed.ResetAfter)
+}
+
+func TestLimiter_EvictsIdleBuckets(t *testing.T) {
+	limiter, fake := newLimiter(2, time.Minute)
+
+	for i := 0; i < 100; i++ {
+		limiter.Allow(strconv.Itoa(i))
+	}
+	assert.Equal(t, 100, limiter.Len())
+
+	fake.Advance(30 * time.Second)
+	limiter.Allow("busy")
+	limiter.Allow("busy")
+	assert.Equal(t, 101, limiter.Len(), "buckets are swept once per period")
+
+	fake.Advance(30 * time.Second)
+	limiter.Allow("new")
+	assert.Equal(t, 2, limiter.Len(), "only buckets still refilling are kept")
+
+	decision := limiter.Allow("0")
+	assert.Equal(t, 1, decision.Remaining, "an evicted client starts with a full bucket")
+}
+
+func TestLimiter_Concurrent(t *testing.T) {
+	limiter, _ := newLimiter(50, time.Hour)
+
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	allowed := 0
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if limiter.Allow("client").Allowed {
+				mutex.Lock()
+				allowed++
+				mutex.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 50, allowed)
+}
@@ -0,0 +1,88 @@
+package render
+
+import (
+	"container/list"
+	"sync"
+
+	"rakia-tech-test/internal/domain/entities"
+)
+
+// Cache keeps the rendered content of recently read posts, so a post is
+// rendered once after each change rather than on every read. It holds at
+// most capacity posts and evicts the least recently used one first. It is
+// safe for concurrent use.
+type Cache struct {
+	capacity int
+	entries  map[int]*list.Element
+	// order lists the entries from the most to the least recently used.
+	order *list.List
+	mutex sync.Mutex
+}
+
+// cacheEntry is the rendered content of one post, with what it was
+// rendered from.
+type cacheEntry struct {
+	postID  int
+	version int
+	format  entities.ContentFormat
+	content string
+	html    string
+}
+
+func NewCache(capacity int) *Cache {
+	return &Cache{
+		capacity: capacity,
+		entries:  make(map[int]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Render returns the content of post as HTML. The cached copy is used while
+// the version, format and content of the post are the ones it was rendered
+// from.
+func (c *Cache) Render(post *entities.Post) string {
+	c.mutex.Lock()
+	if element, ok := c.entries[post.ID]; ok {
+		entry := element.Value.(*cacheEntry)
+		if entry.version == post.Version && entry.format == post.ContentFormat && entry.content == post.Content {
+			c.order.MoveToFront(element)
+			c.mutex.Unlock()
+			return entry.html
+		}
+	}
+	c.mutex.Unlock()
+
+	// Rendering happens outside the lock so slow posts do not hold up
+	// reads of other posts.
+	entry := &cacheEntry{
+		postID:  post.ID,
+		version: post.Version,
+		format:  post.ContentFormat,
+		content: post.Content,
+		html:    Content(post.ContentFormat, post.Content),
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if element, ok := c.entries[post.ID]; ok {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return entry.html
+	}
+	c.entries[post.ID] = c.order.PushFront(entry)
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).postID)
+	}
+	return entry.html
+}
+
+// Len returns the number of posts in the cache.
+func (c *Cache) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.order.Len()
+}
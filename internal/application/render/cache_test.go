@@ -0,0 +1,42 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"rakia-tech-test/internal/domain/entities"
+)
+
+func TestCache_Render(t *testing.T) {
+	cache := NewCache(2)
+	post := &entities.Post{ID: 1, Version: 1, Content: "*a*", ContentFormat: entities.FormatMarkdown}
+
+	assert.Equal(t, "<p><em>a</em></p>\n", cache.Render(post))
+	assert.Equal(t, "<p><em>a</em></p>\n", cache.Render(post))
+	assert.Equal(t, 1, cache.Len())
+
+	post.Content, post.Version = "**a**", 2
+	assert.Equal(t, "<p><strong>a</strong></p>\n", cache.Render(post), "a new version is rendered again")
+
+	post.ContentFormat = entities.FormatPlain
+	assert.Equal(t, "<p>**a**</p>\n", cache.Render(post), "so is content that changed without a new version")
+	assert.Equal(t, 1, cache.Len())
+}
+
+func TestCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewCache(2)
+	first := &entities.Post{ID: 1, Version: 1, Content: "one"}
+	second := &entities.Post{ID: 2, Version: 1, Content: "two"}
+	third := &entities.Post{ID: 3, Version: 1, Content: "three"}
+
+	cache.Render(first)
+	cache.Render(second)
+	cache.Render(first)
+	cache.Render(third)
+
+	assert.Equal(t, 2, cache.Len())
+	assert.Contains(t, cache.entries, 1)
+	assert.NotContains(t, cache.entries, 2)
+	assert.Contains(t, cache.entries, 3)
+}
@@ -0,0 +1,614 @@
+package render
+
+import (
+	"html"
+	"strings"
+)
+
+// maxLinkParens bounds the nesting of parentheses in a link destination,
+// as CommonMark allows, so an unclosed destination is not scanned again by
+// every link after it.
+const maxLinkParens = 32
+
+// writeInline renders the inline content of a paragraph or heading. All
+// text is escaped; the only markup written is the one the syntax asks for.
+//
+// The content is rendered in one pass, as CommonMark does: text is turned
+// into nodes as it is read, runs of emphasis delimiters are kept on a
+// stack and matched once their closers are known, and brackets are kept
+// on another stack until a link closes them. No part of the content is
+// read again, so rendering takes time linear in its length.
+func writeInline(b *strings.Builder, s string) {
+	p := &inlineParser{s: s}
+	p.parse()
+	p.processEmphasis(nil)
+	writeNodes(b, p.nodes)
+}
+
+// inlineNode is a piece of rendered HTML, or a delimiter run when delim is
+// set.
+type inlineNode struct {
+	html  string
+	delim *delimiter
+}
+
+// delimiter is a run of *, _ or ~~ that may open or close emphasis. A run
+// gives up characters to each match it takes part in; the tags of the
+// matches are written around the characters it has left.
+type delimiter struct {
+	c                 byte
+	length            int
+	left              int
+	canOpen, canClose bool
+	// open is written after the characters left and close before them.
+	open, close string
+	prev, next  *delimiter
+}
+
+// bracket is a [ or ![ that may start a link or image.
+type bracket struct {
+	node  int
+	image bool
+	// bottom is the top of the delimiter stack when the bracket was read.
+	bottom *delimiter
+}
+
+// delimiterKind groups the closers that look for the same openers.
+type delimiterKind struct {
+	c       byte
+	canOpen bool
+	length3 int
+}
+
+type inlineParser struct {
+	s     string
+	nodes []inlineNode
+	// delimiters is the top of the delimiter stack.
+	delimiters *delimiter
+	brackets   []*bracket
+	// Links cannot contain links: the brackets below linksClosedBelow
+	// start no link, though they may still start an image.
+	linksClosedBelow int
+	// noCodeCloser and noTitleCloser remember the searches that reached
+	// the end of the content, so they are not run again.
+	noCodeCloser  map[int]bool
+	noTitleCloser map[byte]bool
+}
+
+func (p *inlineParser) text(html string) {
+	p.nodes = append(p.nodes, inlineNode{html: html})
+}
+
+func (p *inlineParser) parse() {
+	s := p.s
+	for i := 0; i < len(s); {
+		switch c := s[i]; c {
+		case '\\':
+			switch {
+			case i+1 < len(s) && s[i+1] == '\n':
+				p.text("<br />\n")
+				i += 2
+			case i+1 < len(s) && isPunct(s[i+1]):
+				p.text(html.EscapeString(s[i+1 : i+2]))
+				i += 2
+			default:
+				p.text("\\")
+				i++
+			}
+		case ' ':
+			n := runLength(s, i, ' ')
+			switch {
+			case i+n == len(s):
+				// Trailing spaces are dropped.
+			case s[i+n] == '\n' && n >= 2:
+				p.text("<br />\n")
+				n++
+			case s[i+n] == '\n':
+				p.text("\n")
+				n++
+			default:
+				p.text(s[i : i+n])
+			}
+			i += n
+		case '`':
+			n := runLength(s, i, '`')
+			if end, code, ok := p.codeSpan(i, n); ok {
+				p.text("<code>" + html.EscapeString(code) + "</code>")
+				i = end
+				continue
+			}
+			p.text(s[i : i+n])
+			i += n
+		case '*', '_', '~':
+			n := runLength(s, i, c)
+			p.delimiterRun(i, n)
+			i += n
+		case '!':
+			if i+1 < len(s) && s[i+1] == '[' {
+				p.openBracket("![", true)
+				i += 2
+				continue
+			}
+			p.text("!")
+			i++
+		case '[':
+			p.openBracket("[", false)
+			i++
+		case ']':
+			i = p.closeBracket(i)
+		case '<':
+			if link, end, ok := autolink(s, i); ok {
+				p.text(link)
+				i = end
+				continue
+			}
+			p.text("&lt;")
+			i++
+		case '&':
+			// Entities are passed through; browsers decode them to text.
+			if entity := entityPattern.FindString(s[i:]); entity != "" && html.UnescapeString(entity) != entity {
+				p.text(entity)
+				i += len(entity)
+				continue
+			}
+			p.text("&amp;")
+			i++
+		case '>', '"', '\'':
+			p.text(html.EscapeString(s[i : i+1]))
+			i++
+		default:
+			end := i + 1
+			for end < len(s) && !strings.ContainsRune("\\ `*_~![]<&>\"'", rune(s[end])) {
+				end++
+			}
+			p.text(s[i:end])
+			i = end
+		}
+	}
+}
+
+// codeSpan parses the code span opened by the run of n backticks at s[i].
+func (p *inlineParser) codeSpan(i, n int) (int, string, bool) {
+	if p.noCodeCloser[n] {
+		return 0, "", false
+	}
+	end, code, ok := codeSpan(p.s, i)
+	if !ok {
+		// No run of n backticks follows, so no later run of n opens a
+		// code span either.
+		if p.noCodeCloser == nil {
+			p.noCodeCloser = make(map[int]bool)
+		}
+		p.noCodeCloser[n] = true
+	}
+	return end, code, ok
+}
+
+// delimiterRun adds the run of n delimiter characters at s[i]. Runs that
+// can neither open nor close emphasis, and tildes other than ~~, are text.
+func (p *inlineParser) delimiterRun(i, n int) {
+	s := p.s
+	d := &delimiter{c: s[i], length: n, left: n, canOpen: canOpen(s, i, n), canClose: canClose(s, i, n)}
+	if (!d.canOpen && !d.canClose) || (d.c == '~' && n != 2) {
+		p.text(s[i : i+n])
+		return
+	}
+	d.prev = p.delimiters
+	if d.prev != nil {
+		d.prev.next = d
+	}
+	p.delimiters = d
+	p.nodes = append(p.nodes, inlineNode{delim: d})
+}
+
+func (p *inlineParser) openBracket(text string, image bool) {
+	p.brackets = append(p.brackets, &bracket{node: len(p.nodes), image: image, bottom: p.delimiters})
+	p.text(text)
+}
+
+// closeBracket handles the ] at s[i] and returns the position after what
+// it closes: the destination of a link or image, or the bracket itself.
+// Emphasis inside the brackets is matched before it is wrapped in a link,
+// so it cannot cross the link.
+func (p *inlineParser) closeBracket(i int) int {
+	if len(p.brackets) == 0 {
+		p.text("]")
+		return i + 1
+	}
+	top := len(p.brackets) - 1
+	opener := p.brackets[top]
+	closed := !opener.image && top < p.linksClosedBelow
+	p.brackets = p.brackets[:top]
+	if p.linksClosedBelow > top {
+		p.linksClosedBelow = top
+	}
+
+	destination, title, end, ok := p.linkTail(i + 1)
+	if !ok || closed {
+		p.text("]")
+		return i + 1
+	}
+
+	p.processEmphasis(opener.bottom)
+	var label strings.Builder
+	writeNodes(&label, p.nodes[opener.node+1:])
+	p.nodes = p.nodes[:opener.node]
+	if opener.image {
+		p.text(imageHTML(label.String(), destination, title))
+	} else {
+		p.text(linkHTML(label.String(), destination, title))
+		p.linksClosedBelow = len(p.brackets)
+	}
+	return end
+}
+
+// processEmphasis matches the delimiters above bottom on the stack, as in
+// CommonMark, and takes them off the stack. Each closer looks for the
+// nearest opener of its character; where none is found, the search for
+// later closers of the same kind stops at that closer, so no opener is
+// looked at twice.
+func (p *inlineParser) processEmphasis(bottom *delimiter) {
+	var closer *delimiter
+	for d := p.delimiters; d != bottom; d = d.prev {
+		closer = d
+	}
+
+	openersBottom := make(map[delimiterKind]*delimiter)
+	for closer != nil {
+		if !closer.canClose {
+			closer = closer.next
+			continue
+		}
+		kind := delimiterKind{c: closer.c, canOpen: closer.canOpen, length3: closer.length % 3}
+		limit, limited := openersBottom[kind]
+		opener := closer.prev
+		for opener != nil && opener != bottom && (!limited || opener != limit) && !matches(opener, closer) {
+			opener = opener.prev
+		}
+
+		if opener == nil || opener == bottom || (limited && opener == limit) {
+			openersBottom[kind] = closer.prev
+			next := closer.next
+			if !closer.canOpen {
+				p.remove(closer)
+			}
+			closer = next
+			continue
+		}
+
+		use := 1
+		if opener.left >= 2 && closer.left >= 2 {
+			use = 2
+		}
+		open, close := emphasisTags(closer.c, use)
+		opener.left -= use
+		closer.left -= use
+		opener.open = open + opener.open
+		closer.close += close
+
+		// The delimiters in between are left unmatched.
+		opener.next = closer
+		closer.prev = opener
+		if opener.left == 0 {
+			p.remove(opener)
+		}
+		if closer.left == 0 {
+			next := closer.next
+			p.remove(closer)
+			closer = next
+		}
+	}
+
+	p.delimiters = bottom
+	if bottom != nil {
+		bottom.next = nil
+	}
+}
+
+func (p *inlineParser) remove(d *delimiter) {
+	if d.prev != nil {
+		d.prev.next = d.next
+	}
+	if d.next != nil {
+		d.next.prev = d.prev
+	} else {
+		p.delimiters = d.prev
+	}
+}
+
+// matches reports whether opener can be closed by closer. A run that can
+// both open and close only matches one whose length does not add up to a
+// multiple of three with its own, so ***a**b* nests as expected; tildes
+// only match in pairs.
+func matches(opener, closer *delimiter) bool {
+	if opener.c != closer.c || !opener.canOpen {
+		return false
+	}
+	if closer.c == '~' {
+		return opener.left == 2 && closer.left == 2
+	}
+	if (opener.canClose || closer.canOpen) && closer.length%3 != 0 && (opener.length+closer.length)%3 == 0 {
+		return false
+	}
+	return true
+}
+
+func emphasisTags(c byte, use int) (string, string) {
+	switch {
+	case c == '~':
+		return "<del>", "</del>"
+	case use == 2:
+		return "<strong>", "</strong>"
+	default:
+		return "<em>", "</em>"
+	}
+}
+
+func writeNodes(b *strings.Builder, nodes []inlineNode) {
+	for _, node := range nodes {
+		if d := node.delim; d != nil {
+			b.WriteString(d.close)
+			for i := 0; i < d.left; i++ {
+				b.WriteByte(d.c)
+			}
+			b.WriteString(d.open)
+			continue
+		}
+		b.WriteString(node.html)
+	}
+}
+
+func runLength(s string, i int, c byte) int {
+	n := 0
+	for i+n < len(s) && s[i+n] == c {
+		n++
+	}
+	return n
+}
+
+func isPunct(c byte) bool {
+	return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) >= 0
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\t'
+}
+
+// isWordByte reports whether c is part of a word. Bytes of multi-byte
+// characters count as letters.
+func isWordByte(c byte) bool {
+	return c >= 0x80 || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// codeSpan parses the code span opened by the backtick run at s[i]. It is
+// closed by the next run of the same length.
+func codeSpan(s string, i int) (int, string, bool) {
+	n := runLength(s, i, '`')
+	for j := i + n; j < len(s); {
+		if s[j] != '`' {
+			j++
+			continue
+		}
+		m := runLength(s, j, '`')
+		if m == n {
+			code := strings.ReplaceAll(s[i+n:j], "\n", " ")
+			if len(code) >= 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.TrimSpace(code) != "" {
+				code = code[1 : len(code)-1]
+			}
+			return j + m, code, true
+		}
+		j += m
+	}
+	return 0, "", false
+}
+
+// canOpen reports whether the delimiter run of n bytes at s[i] can open
+// emphasis: it must be followed by text, and an underscore must not start
+// inside a word.
+func canOpen(s string, i, n int) bool {
+	if i+n >= len(s) || isSpace(s[i+n]) {
+		return false
+	}
+	return s[i] != '_' || i == 0 || !isWordByte(s[i-1])
+}
+
+// canClose reports whether the delimiter run of n bytes at s[j] can close
+// emphasis: it must follow text, and an underscore must not end inside a
+// word.
+func canClose(s string, j, n int) bool {
+	if j == 0 || isSpace(s[j-1]) {
+		return false
+	}
+	return s[j] != '_' || j+n == len(s) || !isWordByte(s[j+n])
+}
+
+// linkHTML renders a link to destination with the given rendered label. A
+// link to a URL with a disallowed scheme is rendered as its label.
+func linkHTML(label, destination, title string) string {
+	href, safe := safeURL(destination, linkSchemes)
+	if !safe {
+		return label
+	}
+	a := `<a href="` + html.EscapeString(href) + `"`
+	if title != "" {
+		a += ` title="` + html.EscapeString(title) + `"`
+	}
+	return a + ">" + label + "</a>"
+}
+
+// imageHTML renders an image from source whose alternative text is the
+// rendered label. An image from a URL with a disallowed scheme is rendered
+// as its alternative text.
+func imageHTML(label, source, title string) string {
+	// The label is stripped of its tags, which leaves its text escaped.
+	alt := stripTags(label)
+
+	src, safe := safeURL(source, imageSchemes)
+	if !safe {
+		return alt
+	}
+	img := `<img src="` + html.EscapeString(src) + `" alt="` + strings.ReplaceAll(alt, `"`, "&#34;") + `"`
+	if title != "" {
+		img += ` title="` + html.EscapeString(title) + `"`
+	}
+	return img + " />"
+}
+
+func stripTags(s string) string {
+	var b strings.Builder
+	inTag := false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '<':
+			inTag = true
+		case s[i] == '>' && inTag:
+			inTag = false
+		case !inTag:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// linkTail parses the (destination "title") of a link or image starting
+// at s[i] and returns the position after it. The destination may be
+// wrapped in angle brackets, and the title in double or single quotes or
+// parentheses.
+func (p *inlineParser) linkTail(i int) (destination, title string, end int, ok bool) {
+	s := p.s
+	if i >= len(s) || s[i] != '(' {
+		return "", "", 0, false
+	}
+
+	j := skipSpaces(s, i+1)
+	if j < len(s) && s[j] == '<' {
+		k := j + 1
+		for k < len(s) && s[k] != '>' && s[k] != '<' && s[k] != '\n' {
+			if s[k] == '\\' {
+				k++
+			}
+			k++
+		}
+		if k >= len(s) || s[k] != '>' {
+			return "", "", 0, false
+		}
+		destination = s[j+1 : k]
+		j = k + 1
+	} else {
+		k, parens := j, 0
+		for ; k < len(s) && !isSpace(s[k]); k++ {
+			if s[k] == '\\' && k+1 < len(s) {
+				k++
+				continue
+			}
+			if s[k] == '(' {
+				parens++
+				if parens > maxLinkParens {
+					return "", "", 0, false
+				}
+			}
+			if s[k] == ')' {
+				if parens == 0 {
+					break
+				}
+				parens--
+			}
+		}
+		destination = s[j:k]
+		j = k
+	}
+
+	if k := skipSpaces(s, j); k > j && k < len(s) && (s[k] == '"' || s[k] == '\'' || s[k] == '(') {
+		closer := s[k]
+		if closer == '(' {
+			closer = ')'
+		}
+		if p.noTitleCloser[closer] {
+			return "", "", 0, false
+		}
+		m := k + 1
+		for m < len(s) && s[m] != closer {
+			if s[m] == '\\' {
+				m++
+			}
+			m++
+		}
+		if m >= len(s) {
+			// Later titles would not be closed either.
+			if p.noTitleCloser == nil {
+				p.noTitleCloser = make(map[byte]bool)
+			}
+			p.noTitleCloser[closer] = true
+			return "", "", 0, false
+		}
+		title = s[k+1 : m]
+		j = m + 1
+	}
+
+	j = skipSpaces(s, j)
+	if j >= len(s) || s[j] != ')' {
+		return "", "", 0, false
+	}
+	return unescape(destination), unescape(title), j + 1, true
+}
+
+func skipSpaces(s string, i int) int {
+	for i < len(s) && isSpace(s[i]) {
+		i++
+	}
+	return i
+}
+
+// unescape resolves the backslash escapes and entities in a link
+// destination or title.
+func unescape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && isPunct(s[i+1]) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return html.UnescapeString(b.String())
+}
+
+// autolink renders the URL or email address in angle brackets at s[i].
+func autolink(s string, i int) (string, int, bool) {
+	if m := uriAutolinkPattern.FindStringSubmatch(s[i:]); m != nil {
+		href, safe := safeURL(m[1], linkSchemes)
+		if !safe {
+			return "", 0, false
+		}
+		return `<a href="` + html.EscapeString(href) + `">` + html.EscapeString(m[1]) + "</a>", i + len(m[0]), true
+	}
+	if m := emailAutolinkPattern.FindStringSubmatch(s[i:]); m != nil {
+		return `<a href="mailto:` + html.EscapeString(m[1]) + `">` + html.EscapeString(m[1]) + "</a>", i + len(m[0]), true
+	}
+	return "", 0, false
+}
+
+// safeURL reports whether raw is relative or uses one of schemes, and
+// returns it cleaned up for an attribute. Browsers ignore control
+// characters and whitespace in URLs, so "java\tscript:" is checked, and
+// written, as "javascript:".
+func safeURL(raw string, schemes []string) (string, bool) {
+	url := strings.ReplaceAll(strings.TrimSpace(raw), " ", "%20")
+	url = strings.Map(func(r rune) rune {
+		if r < ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, url)
+
+	colon := strings.IndexAny(url, ":/?#")
+	if colon <= 0 || url[colon] != ':' {
+		return url, true
+	}
+	scheme := strings.ToLower(url[:colon])
+	for _, allowed := range schemes {
+		if scheme == allowed {
+			return url, true
+		}
+	}
+	return "", false
+}
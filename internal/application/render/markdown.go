@@ -0,0 +1,477 @@
+// Package render turns the content of posts into HTML that is safe to embed
+// in a page.
+//
+// The Markdown renderer covers the common subset of CommonMark: ATX and
+// setext headings, paragraphs, fenced and indented code, block quotes,
+// lists, thematic breaks, emphasis, code spans, links, images, autolinks
+// and hard line breaks, plus ~~strikethrough~~. Raw HTML is not supported:
+// it is escaped and shows up as text. The renderer only emits the tags it
+// writes itself, with no event handler attributes, and links and images
+// only keep URLs with an allowed scheme, so the output needs no further
+// sanitizing.
+package render
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"rakia-tech-test/internal/domain/entities"
+)
+
+var (
+	thematicBreakPattern = regexp.MustCompile(`^ {0,3}(?:(?:\*[ ]*){3,}|(?:-[ ]*){3,}|(?:_[ ]*){3,})$`)
+	setextPattern        = regexp.MustCompile(`^ {0,3}(?:=+|-+)[ ]*$`)
+	paragraphBreak       = regexp.MustCompile(`\n[ \t]*\n`)
+	languagePattern      = regexp.MustCompile(`^[A-Za-z0-9_+-]+$`)
+	entityPattern        = regexp.MustCompile(`^&(?:#[0-9]{1,7}|#[xX][0-9A-Fa-f]{1,6}|[A-Za-z][A-Za-z0-9]{1,31});`)
+	uriAutolinkPattern   = regexp.MustCompile(`^<([A-Za-z][A-Za-z0-9+.-]{1,31}:[^\s<>]*)>`)
+	emailAutolinkPattern = regexp.MustCompile(`^<([A-Za-z0-9.!#$%&'*+/=?^_{|}~-]+@[A-Za-z0-9](?:[A-Za-z0-9-]{0,61}[A-Za-z0-9])?(?:\.[A-Za-z0-9](?:[A-Za-z0-9-]{0,61}[A-Za-z0-9])?)*)>`)
+)
+
+// maxBlockDepth bounds the nesting of block quotes and lists. Deeper
+// markers are rendered as text, so every level of nesting, which renders
+// its content apart and copies it into its parent, adds to the cost of
+// rendering at most this many times.
+const maxBlockDepth = 32
+
+var (
+	// linkSchemes are the URL schemes links may use. Relative URLs are
+	// always allowed.
+	linkSchemes = []string{"http", "https", "mailto"}
+	// imageSchemes are the URL schemes images may be loaded from.
+	imageSchemes = []string{"http", "https"}
+)
+
+// Content renders content written in format as HTML.
+func Content(format entities.ContentFormat, content string) string {
+	if format == entities.FormatMarkdown {
+		return Markdown(content)
+	}
+	return Plain(content)
+}
+
+// Plain renders plain text as HTML paragraphs. Blank lines separate the
+// paragraphs and other line breaks are kept.
+func Plain(src string) string {
+	var b strings.Builder
+	for _, paragraph := range paragraphBreak.Split(normalizeNewlines(src), -1) {
+		paragraph = strings.Trim(paragraph, "\n")
+		if strings.TrimSpace(paragraph) == "" {
+			continue
+		}
+		b.WriteString("<p>")
+		b.WriteString(strings.ReplaceAll(html.EscapeString(paragraph), "\n", "<br />\n"))
+		b.WriteString("</p>\n")
+	}
+	return b.String()
+}
+
+// Markdown renders Markdown as sanitized HTML.
+func Markdown(src string) string {
+	lines := strings.Split(normalizeNewlines(src), "\n")
+	for i, line := range lines {
+		lines[i] = expandTabs(line)
+	}
+
+	var b strings.Builder
+	writeBlocks(&b, lines, false, 0)
+	return b.String()
+}
+
+func normalizeNewlines(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\r", "\n")
+	return strings.ReplaceAll(s, "\x00", "\uFFFD")
+}
+
+// expandTabs replaces the tabs in line with spaces up to the next multiple
+// of four columns, so indentation can be measured in spaces.
+func expandTabs(line string) string {
+	if !strings.Contains(line, "\t") {
+		return line
+	}
+	var b strings.Builder
+	column := 0
+	for _, r := range line {
+		if r == '\t' {
+			spaces := 4 - column%4
+			b.WriteString(strings.Repeat(" ", spaces))
+			column += spaces
+			continue
+		}
+		b.WriteRune(r)
+		column++
+	}
+	return b.String()
+}
+
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+func indentOf(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// writeBlocks renders lines as a sequence of blocks, nested depth levels
+// deep in quotes and lists. Paragraphs in tight lists are written without
+// their <p> tags.
+func writeBlocks(b *strings.Builder, lines []string, tight bool, depth int) {
+	nests := depth < maxBlockDepth
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case isBlank(line):
+			i++
+		case isFence(line):
+			i = writeFencedCode(b, lines, i)
+		case indentOf(line) >= 4:
+			i = writeIndentedCode(b, lines, i)
+		case isHeading(line):
+			level, text, _ := parseHeading(line)
+			writeHeading(b, level, text)
+			i++
+		case thematicBreakPattern.MatchString(line):
+			b.WriteString("<hr />\n")
+			i++
+		case nests && isQuote(line):
+			i = writeQuote(b, lines, i, depth)
+		case nests && isListItem(line):
+			i = writeList(b, lines, i, depth)
+		default:
+			i = writeParagraph(b, lines, i, tight)
+		}
+	}
+}
+
+// startsBlock reports whether line starts a block that interrupts a
+// paragraph.
+func startsBlock(line string) bool {
+	if isFence(line) || isHeading(line) || thematicBreakPattern.MatchString(line) || isQuote(line) {
+		return true
+	}
+	marker, content, ok := parseListItem(line)
+	return ok && content != "" && (!marker.ordered || marker.start == 1)
+}
+
+func writeHeading(b *strings.Builder, level int, text string) {
+	tag := "h" + strconv.Itoa(level)
+	b.WriteString("<" + tag + ">")
+	writeInline(b, text)
+	b.WriteString("</" + tag + ">\n")
+}
+
+func isHeading(line string) bool {
+	_, _, ok := parseHeading(line)
+	return ok
+}
+
+// parseHeading parses an ATX heading: one to six #, a space and the text,
+// optionally followed by a closing run of #.
+func parseHeading(line string) (int, string, bool) {
+	s := strings.TrimLeft(line, " ")
+	if len(line)-len(s) > 3 {
+		return 0, "", false
+	}
+	level := 0
+	for level < len(s) && s[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 || (level < len(s) && s[level] != ' ') {
+		return 0, "", false
+	}
+
+	text := strings.TrimSpace(s[level:])
+	if closed := strings.TrimRight(text, "#"); closed == "" || strings.HasSuffix(closed, " ") {
+		text = strings.TrimSpace(closed)
+	}
+	return level, text, true
+}
+
+func writeParagraph(b *strings.Builder, lines []string, i int, tight bool) int {
+	var text []string
+	for start := i; i < len(lines) && !isBlank(lines[i]); i++ {
+		if i > start {
+			if setextPattern.MatchString(lines[i]) {
+				level := 2
+				if strings.TrimSpace(lines[i])[0] == '=' {
+					level = 1
+				}
+				writeHeading(b, level, strings.TrimSpace(strings.Join(text, "\n")))
+				return i + 1
+			}
+			if startsBlock(lines[i]) {
+				break
+			}
+		}
+		text = append(text, strings.TrimLeft(lines[i], " "))
+	}
+
+	content := strings.TrimRight(strings.Join(text, "\n"), " ")
+	if !tight {
+		b.WriteString("<p>")
+	}
+	writeInline(b, content)
+	if !tight {
+		b.WriteString("</p>")
+	}
+	b.WriteString("\n")
+	return i
+}
+
+func isFence(line string) bool {
+	_, _, _, ok := parseFence(line)
+	return ok
+}
+
+// parseFence parses the opening line of a fenced code block: at least three
+// backticks or tildes, optionally followed by an info string.
+func parseFence(line string) (indent int, fence, info string, ok bool) {
+	s := strings.TrimLeft(line, " ")
+	indent = len(line) - len(s)
+	if indent > 3 || len(s) < 3 || (s[0] != '`' && s[0] != '~') {
+		return 0, "", "", false
+	}
+	n := runLength(s, 0, s[0])
+	if n < 3 {
+		return 0, "", "", false
+	}
+	info = strings.TrimSpace(s[n:])
+	if s[0] == '`' && strings.Contains(info, "`") {
+		return 0, "", "", false
+	}
+	return indent, s[:n], info, true
+}
+
+// isFenceClose reports whether line closes a code block opened by fence.
+func isFenceClose(line, fence string) bool {
+	s := strings.TrimLeft(line, " ")
+	if len(line)-len(s) > 3 || !strings.HasPrefix(s, fence) {
+		return false
+	}
+	return strings.TrimSpace(strings.TrimLeft(s, fence[:1])) == ""
+}
+
+func writeFencedCode(b *strings.Builder, lines []string, i int) int {
+	indent, fence, info, _ := parseFence(lines[i])
+
+	b.WriteString("<pre><code")
+	if fields := strings.Fields(info); len(fields) > 0 && languagePattern.MatchString(fields[0]) {
+		b.WriteString(` class="language-` + fields[0] + `"`)
+	}
+	b.WriteString(">")
+	for i++; i < len(lines); i++ {
+		line := lines[i]
+		if isFenceClose(line, fence) {
+			i++
+			break
+		}
+		line = line[min(indent, indentOf(line)):]
+		b.WriteString(html.EscapeString(line))
+		b.WriteString("\n")
+	}
+	b.WriteString("</code></pre>\n")
+	return i
+}
+
+func writeIndentedCode(b *strings.Builder, lines []string, i int) int {
+	var code []string
+	for ; i < len(lines) && (isBlank(lines[i]) || indentOf(lines[i]) >= 4); i++ {
+		code = append(code, lines[i][min(4, indentOf(lines[i])):])
+	}
+	// Blank lines after the code belong to the following block.
+	for len(code) > 0 && isBlank(code[len(code)-1]) {
+		code = code[:len(code)-1]
+		i--
+	}
+
+	b.WriteString("<pre><code>")
+	for _, line := range code {
+		b.WriteString(html.EscapeString(line))
+		b.WriteString("\n")
+	}
+	b.WriteString("</code></pre>\n")
+	return i
+}
+
+func isQuote(line string) bool {
+	_, ok := parseQuote(line)
+	return ok
+}
+
+// parseQuote returns line without its block quote marker.
+func parseQuote(line string) (string, bool) {
+	s := strings.TrimLeft(line, " ")
+	if len(line)-len(s) > 3 || !strings.HasPrefix(s, ">") {
+		return "", false
+	}
+	return strings.TrimPrefix(s[1:], " "), true
+}
+
+func writeQuote(b *strings.Builder, lines []string, i, depth int) int {
+	var quoted []string
+	for ; i < len(lines); i++ {
+		if line, ok := parseQuote(lines[i]); ok {
+			quoted = append(quoted, line)
+			continue
+		}
+		// A line without the marker may lazily continue a paragraph.
+		last := len(quoted) - 1
+		if isBlank(lines[i]) || isBlank(quoted[last]) || startsBlock(lines[i]) || isFence(quoted[last]) {
+			break
+		}
+		quoted = append(quoted, lines[i])
+	}
+
+	b.WriteString("<blockquote>\n")
+	writeBlocks(b, quoted, false, depth+1)
+	b.WriteString("</blockquote>\n")
+	return i
+}
+
+// listMarker describes the marker of a list item. Items belong to the same
+// list when their markers have the same kind and delimiter.
+type listMarker struct {
+	ordered   bool
+	delimiter byte
+	start     int
+	// width is the indentation of the content of the item.
+	width int
+}
+
+func isListItem(line string) bool {
+	_, _, ok := parseListItem(line)
+	return ok
+}
+
+// parseListItem parses the first line of a list item: a bullet (-, + or *)
+// or a number followed by . or ), then at least one space or the end of
+// the line.
+func parseListItem(line string) (listMarker, string, bool) {
+	s := strings.TrimLeft(line, " ")
+	indent := len(line) - len(s)
+	if indent > 3 || s == "" {
+		return listMarker{}, "", false
+	}
+
+	marker := listMarker{}
+	n := 0
+	switch {
+	case s[0] == '-' || s[0] == '+' || s[0] == '*':
+		marker.delimiter, n = s[0], 1
+	default:
+		for n < len(s) && n < 9 && s[n] >= '0' && s[n] <= '9' {
+			n++
+		}
+		if n == 0 || n == len(s) || (s[n] != '.' && s[n] != ')') {
+			return listMarker{}, "", false
+		}
+		marker.ordered, marker.delimiter = true, s[n]
+		marker.start, _ = strconv.Atoi(s[:n])
+		n++
+	}
+
+	rest := s[n:]
+	if rest == "" || isBlank(rest) {
+		marker.width = indent + n + 1
+		return marker, "", true
+	}
+	spaces := indentOf(rest)
+	if spaces == 0 {
+		return listMarker{}, "", false
+	}
+	// Content indented by five or more spaces is indented code, which
+	// starts one space after the marker.
+	if spaces > 4 {
+		spaces = 1
+	}
+	marker.width = indent + n + spaces
+	return marker, rest[spaces:], true
+}
+
+func writeList(b *strings.Builder, lines []string, i, depth int) int {
+	first, _, _ := parseListItem(lines[i])
+	var items [][]string
+	loose := false
+
+	for i < len(lines) {
+		if thematicBreakPattern.MatchString(lines[i]) {
+			break
+		}
+		marker, content, ok := parseListItem(lines[i])
+		if !ok || marker.ordered != first.ordered || marker.delimiter != first.delimiter {
+			break
+		}
+
+		item := []string{content}
+	collect:
+		for i++; i < len(lines); i++ {
+			line := lines[i]
+			switch {
+			case isBlank(line):
+				item = append(item, "")
+			case indentOf(line) >= marker.width:
+				item = append(item, line[marker.width:])
+			case !isBlank(item[len(item)-1]) && !startsBlock(line) && !thematicBreakPattern.MatchString(line):
+				// Lazy continuation of the paragraph.
+				item = append(item, strings.TrimLeft(line, " "))
+			default:
+				break collect
+			}
+		}
+
+		// Blank lines between two items make the list loose, as do blank
+		// lines between the blocks of an item.
+		trailing := 0
+		for len(item) > 1 && isBlank(item[len(item)-1]) {
+			item = item[:len(item)-1]
+			trailing++
+		}
+		if trailing > 0 && i < len(lines) {
+			if next, _, ok := parseListItem(lines[i]); ok && next.ordered == first.ordered && next.delimiter == first.delimiter {
+				loose = true
+			}
+		}
+		if hasInnerBlank(item) {
+			loose = true
+		}
+		items = append(items, item)
+	}
+
+	tag := "ul"
+	if first.ordered {
+		tag = "ol"
+	}
+	if first.ordered && first.start != 1 {
+		b.WriteString(`<ol start="` + strconv.Itoa(first.start) + `">` + "\n")
+	} else {
+		b.WriteString("<" + tag + ">\n")
+	}
+	for _, item := range items {
+		var content strings.Builder
+		writeBlocks(&content, item, !loose, depth+1)
+		b.WriteString("<li>")
+		b.WriteString(strings.TrimSuffix(content.String(), "\n"))
+		b.WriteString("</li>\n")
+	}
+	b.WriteString("</" + tag + ">\n")
+	return i
+}
+
+// hasInnerBlank reports whether a blank line separates two blocks of an
+// item.
+func hasInnerBlank(item []string) bool {
+	seen := false
+	for i, line := range item {
+		if !isBlank(line) {
+			seen = true
+			continue
+		}
+		if seen && i+1 < len(item) && !isBlank(item[i+1]) && indentOf(item[i+1]) < 4 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,221 @@
+package render
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"rakia-tech-test/internal/domain/entities"
+)
+
+func TestMarkdown_Blocks(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		expected string
+	}{
+		{
+			name:     "paragraphs",
+			src:      "one\ntwo\n\nthree",
+			expected: "<p>one\ntwo</p>\n<p>three</p>\n",
+		},
+		{
+			name:     "atx headings",
+			src:      "# Title #\n###### Small\n#hashtag",
+			expected: "<h1>Title</h1>\n<h6>Small</h6>\n<p>#hashtag</p>\n",
+		},
+		{
+			name:     "setext headings",
+			src:      "Title\n=====\nSub\n---",
+			expected: "<h1>Title</h1>\n<h2>Sub</h2>\n",
+		},
+		{
+			name:     "thematic break",
+			src:      "a\n\n* * *\n\nb",
+			expected: "<p>a</p>\n<hr />\n<p>b</p>\n",
+		},
+		{
+			name:     "fenced code",
+			src:      "```go\nif a < b {\n}\n```\nafter",
+			expected: "<pre><code class=\"language-go\">if a &lt; b {\n}\n</code></pre>\n<p>after</p>\n",
+		},
+		{
+			name:     "fenced code with a hostile language",
+			src:      "~~~ \"onmouseover=alert(1)\n<script>\n~~~",
+			expected: "<pre><code>&lt;script&gt;\n</code></pre>\n",
+		},
+		{
+			name:     "unclosed fence runs to the end",
+			src:      "```\ncode",
+			expected: "<pre><code>code\n</code></pre>\n",
+		},
+		{
+			name:     "indented code",
+			src:      "    *not emphasis*\n\n    more\n\ntext",
+			expected: "<pre><code>*not emphasis*\n\nmore\n</code></pre>\n<p>text</p>\n",
+		},
+		{
+			name:     "block quote with lazy continuation",
+			src:      "> # Quote\n> first\nsecond\n\nafter",
+			expected: "<blockquote>\n<h1>Quote</h1>\n<p>first\nsecond</p>\n</blockquote>\n<p>after</p>\n",
+		},
+		{
+			name:     "tight bullet list",
+			src:      "- one\n- two\n  continued\n- three",
+			expected: "<ul>\n<li>one</li>\n<li>two\ncontinued</li>\n<li>three</li>\n</ul>\n",
+		},
+		{
+			name:     "loose ordered list",
+			src:      "3. one\n\n4. two",
+			expected: "<ol start=\"3\">\n<li><p>one</p></li>\n<li><p>two</p></li>\n</ol>\n",
+		},
+		{
+			name:     "nested list",
+			src:      "* one\n  * nested\n* two",
+			expected: "<ul>\n<li>one\n<ul>\n<li>nested</li>\n</ul></li>\n<li>two</li>\n</ul>\n",
+		},
+		{
+			name:     "list interrupts a paragraph",
+			src:      "Shopping:\n- milk\n\n1) first",
+			expected: "<p>Shopping:</p>\n<ul>\n<li>milk</li>\n</ul>\n<ol>\n<li>first</li>\n</ol>\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Markdown(tt.src))
+		})
+	}
+}
+
+func TestMarkdown_Inline(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		expected string
+	}{
+		{name: "emphasis", src: "*a* _b_ **c** __d__", expected: "<em>a</em> <em>b</em> <strong>c</strong> <strong>d</strong>"},
+		{name: "nested emphasis", src: "*a **b** c*", expected: "<em>a <strong>b</strong> c</em>"},
+		{name: "triple emphasis", src: "***a*** ***b** c* ***d* e**", expected: "<em><strong>a</strong></em> <em><strong>b</strong> c</em> <strong><em>d</em> e</strong>"},
+		{name: "unbalanced emphasis", src: "**a* b", expected: "*<em>a</em> b"},
+		{name: "no emphasis inside words or around spaces", src: "snake_case_name a * b", expected: "snake_case_name a * b"},
+		{name: "strikethrough", src: "~~gone~~ ~kept~", expected: "<del>gone</del> ~kept~"},
+		{name: "code span", src: "`a <b> *c*` `` ` ``", expected: "<code>a &lt;b&gt; *c*</code> <code>`</code>"},
+		{name: "backslash escapes", src: `\*not\* \[x\]`, expected: "*not* [x]"},
+		{name: "hard breaks", src: "a  \nb\\\nc \nd", expected: "a<br />\nb<br />\nc\nd"},
+		{name: "entities", src: "&copy; &#169; &bogus; AT&T", expected: "&copy; &#169; &amp;bogus; AT&amp;T"},
+		{name: "link", src: `[the *site*](https://example.com/a_(b) "Home")`, expected: `<a href="https://example.com/a_(b)" title="Home">the <em>site</em></a>`},
+		{name: "relative link", src: "[post](/api/v1/posts/1?render=html&x=1)", expected: `<a href="/api/v1/posts/1?render=html&amp;x=1">post</a>`},
+		{name: "link in angle brackets", src: "[mail](<mailto:me@example.com>)", expected: `<a href="mailto:me@example.com">mail</a>`},
+		{name: "not a link", src: "[a] (b) [c]", expected: "[a] (b) [c]"},
+		{name: "links do not nest", src: "[a [b](c) d](e)", expected: `[a <a href="c">b</a> d](e)`},
+		{name: "emphasis stays inside links", src: "*a [b*](c)", expected: `*a <a href="c">b*</a>`},
+		{name: "image", src: `![a *cat*](cat.png "Cat")`, expected: `<img src="cat.png" alt="a cat" title="Cat" />`},
+		{name: "autolinks", src: "<https://example.com> <me@example.com>", expected: `<a href="https://example.com">https://example.com</a> <a href="mailto:me@example.com">me@example.com</a>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, "<p>"+tt.expected+"</p>\n", Markdown(tt.src))
+		})
+	}
+}
+
+func TestMarkdown_Sanitizes(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		expected string
+	}{
+		{name: "script tag", src: "<script>alert(1)</script>", expected: "&lt;script&gt;alert(1)&lt;/script&gt;"},
+		{name: "event handler", src: `<img src=x onerror="alert(1)">`, expected: "&lt;img src=x onerror=&#34;alert(1)&#34;&gt;"},
+		{name: "javascript link", src: "[click](javascript:alert(1))", expected: "click"},
+		{name: "obfuscated scheme", src: "[click](JaVa&#9;ScRiPt:alert(1))", expected: "click"},
+		{name: "entity encoded scheme", src: "[click](&#106;avascript:alert(1))", expected: "click"},
+		{name: "data link", src: "[click](data:text/html;base64,PHNjcmlwdD4=)", expected: "click"},
+		{name: "javascript autolink", src: "<javascript:alert(1)>", expected: "&lt;javascript:alert(1)&gt;"},
+		{name: "data image", src: "![x](data:image/svg+xml,%3Csvg%20onload=alert(1)%3E)", expected: "x"},
+		{name: "quotes in attributes", src: `[a](/x"onmouseover="alert(1) 't"itle')`, expected: `<a href="/x&#34;onmouseover=&#34;alert(1)" title="t&#34;itle">a</a>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, "<p>"+tt.expected+"</p>\n", Markdown(tt.src))
+		})
+	}
+}
+
+func TestMarkdown_OnlyWritesKnownTags(t *testing.T) {
+	src := strings.Join([]string{
+		"# <h1 onclick=x>", "> <iframe src=javascript:x>", "- <a href=javascript:x>a</a>",
+		"```\n</code><script>\n```", "`</code><script>`", "[<b>](#)", "![<svg/onload=x>](x.png)",
+	}, "\n\n")
+
+	rendered := Markdown(src)
+	assert.NotContains(t, rendered, "<script")
+	assert.NotContains(t, rendered, "<iframe")
+	assert.NotContains(t, rendered, "<svg")
+	assert.NotContains(t, rendered, "<h1 ")
+	assert.NotContains(t, rendered, "javascript:x>")
+}
+
+func TestPlain(t *testing.T) {
+	assert.Equal(t, "<p>one<br />\n&lt;two&gt;</p>\n<p>three &amp; four</p>\n", Plain("one\r\n<two>\n \nthree & four\n"))
+	assert.Empty(t, Plain(" \n"))
+}
+
+func TestContent(t *testing.T) {
+	assert.Equal(t, "<p><em>a</em></p>\n", Content(entities.FormatMarkdown, "*a*"))
+	assert.Equal(t, "<p>*a*</p>\n", Content(entities.FormatPlain, "*a*"))
+}
+
+func TestMarkdown_NestingDepth(t *testing.T) {
+	deep := strings.Repeat("> ", maxBlockDepth) + "> x"
+	rendered := Markdown(deep)
+	assert.Equal(t, maxBlockDepth, strings.Count(rendered, "<blockquote>"))
+	assert.Contains(t, rendered, "<p>&gt; x</p>", "deeper markers are text")
+}
+
+// nestedLines returns a list nested one level deeper on every line, about
+// size bytes long.
+func nestedLines(size int) string {
+	var b strings.Builder
+	for depth := 0; b.Len() < size-200; depth++ {
+		b.WriteString(strings.Repeat("  ", depth) + "- item\n")
+	}
+	return b.String()
+}
+
+func TestMarkdown_PathologicalInput(t *testing.T) {
+	var backticks strings.Builder
+	for n := 1; n <= 300; n++ {
+		backticks.WriteString(strings.Repeat("`", n) + " a ")
+	}
+	tests := []struct {
+		name string
+		src  string
+	}{
+		{name: "unclosed emphasis", src: strings.Repeat("*a _b ", 10000)},
+		{name: "delimiter runs", src: strings.Repeat("**_", 20000)},
+		{name: "nested emphasis", src: strings.Repeat("*a ", 10000) + strings.Repeat("b* ", 10000)},
+		{name: "unclosed brackets", src: strings.Repeat("[", 60000)},
+		{name: "links in brackets", src: strings.Repeat("[", 30000) + strings.Repeat("[a](b)", 5000)},
+		{name: "unclosed destinations", src: strings.Repeat("[a](b", 12000)},
+		{name: "unclosed titles", src: strings.Repeat(`[a](b "c`, 8000)},
+		{name: "backtick runs", src: backticks.String()},
+		{name: "nested lists", src: strings.Repeat("- ", entities.MaxContentLength/2-1) + "x"},
+		{name: "nested quotes", src: strings.Repeat(">", entities.MaxContentLength-1) + "x"},
+		{name: "nested quotes and lists", src: strings.Repeat("> - ", entities.MaxContentLength/4-1) + "x"},
+		{name: "nested list lines", src: nestedLines(entities.MaxContentLength)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			Markdown(tt.src)
+			assert.Less(t, time.Since(start), time.Second, "rendering takes time linear in the content")
+		})
+	}
+}
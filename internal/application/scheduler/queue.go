@@ -0,0 +1,135 @@
+// Package scheduler publishes posts at the time their publication was
+// scheduled for. A repository decorator keeps a queue of pending
+// publications in step with the stored posts, and the Scheduler publishes
+// each one when it falls due.
+package scheduler
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Job is the pending publication of a post.
+type Job struct {
+	PostID int
+	At     time.Time
+}
+
+// Queue holds at most one pending publication per post. It is safe for
+// concurrent use.
+type Queue struct {
+	jobs    map[int]time.Time
+	changed chan struct{}
+	mutex   sync.Mutex
+}
+
+func NewQueue() *Queue {
+	return &Queue{
+		jobs:    make(map[int]time.Time),
+		changed: make(chan struct{}, 1),
+	}
+}
+
+// Put schedules the publication of a post at job.At, replacing any earlier
+// job for the same post.
+func (q *Queue) Put(job Job) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.jobs[job.PostID] = job.At
+	q.notify()
+}
+
+// Remove cancels the pending publication of a post, if any.
+func (q *Queue) Remove(postID int) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if _, ok := q.jobs[postID]; ok {
+		delete(q.jobs, postID)
+		q.notify()
+	}
+}
+
+// Complete removes job unless the post has been rescheduled since it was
+// taken from the queue.
+func (q *Queue) Complete(job Job) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if at, ok := q.jobs[job.PostID]; ok && at.Equal(job.At) {
+		delete(q.jobs, job.PostID)
+		q.notify()
+	}
+}
+
+// Reset replaces every job with jobs.
+func (q *Queue) Reset(jobs []Job) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.jobs = make(map[int]time.Time, len(jobs))
+	for _, job := range jobs {
+		q.jobs[job.PostID] = job.At
+	}
+	q.notify()
+}
+
+// Next returns the earliest job, or false when the queue is empty.
+func (q *Queue) Next() (Job, bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	var next Job
+	found := false
+	for id, at := range q.jobs {
+		if !found || at.Before(next.At) || (at.Equal(next.At) && id < next.PostID) {
+			next = Job{PostID: id, At: at}
+			found = true
+		}
+	}
+	return next, found
+}
+
+// Due returns the jobs due at now, earliest first.
+func (q *Queue) Due(now time.Time) []Job {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	var due []Job
+	for id, at := range q.jobs {
+		if !at.After(now) {
+			due = append(due, Job{PostID: id, At: at})
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].At.Equal(due[j].At) {
+			return due[i].At.Before(due[j].At)
+		}
+		return due[i].PostID < due[j].PostID
+	})
+	return due
+}
+
+// Len returns the number of pending jobs.
+func (q *Queue) Len() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	return len(q.jobs)
+}
+
+// Changed returns a channel that receives a value after the queue changed.
+// Changes made while nobody is receiving are coalesced into one.
+func (q *Queue) Changed() <-chan struct{} {
+	return q.changed
+}
+
+// notify signals a change without blocking. The caller must hold the mutex.
+func (q *Queue) notify() {
+	select {
+	case q.changed <- struct{}{}:
+	default:
+	}
+}
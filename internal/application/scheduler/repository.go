@@ -0,0 +1,150 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/repositories"
+)
+
+// ScheduledRepository decorates a PostRepository and keeps a Queue of
+// pending publications in sync with every write that goes through it. Reads
+// are passed through.
+//
+// Writes are serialized so the queue applies them in the order the
+// underlying repository did. Trashed posts lose their pending publication
+// and get it back when they are restored.
+type ScheduledRepository struct {
+	repositories.PostRepository
+	queue *Queue
+	mutex sync.Mutex
+}
+
+// NewScheduledRepository queues the pending publications of the posts
+// already stored in repo and returns the decorated repository. This is how
+// schedules survive a restart.
+func NewScheduledRepository(ctx context.Context, repo repositories.PostRepository, queue *Queue) (*ScheduledRepository, error) {
+	posts, err := repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var jobs []Job
+	for _, post := range posts {
+		if post.IsScheduled() {
+			jobs = append(jobs, Job{PostID: post.ID, At: *post.PublishAt})
+		}
+	}
+	queue.Reset(jobs)
+
+	return &ScheduledRepository{PostRepository: repo, queue: queue}, nil
+}
+
+func (r *ScheduledRepository) Create(ctx context.Context, post *entities.Post) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if err := r.PostRepository.Create(ctx, post); err != nil {
+		return err
+	}
+	r.sync(post)
+	return nil
+}
+
+func (r *ScheduledRepository) CreatePost(ctx context.Context, title, content string, format entities.ContentFormat, author string, authorID int, tags []string, createdAt time.Time) (*entities.Post, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	post, err := r.PostRepository.CreatePost(ctx, title, content, format, author, authorID, tags, createdAt)
+	if err != nil {
+		return nil, err
+	}
+	r.sync(post)
+	return post, nil
+}
+
+func (r *ScheduledRepository) Update(ctx context.Context, id int, post *entities.Post, expectedVersion int) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if err := r.PostRepository.Update(ctx, id, post, expectedVersion); err != nil {
+		return err
+	}
+	postCopy := *post
+	postCopy.ID = id
+	r.sync(&postCopy)
+	return nil
+}
+
+func (r *ScheduledRepository) Delete(ctx context.Context, id int, expectedVersion int) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if err := r.PostRepository.Delete(ctx, id, expectedVersion); err != nil {
+		return err
+	}
+	r.queue.Remove(id)
+	return nil
+}
+
+func (r *ScheduledRepository) LoadData(ctx context.Context, posts []*entities.Post) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if err := r.PostRepository.LoadData(ctx, posts); err != nil {
+		return err
+	}
+	for _, post := range posts {
+		r.sync(post)
+	}
+	return nil
+}
+
+func (r *ScheduledRepository) Trash(ctx context.Context, id int, expectedVersion int, deletedAt time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if err := r.PostRepository.Trash(ctx, id, expectedVersion, deletedAt); err != nil {
+		return err
+	}
+	r.queue.Remove(id)
+	return nil
+}
+
+func (r *ScheduledRepository) Restore(ctx context.Context, id int) (*entities.Post, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	post, err := r.PostRepository.Restore(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	r.sync(post)
+	return post, nil
+}
+
+func (r *ScheduledRepository) Purge(ctx context.Context, deletedBefore time.Time) ([]int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	purged, err := r.PostRepository.Purge(ctx, deletedBefore)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range purged {
+		r.queue.Remove(id)
+	}
+	return purged, nil
+}
+
+// sync queues the publication of post when one is pending and removes it
+// otherwise. The caller must hold the mutex.
+func (r *ScheduledRepository) sync(post *entities.Post) {
+	if post.IsScheduled() && !post.IsDeleted() {
+		r.queue.Put(Job{PostID: post.ID, At: *post.PublishAt})
+	} else {
+		r.queue.Remove(post.ID)
+	}
+}
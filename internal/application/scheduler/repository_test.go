@@ -0,0 +1,90 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/repositories"
+	"rakia-tech-test/internal/domain/repositories/repositorytest"
+	memory_repositories "rakia-tech-test/internal/infrastructure/repositories"
+)
+
+func TestScheduledRepository_Contract(t *testing.T) {
+	repositorytest.RunPostRepositoryTests(t, func(t *testing.T) repositories.PostRepository {
+		repo, err := NewScheduledRepository(context.Background(), memory_repositories.NewMemoryPostRepository(), NewQueue())
+		require.NoError(t, err)
+		return repo
+	})
+}
+
+func TestScheduledRepository_KeepsQueueInSync(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	publishAt := now.Add(time.Hour)
+
+	inner := memory_repositories.NewMemoryPostRepository()
+	require.NoError(t, inner.Create(ctx, scheduledPost(t, 1, now, publishAt)))
+
+	queue := NewQueue()
+	repo, err := NewScheduledRepository(ctx, inner, queue)
+	require.NoError(t, err)
+	assert.Equal(t, []Job{{PostID: 1, At: publishAt}}, queue.Due(publishAt), "existing schedules are queued")
+
+	created, err := repo.CreatePost(ctx, "Fresh", "Brand new text", "", "Author", 0, nil, now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, queue.Len(), "drafts are not queued")
+
+	version := created.Version
+	require.NoError(t, created.TransitionTo(entities.StatusReview, now))
+	require.NoError(t, repo.Update(ctx, created.ID, created, version))
+	version = created.Version
+	require.NoError(t, created.SchedulePublication(publishAt.Add(time.Hour), now))
+	require.NoError(t, repo.Update(ctx, created.ID, created, version))
+	assert.Equal(t, 2, queue.Len())
+
+	// A rejected write leaves the queue alone.
+	stale := *created
+	stale.PublishAt = nil
+	assert.ErrorIs(t, repo.Update(ctx, created.ID, &stale, version), repositories.ErrVersionConflict)
+	assert.Equal(t, 2, queue.Len())
+
+	version = created.Version
+	require.NoError(t, created.TransitionTo(entities.StatusDraft, now))
+	require.NoError(t, repo.Update(ctx, created.ID, created, version))
+	assert.Equal(t, 1, queue.Len(), "a transition cancels the schedule")
+
+	trashedAt := now.Add(-time.Hour)
+	require.NoError(t, repo.Trash(ctx, 1, repositories.AnyVersion, trashedAt))
+	assert.Zero(t, queue.Len(), "trashed posts are not published")
+
+	_, err = repo.Restore(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, queue.Len())
+
+	require.NoError(t, repo.Trash(ctx, 1, repositories.AnyVersion, trashedAt))
+	_, err = repo.Purge(ctx, now)
+	require.NoError(t, err)
+	assert.Zero(t, queue.Len())
+
+	require.NoError(t, repo.LoadData(ctx, []*entities.Post{scheduledPost(t, 10, now, publishAt)}))
+	assert.Equal(t, 1, queue.Len())
+
+	require.NoError(t, repo.Delete(ctx, 10, repositories.AnyVersion))
+	assert.Zero(t, queue.Len())
+}
+
+// scheduledPost returns a post in review whose publication is scheduled at
+// publishAt.
+func scheduledPost(t *testing.T, id int, now, publishAt time.Time) *entities.Post {
+	t.Helper()
+	post, err := entities.NewPost(id, "Title", "Content", "Author", now)
+	require.NoError(t, err)
+	require.NoError(t, post.TransitionTo(entities.StatusReview, now))
+	require.NoError(t, post.SchedulePublication(publishAt, now))
+	return post
+}
@@ -0,0 +1,92 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"rakia-tech-test/internal/domain/clock"
+	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/repositories"
+)
+
+// RetryDelay is how long the Scheduler waits before trying again to publish
+// a post whose publication failed.
+const RetryDelay = time.Minute
+
+// Publisher publishes a post whose scheduled publication is due.
+type Publisher interface {
+	PublishScheduled(ctx context.Context, id int) (*entities.Post, error)
+}
+
+// Scheduler publishes the posts in a Queue as their publication falls due.
+type Scheduler struct {
+	queue     *Queue
+	publisher Publisher
+	clock     clock.Clock
+	logger    *logrus.Logger
+}
+
+func NewScheduler(queue *Queue, publisher Publisher, clock clock.Clock, logger *logrus.Logger) *Scheduler {
+	return &Scheduler{
+		queue:     queue,
+		publisher: publisher,
+		clock:     clock,
+		logger:    logger,
+	}
+}
+
+// Run publishes due posts until ctx is done. It sleeps until the earliest
+// pending publication and wakes up early whenever the queue changes.
+func (s *Scheduler) Run(ctx context.Context) {
+	s.logger.WithField("pending", s.queue.Len()).Info("Publication scheduler started")
+
+	for {
+		for _, job := range s.queue.Due(s.clock.Now()) {
+			if ctx.Err() != nil {
+				break
+			}
+			s.publish(ctx, job)
+		}
+
+		var wake <-chan time.Time
+		if next, ok := s.queue.Next(); ok {
+			wake = s.clock.After(next.At.Sub(s.clock.Now()))
+		}
+
+		select {
+		case <-ctx.Done():
+			s.logger.Info("Publication scheduler stopped")
+			return
+		case <-s.queue.Changed():
+		case <-wake:
+		}
+	}
+}
+
+// publish publishes the post of job. On success the repository decorator
+// removes the job; publications that are gone are dropped, and any other
+// failure is retried after RetryDelay.
+func (s *Scheduler) publish(ctx context.Context, job Job) {
+	log := s.logger.WithFields(logrus.Fields{
+		"post_id":    job.PostID,
+		"publish_at": job.At,
+	})
+
+	_, err := s.publisher.PublishScheduled(ctx, job.PostID)
+	switch {
+	case err == nil:
+		log.Info("Published scheduled post")
+	case errors.Is(err, repositories.ErrPostNotFound), errors.Is(err, entities.ErrPublicationNotDue):
+		log.Debug("Dropping stale scheduled publication")
+		s.queue.Complete(job)
+	default:
+		if ctx.Err() != nil {
+			return
+		}
+		log.WithError(err).Error("Failed to publish scheduled post, retrying later")
+		s.queue.Put(Job{PostID: job.PostID, At: s.clock.Now().Add(RetryDelay)})
+	}
+}
@@ -0,0 +1,250 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"rakia-tech-test/internal/application/services"
+	"rakia-tech-test/internal/domain/clock"
+	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/repositories"
+	memory_repositories "rakia-tech-test/internal/infrastructure/repositories"
+)
+
+var start = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func TestQueue_Order(t *testing.T) {
+	queue := NewQueue()
+	_, ok := queue.Next()
+	assert.False(t, ok)
+
+	queue.Put(Job{PostID: 2, At: start.Add(2 * time.Hour)})
+	queue.Put(Job{PostID: 3, At: start.Add(time.Hour)})
+	queue.Put(Job{PostID: 1, At: start.Add(time.Hour)})
+
+	next, ok := queue.Next()
+	require.True(t, ok)
+	assert.Equal(t, Job{PostID: 1, At: start.Add(time.Hour)}, next)
+	assert.Equal(t, []Job{
+		{PostID: 1, At: start.Add(time.Hour)},
+		{PostID: 3, At: start.Add(time.Hour)},
+	}, queue.Due(start.Add(time.Hour)))
+
+	// Completing a job that was rescheduled meanwhile keeps the new one.
+	queue.Put(Job{PostID: 1, At: start.Add(3 * time.Hour)})
+	queue.Complete(Job{PostID: 1, At: start.Add(time.Hour)})
+	assert.Equal(t, 3, queue.Len())
+	queue.Complete(Job{PostID: 1, At: start.Add(3 * time.Hour)})
+	assert.Equal(t, 2, queue.Len())
+}
+
+func TestScheduler_PublishesWhenDue(t *testing.T) {
+	ctx := context.Background()
+	fake := clock.NewFake(start)
+	inner := memory_repositories.NewMemoryPostRepository()
+	service, queue := newService(t, inner, fake)
+	stop := run(t, NewScheduler(queue, service, fake, quietLogger()))
+	defer stop()
+
+	early := scheduleNew(t, service, start.Add(time.Hour))
+	late := scheduleNew(t, service, start.Add(2*time.Hour))
+	waitForTimer(t, fake)
+
+	fake.Advance(59 * time.Minute)
+	assert.Equal(t, entities.StatusReview, status(t, inner, early), "not due yet")
+
+	fake.Advance(time.Minute)
+	require.Eventually(t, func() bool {
+		return status(t, inner, early) == entities.StatusPublished
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, entities.StatusReview, status(t, inner, late))
+	assert.Equal(t, 1, queue.Len())
+
+	// Cancelling a schedule removes it from the queue.
+	_, err := service.TransitionPost(ctx, late, entities.StatusDraft, nil)
+	require.NoError(t, err)
+	assert.Zero(t, queue.Len())
+}
+
+func TestScheduler_RescheduleWakesUp(t *testing.T) {
+	fake := clock.NewFake(start)
+	inner := memory_repositories.NewMemoryPostRepository()
+	service, queue := newService(t, inner, fake)
+	stop := run(t, NewScheduler(queue, service, fake, quietLogger()))
+	defer stop()
+
+	id := scheduleNew(t, service, start.Add(24*time.Hour))
+	waitForTimer(t, fake)
+
+	// Moving the publication earlier must not wait for the old timer.
+	_, err := service.SchedulePost(context.Background(), id, start.Add(time.Minute), nil)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return fake.Waiters() >= 2 }, time.Second, time.Millisecond)
+
+	fake.Advance(time.Minute)
+	require.Eventually(t, func() bool {
+		return status(t, inner, id) == entities.StatusPublished
+	}, time.Second, time.Millisecond)
+}
+
+func TestScheduler_SurvivesRestart(t *testing.T) {
+	fake := clock.NewFake(start)
+	inner := memory_repositories.NewMemoryPostRepository()
+
+	service, _ := newService(t, inner, fake)
+	id := scheduleNew(t, service, start.Add(time.Hour))
+
+	// A fresh decorator over the same storage finds the pending schedule.
+	service, queue := newService(t, inner, fake)
+	assert.Equal(t, 1, queue.Len())
+	stop := run(t, NewScheduler(queue, service, fake, quietLogger()))
+	defer stop()
+
+	waitForTimer(t, fake)
+	fake.Advance(time.Hour)
+	require.Eventually(t, func() bool {
+		return status(t, inner, id) == entities.StatusPublished
+	}, time.Second, time.Millisecond)
+}
+
+func TestScheduler_PublishesOverdueOnStart(t *testing.T) {
+	fake := clock.NewFake(start)
+	inner := memory_repositories.NewMemoryPostRepository()
+	service, queue := newService(t, inner, fake)
+	id := scheduleNew(t, service, start.Add(time.Hour))
+
+	// The server was down when the publication fell due.
+	fake.Advance(2 * time.Hour)
+	stop := run(t, NewScheduler(queue, service, fake, quietLogger()))
+	defer stop()
+
+	require.Eventually(t, func() bool {
+		return status(t, inner, id) == entities.StatusPublished
+	}, time.Second, time.Millisecond)
+}
+
+func TestScheduler_RetriesFailures(t *testing.T) {
+	fake := clock.NewFake(start)
+	queue := NewQueue()
+	queue.Put(Job{PostID: 1, At: start})
+	queue.Put(Job{PostID: 2, At: start})
+
+	publisher := &flakyPublisher{errs: map[int]error{
+		1: errors.New("storage unavailable"),
+		2: repositories.ErrPostNotFound,
+	}}
+	stop := run(t, NewScheduler(queue, publisher, fake, quietLogger()))
+	defer stop()
+
+	waitForTimer(t, fake)
+	next, ok := queue.Next()
+	require.True(t, ok)
+	assert.Equal(t, Job{PostID: 1, At: start.Add(RetryDelay)}, next, "failures are retried later")
+	assert.Equal(t, 1, queue.Len(), "missing posts are dropped")
+
+	publisher.fail(1, nil)
+	fake.Advance(RetryDelay)
+	require.Eventually(t, func() bool { return publisher.published(1) }, time.Second, time.Millisecond)
+}
+
+// flakyPublisher fails the publications listed in errs and records the
+// others.
+type flakyPublisher struct {
+	errs  map[int]error
+	done  map[int]bool
+	mutex sync.Mutex
+}
+
+func (p *flakyPublisher) PublishScheduled(_ context.Context, id int) (*entities.Post, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if err := p.errs[id]; err != nil {
+		return nil, err
+	}
+	if p.done == nil {
+		p.done = make(map[int]bool)
+	}
+	p.done[id] = true
+	return &entities.Post{ID: id}, nil
+}
+
+func (p *flakyPublisher) fail(id int, err error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.errs[id] = err
+}
+
+func (p *flakyPublisher) published(id int) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.done[id]
+}
+
+// newService builds a post service whose writes keep a fresh queue in sync
+// with inner.
+func newService(t *testing.T, inner repositories.PostRepository, c clock.Clock) (*services.PostService, *Queue) {
+	t.Helper()
+	queue := NewQueue()
+	repo, err := NewScheduledRepository(context.Background(), inner, queue)
+	require.NoError(t, err)
+	return services.NewPostService(repo, memory_repositories.NewMemoryRevisionRepository(), memory_repositories.NewMemoryAuthorRepository(), memory_repositories.NewMemoryCommentRepository(), c, quietLogger()), queue
+}
+
+// scheduleNew creates a post, sends it to review and schedules its
+// publication at publishAt.
+func scheduleNew(t *testing.T, service *services.PostService, publishAt time.Time) int {
+	t.Helper()
+	ctx := context.Background()
+	post, err := service.CreatePost(ctx, "Title", "Content", "", "Author", 0, nil)
+	require.NoError(t, err)
+	_, err = service.TransitionPost(ctx, post.ID, entities.StatusReview, nil)
+	require.NoError(t, err)
+	_, err = service.SchedulePost(ctx, post.ID, publishAt, nil)
+	require.NoError(t, err)
+	return post.ID
+}
+
+// run starts s in the background and returns a function stopping it.
+func run(t *testing.T, s *Scheduler) func() {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Run(ctx)
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// waitForTimer waits until the scheduler sleeps on the fake clock.
+func waitForTimer(t *testing.T, fake *clock.Fake) {
+	t.Helper()
+	require.Eventually(t, func() bool { return fake.Waiters() > 0 }, time.Second, time.Millisecond)
+}
+
+func status(t *testing.T, repo repositories.PostRepository, id int) entities.PostStatus {
+	t.Helper()
+	post, err := repo.GetByID(context.Background(), id)
+	require.NoError(t, err)
+	return post.Status
+}
+
+func quietLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
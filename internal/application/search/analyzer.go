@@ -0,0 +1,77 @@
+// Package search maintains an inverted index over the title and content of
+// posts and ranks matches with BM25.
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Token is an indexed term together with the byte range of the word it came
+// from in the analyzed text.
+type Token struct {
+	Term  string
+	Start int
+	End   int
+}
+
+// stopwords are frequent English words that carry no meaning for search and
+// are left out of the index and of queries.
+var stopwords = map[string]bool{
+	"a": true, "about": true, "after": true, "all": true, "also": true, "an": true,
+	"and": true, "any": true, "are": true, "as": true, "at": true, "be": true,
+	"been": true, "but": true, "by": true, "can": true, "do": true, "does": true,
+	"for": true, "from": true, "had": true, "has": true, "have": true, "he": true,
+	"her": true, "his": true, "how": true, "i": true, "if": true, "in": true,
+	"into": true, "is": true, "it": true, "its": true, "just": true, "me": true,
+	"my": true, "no": true, "not": true, "of": true, "on": true, "or": true,
+	"our": true, "she": true, "so": true, "than": true, "that": true, "the": true,
+	"their": true, "them": true, "then": true, "there": true, "these": true,
+	"they": true, "this": true, "those": true, "to": true, "too": true, "was": true,
+	"we": true, "were": true, "what": true, "when": true, "where": true,
+	"which": true, "while": true, "who": true, "why": true, "will": true,
+	"with": true, "would": true, "you": true, "your": true,
+}
+
+// Analyze splits text into words, folds them to lower case, drops stopwords
+// and stems the rest.
+func Analyze(text string) []Token {
+	words := splitWords(text)
+	tokens := make([]Token, 0, len(words))
+	for _, word := range words {
+		if term, ok := termOf(text[word.Start:word.End]); ok {
+			tokens = append(tokens, Token{Term: term, Start: word.Start, End: word.End})
+		}
+	}
+	return tokens
+}
+
+// termOf returns the indexed term of a word, or false for a stopword.
+func termOf(word string) (string, bool) {
+	folded := strings.ToLower(word)
+	if stopwords[folded] {
+		return "", false
+	}
+	return Stem(folded), true
+}
+
+// splitWords returns the byte ranges of the runs of letters and digits in
+// text. The Term of the returned tokens is empty.
+func splitWords(text string) []Token {
+	var words []Token
+	start := -1
+	for i, r := range text {
+		inWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case inWord && start < 0:
+			start = i
+		case !inWord && start >= 0:
+			words = append(words, Token{Start: start, End: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		words = append(words, Token{Start: start, End: len(text)})
+	}
+	return words
+}
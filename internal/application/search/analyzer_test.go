@@ -0,0 +1,120 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStem(t *testing.T) {
+	tests := map[string]string{
+		"caresses":        "caress",
+		"ponies":          "poni",
+		"cats":            "cat",
+		"feed":            "feed",
+		"agreed":          "agre",
+		"plastered":       "plaster",
+		"motoring":        "motor",
+		"sing":            "sing",
+		"conflated":       "conflat",
+		"hopping":         "hop",
+		"falling":         "fall",
+		"filing":          "file",
+		"happy":           "happi",
+		"relational":      "relat",
+		"conditional":     "condit",
+		"digitizer":       "digit",
+		"hopefulness":     "hope",
+		"formalize":       "formal",
+		"electrical":      "electr",
+		"revival":         "reviv",
+		"adoption":        "adopt",
+		"controlling":     "control",
+		"generalizations": "gener",
+		"connected":       "connect",
+		"connecting":      "connect",
+		"connection":      "connect",
+		"go":              "go",
+		"café":            "café",
+		"ied":             "i",
+	}
+
+	for word, expected := range tests {
+		t.Run(word, func(t *testing.T) {
+			assert.Equal(t, expected, Stem(word))
+		})
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	text := "The Runners were RUNNING, in Zürich's rain!"
+
+	assert.Equal(t, []Token{
+		{Term: "runner", Start: 4, End: 11},
+		{Term: "run", Start: 17, End: 24},
+		{Term: "zürich", Start: 29, End: 36},
+		{Term: "s", Start: 37, End: 38},
+		{Term: "rain", Start: 39, End: 43},
+	}, Analyze(text))
+
+	assert.Empty(t, Analyze("  the, of AND!  "))
+}
+
+func TestQuery_Highlight(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		text     string
+		width    int
+		expected string
+	}{
+		{
+			name:     "whole text",
+			query:    "connect",
+			text:     "Connected systems & connections",
+			expected: "<mark>Connected</mark> systems &amp; <mark>connections</mark>",
+		},
+		{
+			name:     "no match",
+			query:    "rust",
+			text:     "Go is <fun>",
+			expected: "Go is &lt;fun&gt;",
+		},
+		{
+			name:     "short text is not cut",
+			query:    "go",
+			text:     "Go tips",
+			width:    40,
+			expected: "<mark>Go</mark> tips",
+		},
+		{
+			name:     "window around the densest matches",
+			query:    "index search",
+			text:     "A search here. Then a long stretch of unrelated words follows before the index powers search and more text.",
+			width:    40,
+			expected: "…the <mark>index</mark> powers <mark>search</mark> and more text…",
+		},
+		{
+			name:     "no match falls back to the beginning",
+			query:    "missing",
+			text:     "One two three four five six seven",
+			width:    15,
+			expected: "One two three…",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ParseQuery(tt.query).Highlight(tt.text, tt.width))
+		})
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	q := ParseQuery("Running runs, the RUN")
+	assert.Equal(t, []string{"run"}, q.Terms())
+	assert.False(t, q.IsEmpty())
+
+	assert.True(t, ParseQuery("the and of").IsEmpty())
+	assert.True(t, ParseQuery("").IsEmpty())
+}
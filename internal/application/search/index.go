@@ -0,0 +1,202 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"sync"
+
+	"rakia-tech-test/internal/domain/entities"
+)
+
+// BM25 parameters: k1 controls how quickly repeated terms stop adding to
+// the score and b how strongly scores are normalized by field length.
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+type field int
+
+const (
+	titleField field = iota
+	contentField
+	numFields
+)
+
+// fieldWeights make a match in the title count twice as much as one in the
+// content.
+var fieldWeights = [numFields]float64{titleField: 2, contentField: 1}
+
+// Hit is a post matching a query together with its relevance score.
+type Hit struct {
+	PostID int
+	Score  float64
+}
+
+// document is the indexed form of one post.
+type document struct {
+	freqs   [numFields]map[string]int
+	lengths [numFields]int
+}
+
+// Index is an inverted index over the title and content of posts. It is safe
+// for concurrent use.
+type Index struct {
+	mutex    sync.RWMutex
+	docs     map[int]*document
+	postings map[string]map[int]struct{}
+	// totalLengths sum the field lengths of all documents, for the average
+	// lengths BM25 normalizes by.
+	totalLengths [numFields]int
+}
+
+func NewIndex() *Index {
+	return &Index{
+		docs:     make(map[int]*document),
+		postings: make(map[string]map[int]struct{}),
+	}
+}
+
+// Put indexes post, replacing what was indexed under its ID before.
+func (idx *Index) Put(post *entities.Post) {
+	idx.mutex.Lock()
+	defer idx.mutex.Unlock()
+
+	idx.put(post)
+}
+
+// Remove drops the post with the given ID from the index.
+func (idx *Index) Remove(id int) {
+	idx.mutex.Lock()
+	defer idx.mutex.Unlock()
+
+	idx.remove(id)
+}
+
+// Reset replaces the whole index with posts.
+func (idx *Index) Reset(posts []*entities.Post) {
+	idx.mutex.Lock()
+	defer idx.mutex.Unlock()
+
+	idx.docs = make(map[int]*document, len(posts))
+	idx.postings = make(map[string]map[int]struct{})
+	idx.totalLengths = [numFields]int{}
+	for _, post := range posts {
+		idx.put(post)
+	}
+}
+
+// Len returns the number of indexed posts.
+func (idx *Index) Len() int {
+	idx.mutex.RLock()
+	defer idx.mutex.RUnlock()
+
+	return len(idx.docs)
+}
+
+// Search returns up to limit posts matching q, best first, scored with
+// BM25F: the weighted, length-normalized term frequencies of both fields are
+// combined before saturation. Equal scores are ordered by ID. A limit of
+// zero returns every match.
+func (idx *Index) Search(q Query, limit int) []Hit {
+	idx.mutex.RLock()
+	defer idx.mutex.RUnlock()
+
+	n := float64(len(idx.docs))
+	var avgLengths [numFields]float64
+	for f := field(0); f < numFields; f++ {
+		if n > 0 {
+			avgLengths[f] = float64(idx.totalLengths[f]) / n
+		}
+	}
+
+	scores := make(map[int]float64)
+	for _, term := range q.terms {
+		postings := idx.postings[term]
+		if len(postings) == 0 {
+			continue
+		}
+		df := float64(len(postings))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+
+		for id := range postings {
+			doc := idx.docs[id]
+			tf := 0.0
+			for f := field(0); f < numFields; f++ {
+				freq := doc.freqs[f][term]
+				if freq == 0 {
+					continue
+				}
+				norm := 1.0
+				if avgLengths[f] > 0 {
+					norm = 1 - b + b*float64(doc.lengths[f])/avgLengths[f]
+				}
+				tf += fieldWeights[f] * float64(freq) / norm
+			}
+			scores[id] += idf * tf * (k1 + 1) / (k1 + tf)
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for id, score := range scores {
+		hits = append(hits, Hit{PostID: id, Score: score})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].PostID < hits[j].PostID
+	})
+
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+// put indexes post. The caller must hold the write lock.
+func (idx *Index) put(post *entities.Post) {
+	idx.remove(post.ID)
+
+	doc := &document{}
+	for f, text := range [numFields]string{titleField: post.Title, contentField: post.Content} {
+		tokens := Analyze(text)
+		doc.freqs[f] = make(map[string]int, len(tokens))
+		doc.lengths[f] = len(tokens)
+		idx.totalLengths[f] += len(tokens)
+
+		for _, token := range tokens {
+			doc.freqs[f][token.Term]++
+			postings, exists := idx.postings[token.Term]
+			if !exists {
+				postings = make(map[int]struct{})
+				idx.postings[token.Term] = postings
+			}
+			postings[post.ID] = struct{}{}
+		}
+	}
+
+	idx.docs[post.ID] = doc
+}
+
+// remove drops the post with the given ID. The caller must hold the write
+// lock.
+func (idx *Index) remove(id int) {
+	doc, exists := idx.docs[id]
+	if !exists {
+		return
+	}
+
+	for f := field(0); f < numFields; f++ {
+		idx.totalLengths[f] -= doc.lengths[f]
+		for term := range doc.freqs[f] {
+			postings := idx.postings[term]
+			delete(postings, id)
+			if len(postings) == 0 {
+				delete(idx.postings, term)
+			}
+		}
+	}
+
+	delete(idx.docs, id)
+}
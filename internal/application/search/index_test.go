@@ -0,0 +1,111 @@
+package search
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"rakia-tech-test/internal/domain/entities"
+)
+
+// mustPost returns a published post.
+func mustPost(t *testing.T, id int, title, content string) *entities.Post {
+	t.Helper()
+
+	post, err := entities.NewPost(id, title, content, "Author", time.Now())
+	require.NoError(t, err)
+	post.Status = entities.StatusPublished
+	return post
+}
+
+func hitIDs(hits []Hit) []int {
+	ids := make([]int, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.PostID
+	}
+	return ids
+}
+
+func TestIndex_Search(t *testing.T) {
+	idx := NewIndex()
+	idx.Reset([]*entities.Post{
+		mustPost(t, 1, "Cooking pasta", "Boil water, add salt and cook the pasta until done."),
+		mustPost(t, 2, "Go concurrency", "Goroutines and channels make concurrent programs simple."),
+		mustPost(t, 3, "A long trip", "We drove for days. Along the way we stopped to cook dinner, "+
+			"watch the stars, fix the car, read books and talk about everything and nothing."),
+		mustPost(t, 4, "Concurrent cooking", "Cooking several dishes at once is like running goroutines."),
+	})
+
+	testCases := []struct {
+		name     string
+		query    string
+		expected []int
+	}{
+		{name: "stemmed match in title and content", query: "cooked", expected: []int{4, 1, 3}},
+		{name: "any term matches", query: "pasta goroutines", expected: []int{1, 2, 4}},
+		{name: "case folding", query: "CONCURRENCY", expected: []int{2, 4}},
+		{name: "no match", query: "rust", expected: []int{}},
+		{name: "stopwords only", query: "the and", expected: []int{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, hitIDs(idx.Search(ParseQuery(tc.query), 0)))
+		})
+	}
+
+	hits := idx.Search(ParseQuery("cooked"), 2)
+	require.Len(t, hits, 2)
+	assert.Greater(t, hits[0].Score, hits[1].Score)
+}
+
+func TestIndex_BM25(t *testing.T) {
+	idx := NewIndex()
+	idx.Reset([]*entities.Post{
+		mustPost(t, 1, "Notes", "apple"),
+		mustPost(t, 2, "Notes", "apple apple apple"),
+		mustPost(t, 3, "Notes", "apple banana cherry damson elderberry fig grape"),
+		mustPost(t, 4, "Notes", "banana"),
+	})
+
+	scores := make(map[int]float64)
+	for _, hit := range idx.Search(ParseQuery("apple"), 0) {
+		scores[hit.PostID] = hit.Score
+	}
+
+	require.Len(t, scores, 3)
+	assert.Greater(t, scores[2], scores[1], "repeated terms score higher")
+	assert.Less(t, scores[2], 3*scores[1], "term frequency saturates")
+	assert.Greater(t, scores[1], scores[3], "longer fields are normalized down")
+
+	// A rarer term is worth more than a common one.
+	rare := idx.Search(ParseQuery("fig"), 0)
+	common := idx.Search(ParseQuery("banana"), 0)
+	require.Len(t, rare, 1)
+	require.Len(t, common, 2)
+	assert.Greater(t, rare[0].Score, common[len(common)-1].Score)
+}
+
+func TestIndex_PutAndRemove(t *testing.T) {
+	idx := NewIndex()
+	idx.Put(mustPost(t, 1, "Old title", "Old content"))
+	idx.Put(mustPost(t, 2, "Other", "Old gossip"))
+	assert.Equal(t, 2, idx.Len())
+
+	idx.Put(mustPost(t, 1, "New title", "New content"))
+	assert.Equal(t, 2, idx.Len())
+	assert.Equal(t, []int{2}, hitIDs(idx.Search(ParseQuery("old"), 0)), "re-indexing replaces the old terms")
+	assert.Equal(t, []int{1}, hitIDs(idx.Search(ParseQuery("new"), 0)))
+
+	idx.Remove(1)
+	idx.Remove(42)
+	assert.Equal(t, 1, idx.Len())
+	assert.Empty(t, idx.Search(ParseQuery("new"), 0))
+	assert.Empty(t, idx.postings["new"], "postings of removed posts are dropped")
+
+	idx.Reset(nil)
+	assert.Equal(t, 0, idx.Len())
+	assert.Empty(t, idx.Search(ParseQuery("old"), 0))
+}
@@ -0,0 +1,117 @@
+package search
+
+import (
+	"html"
+	"strings"
+)
+
+// Query is a parsed search query: the distinct terms of its words. A post
+// matches when it contains any of them.
+type Query struct {
+	terms []string
+}
+
+// ParseQuery analyzes text the way posts are indexed.
+func ParseQuery(text string) Query {
+	var q Query
+	seen := make(map[string]bool)
+	for _, token := range Analyze(text) {
+		if !seen[token.Term] {
+			seen[token.Term] = true
+			q.terms = append(q.terms, token.Term)
+		}
+	}
+	return q
+}
+
+// Terms returns the distinct terms of the query in the order they appear.
+func (q Query) Terms() []string {
+	return q.terms
+}
+
+// IsEmpty reports whether the query has no terms, for example because it
+// only consists of stopwords.
+func (q Query) IsEmpty() bool {
+	return len(q.terms) == 0
+}
+
+func (q Query) has(term string) bool {
+	for _, t := range q.terms {
+		if t == term {
+			return true
+		}
+	}
+	return false
+}
+
+// Highlight returns an HTML-safe excerpt of text in which the words matching
+// the query are wrapped in <mark> tags. When width is positive and text is
+// longer, the excerpt is cut at word boundaries to about width bytes around
+// the densest group of matches and the cuts are marked with an ellipsis.
+func (q Query) Highlight(text string, width int) string {
+	words := splitWords(text)
+	matched := make([]bool, len(words))
+	anyMatch := false
+	for i, word := range words {
+		if term, ok := termOf(text[word.Start:word.End]); ok && q.has(term) {
+			matched[i] = true
+			anyMatch = true
+		}
+	}
+
+	start, end := 0, len(text)
+	if width > 0 && len(text) > width && len(words) > 0 {
+		first := 0
+		if anyMatch {
+			// Start at the match followed by the most matches within the
+			// window, then step back to show some context before it.
+			best := -1
+			for i := range words {
+				if !matched[i] {
+					continue
+				}
+				count := 0
+				for j := i; j < len(words) && words[j].End <= words[i].Start+width; j++ {
+					if matched[j] {
+						count++
+					}
+				}
+				if count > best {
+					best, first = count, i
+				}
+			}
+			anchor := words[first].Start
+			for first > 0 && words[first-1].Start >= anchor-width/4 {
+				first--
+			}
+		}
+
+		start = words[first].Start
+		end = words[first].End
+		for j := first + 1; j < len(words) && words[j].End <= start+width; j++ {
+			end = words[j].End
+		}
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	pos := start
+	for i, word := range words {
+		if !matched[i] || word.Start < start || word.End > end {
+			continue
+		}
+		b.WriteString(html.EscapeString(text[pos:word.Start]))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(text[word.Start:word.End]))
+		b.WriteString("</mark>")
+		pos = word.End
+	}
+	b.WriteString(html.EscapeString(text[pos:end]))
+	if end < len(text) {
+		b.WriteString("…")
+	}
+
+	return b.String()
+}
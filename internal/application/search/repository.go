@@ -0,0 +1,148 @@
+package search
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/repositories"
+)
+
+// IndexedRepository decorates a PostRepository and keeps an Index in sync
+// with every write that goes through it. Reads are passed through.
+//
+// Only published posts are searchable. Writes are serialized so the index
+// applies them in the order the underlying repository did; posts that are
+// trashed or leave the published state are removed from the index and put
+// back when they are restored or republished.
+type IndexedRepository struct {
+	repositories.PostRepository
+	index *Index
+	mutex sync.Mutex
+}
+
+// NewIndexedRepository indexes the posts already stored in repo and returns
+// the decorated repository.
+func NewIndexedRepository(ctx context.Context, repo repositories.PostRepository, index *Index) (*IndexedRepository, error) {
+	posts, err := repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	searchable := make([]*entities.Post, 0, len(posts))
+	for _, post := range posts {
+		if post.IsPublished() {
+			searchable = append(searchable, post)
+		}
+	}
+	index.Reset(searchable)
+
+	return &IndexedRepository{PostRepository: repo, index: index}, nil
+}
+
+func (r *IndexedRepository) Create(ctx context.Context, post *entities.Post) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if err := r.PostRepository.Create(ctx, post); err != nil {
+		return err
+	}
+	r.sync(post)
+	return nil
+}
+
+func (r *IndexedRepository) CreatePost(ctx context.Context, title, content string, format entities.ContentFormat, author string, authorID int, tags []string, createdAt time.Time) (*entities.Post, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	post, err := r.PostRepository.CreatePost(ctx, title, content, format, author, authorID, tags, createdAt)
+	if err != nil {
+		return nil, err
+	}
+	r.sync(post)
+	return post, nil
+}
+
+func (r *IndexedRepository) Update(ctx context.Context, id int, post *entities.Post, expectedVersion int) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if err := r.PostRepository.Update(ctx, id, post, expectedVersion); err != nil {
+		return err
+	}
+	postCopy := *post
+	postCopy.ID = id
+	r.sync(&postCopy)
+	return nil
+}
+
+func (r *IndexedRepository) Delete(ctx context.Context, id int, expectedVersion int) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if err := r.PostRepository.Delete(ctx, id, expectedVersion); err != nil {
+		return err
+	}
+	r.index.Remove(id)
+	return nil
+}
+
+func (r *IndexedRepository) LoadData(ctx context.Context, posts []*entities.Post) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if err := r.PostRepository.LoadData(ctx, posts); err != nil {
+		return err
+	}
+	for _, post := range posts {
+		r.sync(post)
+	}
+	return nil
+}
+
+func (r *IndexedRepository) Trash(ctx context.Context, id int, expectedVersion int, deletedAt time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if err := r.PostRepository.Trash(ctx, id, expectedVersion, deletedAt); err != nil {
+		return err
+	}
+	r.index.Remove(id)
+	return nil
+}
+
+func (r *IndexedRepository) Restore(ctx context.Context, id int) (*entities.Post, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	post, err := r.PostRepository.Restore(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	r.sync(post)
+	return post, nil
+}
+
+func (r *IndexedRepository) Purge(ctx context.Context, deletedBefore time.Time) ([]int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	purged, err := r.PostRepository.Purge(ctx, deletedBefore)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range purged {
+		r.index.Remove(id)
+	}
+	return purged, nil
+}
+
+// sync puts post in the index when it is searchable and removes it
+// otherwise. The caller must hold the mutex.
+func (r *IndexedRepository) sync(post *entities.Post) {
+	if post.IsPublished() && !post.IsDeleted() {
+		r.index.Put(post)
+	} else {
+		r.index.Remove(post.ID)
+	}
+}
@@ -0,0 +1,87 @@
+package search
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/repositories"
+	"rakia-tech-test/internal/domain/repositories/repositorytest"
+	memory_repositories "rakia-tech-test/internal/infrastructure/repositories"
+)
+
+func TestIndexedRepository_Contract(t *testing.T) {
+	repositorytest.RunPostRepositoryTests(t, func(t *testing.T) repositories.PostRepository {
+		repo, err := NewIndexedRepository(context.Background(), memory_repositories.NewMemoryPostRepository(), NewIndex())
+		require.NoError(t, err)
+		return repo
+	})
+}
+
+func TestIndexedRepository_KeepsIndexInSync(t *testing.T) {
+	ctx := context.Background()
+	search := func(idx *Index, q string) []int {
+		return hitIDs(idx.Search(ParseQuery(q), 0))
+	}
+
+	inner := memory_repositories.NewMemoryPostRepository()
+	require.NoError(t, inner.Create(ctx, mustPost(t, 1, "Existing", "Stored before indexing")))
+
+	idx := NewIndex()
+	repo, err := NewIndexedRepository(ctx, inner, idx)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1}, search(idx, "stored"), "existing posts are indexed")
+
+	created, err := repo.CreatePost(ctx, "Fresh", "Brand new text", "", "Author", 0, nil, time.Now())
+	require.NoError(t, err)
+	assert.Empty(t, search(idx, "brand"), "drafts are not searchable")
+
+	for _, status := range []entities.PostStatus{entities.StatusReview, entities.StatusPublished} {
+		version := created.Version
+		require.NoError(t, created.TransitionTo(status, time.Now()))
+		require.NoError(t, repo.Update(ctx, created.ID, created, version))
+	}
+	assert.Equal(t, []int{created.ID}, search(idx, "brand"))
+
+	require.NoError(t, repo.LoadData(ctx, []*entities.Post{mustPost(t, 10, "Loaded", "From the seed file")}))
+	assert.Equal(t, []int{10}, search(idx, "seed"))
+
+	updated := *created
+	require.NoError(t, updated.Update("Fresh", "Rewritten text", "Author", time.Now()))
+	require.NoError(t, repo.Update(ctx, created.ID, &updated, created.Version))
+	assert.Empty(t, search(idx, "brand"))
+	assert.Equal(t, []int{created.ID}, search(idx, "rewritten"))
+
+	// A rejected write leaves the index alone.
+	stale := updated
+	stale.Content = "Stale text"
+	assert.ErrorIs(t, repo.Update(ctx, created.ID, &stale, created.Version), repositories.ErrVersionConflict)
+	assert.Empty(t, search(idx, "stale"))
+
+	trashedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, repo.Trash(ctx, 1, repositories.AnyVersion, trashedAt))
+	assert.Empty(t, search(idx, "stored"), "trashed posts are not searchable")
+
+	_, err = repo.Restore(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1}, search(idx, "stored"))
+
+	require.NoError(t, repo.Trash(ctx, 1, repositories.AnyVersion, trashedAt))
+	_, err = repo.Purge(ctx, trashedAt.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, search(idx, "stored"))
+
+	require.NoError(t, repo.Delete(ctx, 10, repositories.AnyVersion))
+	assert.Empty(t, search(idx, "seed"))
+	assert.Equal(t, 1, idx.Len())
+
+	archived := updated
+	require.NoError(t, archived.TransitionTo(entities.StatusArchived, time.Now()))
+	require.NoError(t, repo.Update(ctx, created.ID, &archived, updated.Version))
+	assert.Empty(t, search(idx, "rewritten"), "archived posts are not searchable")
+	assert.Zero(t, idx.Len())
+}
@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"rakia-tech-test/internal/application/principal"
+	"rakia-tech-test/internal/domain/clock"
+	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/errdefs"
+	"rakia-tech-test/internal/domain/repositories"
+)
+
+const (
+	// apiKeyTag starts every API key, so keys are easy to recognize, for
+	// example by secret scanners.
+	apiKeyTag = "rk_"
+	// apiKeyPrefixBytes and apiKeySecretBytes are the amount of randomness
+	// in the prefix and in the secret part of a key.
+	apiKeyPrefixBytes = 6
+	apiKeySecretBytes = 32
+)
+
+var (
+	ErrInvalidAPIKey = errdefs.New(errdefs.ErrUnauthenticated, "API key is invalid, expired or revoked")
+	ErrScopeNotHeld  = errdefs.New(errdefs.ErrForbidden, "an API key cannot be granted scopes its issuer does not hold")
+	errNoIssuer      = errdefs.New(errdefs.ErrUnauthenticated, "API keys can only be issued by an authenticated caller")
+)
+
+// APIKeyService issues the API keys machine clients authenticate with and
+// checks them on every request.
+//
+// Keys have the form rk_<prefix>_<secret>. The prefix, rk_ included, is
+// stored to identify the key; the key as a whole only as a SHA-256 hash.
+// Keys are long and random, so a fast hash is enough to keep them from
+// being recovered.
+type APIKeyService struct {
+	keyRepo  repositories.APIKeyRepository
+	userRepo repositories.UserRepository
+	clock    clock.Clock
+	logger   *logrus.Logger
+}
+
+func NewAPIKeyService(keyRepo repositories.APIKeyRepository, userRepo repositories.UserRepository, clock clock.Clock, logger *logrus.Logger) *APIKeyService {
+	return &APIKeyService{
+		keyRepo:  keyRepo,
+		userRepo: userRepo,
+		clock:    clock,
+		logger:   logger,
+	}
+}
+
+// IssueAPIKey creates a key acting for the caller behind ctx, limited to
+// scopes and valid until expiresAt, or indefinitely when it is nil. Callers
+// can only grant the scopes they hold. It returns the key along with the key
+// in plain text, which is not stored and cannot be retrieved again.
+func (s *APIKeyService) IssueAPIKey(ctx context.Context, name string, scopes []entities.Scope, expiresAt *time.Time) (*entities.APIKey, string, error) {
+	issuer, ok := principal.FromContext(ctx)
+	if !ok {
+		return nil, "", errNoIssuer
+	}
+	s.log(ctx).WithFields(logrus.Fields{
+		"name":    name,
+		"scopes":  scopes,
+		"user_id": issuer.UserID,
+	}).Info("Issuing new API key")
+
+	for _, scope := range scopes {
+		if scope.Valid() && !issuer.HasScope(scope) {
+			return nil, "", ErrScopeNotHeld
+		}
+	}
+
+	prefix, err := randomHex(apiKeyPrefixBytes)
+	if err != nil {
+		return nil, "", err
+	}
+	prefix = apiKeyTag + prefix
+	secret, err := randomHex(apiKeySecretBytes)
+	if err != nil {
+		return nil, "", err
+	}
+	plaintext := prefix + "_" + secret
+
+	key, err := entities.NewAPIKey(0, issuer.UserID, name, prefix, hashAPIKey(plaintext), scopes, expiresAt, s.clock.Now())
+	if err != nil {
+		return nil, "", err
+	}
+	if err := s.keyRepo.Create(ctx, key); err != nil {
+		return nil, "", err
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"api_key_id": key.ID,
+		"prefix":     key.Prefix,
+	}).Info("API key issued successfully")
+	return key, plaintext, nil
+}
+
+// GetAPIKey returns the key with the given ID.
+func (s *APIKeyService) GetAPIKey(ctx context.Context, id int) (*entities.APIKey, error) {
+	return s.keyRepo.GetByID(ctx, id)
+}
+
+// ListAPIKeys returns every key, revoked and expired ones included.
+func (s *APIKeyService) ListAPIKeys(ctx context.Context) ([]*entities.APIKey, error) {
+	return s.keyRepo.List(ctx)
+}
+
+// RevokeAPIKey stops the key with the given ID from authenticating
+// requests. Revoking a key twice is harmless.
+func (s *APIKeyService) RevokeAPIKey(ctx context.Context, id int) (*entities.APIKey, error) {
+	key, err := s.keyRepo.Revoke(ctx, id, s.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	s.log(ctx).WithField("api_key_id", id).Info("API key revoked")
+	return key, nil
+}
+
+// Authenticate returns the principal of an API key: the user the key acts
+// for, limited to the scopes of the key. Unknown, expired and revoked keys
+// fail alike, with ErrInvalidAPIKey.
+func (s *APIKeyService) Authenticate(ctx context.Context, plaintext string) (principal.Principal, error) {
+	separator := strings.LastIndexByte(plaintext, '_')
+	if !strings.HasPrefix(plaintext, apiKeyTag) || separator < len(apiKeyTag) {
+		return principal.Principal{}, ErrInvalidAPIKey
+	}
+
+	key, err := s.keyRepo.GetByPrefix(ctx, plaintext[:separator])
+	if errors.Is(err, repositories.ErrAPIKeyNotFound) {
+		return principal.Principal{}, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return principal.Principal{}, err
+	}
+	now := s.clock.Now()
+	if subtle.ConstantTimeCompare([]byte(hashAPIKey(plaintext)), []byte(key.SecretHash)) != 1 || !key.Active(now) {
+		return principal.Principal{}, ErrInvalidAPIKey
+	}
+
+	user, err := s.userRepo.GetByID(ctx, key.UserID)
+	if errors.Is(err, repositories.ErrUserNotFound) {
+		return principal.Principal{}, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return principal.Principal{}, err
+	}
+
+	// The request is authenticated whether or not the use is recorded.
+	if err := s.keyRepo.MarkUsed(ctx, key.ID, now); err != nil {
+		s.log(ctx).WithError(err).WithField("api_key_id", key.ID).Warn("Failed to record API key use")
+	}
+
+	return principal.Principal{
+		UserID:   user.ID,
+		Name:     user.Name,
+		Role:     user.Role,
+		APIKeyID: key.ID,
+		Scopes:   key.Scopes,
+	}, nil
+}
+
+// log returns a log entry tagged with the ID of the request behind ctx.
+func (s *APIKeyService) log(ctx context.Context) *logrus.Entry {
+	return requestLog(ctx, s.logger)
+}
+
+func hashAPIKey(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"rakia-tech-test/internal/application/principal"
+	"rakia-tech-test/internal/domain/clock"
+	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/errdefs"
+	memory_repositories "rakia-tech-test/internal/infrastructure/repositories"
+)
+
+// newAPIKeyService returns a service with one user, and a context with that
+// user signed in.
+func newAPIKeyService(t *testing.T) (*APIKeyService, *clock.Fake, context.Context) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel)
+
+	users := memory_repositories.NewMemoryUserRepository()
+	user, err := entities.NewUser(0, "alice", "Alice", entities.RoleAdmin, "hash", fake.Now())
+	require.NoError(t, err)
+	require.NoError(t, users.Create(context.Background(), user))
+
+	service := NewAPIKeyService(memory_repositories.NewMemoryAPIKeyRepository(), users, fake, logger)
+	ctx := principal.NewContext(context.Background(), principal.Principal{UserID: user.ID, Name: user.Name, Role: user.Role})
+	return service, fake, ctx
+}
+
+func TestAPIKeyService_IssueAndAuthenticate(t *testing.T) {
+	keys, fake, ctx := newAPIKeyService(t)
+
+	scopes := []entities.Scope{entities.ScopePostsWrite, entities.ScopePostsRead, entities.ScopePostsWrite}
+	key, plaintext, err := keys.IssueAPIKey(ctx, " CI importer ", scopes, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "CI importer", key.Name)
+	assert.Equal(t, []entities.Scope{entities.ScopePostsRead, entities.ScopePostsWrite}, key.Scopes)
+	assert.True(t, strings.HasPrefix(plaintext, key.Prefix+"_"))
+	assert.NotContains(t, key.SecretHash, plaintext)
+
+	fake.Advance(time.Minute)
+	p, err := keys.Authenticate(context.Background(), plaintext)
+	require.NoError(t, err)
+	assert.Equal(t, principal.Principal{UserID: 1, Name: "Alice", Role: entities.RoleAdmin, APIKeyID: key.ID, Scopes: key.Scopes}, p)
+	assert.True(t, p.HasScope(entities.ScopePostsWrite))
+	assert.False(t, p.HasScope(entities.ScopePostsDelete))
+
+	stored, err := keys.GetAPIKey(ctx, key.ID)
+	require.NoError(t, err)
+	require.NotNil(t, stored.LastUsedAt)
+	assert.True(t, fake.Now().Equal(*stored.LastUsedAt))
+
+	for _, wrong := range []string{"", "garbage", key.Prefix, key.Prefix + "_", plaintext + "0", "rk_000000000000_" + plaintext[len(key.Prefix)+1:]} {
+		_, err = keys.Authenticate(context.Background(), wrong)
+		assert.ErrorIs(t, err, ErrInvalidAPIKey, wrong)
+	}
+}
+
+func TestAPIKeyService_IssueRejects(t *testing.T) {
+	keys, fake, ctx := newAPIKeyService(t)
+
+	_, _, err := keys.IssueAPIKey(context.Background(), "key", []entities.Scope{entities.ScopePostsRead}, nil)
+	assert.ErrorIs(t, err, errdefs.ErrUnauthenticated)
+
+	past := fake.Now().Add(-time.Hour)
+	_, _, err = keys.IssueAPIKey(ctx, "", []entities.Scope{"posts:everything"}, &past)
+	assert.ErrorIs(t, err, entities.ErrAPIKeyNameRequired)
+	assert.ErrorIs(t, err, entities.ErrInvalidScope)
+	assert.ErrorIs(t, err, entities.ErrAPIKeyExpiryInPast)
+
+	limited := principal.NewContext(context.Background(), principal.Principal{
+		UserID: 1, Role: entities.RoleAdmin, APIKeyID: 1, Scopes: []entities.Scope{entities.ScopeAdmin, entities.ScopePostsRead},
+	})
+	_, _, err = keys.IssueAPIKey(limited, "key", []entities.Scope{entities.ScopePostsRead}, nil)
+	assert.NoError(t, err)
+	_, _, err = keys.IssueAPIKey(limited, "key", []entities.Scope{entities.ScopePostsWrite}, nil)
+	assert.ErrorIs(t, err, ErrScopeNotHeld)
+	assert.ErrorIs(t, err, errdefs.ErrForbidden)
+}
+
+func TestAPIKeyService_ExpiryAndRevocation(t *testing.T) {
+	keys, fake, ctx := newAPIKeyService(t)
+
+	expiresAt := fake.Now().Add(time.Hour)
+	expiring, expiringText, err := keys.IssueAPIKey(ctx, "expiring", []entities.Scope{entities.ScopePostsRead}, &expiresAt)
+	require.NoError(t, err)
+	revoked, revokedText, err := keys.IssueAPIKey(ctx, "revoked", []entities.Scope{entities.ScopePostsRead}, nil)
+	require.NoError(t, err)
+
+	_, err = keys.RevokeAPIKey(ctx, revoked.ID)
+	require.NoError(t, err)
+	_, err = keys.Authenticate(ctx, revokedText)
+	assert.ErrorIs(t, err, ErrInvalidAPIKey, "revoked keys stop working")
+	_, err = keys.Authenticate(ctx, expiringText)
+	assert.NoError(t, err)
+
+	fake.Advance(time.Hour)
+	_, err = keys.Authenticate(ctx, expiringText)
+	assert.ErrorIs(t, err, ErrInvalidAPIKey, "expired keys stop working")
+
+	listed, err := keys.ListAPIKeys(ctx)
+	require.NoError(t, err)
+	require.Len(t, listed, 2)
+	assert.Equal(t, expiring.ID, listed[0].ID)
+	assert.NotNil(t, listed[1].RevokedAt)
+}
@@ -0,0 +1,269 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+
+	"rakia-tech-test/internal/application/jwt"
+	"rakia-tech-test/internal/application/principal"
+	"rakia-tech-test/internal/domain/clock"
+	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/errdefs"
+	"rakia-tech-test/internal/domain/repositories"
+	"rakia-tech-test/internal/domain/validation"
+)
+
+const (
+	// DefaultAccessTokenTTL is how long access tokens are accepted for.
+	DefaultAccessTokenTTL = 15 * time.Minute
+	// DefaultRefreshTokenTTL is how long refresh tokens are accepted for.
+	DefaultRefreshTokenTTL = 7 * 24 * time.Hour
+	// MinPasswordLength and MaxPasswordLength bound the length of a
+	// password, in bytes. bcrypt ignores anything past 72 bytes.
+	MinPasswordLength = 8
+	MaxPasswordLength = 72
+
+	tokenUseAccess  = "access"
+	tokenUseRefresh = "refresh"
+)
+
+var (
+	ErrInvalidCredentials = errdefs.New(errdefs.ErrUnauthenticated, "invalid username or password")
+	ErrInvalidToken       = errdefs.New(errdefs.ErrUnauthenticated, "token is invalid or has expired")
+	ErrPasswordTooShort   = validation.New("password", validation.CodeTooShort,
+		fmt.Sprintf("password must be at least %d characters", MinPasswordLength),
+		map[string]any{"min": MinPasswordLength})
+	ErrPasswordTooLong = validation.New("password", validation.CodeTooLong,
+		fmt.Sprintf("password must be at most %d characters", MaxPasswordLength),
+		map[string]any{"max": MaxPasswordLength})
+)
+
+// TokenPair is what a successful sign-in hands out: a short-lived access
+// token for the requests themselves and a longer-lived refresh token to get
+// the next pair with.
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	// ExpiresIn is how long the access token is accepted for.
+	ExpiresIn time.Duration
+}
+
+// AuthService signs users in with their password and issues the tokens
+// that authenticate their requests. Access tokens are stateless: they stay
+// valid until they expire, but stop working for users that no longer exist.
+// Refresh tokens are used once: every refresh revokes the token it was made
+// with and hands out a new one, and signing out revokes the token too.
+type AuthService struct {
+	userRepo   repositories.UserRepository
+	signer     *jwt.Signer
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	clock      clock.Clock
+	logger     *logrus.Logger
+}
+
+func NewAuthService(userRepo repositories.UserRepository, signer *jwt.Signer, accessTTL, refreshTTL time.Duration, clock clock.Clock, logger *logrus.Logger) *AuthService {
+	return &AuthService{
+		userRepo:   userRepo,
+		signer:     signer,
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+		clock:      clock,
+		logger:     logger,
+	}
+}
+
+// CreateUser adds a user with role who signs in with username and password
+// and writes under name.
+func (s *AuthService) CreateUser(ctx context.Context, username, name string, role entities.Role, password string) (*entities.User, error) {
+	s.log(ctx).WithFields(logrus.Fields{
+		"username": username,
+		"role":     role,
+	}).Info("Creating new user")
+
+	switch {
+	case len(password) < MinPasswordLength:
+		return nil, ErrPasswordTooShort
+	case len(password) > MaxPasswordLength:
+		return nil, ErrPasswordTooLong
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := entities.NewUser(0, username, name, role, string(hash), s.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return nil, err
+	}
+
+	s.log(ctx).WithField("user_id", user.ID).Info("User created successfully")
+	return user, nil
+}
+
+// Login checks the password of the user and issues their first token pair.
+// Unknown usernames and wrong passwords fail alike, with
+// ErrInvalidCredentials.
+func (s *AuthService) Login(ctx context.Context, username, password string) (*TokenPair, error) {
+	user, err := s.userRepo.GetByUsername(ctx, username)
+	if errors.Is(err, repositories.ErrUserNotFound) {
+		// Spend the time a real check takes, so response times do not tell
+		// which usernames exist.
+		_ = bcrypt.CompareHashAndPassword(dummyPasswordHash(), []byte(password))
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		s.log(ctx).WithField("user_id", user.ID).Warn("Rejected sign-in with a wrong password")
+		return nil, ErrInvalidCredentials
+	}
+
+	s.log(ctx).WithField("user_id", user.ID).Info("User signed in")
+	return s.issue(user.ID)
+}
+
+// Refresh trades a refresh token for a new token pair. The token is revoked
+// on the way, so a refresh token is only good for one refresh.
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	user, err := s.revoke(ctx, refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.issue(user.ID)
+}
+
+// Logout revokes a refresh token, so no new token pair can be had with it.
+// Access tokens issued with it stay valid until they expire.
+func (s *AuthService) Logout(ctx context.Context, refreshToken string) error {
+	user, err := s.revoke(ctx, refreshToken)
+	if err != nil {
+		return err
+	}
+
+	s.log(ctx).WithField("user_id", user.ID).Info("User signed out")
+	return nil
+}
+
+// Authenticate returns the principal an access token was issued to.
+func (s *AuthService) Authenticate(ctx context.Context, accessToken string) (principal.Principal, error) {
+	user, _, err := s.verify(ctx, accessToken, tokenUseAccess)
+	if err != nil {
+		return principal.Principal{}, err
+	}
+
+	return principal.Principal{UserID: user.ID, Name: user.Name, Role: user.Role}, nil
+}
+
+// verify checks token and returns the user it was issued to and its claims,
+// provided it is meant for use.
+func (s *AuthService) verify(ctx context.Context, token, use string) (*entities.User, jwt.Claims, error) {
+	claims, err := s.signer.Verify(token, s.clock.Now())
+	if err != nil || claims.Use != use {
+		return nil, claims, ErrInvalidToken
+	}
+	id, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return nil, claims, ErrInvalidToken
+	}
+
+	user, err := s.userRepo.GetByID(ctx, id)
+	if errors.Is(err, repositories.ErrUserNotFound) {
+		return nil, claims, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, claims, err
+	}
+	return user, claims, nil
+}
+
+// revoke checks refreshToken like verify and revokes it. Tokens revoked
+// before, whether used for a refresh or signed out, fail with
+// ErrInvalidToken.
+func (s *AuthService) revoke(ctx context.Context, refreshToken string) (*entities.User, error) {
+	user, claims, err := s.verify(ctx, refreshToken, tokenUseRefresh)
+	if err != nil {
+		return nil, err
+	}
+	if claims.ID == "" {
+		return nil, ErrInvalidToken
+	}
+
+	err = s.userRepo.RevokeToken(ctx, claims.ID, time.Unix(claims.ExpiresAt, 0), s.clock.Now())
+	if errors.Is(err, repositories.ErrTokenRevoked) {
+		s.log(ctx).WithField("user_id", user.ID).Warn("Rejected a revoked refresh token")
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *AuthService) issue(userID int) (*TokenPair, error) {
+	now := s.clock.Now()
+	sign := func(use string, ttl time.Duration) (string, error) {
+		return s.signer.Sign(jwt.Claims{
+			Subject:   strconv.Itoa(userID),
+			Use:       use,
+			ID:        newTokenID(),
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(ttl).Unix(),
+		})
+	}
+
+	access, err := sign(tokenUseAccess, s.accessTTL)
+	if err != nil {
+		return nil, err
+	}
+	refresh, err := sign(tokenUseRefresh, s.refreshTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		ExpiresIn:    s.accessTTL,
+	}, nil
+}
+
+// log returns a log entry tagged with the ID of the request behind ctx.
+func (s *AuthService) log(ctx context.Context) *logrus.Entry {
+	return requestLog(ctx, s.logger)
+}
+
+func newTokenID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(buf)
+}
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+)
+
+// dummyPasswordHash returns a hash no password is checked against for real.
+func dummyPasswordHash() []byte {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte("not a password"), bcrypt.DefaultCost)
+	})
+	return dummyHash
+}
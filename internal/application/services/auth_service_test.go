@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"rakia-tech-test/internal/application/jwt"
+	"rakia-tech-test/internal/application/principal"
+	"rakia-tech-test/internal/domain/clock"
+	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/errdefs"
+	"rakia-tech-test/internal/domain/repositories"
+	memory_repositories "rakia-tech-test/internal/infrastructure/repositories"
+)
+
+func newAuthService() (*AuthService, *clock.Fake) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel)
+
+	signer := jwt.NewSigner([]byte("secret"))
+	return NewAuthService(memory_repositories.NewMemoryUserRepository(), signer, time.Minute, time.Hour, fake, logger), fake
+}
+
+func TestAuthService_CreateUser(t *testing.T) {
+	auth, _ := newAuthService()
+	ctx := context.Background()
+
+	user, err := auth.CreateUser(ctx, " Alice ", "Alice Liddell", entities.RoleAuthor, "correct horse")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", user.Username)
+	assert.NotContains(t, user.PasswordHash, "correct horse")
+
+	_, err = auth.CreateUser(ctx, "ALICE", "Other", entities.RoleAuthor, "correct horse")
+	assert.ErrorIs(t, err, repositories.ErrUserExists)
+	_, err = auth.CreateUser(ctx, "bob", "Bob", entities.RoleAuthor, "short")
+	assert.ErrorIs(t, err, ErrPasswordTooShort)
+}
+
+func TestAuthService_LoginAndAuthenticate(t *testing.T) {
+	auth, fake := newAuthService()
+	ctx := context.Background()
+
+	user, err := auth.CreateUser(ctx, "alice", "Alice", entities.RoleAuthor, "correct horse")
+	require.NoError(t, err)
+
+	_, err = auth.Login(ctx, "alice", "wrong password")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = auth.Login(ctx, "nobody", "correct horse")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	assert.ErrorIs(t, err, errdefs.ErrUnauthenticated)
+
+	tokens, err := auth.Login(ctx, "Alice", "correct horse")
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, tokens.ExpiresIn)
+
+	p, err := auth.Authenticate(ctx, tokens.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, principal.Principal{UserID: user.ID, Name: "Alice", Role: entities.RoleAuthor}, p)
+
+	_, err = auth.Authenticate(ctx, tokens.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidToken, "refresh tokens do not authenticate requests")
+	_, err = auth.Authenticate(ctx, "garbage")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	fake.Advance(time.Minute)
+	_, err = auth.Authenticate(ctx, tokens.AccessToken)
+	assert.ErrorIs(t, err, ErrInvalidToken, "access tokens expire")
+}
+
+func TestAuthService_Refresh(t *testing.T) {
+	auth, fake := newAuthService()
+	ctx := context.Background()
+
+	_, err := auth.CreateUser(ctx, "alice", "Alice", entities.RoleAuthor, "correct horse")
+	require.NoError(t, err)
+	tokens, err := auth.Login(ctx, "alice", "correct horse")
+	require.NoError(t, err)
+
+	_, err = auth.Refresh(ctx, tokens.AccessToken)
+	assert.ErrorIs(t, err, ErrInvalidToken, "access tokens cannot be refreshed")
+
+	fake.Advance(30 * time.Minute)
+	refreshed, err := auth.Refresh(ctx, tokens.RefreshToken)
+	require.NoError(t, err)
+	assert.NotEqual(t, tokens.AccessToken, refreshed.AccessToken)
+	_, err = auth.Authenticate(ctx, refreshed.AccessToken)
+	assert.NoError(t, err)
+
+	assert.NotEqual(t, tokens.RefreshToken, refreshed.RefreshToken)
+	_, err = auth.Refresh(ctx, tokens.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidToken, "refresh tokens are used once")
+
+	fake.Advance(45 * time.Minute)
+	_, err = auth.Refresh(ctx, refreshed.RefreshToken)
+	assert.NoError(t, err, "the refreshed pair carries a new refresh token")
+
+	unused, err := auth.Login(ctx, "alice", "correct horse")
+	require.NoError(t, err)
+	fake.Advance(time.Hour)
+	_, err = auth.Refresh(ctx, unused.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidToken, "refresh tokens expire")
+}
+
+func TestAuthService_Logout(t *testing.T) {
+	auth, _ := newAuthService()
+	ctx := context.Background()
+
+	_, err := auth.CreateUser(ctx, "alice", "Alice", entities.RoleAuthor, "correct horse")
+	require.NoError(t, err)
+	tokens, err := auth.Login(ctx, "alice", "correct horse")
+	require.NoError(t, err)
+	other, err := auth.Login(ctx, "alice", "correct horse")
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, auth.Logout(ctx, tokens.AccessToken), ErrInvalidToken, "only refresh tokens are revoked")
+	require.NoError(t, auth.Logout(ctx, tokens.RefreshToken))
+	_, err = auth.Refresh(ctx, tokens.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidToken, "revoked tokens cannot be refreshed")
+	assert.ErrorIs(t, auth.Logout(ctx, tokens.RefreshToken), ErrInvalidToken)
+
+	_, err = auth.Authenticate(ctx, tokens.AccessToken)
+	assert.NoError(t, err, "access tokens run out on their own")
+	_, err = auth.Refresh(ctx, other.RefreshToken)
+	assert.NoError(t, err, "other sign-ins are left alone")
+}
@@ -0,0 +1,210 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sirupsen/logrus"
+
+	"rakia-tech-test/internal/domain/clock"
+	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/errdefs"
+	"rakia-tech-test/internal/domain/repositories"
+)
+
+var ErrAuthorHasPosts = errdefs.New(errdefs.ErrConflict, "author still has posts")
+
+// errAuthorUnchanged stops the rename of a post that already carries the
+// new name or has moved to another author meanwhile.
+var errAuthorUnchanged = errors.New("post author unchanged")
+
+// AuthorService manages the authors posts refer to. Renaming an author
+// rewrites the name carried by their posts through the PostService, so the
+// posts record the change in their history. Authors are changed under the
+// same policy as their posts: editors and admins change every author, and
+// authors only their own record.
+type AuthorService struct {
+	authorRepo repositories.AuthorRepository
+	posts      *PostService
+	clock      clock.Clock
+	logger     *logrus.Logger
+}
+
+func NewAuthorService(authorRepo repositories.AuthorRepository, posts *PostService, clock clock.Clock, logger *logrus.Logger) *AuthorService {
+	return &AuthorService{
+		authorRepo: authorRepo,
+		posts:      posts,
+		clock:      clock,
+		logger:     logger,
+	}
+}
+
+func (s *AuthorService) CreateAuthor(ctx context.Context, name, bio string) (*entities.Author, error) {
+	s.log(ctx).WithField("name", name).Info("Creating new author")
+
+	if err := authorize(ctx, name); err != nil {
+		return nil, err
+	}
+	author, err := s.authorRepo.CreateAuthor(ctx, name, bio, s.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	s.log(ctx).WithField("author_id", author.ID).Info("Author created successfully")
+	return author, nil
+}
+
+func (s *AuthorService) GetAuthor(ctx context.Context, id int) (*entities.Author, error) {
+	s.log(ctx).WithField("author_id", id).Debug("Retrieving author by ID")
+
+	return s.authorRepo.GetByID(ctx, id)
+}
+
+func (s *AuthorService) ListAuthors(ctx context.Context) ([]*entities.Author, error) {
+	s.log(ctx).Debug("Retrieving all authors")
+
+	return s.authorRepo.List(ctx)
+}
+
+// UpdateAuthor replaces the name and bio of the author with the given ID. A
+// new name is written into every live post of the author as an ordinary
+// edit; posts in the trash keep the old name until they are edited again.
+func (s *AuthorService) UpdateAuthor(ctx context.Context, id int, name, bio string) (*entities.Author, error) {
+	s.log(ctx).WithFields(logrus.Fields{
+		"author_id": id,
+		"name":      name,
+	}).Info("Updating author")
+
+	author, err := s.authorRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorize(ctx, author.Name); err != nil {
+		return nil, err
+	}
+	oldName := author.Name
+	if err := author.Update(name, bio, s.clock.Now()); err != nil {
+		return nil, err
+	}
+	// Like posts, authors cannot be handed to another name.
+	if err := authorize(ctx, author.Name); err != nil {
+		return nil, err
+	}
+	if err := s.authorRepo.Update(ctx, author); err != nil {
+		return nil, err
+	}
+
+	if author.Name != oldName {
+		renamed, err := s.posts.renameAuthor(ctx, author)
+		if err != nil {
+			return nil, err
+		}
+		s.log(ctx).WithFields(logrus.Fields{
+			"author_id": id,
+			"posts":     renamed,
+		}).Info("Author renamed in posts")
+	}
+
+	s.log(ctx).WithField("author_id", id).Info("Author updated successfully")
+	return author, nil
+}
+
+// DeleteAuthor removes the author with the given ID. Authors are only
+// removed once no post, trashed ones included, refers to them.
+func (s *AuthorService) DeleteAuthor(ctx context.Context, id int) error {
+	s.log(ctx).WithField("author_id", id).Info("Deleting author")
+
+	author, err := s.authorRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := authorize(ctx, author.Name); err != nil {
+		return err
+	}
+	hasPosts, err := s.posts.hasPostsBy(ctx, id)
+	if err != nil {
+		return err
+	}
+	if hasPosts {
+		return ErrAuthorHasPosts
+	}
+
+	if err := s.authorRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.log(ctx).WithField("author_id", id).Info("Author deleted successfully")
+	return nil
+}
+
+func (s *AuthorService) log(ctx context.Context) *logrus.Entry {
+	return requestLog(ctx, s.logger)
+}
+
+// renameAuthor writes the current name of author into each live post linked
+// to it and returns how many posts changed. Every post is edited on its own,
+// under the same checks as other post writes, retrying when a concurrent
+// write gets in between.
+func (s *PostService) renameAuthor(ctx context.Context, author *entities.Author) (int, error) {
+	renamed := 0
+	query := repositories.PostQuery{Limit: MaxPageLimit, Filter: repositories.PostFilter{AuthorID: author.ID}}
+	for {
+		page, err := s.postRepo.ListPosts(ctx, query)
+		if err != nil {
+			return renamed, err
+		}
+
+		for _, post := range page.Posts {
+			for {
+				_, err := s.edit(ctx, post.ID, nil, 0, authorized(ctx, func(post *entities.Post) error {
+					if post.AuthorID != author.ID || post.Author == author.Name {
+						return errAuthorUnchanged
+					}
+					return post.Update(post.Title, post.Content, author.Name, s.clock.Now())
+				}))
+				if errors.Is(err, repositories.ErrVersionConflict) {
+					continue
+				}
+				if errors.Is(err, repositories.ErrPostNotFound) || errors.Is(err, errAuthorUnchanged) {
+					break
+				}
+				if err != nil {
+					return renamed, err
+				}
+				renamed++
+				break
+			}
+		}
+
+		if !page.HasNext {
+			return renamed, nil
+		}
+		query.After = &repositories.Cursor{ID: page.Posts[len(page.Posts)-1].ID}
+	}
+}
+
+// hasPostsBy reports whether any post, live or trashed, refers to the author
+// with the given ID.
+func (s *PostService) hasPostsBy(ctx context.Context, authorID int) (bool, error) {
+	page, err := s.postRepo.ListPosts(ctx, repositories.PostQuery{
+		Limit:  1,
+		Filter: repositories.PostFilter{AuthorID: authorID},
+	})
+	if err != nil {
+		return false, err
+	}
+	if len(page.Posts) > 0 {
+		return true, nil
+	}
+
+	trash, err := s.postRepo.GetTrash(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, post := range trash {
+		if post.AuthorID == authorID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
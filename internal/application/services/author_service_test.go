@@ -0,0 +1,178 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"rakia-tech-test/internal/application/policy"
+	"rakia-tech-test/internal/application/principal"
+	"rakia-tech-test/internal/domain/clock"
+	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/repositories"
+	memory_repositories "rakia-tech-test/internal/infrastructure/repositories"
+)
+
+// newAuthorServices wires the author and post services to in-memory
+// repositories and returns them with the post repository and clock they use.
+func newAuthorServices() (*AuthorService, *PostService, repositories.PostRepository, *clock.Fake) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel)
+
+	postRepo := memory_repositories.NewMemoryPostRepository()
+	authorRepo := memory_repositories.NewMemoryAuthorRepository()
+	posts := NewPostService(postRepo, memory_repositories.NewMemoryRevisionRepository(), authorRepo, memory_repositories.NewMemoryCommentRepository(), fake, logger)
+	return NewAuthorService(authorRepo, posts, fake, logger), posts, postRepo, fake
+}
+
+func TestPostService_CreatePostResolvesAuthor(t *testing.T) {
+	authors, posts, _, _ := newAuthorServices()
+	ctx := context.Background()
+
+	first, err := posts.CreatePost(ctx, "First", "Content", "", "Author 1", 0, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, first.AuthorID, "an unknown name adds an author")
+	assert.Equal(t, "Author 1", first.Author)
+
+	second, err := posts.CreatePost(ctx, "Second", "Content", "", " author  1 ", 0, nil)
+	require.NoError(t, err)
+	assert.Equal(t, first.AuthorID, second.AuthorID, "names match up to case and spacing")
+	assert.Equal(t, "Author 1", second.Author, "the post carries the canonical name")
+
+	byID, err := posts.CreatePost(ctx, "Third", "Content", "", "ignored", first.AuthorID, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Author 1", byID.Author, "the ID wins over the name")
+
+	_, err = posts.CreatePost(ctx, "Fourth", "Content", "", "", 99, nil)
+	assert.ErrorIs(t, err, ErrUnknownAuthor)
+
+	list, err := authors.ListAuthors(ctx)
+	require.NoError(t, err)
+	assert.Len(t, list, 1)
+}
+
+func TestAuthorService_UpdateAuthorRenamesPosts(t *testing.T) {
+	authors, posts, postRepo, _ := newAuthorServices()
+	ctx := context.Background()
+
+	author, err := authors.CreateAuthor(ctx, "Old Name", "")
+	require.NoError(t, err)
+	post, err := posts.CreatePost(ctx, "Title", "Content", "", "", author.ID, nil)
+	require.NoError(t, err)
+	other, err := posts.CreatePost(ctx, "Other", "Content", "", "Someone Else", 0, nil)
+	require.NoError(t, err)
+
+	updated, err := authors.UpdateAuthor(ctx, author.ID, "New Name", "Bio")
+	require.NoError(t, err)
+	assert.Equal(t, "New Name", updated.Name)
+	assert.Equal(t, "Bio", updated.Bio)
+
+	renamed, err := postRepo.GetByID(ctx, post.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "New Name", renamed.Author)
+	assert.Equal(t, post.Version+1, renamed.Version)
+	asAuthor := principal.NewContext(ctx, principal.Principal{Name: "New Name"})
+	revisions, err := posts.ListRevisions(asAuthor, post.ID)
+	require.NoError(t, err)
+	assert.Len(t, revisions, 2, "the rename is recorded in the history of the post")
+
+	untouched, err := postRepo.GetByID(ctx, other.ID)
+	require.NoError(t, err)
+	assert.Equal(t, other.Version, untouched.Version)
+
+	_, err = authors.UpdateAuthor(ctx, author.ID, "someone else", "")
+	assert.ErrorIs(t, err, repositories.ErrAuthorExists)
+	_, err = authors.UpdateAuthor(ctx, 99, "Name", "")
+	assert.ErrorIs(t, err, repositories.ErrAuthorNotFound)
+}
+
+func TestPostService_RestoreRevisionKeepsRenamedAuthor(t *testing.T) {
+	authors, posts, postRepo, _ := newAuthorServices()
+	ctx := context.Background()
+
+	author, err := authors.CreateAuthor(ctx, "Old Name", "")
+	require.NoError(t, err)
+	post, err := posts.CreatePost(ctx, "Title", "Content", "", "", author.ID, nil)
+	require.NoError(t, err)
+	_, err = posts.UpdatePost(ctx, post.ID, "Edited", "Edited Content", "", "", author.ID, nil, nil)
+	require.NoError(t, err)
+	_, err = authors.UpdateAuthor(ctx, author.ID, "New Name", "")
+	require.NoError(t, err)
+
+	restored, err := posts.RestoreRevision(ctx, post.ID, 1, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Title", restored.Title)
+	assert.Equal(t, "Content", restored.Content)
+
+	stored, err := postRepo.GetByID(ctx, post.ID)
+	require.NoError(t, err)
+	assert.Equal(t, author.ID, stored.AuthorID, "the post stays with its author")
+	assert.Equal(t, "New Name", stored.Author)
+	list, err := authors.ListAuthors(ctx)
+	require.NoError(t, err)
+	assert.Len(t, list, 1, "the old name does not come back as a new author")
+}
+
+func TestAuthorService_Authorization(t *testing.T) {
+	authors, posts, postRepo, _ := newAuthorServices()
+	as := func(name string, role entities.Role) context.Context {
+		return principal.NewContext(context.Background(), principal.Principal{Name: name, Role: role})
+	}
+	alice, mallory := as("Alice", entities.RoleAuthor), as("Mallory", entities.RoleAuthor)
+	editor, reader := as("Eve", entities.RoleEditor), as("Rita", entities.RoleReader)
+
+	_, err := authors.CreateAuthor(reader, "Rita", "")
+	assert.ErrorIs(t, err, policy.ErrReadOnly)
+	_, err = authors.CreateAuthor(mallory, "Alice", "")
+	assert.ErrorIs(t, err, policy.ErrNotOwner)
+	author, err := authors.CreateAuthor(alice, "Alice", "")
+	require.NoError(t, err)
+	post, err := posts.CreatePost(alice, "Title", "Content", "", "", author.ID, nil)
+	require.NoError(t, err)
+
+	// Renaming the author of a post would hand the post over.
+	_, err = authors.UpdateAuthor(mallory, author.ID, "Mallory", "")
+	assert.ErrorIs(t, err, policy.ErrNotOwner)
+	_, err = authors.UpdateAuthor(alice, author.ID, "Mallory", "")
+	assert.ErrorIs(t, err, policy.ErrNotOwner, "authors cannot give their record away")
+	assert.ErrorIs(t, authors.DeleteAuthor(mallory, author.ID), policy.ErrNotOwner)
+	unchanged, err := postRepo.GetByID(context.Background(), post.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", unchanged.Author)
+
+	updated, err := authors.UpdateAuthor(alice, author.ID, "alice", "Writes things")
+	require.NoError(t, err)
+	assert.Equal(t, "Writes things", updated.Bio)
+	_, err = authors.UpdateAuthor(editor, author.ID, "Alice Liddell", "")
+	require.NoError(t, err)
+	renamed, err := postRepo.GetByID(context.Background(), post.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Alice Liddell", renamed.Author)
+}
+
+func TestAuthorService_DeleteAuthor(t *testing.T) {
+	authors, posts, _, fake := newAuthorServices()
+	ctx := context.Background()
+
+	author, err := authors.CreateAuthor(ctx, "Writer", "")
+	require.NoError(t, err)
+	post, err := posts.CreatePost(ctx, "Title", "Content", "", "Writer", 0, nil)
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, authors.DeleteAuthor(ctx, author.ID), ErrAuthorHasPosts)
+
+	require.NoError(t, posts.DeletePost(ctx, post.ID, nil))
+	assert.ErrorIs(t, authors.DeleteAuthor(ctx, author.ID), ErrAuthorHasPosts, "trashed posts still refer to the author")
+
+	fake.Advance(time.Hour)
+	purged, err := posts.PurgeTrash(ctx, time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, 1, purged)
+	require.NoError(t, authors.DeleteAuthor(ctx, author.ID))
+	assert.ErrorIs(t, authors.DeleteAuthor(ctx, author.ID), repositories.ErrAuthorNotFound)
+}
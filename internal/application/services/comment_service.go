@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+
+	"rakia-tech-test/internal/application/policy"
+	"rakia-tech-test/internal/application/principal"
+	"rakia-tech-test/internal/domain/clock"
+	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/errdefs"
+	"rakia-tech-test/internal/domain/repositories"
+)
+
+var ErrCommentsClosed = errdefs.New(errdefs.ErrConflict, "comments are only open on published posts")
+
+// CommentThreads is one page of the comment threads on a post.
+type CommentThreads struct {
+	Threads []*entities.CommentThread
+	HasNext bool
+}
+
+// CommentService manages the comments on posts. Comments share the fate of
+// their post: they are only reachable while the caller can see the post,
+// follow it into the trash and back, and are removed when it is purged.
+type CommentService struct {
+	commentRepo repositories.CommentRepository
+	posts       *PostService
+	clock       clock.Clock
+	logger      *logrus.Logger
+}
+
+func NewCommentService(commentRepo repositories.CommentRepository, posts *PostService, clock clock.Clock, logger *logrus.Logger) *CommentService {
+	return &CommentService{
+		commentRepo: commentRepo,
+		posts:       posts,
+		clock:       clock,
+		logger:      logger,
+	}
+}
+
+// ListComments returns one page of the threads on the post with the given
+// ID, each with every reply below it. A zero query.Limit means
+// DefaultPageLimit.
+func (s *CommentService) ListComments(ctx context.Context, postID int, query repositories.CommentQuery) (*CommentThreads, error) {
+	s.log(ctx).WithFields(logrus.Fields{
+		"post_id": postID,
+		"limit":   query.Limit,
+		"after":   query.After,
+	}).Debug("Listing comments")
+
+	if query.Limit == 0 {
+		query.Limit = DefaultPageLimit
+	}
+	if query.Limit < 0 || query.Limit > MaxPageLimit {
+		return nil, ErrInvalidPageLimit
+	}
+	if _, err := s.posts.getVisible(ctx, postID); err != nil {
+		return nil, err
+	}
+
+	page, err := s.commentRepo.ListThreads(ctx, postID, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CommentThreads{
+		Threads: entities.CommentTree(page.Comments),
+		HasNext: page.HasNext,
+	}, nil
+}
+
+// CreateComment adds a comment to the post with the given ID, replying to
+// the comment parentID unless it is zero. The comment is written under the
+// name of the caller behind ctx. Only published posts take new comments.
+func (s *CommentService) CreateComment(ctx context.Context, postID, parentID int, content string) (*entities.Comment, error) {
+	author := caller(ctx).Name
+	s.log(ctx).WithFields(logrus.Fields{
+		"post_id":   postID,
+		"parent_id": parentID,
+		"author":    author,
+	}).Info("Creating new comment")
+
+	post, err := s.posts.getVisible(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	if post.Status != entities.StatusPublished {
+		return nil, ErrCommentsClosed
+	}
+
+	comment, err := s.commentRepo.CreateComment(ctx, postID, parentID, author, content, s.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	s.log(ctx).WithField("comment_id", comment.ID).Info("Comment created successfully")
+	return comment, nil
+}
+
+// UpdateComment replaces the content of the comment with the given ID on the
+// post with the given ID. The caller may only edit comments the policy lets
+// them change.
+func (s *CommentService) UpdateComment(ctx context.Context, postID, id int, content string) (*entities.Comment, error) {
+	s.log(ctx).WithFields(logrus.Fields{
+		"post_id":    postID,
+		"comment_id": id,
+	}).Info("Updating comment")
+
+	comment, err := s.getComment(ctx, postID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizeComment(ctx, comment.Author); err != nil {
+		return nil, err
+	}
+	if err := comment.Edit(content, s.clock.Now()); err != nil {
+		return nil, err
+	}
+	if err := s.commentRepo.Update(ctx, comment); err != nil {
+		return nil, err
+	}
+
+	s.log(ctx).WithField("comment_id", id).Info("Comment updated successfully")
+	return comment, nil
+}
+
+// DeleteComment removes the comment with the given ID on the post with the
+// given ID, together with the replies below it. The caller may only delete
+// comments the policy lets them change; the replies go along whoever wrote
+// them.
+func (s *CommentService) DeleteComment(ctx context.Context, postID, id int) error {
+	s.log(ctx).WithFields(logrus.Fields{
+		"post_id":    postID,
+		"comment_id": id,
+	}).Info("Deleting comment")
+
+	comment, err := s.getComment(ctx, postID, id)
+	if err != nil {
+		return err
+	}
+	if err := authorizeComment(ctx, comment.Author); err != nil {
+		return err
+	}
+
+	deleted, err := s.commentRepo.Delete(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"comment_id":  id,
+		"comment_ids": deleted,
+	}).Info("Comment deleted successfully")
+	return nil
+}
+
+// getComment returns the comment with the given ID if it belongs to the post
+// with the given ID and the caller can see that post.
+func (s *CommentService) getComment(ctx context.Context, postID, id int) (*entities.Comment, error) {
+	if _, err := s.posts.getVisible(ctx, postID); err != nil {
+		return nil, err
+	}
+
+	comment, err := s.commentRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if comment.PostID != postID {
+		return nil, repositories.ErrCommentNotFound
+	}
+	return comment, nil
+}
+
+// authorizeComment checks with the policy that the caller behind ctx may
+// change a comment written by author. Like authorize, it lets requests
+// without a caller through.
+func authorizeComment(ctx context.Context, author string) error {
+	p, ok := principal.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	return policy.AuthorizeComment(p, author)
+}
+
+func (s *CommentService) log(ctx context.Context) *logrus.Entry {
+	return requestLog(ctx, s.logger)
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"rakia-tech-test/internal/application/policy"
+	"rakia-tech-test/internal/application/principal"
+	"rakia-tech-test/internal/domain/clock"
+	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/repositories"
+	memory_repositories "rakia-tech-test/internal/infrastructure/repositories"
+)
+
+// newCommentServices wires the comment and post services to in-memory
+// repositories and returns them with the clock they use.
+func newCommentServices() (*CommentService, *PostService, *clock.Fake) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel)
+
+	commentRepo := memory_repositories.NewMemoryCommentRepository()
+	posts := NewPostService(memory_repositories.NewMemoryPostRepository(), memory_repositories.NewMemoryRevisionRepository(),
+		memory_repositories.NewMemoryAuthorRepository(), commentRepo, fake, logger)
+	return NewCommentService(commentRepo, posts, fake, logger), posts, fake
+}
+
+// commenter returns a context for a reader signed in as name.
+func commenter(name string) context.Context {
+	return principal.NewContext(context.Background(), principal.Principal{Name: name, Role: entities.RoleReader})
+}
+
+// publishedPost creates a post and takes it through review to published.
+func publishedPost(t *testing.T, posts *PostService, title string) *entities.Post {
+	t.Helper()
+	ctx := context.Background()
+
+	post, err := posts.CreatePost(ctx, title, "Content", "", "Author", 0, nil)
+	require.NoError(t, err)
+	for _, status := range []entities.PostStatus{entities.StatusReview, entities.StatusPublished} {
+		post, err = posts.TransitionPost(ctx, post.ID, status, nil)
+		require.NoError(t, err)
+	}
+	return post
+}
+
+func TestCommentService_Threads(t *testing.T) {
+	comments, posts, _ := newCommentServices()
+	ctx := context.Background()
+	post := publishedPost(t, posts, "Post")
+
+	first, err := comments.CreateComment(commenter("Reader"), post.ID, 0, "First")
+	require.NoError(t, err)
+	reply, err := comments.CreateComment(commenter("Author"), post.ID, first.ID, "Thanks")
+	require.NoError(t, err)
+	_, err = comments.CreateComment(commenter("Reader"), post.ID, reply.ID, "You're welcome")
+	require.NoError(t, err)
+	second, err := comments.CreateComment(commenter("Other"), post.ID, 0, "Second")
+	require.NoError(t, err)
+
+	page, err := comments.ListComments(ctx, post.ID, repositories.CommentQuery{Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, page.Threads, 1)
+	assert.True(t, page.HasNext)
+	assert.Equal(t, first.ID, page.Threads[0].ID)
+	require.Len(t, page.Threads[0].Replies, 1)
+	require.Len(t, page.Threads[0].Replies[0].Replies, 1)
+	assert.Equal(t, "You're welcome", page.Threads[0].Replies[0].Replies[0].Content)
+
+	page, err = comments.ListComments(ctx, post.ID, repositories.CommentQuery{After: first.ID})
+	require.NoError(t, err)
+	require.Len(t, page.Threads, 1)
+	assert.Equal(t, second.ID, page.Threads[0].ID)
+	assert.False(t, page.HasNext)
+
+	_, err = comments.ListComments(ctx, post.ID, repositories.CommentQuery{Limit: MaxPageLimit + 1})
+	assert.ErrorIs(t, err, ErrInvalidPageLimit)
+	_, err = comments.ListComments(ctx, 99, repositories.CommentQuery{})
+	assert.ErrorIs(t, err, repositories.ErrPostNotFound)
+}
+
+func TestCommentService_CreateComment(t *testing.T) {
+	comments, posts, _ := newCommentServices()
+	ctx := context.Background()
+	published := publishedPost(t, posts, "Published")
+	other := publishedPost(t, posts, "Other")
+	draft, err := posts.CreatePost(ctx, "Draft", "Content", "", "Author", 0, nil)
+	require.NoError(t, err)
+
+	_, err = comments.CreateComment(commenter("Reader"), draft.ID, 0, "Hidden")
+	assert.ErrorIs(t, err, repositories.ErrPostNotFound, "other callers cannot see drafts")
+	asAuthor := principal.NewContext(ctx, principal.Principal{Name: "Author"})
+	_, err = comments.CreateComment(asAuthor, draft.ID, 0, "Note to self")
+	assert.ErrorIs(t, err, ErrCommentsClosed)
+
+	root, err := comments.CreateComment(commenter("Reader"), published.ID, 0, "Hello")
+	require.NoError(t, err)
+	assert.Equal(t, "Reader", root.Author, "comments are written under the name of the caller")
+	_, err = comments.CreateComment(ctx, published.ID, 0, "Hello")
+	assert.ErrorIs(t, err, entities.ErrCommentAuthorRequired)
+	_, err = comments.CreateComment(commenter("Reader"), other.ID, root.ID, "Wrong post")
+	assert.ErrorIs(t, err, repositories.ErrParentNotFound)
+	_, err = comments.CreateComment(commenter("Reader"), published.ID, 0, " ")
+	assert.ErrorIs(t, err, entities.ErrCommentContentRequired)
+}
+
+func TestCommentService_UpdateAndDelete(t *testing.T) {
+	comments, posts, fake := newCommentServices()
+	ctx := context.Background()
+	post := publishedPost(t, posts, "Post")
+	other := publishedPost(t, posts, "Other")
+
+	root, err := comments.CreateComment(commenter("Reader"), post.ID, 0, "Frist")
+	require.NoError(t, err)
+	_, err = comments.CreateComment(commenter("Other"), post.ID, root.ID, "Reply")
+	require.NoError(t, err)
+
+	fake.Advance(time.Minute)
+	edited, err := comments.UpdateComment(ctx, post.ID, root.ID, "First")
+	require.NoError(t, err)
+	assert.Equal(t, "First", edited.Content)
+	assert.Equal(t, fake.Now(), edited.UpdatedAt)
+
+	_, err = comments.UpdateComment(ctx, other.ID, root.ID, "Moved")
+	assert.ErrorIs(t, err, repositories.ErrCommentNotFound, "comments are addressed through their post")
+	assert.ErrorIs(t, comments.DeleteComment(ctx, other.ID, root.ID), repositories.ErrCommentNotFound)
+
+	require.NoError(t, comments.DeleteComment(ctx, post.ID, root.ID))
+	page, err := comments.ListComments(ctx, post.ID, repositories.CommentQuery{})
+	require.NoError(t, err)
+	assert.Empty(t, page.Threads, "replies are deleted with their parent")
+}
+
+func TestCommentService_Authorization(t *testing.T) {
+	comments, posts, _ := newCommentServices()
+	post := publishedPost(t, posts, "Post")
+	reader, other := commenter("Reader"), commenter("Other")
+	author := principal.NewContext(context.Background(), principal.Principal{Name: "Author", Role: entities.RoleAuthor})
+	editor := principal.NewContext(context.Background(), principal.Principal{Name: "Eve", Role: entities.RoleEditor})
+
+	root, err := comments.CreateComment(reader, post.ID, 0, "Hello")
+	require.NoError(t, err)
+
+	_, err = comments.UpdateComment(other, post.ID, root.ID, "Taken")
+	assert.ErrorIs(t, err, policy.ErrNotCommenter)
+	_, err = comments.UpdateComment(author, post.ID, root.ID, "Taken")
+	assert.ErrorIs(t, err, policy.ErrNotCommenter, "the author of the post does not own its comments")
+	assert.ErrorIs(t, comments.DeleteComment(other, post.ID, root.ID), policy.ErrNotCommenter)
+
+	edited, err := comments.UpdateComment(commenter(" reader "), post.ID, root.ID, "Hello again")
+	require.NoError(t, err)
+	assert.Equal(t, "Hello again", edited.Content)
+	edited, err = comments.UpdateComment(editor, post.ID, root.ID, "Moderated")
+	require.NoError(t, err)
+	assert.Equal(t, "Reader", edited.Author)
+
+	reply, err := comments.CreateComment(other, post.ID, root.ID, "Reply")
+	require.NoError(t, err)
+	require.NoError(t, comments.DeleteComment(other, post.ID, reply.ID))
+	require.NoError(t, comments.DeleteComment(editor, post.ID, root.ID))
+}
+
+func TestCommentService_FollowPostIntoTrash(t *testing.T) {
+	comments, posts, fake := newCommentServices()
+	ctx := context.Background()
+	post := publishedPost(t, posts, "Post")
+
+	comment, err := comments.CreateComment(commenter("Reader"), post.ID, 0, "Hello")
+	require.NoError(t, err)
+
+	require.NoError(t, posts.DeletePost(ctx, post.ID, nil))
+	_, err = comments.ListComments(ctx, post.ID, repositories.CommentQuery{})
+	assert.ErrorIs(t, err, repositories.ErrPostNotFound)
+	_, err = comments.UpdateComment(ctx, post.ID, comment.ID, "Edited")
+	assert.ErrorIs(t, err, repositories.ErrPostNotFound)
+
+	_, err = posts.RestorePost(ctx, post.ID)
+	require.NoError(t, err)
+	page, err := comments.ListComments(ctx, post.ID, repositories.CommentQuery{})
+	require.NoError(t, err)
+	require.Len(t, page.Threads, 1, "restoring the post brings its comments back")
+
+	require.NoError(t, posts.DeletePost(ctx, post.ID, nil))
+	fake.Advance(time.Hour)
+	purged, err := posts.PurgeTrash(ctx, time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, 1, purged)
+
+	_, err = comments.commentRepo.GetByID(ctx, comment.ID)
+	assert.ErrorIs(t, err, repositories.ErrCommentNotFound, "purging the post removes its comments")
+}
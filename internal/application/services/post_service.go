@@ -1,93 +1,699 @@
 package services
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"rakia-tech-test/internal/application/diff"
+	"rakia-tech-test/internal/application/policy"
+	"rakia-tech-test/internal/application/principal"
+	"rakia-tech-test/internal/application/render"
+	"rakia-tech-test/internal/application/requestid"
+	"rakia-tech-test/internal/domain/clock"
 	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/errdefs"
 	"rakia-tech-test/internal/domain/repositories"
+	"rakia-tech-test/internal/domain/validation"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
 
+const (
+	// DefaultPageLimit is the page size used when the caller does not ask
+	// for one.
+	DefaultPageLimit = 20
+	// MaxPageLimit caps the page size a caller may ask for.
+	MaxPageLimit = 100
+	// renderCacheSize caps the number of posts whose rendered content is
+	// kept.
+	renderCacheSize = 1000
+)
+
+var (
+	ErrInvalidDiffMode  = errdefs.New(errdefs.ErrValidation, "diff mode must be line or word")
+	ErrInvalidPageLimit = errdefs.New(errdefs.ErrValidation, fmt.Sprintf("limit must be between 1 and %d", MaxPageLimit))
+	ErrInvalidSort      = errdefs.New(errdefs.ErrValidation, "sort must be one of id, -id, title or author")
+	ErrInvalidIDRange   = errdefs.New(errdefs.ErrValidation, "ID bounds must be positive and the lower bound must not exceed the upper one")
+	ErrInvalidDateRange = errdefs.New(errdefs.ErrValidation, "created_after must be before created_before")
+	ErrUnknownAuthor    = validation.New("author_id", validation.CodeInvalid, "author_id does not refer to an existing author", nil)
+)
+
+// VersionMatcher reports whether a conditional request accepts the current
+// version of a post. A nil VersionMatcher accepts any version.
+type VersionMatcher func(version int) bool
+
+// RevisionDiff holds the per-field differences between two revisions of a
+// post.
+type RevisionDiff struct {
+	PostID  int
+	From    int
+	To      int
+	Mode    diff.Mode
+	Title   []diff.Edit
+	Content []diff.Edit
+	Author  []diff.Edit
+}
+
 type PostService struct {
-	postRepo repositories.PostRepository
-	logger   *logrus.Logger
+	postRepo     repositories.PostRepository
+	revisionRepo repositories.RevisionRepository
+	authorRepo   repositories.AuthorRepository
+	commentRepo  repositories.CommentRepository
+	rendered     *render.Cache
+	clock        clock.Clock
+	logger       *logrus.Logger
 }
 
-func NewPostService(postRepo repositories.PostRepository, logger *logrus.Logger) *PostService {
+func NewPostService(postRepo repositories.PostRepository, revisionRepo repositories.RevisionRepository, authorRepo repositories.AuthorRepository, commentRepo repositories.CommentRepository, clock clock.Clock, logger *logrus.Logger) *PostService {
 	return &PostService{
-		postRepo: postRepo,
-		logger:   logger,
+		postRepo:     postRepo,
+		revisionRepo: revisionRepo,
+		authorRepo:   authorRepo,
+		commentRepo:  commentRepo,
+		rendered:     render.NewCache(renderCacheSize),
+		clock:        clock,
+		logger:       logger,
 	}
 }
 
-func (s *PostService) CreatePost(title, content, author string) (*entities.Post, error) {
-	s.logger.WithFields(logrus.Fields{
-		"title":  title,
-		"author": author,
+// CreatePost stores a new draft written by the author with the given ID or,
+// when authorID is zero, by the author named author, who is added if they
+// are new. An empty format means plain text.
+func (s *PostService) CreatePost(ctx context.Context, title, content string, format entities.ContentFormat, author string, authorID int, tags []string) (*entities.Post, error) {
+	s.log(ctx).WithFields(logrus.Fields{
+		"title":     title,
+		"format":    format,
+		"author":    author,
+		"author_id": authorID,
+		"tags":      tags,
 	}).Info("Creating new post")
 
-	post, err := s.postRepo.CreatePost(title, content, author)
+	byline, err := s.resolveAuthor(ctx, author, authorID)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorize(ctx, byline.Name); err != nil {
+		return nil, err
+	}
+
+	post, err := s.postRepo.CreatePost(ctx, title, content, format, byline.Name, byline.ID, tags, s.clock.Now())
 	if err != nil {
 		return nil, err
 	}
 
-	s.logger.WithField("post_id", post.ID).Info("Post created successfully")
+	s.recordRevisions(ctx, entities.NewRevision(post, nil, editorName(ctx, post.Author), post.CreatedAt))
+
+	s.log(ctx).WithField("post_id", post.ID).Info("Post created successfully")
 	return post, nil
 }
 
-func (s *PostService) GetPostByID(id int) (*entities.Post, error) {
-	s.logger.WithField("post_id", id).Debug("Retrieving post by ID")
+func (s *PostService) GetPostByID(ctx context.Context, id int) (*entities.Post, error) {
+	s.log(ctx).WithField("post_id", id).Debug("Retrieving post by ID")
+
+	return s.getVisible(ctx, id)
+}
+
+// GetPostBySlug returns the post the caller may see whose current or
+// previous slug is slug. A post found under a previous slug carries a
+// different Slug, which callers redirect to.
+func (s *PostService) GetPostBySlug(ctx context.Context, slug string) (*entities.Post, error) {
+	s.log(ctx).WithField("slug", slug).Debug("Retrieving post by slug")
 
-	post, err := s.postRepo.GetByID(id)
+	post, err := s.postRepo.GetBySlug(ctx, slug)
 	if err != nil {
 		return nil, err
 	}
-
+	if !canRead(ctx, post) {
+		return nil, repositories.ErrPostNotFound
+	}
 	return post, nil
 }
 
-func (s *PostService) GetAllPosts() ([]*entities.Post, error) {
-	s.logger.Debug("Retrieving all posts")
+// RenderContent returns the content of post as sanitized HTML, rendered
+// according to its format. The result is cached until the post changes.
+func (s *PostService) RenderContent(post *entities.Post) string {
+	return s.rendered.Render(post)
+}
+
+func (s *PostService) GetAllPosts(ctx context.Context) ([]*entities.Post, error) {
+	s.log(ctx).Debug("Retrieving all posts")
 
-	posts, err := s.postRepo.GetAll()
+	posts, err := s.postRepo.GetAll(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	s.logger.WithField("count", len(posts)).Debug("Retrieved posts")
+	s.log(ctx).WithField("count", len(posts)).Debug("Retrieved posts")
 	return posts, nil
 }
 
-func (s *PostService) UpdatePost(id int, title, content, author string) (*entities.Post, error) {
-	s.logger.WithFields(logrus.Fields{
-		"post_id": id,
-		"title":   title,
-		"author":  author,
+// ListPosts returns one page of the posts the caller may see. A zero limit
+// selects DefaultPageLimit and an empty sort orders by ID.
+func (s *PostService) ListPosts(ctx context.Context, query repositories.PostQuery) (*repositories.PostPage, error) {
+	if query.Limit == 0 {
+		query.Limit = DefaultPageLimit
+	}
+	if query.Limit < 0 || query.Limit > MaxPageLimit {
+		return nil, ErrInvalidPageLimit
+	}
+	if query.Sort == "" {
+		query.Sort = repositories.SortByID
+	}
+	if !query.Sort.Valid() {
+		return nil, ErrInvalidSort
+	}
+	filter := query.Filter
+	if filter.MinID < 0 || filter.MaxID < 0 || (filter.MaxID != 0 && filter.MinID > filter.MaxID) {
+		return nil, ErrInvalidIDRange
+	}
+	if !filter.CreatedAfter.IsZero() && !filter.CreatedBefore.IsZero() && !filter.CreatedAfter.Before(filter.CreatedBefore) {
+		return nil, ErrInvalidDateRange
+	}
+	if filter.Status != "" && !filter.Status.Valid() {
+		return nil, entities.ErrInvalidStatus
+	}
+	if filter.Tag != "" {
+		tag, err := entities.NormalizeTag(filter.Tag)
+		if err != nil {
+			return nil, err
+		}
+		query.Filter.Tag = tag
+	}
+	query.Filter.Viewer = policy.Viewer(caller(ctx))
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"limit": query.Limit,
+		"sort":  query.Sort,
+	}).Debug("Retrieving page of posts")
+
+	page, err := s.postRepo.ListPosts(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	s.log(ctx).WithField("count", len(page.Posts)).Debug("Retrieved posts")
+	return page, nil
+}
+
+// UpdatePost applies the new fields to the post with the given ID. The
+// author is resolved like in CreatePost. Nil tags keep the current ones,
+// while an empty list removes them, and an empty format keeps the current
+// one. The write only succeeds if the post is still at the version that was
+// read, so concurrent updates fail with ErrVersionConflict instead of
+// overwriting each other. match, when set, must accept the current version
+// as well.
+func (s *PostService) UpdatePost(ctx context.Context, id int, title, content string, format entities.ContentFormat, author string, authorID int, tags []string, match VersionMatcher) (*entities.Post, error) {
+	s.log(ctx).WithFields(logrus.Fields{
+		"post_id":   id,
+		"title":     title,
+		"format":    format,
+		"author":    author,
+		"author_id": authorID,
+		"tags":      tags,
 	}).Info("Updating post")
 
-	existingPost, err := s.postRepo.GetByID(id)
+	post, err := s.update(ctx, id, title, content, format, author, authorID, tags, match)
+	if err != nil {
+		return nil, err
+	}
+
+	s.log(ctx).WithField("post_id", id).Info("Post updated successfully")
+	return post, nil
+}
+
+// DeletePost moves the post with the given ID to the trash, from where it can
+// be restored until it is purged. Its comments go with it: they are hidden
+// while the post is in the trash and removed when it is purged. When match is
+// set, the post is only deleted if match accepts its current version. The
+// caller may only delete posts the policy lets them change, which is checked
+// against the version that is deleted.
+func (s *PostService) DeletePost(ctx context.Context, id int, match VersionMatcher) error {
+	s.log(ctx).WithField("post_id", id).Info("Deleting post")
+
+	expectedVersion := repositories.AnyVersion
+	if _, authenticated := principal.FromContext(ctx); match != nil || authenticated {
+		existingPost, err := s.postRepo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if match != nil && !match(existingPost.Version) {
+			return repositories.ErrVersionConflict
+		}
+		if err := authorize(ctx, existingPost.Author); err != nil {
+			return err
+		}
+		expectedVersion = existingPost.Version
+	}
+
+	if err := s.postRepo.Trash(ctx, id, expectedVersion, s.clock.Now()); err != nil {
+		return err
+	}
+
+	s.log(ctx).WithField("post_id", id).Info("Post moved to trash")
+	return nil
+}
+
+// TransitionPost moves the post with the given ID to status along the
+// editorial workflow. Like an update it bumps the version, with the same
+// version checks, and records a revision so the history stays in step with
+// the versions.
+func (s *PostService) TransitionPost(ctx context.Context, id int, status entities.PostStatus, match VersionMatcher) (*entities.Post, error) {
+	s.log(ctx).WithFields(logrus.Fields{
+		"post_id": id,
+		"status":  status,
+	}).Info("Changing post status")
+
+	post, err := s.edit(ctx, id, match, 0, authorized(ctx, func(post *entities.Post) error {
+		return post.TransitionTo(status, s.clock.Now())
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"post_id": id,
+		"status":  post.Status,
+	}).Info("Post status changed successfully")
+	return post, nil
+}
+
+// SchedulePost arranges for the post with the given ID to be published at
+// a later time. The post must be in review, and the schedule is kept until
+// the post is published or moves to another status. Like a transition it
+// bumps the version and records a revision.
+func (s *PostService) SchedulePost(ctx context.Context, id int, at time.Time, match VersionMatcher) (*entities.Post, error) {
+	s.log(ctx).WithFields(logrus.Fields{
+		"post_id":    id,
+		"publish_at": at,
+	}).Info("Scheduling post publication")
+
+	post, err := s.edit(ctx, id, match, 0, authorized(ctx, func(post *entities.Post) error {
+		return post.SchedulePublication(at, s.clock.Now())
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"post_id":    id,
+		"publish_at": post.PublishAt,
+	}).Info("Post publication scheduled successfully")
+	return post, nil
+}
+
+// PublishScheduled publishes the post with the given ID if its scheduled
+// publication is due, and returns entities.ErrPublicationNotDue otherwise.
+func (s *PostService) PublishScheduled(ctx context.Context, id int) (*entities.Post, error) {
+	post, err := s.edit(ctx, id, nil, 0, func(post *entities.Post) error {
+		return post.PublishScheduled(s.clock.Now())
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.log(ctx).WithField("post_id", id).Info("Scheduled post published")
+	return post, nil
+}
+
+// ListTags returns the tags of the published posts with the number of posts
+// carrying each, most used first.
+func (s *PostService) ListTags(ctx context.Context) ([]repositories.TagCount, error) {
+	s.log(ctx).Debug("Retrieving tag counts")
+
+	return s.postRepo.TagCounts(ctx)
+}
+
+// GetTrash returns the trashed posts the caller behind ctx may read, as
+// decided for live posts.
+func (s *PostService) GetTrash(ctx context.Context) ([]*entities.Post, error) {
+	s.log(ctx).Debug("Retrieving trashed posts")
+
+	trash, err := s.postRepo.GetTrash(ctx)
+	if err != nil {
+		return nil, err
+	}
+	visible := make([]*entities.Post, 0, len(trash))
+	for _, post := range trash {
+		if canRead(ctx, post) {
+			visible = append(visible, post)
+		}
+	}
+	return visible, nil
+}
+
+// RestorePost takes a post out of the trash under its original ID.
+func (s *PostService) RestorePost(ctx context.Context, id int) (*entities.Post, error) {
+	s.log(ctx).WithField("post_id", id).Info("Restoring post from trash")
+
+	if _, authenticated := principal.FromContext(ctx); authenticated {
+		trash, err := s.postRepo.GetTrash(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, trashed := range trash {
+			if trashed.ID == id {
+				if err := authorize(ctx, trashed.Author); err != nil {
+					return nil, err
+				}
+				break
+			}
+		}
+	}
+
+	post, err := s.postRepo.Restore(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.log(ctx).WithField("post_id", id).Info("Post restored successfully")
+	return post, nil
+}
+
+// PurgeTrash permanently removes the posts that have been in the trash for
+// longer than retention, together with their revision history and comments,
+// and returns how many posts were removed.
+func (s *PostService) PurgeTrash(ctx context.Context, retention time.Duration) (int, error) {
+	purged, err := s.postRepo.Purge(ctx, s.clock.Now().Add(-retention))
+	if err != nil {
+		return 0, err
+	}
+
+	for _, id := range purged {
+		if err := s.revisionRepo.DeleteByPost(ctx, id); err != nil {
+			s.log(ctx).WithError(err).WithField("post_id", id).Error("Failed to delete revisions of purged post")
+		}
+		if err := s.commentRepo.DeleteByPost(ctx, id); err != nil {
+			s.log(ctx).WithError(err).WithField("post_id", id).Error("Failed to delete comments of purged post")
+		}
+	}
+
+	if len(purged) > 0 {
+		s.log(ctx).WithFields(logrus.Fields{
+			"count":    len(purged),
+			"post_ids": purged,
+		}).Info("Purged trashed posts")
+	}
+	return len(purged), nil
+}
+
+// ListRevisions returns the revision history of a post, oldest first.
+func (s *PostService) ListRevisions(ctx context.Context, postID int) ([]*entities.Revision, error) {
+	s.log(ctx).WithField("post_id", postID).Debug("Retrieving post revisions")
+
+	if _, err := s.getVisible(ctx, postID); err != nil {
+		return nil, err
+	}
+
+	return s.revisionRepo.ListByPost(ctx, postID)
+}
+
+func (s *PostService) GetRevision(ctx context.Context, postID, number int) (*entities.Revision, error) {
+	s.log(ctx).WithFields(logrus.Fields{
+		"post_id":  postID,
+		"revision": number,
+	}).Debug("Retrieving post revision")
+
+	if _, err := s.getVisible(ctx, postID); err != nil {
+		return nil, err
+	}
+
+	return s.revisionRepo.Get(ctx, postID, number)
+}
+
+// DiffRevisions compares revision from with revision to of a post.
+func (s *PostService) DiffRevisions(ctx context.Context, postID, from, to int, mode diff.Mode) (*RevisionDiff, error) {
+	if mode != diff.LineMode && mode != diff.WordMode {
+		return nil, ErrInvalidDiffMode
+	}
+
+	oldRevision, err := s.GetRevision(ctx, postID, from)
+	if err != nil {
+		return nil, err
+	}
+	newRevision, err := s.revisionRepo.Get(ctx, postID, to)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := existingPost.Update(title, content, author); err != nil {
+	result := &RevisionDiff{PostID: postID, From: from, To: to, Mode: mode}
+	result.Title, _ = diff.Compute(mode, oldRevision.Title, newRevision.Title)
+	result.Content, _ = diff.Compute(mode, oldRevision.Content, newRevision.Content)
+	result.Author, _ = diff.Compute(mode, oldRevision.Author, newRevision.Author)
+
+	return result, nil
+}
+
+// RestoreRevision writes the fields of an old revision back to the post. The
+// revision only records the name of its author, so the post goes back to the
+// author of that name and stays with its current one when no author is named
+// so anymore, for example after a rename; the restore never adds an author.
+// The restore is an ordinary edit: it bumps the version and records a new
+// revision, so the history stays append-only. Like GetRevision it answers
+// ErrPostNotFound to callers who cannot see the post, before the policy is
+// asked whether they may change it.
+func (s *PostService) RestoreRevision(ctx context.Context, postID, number int, match VersionMatcher) (*entities.Post, error) {
+	s.log(ctx).WithFields(logrus.Fields{
+		"post_id":  postID,
+		"revision": number,
+	}).Info("Restoring post revision")
+
+	if _, authenticated := principal.FromContext(ctx); authenticated {
+		if _, err := s.getVisible(ctx, postID); err != nil {
+			return nil, err
+		}
+	}
+	revision, err := s.revisionRepo.Get(ctx, postID, number)
+	if err != nil {
 		return nil, err
 	}
 
-	if err := s.postRepo.Update(id, existingPost); err != nil {
+	byline, err := s.authorRepo.GetByName(ctx, revision.Author)
+	if err != nil && !errors.Is(err, repositories.ErrAuthorNotFound) {
 		return nil, err
 	}
 
-	s.logger.WithField("post_id", id).Info("Post updated successfully")
+	post, err := s.edit(ctx, postID, match, number, authorized(ctx, func(post *entities.Post) error {
+		author, authorID := post.Author, post.AuthorID
+		if byline != nil {
+			author, authorID = byline.Name, byline.ID
+		}
+		if err := post.Update(revision.Title, revision.Content, author, s.clock.Now()); err != nil {
+			return err
+		}
+		post.AuthorID = authorID
+		return nil
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	s.log(ctx).WithField("post_id", postID).Info("Post revision restored successfully")
+	return post, nil
+}
+
+// update is the write path of UpdatePost. Nil tags and an empty format are
+// left alone.
+func (s *PostService) update(ctx context.Context, id int, title, content string, format entities.ContentFormat, author string, authorID int, tags []string, match VersionMatcher) (*entities.Post, error) {
+	byline, err := s.resolveAuthor(ctx, author, authorID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.edit(ctx, id, match, 0, authorized(ctx, func(post *entities.Post) error {
+		if tags != nil {
+			if err := post.SetTags(tags); err != nil {
+				return err
+			}
+		}
+		if format != "" {
+			if err := post.SetContentFormat(format); err != nil {
+				return err
+			}
+		}
+		if err := post.Update(title, content, byline.Name, s.clock.Now()); err != nil {
+			return err
+		}
+		post.AuthorID = byline.ID
+		return nil
+	}))
+}
+
+// resolveAuthor returns the author record a write refers to: the one with
+// the given ID or, when authorID is zero, the one named name, which is added
+// if it does not exist yet. A blank name resolves to an unsaved author, so
+// the post validation reports it.
+func (s *PostService) resolveAuthor(ctx context.Context, name string, authorID int) (*entities.Author, error) {
+	if authorID != 0 {
+		author, err := s.authorRepo.GetByID(ctx, authorID)
+		if errors.Is(err, repositories.ErrAuthorNotFound) {
+			return nil, ErrUnknownAuthor
+		}
+		return author, err
+	}
+
+	if entities.NormalizeAuthorName(name) == "" {
+		return &entities.Author{Name: name}, nil
+	}
+	author, err := s.authorRepo.GetByName(ctx, name)
+	if !errors.Is(err, repositories.ErrAuthorNotFound) {
+		return author, err
+	}
+
+	author, err = s.authorRepo.CreateAuthor(ctx, name, "", s.clock.Now())
+	if errors.Is(err, repositories.ErrAuthorExists) {
+		// A concurrent write added the author first.
+		return s.authorRepo.GetByName(ctx, name)
+	}
+	if err == nil {
+		s.log(ctx).WithFields(logrus.Fields{
+			"author_id": author.ID,
+			"author":    author.Name,
+		}).Info("Author added")
+	}
+	return author, err
+}
+
+// edit applies change to the post with the given ID and writes it back if
+// the post is still at the version that was read, then records the new
+// revision. restoredFrom is recorded on that revision.
+func (s *PostService) edit(ctx context.Context, id int, match VersionMatcher, restoredFrom int, change func(*entities.Post) error) (*entities.Post, error) {
+	existingPost, err := s.postRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	readVersion := existingPost.Version
+	if match != nil && !match(readVersion) {
+		return nil, repositories.ErrVersionConflict
+	}
+
+	history, err := s.revisionRepo.ListByPost(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Posts written before revisions were kept, or loaded from the seed
+	// file, have no revision for the version being replaced. Capture it
+	// before it is overwritten so it can still be restored.
+	var baseline, previous *entities.Revision
+	if len(history) > 0 {
+		previous = history[len(history)-1]
+	}
+	if previous == nil || previous.Number != readVersion {
+		baseline = entities.NewRevision(existingPost, previous, existingPost.Author, s.clock.Now())
+		previous = baseline
+	}
+
+	if err := change(existingPost); err != nil {
+		return nil, err
+	}
+
+	if err := s.postRepo.Update(ctx, id, existingPost, readVersion); err != nil {
+		return nil, err
+	}
+
+	revision := entities.NewRevision(existingPost, previous, editorName(ctx, existingPost.Author), existingPost.UpdatedAt)
+	revision.RestoredFrom = restoredFrom
+	if baseline != nil {
+		s.recordRevisions(ctx, baseline, revision)
+	} else {
+		s.recordRevisions(ctx, revision)
+	}
+
 	return existingPost, nil
 }
 
-func (s *PostService) DeletePost(id int) error {
-	s.logger.WithField("post_id", id).Info("Deleting post")
+// getVisible returns the post with the given ID if the caller may see it.
+// Unpublished posts the caller may not read are reported as missing, so
+// their existence does not leak.
+func (s *PostService) getVisible(ctx context.Context, id int) (*entities.Post, error) {
+	post, err := s.postRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !canRead(ctx, post) {
+		return nil, repositories.ErrPostNotFound
+	}
+	return post, nil
+}
 
-	if err := s.postRepo.Delete(id); err != nil {
-		return err
+// authorize checks with the policy that the caller behind ctx may change a
+// post written by author. Requests without a caller come from the server
+// itself, such as scheduled publications, and are not checked; the REST
+// layer lets no anonymous write through.
+func authorize(ctx context.Context, author string) error {
+	p, ok := principal.FromContext(ctx)
+	if !ok {
+		return nil
 	}
+	return policy.Authorize(p, author)
+}
 
-	s.logger.WithField("post_id", id).Info("Post deleted successfully")
-	return nil
+// authorized wraps change so it only applies to posts the caller behind ctx
+// may change, and cannot hand a post to an author the caller may not write
+// for. Running inside edit, the check sees the version that is written.
+func authorized(ctx context.Context, change func(*entities.Post) error) func(*entities.Post) error {
+	return func(post *entities.Post) error {
+		if err := authorize(ctx, post.Author); err != nil {
+			return err
+		}
+		if err := change(post); err != nil {
+			return err
+		}
+		return authorize(ctx, post.Author)
+	}
+}
+
+// caller returns the caller behind ctx, or the zero Principal for anonymous
+// requests.
+func caller(ctx context.Context) principal.Principal {
+	p, _ := principal.FromContext(ctx)
+	return p
+}
+
+// editorName names who makes a write: the caller behind ctx, or author for
+// writes of the server itself, such as scheduled publications.
+func editorName(ctx context.Context, author string) string {
+	if p, ok := principal.FromContext(ctx); ok && p.Name != "" {
+		return p.Name
+	}
+	return author
+}
+
+// canRead checks with the policy that the caller behind ctx may read post.
+func canRead(ctx context.Context, post *entities.Post) bool {
+	return policy.CanRead(caller(ctx), post)
+}
+
+// recordRevisions appends revisions after the post write they describe has
+// succeeded. The write cannot be undone at that point, so the revisions are
+// recorded even if the request is cancelled meanwhile, and a failure is
+// logged rather than reported to the caller.
+func (s *PostService) recordRevisions(ctx context.Context, revisions ...*entities.Revision) {
+	ctx = context.WithoutCancel(ctx)
+	for _, revision := range revisions {
+		err := s.revisionRepo.Append(ctx, revision)
+		if err != nil && !errors.Is(err, repositories.ErrRevisionExists) {
+			s.log(ctx).WithError(err).WithFields(logrus.Fields{
+				"post_id":  revision.PostID,
+				"revision": revision.Number,
+			}).Error("Failed to record post revision")
+		}
+	}
+}
+
+// log returns a log entry tagged with the ID of the request behind ctx.
+func (s *PostService) log(ctx context.Context) *logrus.Entry {
+	return requestLog(ctx, s.logger)
+}
+
+// requestLog returns an entry of logger tagged with the ID of the request
+// behind ctx.
+func requestLog(ctx context.Context, logger *logrus.Logger) *logrus.Entry {
+	entry := logrus.NewEntry(logger)
+	if id := requestid.FromContext(ctx); id != "" {
+		entry = entry.WithField("request_id", id)
+	}
+	return entry
 }
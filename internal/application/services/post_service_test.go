@@ -1,10 +1,17 @@
 package services
 
 import (
+	"context"
 	"errors"
+	"rakia-tech-test/internal/application/diff"
+	"rakia-tech-test/internal/application/policy"
+	"rakia-tech-test/internal/application/principal"
+	"rakia-tech-test/internal/domain/clock"
 	"rakia-tech-test/internal/domain/entities"
 	"rakia-tech-test/internal/domain/repositories"
+	memory_repositories "rakia-tech-test/internal/infrastructure/repositories"
 	"testing"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
@@ -16,12 +23,12 @@ type MockPostRepository struct {
 	mock.Mock
 }
 
-func (m *MockPostRepository) Create(post *entities.Post) error {
+func (m *MockPostRepository) Create(ctx context.Context, post *entities.Post) error {
 	args := m.Called(post)
 	return args.Error(0)
 }
 
-func (m *MockPostRepository) GetByID(id int) (*entities.Post, error) {
+func (m *MockPostRepository) GetByID(ctx context.Context, id int) (*entities.Post, error) {
 	args := m.Called(id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -29,7 +36,15 @@ func (m *MockPostRepository) GetByID(id int) (*entities.Post, error) {
 	return args.Get(0).(*entities.Post), args.Error(1)
 }
 
-func (m *MockPostRepository) GetAll() ([]*entities.Post, error) {
+func (m *MockPostRepository) GetBySlug(ctx context.Context, slug string) (*entities.Post, error) {
+	args := m.Called(slug)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.Post), args.Error(1)
+}
+
+func (m *MockPostRepository) GetAll(ctx context.Context) ([]*entities.Post, error) {
 	args := m.Called()
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -37,22 +52,30 @@ func (m *MockPostRepository) GetAll() ([]*entities.Post, error) {
 	return args.Get(0).([]*entities.Post), args.Error(1)
 }
 
-func (m *MockPostRepository) Update(id int, post *entities.Post) error {
-	args := m.Called(id, post)
+func (m *MockPostRepository) ListPosts(ctx context.Context, query repositories.PostQuery) (*repositories.PostPage, error) {
+	args := m.Called(query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repositories.PostPage), args.Error(1)
+}
+
+func (m *MockPostRepository) Update(ctx context.Context, id int, post *entities.Post, expectedVersion int) error {
+	args := m.Called(id, post, expectedVersion)
 	return args.Error(0)
 }
 
-func (m *MockPostRepository) Delete(id int) error {
-	args := m.Called(id)
+func (m *MockPostRepository) Delete(ctx context.Context, id int, expectedVersion int) error {
+	args := m.Called(id, expectedVersion)
 	return args.Error(0)
 }
 
-func (m *MockPostRepository) Exists(id int) bool {
+func (m *MockPostRepository) Exists(ctx context.Context, id int) bool {
 	args := m.Called(id)
 	return args.Bool(0)
 }
 
-func (m *MockPostRepository) CreatePost(title, content, author string) (*entities.Post, error) {
+func (m *MockPostRepository) CreatePost(ctx context.Context, title, content string, format entities.ContentFormat, author string, authorID int, tags []string, createdAt time.Time) (*entities.Post, error) {
 	args := m.Called(title, content, author)
 	if len(args) >= 2 && args.Get(0) != nil {
 		return args.Get(0).(*entities.Post), args.Error(1)
@@ -60,11 +83,87 @@ func (m *MockPostRepository) CreatePost(title, content, author string) (*entitie
 	return nil, args.Error(1)
 }
 
-func (m *MockPostRepository) LoadData(posts []*entities.Post) error {
+func (m *MockPostRepository) TagCounts(ctx context.Context) ([]repositories.TagCount, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repositories.TagCount), args.Error(1)
+}
+
+func (m *MockPostRepository) LoadData(ctx context.Context, posts []*entities.Post) error {
 	args := m.Called(posts)
 	return args.Error(0)
 }
 
+func (m *MockPostRepository) Trash(ctx context.Context, id int, expectedVersion int, deletedAt time.Time) error {
+	args := m.Called(id, expectedVersion, deletedAt)
+	return args.Error(0)
+}
+
+func (m *MockPostRepository) GetTrash(ctx context.Context) ([]*entities.Post, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entities.Post), args.Error(1)
+}
+
+func (m *MockPostRepository) Restore(ctx context.Context, id int) (*entities.Post, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.Post), args.Error(1)
+}
+
+func (m *MockPostRepository) Purge(ctx context.Context, deletedBefore time.Time) ([]int, error) {
+	args := m.Called(deletedBefore)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]int), args.Error(1)
+}
+
+type MockRevisionRepository struct {
+	mock.Mock
+}
+
+func (m *MockRevisionRepository) Append(ctx context.Context, revision *entities.Revision) error {
+	args := m.Called(revision)
+	return args.Error(0)
+}
+
+func (m *MockRevisionRepository) Get(ctx context.Context, postID, number int) (*entities.Revision, error) {
+	args := m.Called(postID, number)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.Revision), args.Error(1)
+}
+
+func (m *MockRevisionRepository) ListByPost(ctx context.Context, postID int) ([]*entities.Revision, error) {
+	args := m.Called(postID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entities.Revision), args.Error(1)
+}
+
+func (m *MockRevisionRepository) DeleteByPost(ctx context.Context, postID int) error {
+	args := m.Called(postID)
+	return args.Error(0)
+}
+
+// newRevisionRepo returns a revision repository mock with an empty history
+// that accepts every append, for tests that do not look at revisions.
+func newRevisionRepo() *MockRevisionRepository {
+	revisionRepo := new(MockRevisionRepository)
+	revisionRepo.On("ListByPost", mock.Anything).Return([]*entities.Revision{}, nil).Maybe()
+	revisionRepo.On("Append", mock.Anything).Return(nil).Maybe()
+	return revisionRepo
+}
+
 // Test case types
 type createPostTestCase struct {
 	name      string
@@ -116,7 +215,7 @@ func getCreatePostTestCases() []createPostTestCase {
 			content: "Test Content",
 			author:  "Test Author",
 			mockSetup: func(mockRepo *MockPostRepository) *entities.Post {
-				post, _ := entities.NewPost(1, "Test Title", "Test Content", "Test Author")
+				post, _ := entities.NewPost(1, "Test Title", "Test Content", "Test Author", time.Now())
 				mockRepo.On("CreatePost", "Test Title", "Test Content", "Test Author").Return(post, nil).Once()
 				return post
 			},
@@ -148,7 +247,7 @@ func getCreatePostTestCases() []createPostTestCase {
 }
 
 func getGetPostByIDTestCases() []getPostByIDTestCase {
-	testPost, _ := entities.NewPost(1, "Test Title", "Test Content", "Test Author")
+	testPost, _ := entities.NewPost(1, "Test Title", "Test Content", "Test Author", time.Now())
 
 	return []getPostByIDTestCase{
 		{
@@ -203,7 +302,7 @@ func getUpdatePostTestCases() []updatePostTestCase {
 			author:  "Updated Author",
 			mockSetup: func(mockRepo *MockPostRepository, post *entities.Post) {
 				mockRepo.On("GetByID", post.ID).Return(post, nil).Once()
-				mockRepo.On("Update", post.ID, mock.AnythingOfType("*entities.Post")).Return(nil).Once()
+				mockRepo.On("Update", post.ID, mock.AnythingOfType("*entities.Post"), post.Version).Return(nil).Once()
 			},
 			wantError: false,
 		},
@@ -238,7 +337,7 @@ func getDeletePostTestCases() []deletePostTestCase {
 			name: "successful deletion",
 			id:   1,
 			mockSetup: func(mockRepo *MockPostRepository) {
-				mockRepo.On("Delete", 1).Return(nil).Once()
+				mockRepo.On("Trash", 1, repositories.AnyVersion, mock.AnythingOfType("time.Time")).Return(nil).Once()
 			},
 			wantError: false,
 		},
@@ -246,7 +345,7 @@ func getDeletePostTestCases() []deletePostTestCase {
 			name: "post not found",
 			id:   999,
 			mockSetup: func(mockRepo *MockPostRepository) {
-				mockRepo.On("Delete", 999).Return(repositories.ErrPostNotFound).Once()
+				mockRepo.On("Trash", 999, repositories.AnyVersion, mock.AnythingOfType("time.Time")).Return(repositories.ErrPostNotFound).Once()
 			},
 			wantError: true,
 		},
@@ -257,7 +356,7 @@ func getDeletePostTestCases() []deletePostTestCase {
 func TestPostService_CreatePost(t *testing.T) {
 	mockRepo := new(MockPostRepository)
 	logger := logrus.New()
-	service := NewPostService(mockRepo, logger)
+	service := NewPostService(mockRepo, newRevisionRepo(), memory_repositories.NewMemoryAuthorRepository(), memory_repositories.NewMemoryCommentRepository(), clock.System{}, logger)
 
 	testCases := getCreatePostTestCases()
 
@@ -266,7 +365,7 @@ func TestPostService_CreatePost(t *testing.T) {
 			mockRepo.ExpectedCalls = nil
 			expectedPost := tt.mockSetup(mockRepo)
 
-			post, err := service.CreatePost(tt.title, tt.content, tt.author)
+			post, err := service.CreatePost(context.Background(), tt.title, tt.content, "", tt.author, 0, nil)
 
 			if tt.wantError {
 				assert.Error(t, err)
@@ -285,20 +384,95 @@ func TestPostService_CreatePost(t *testing.T) {
 	}
 }
 
+func TestPostService_UpdatePostTags(t *testing.T) {
+	testCases := []struct {
+		name      string
+		tags      []string
+		wantTags  []string
+		wantError error
+	}{
+		{name: "omitted tags are kept", tags: nil, wantTags: []string{"go"}},
+		{name: "tags are replaced", tags: []string{"Rust", "rust"}, wantTags: []string{"rust"}},
+		{name: "empty list removes them", tags: []string{}, wantTags: nil},
+		{name: "invalid tag", tags: []string{"a/b"}, wantError: entities.ErrInvalidTag},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockPostRepository)
+			service := NewPostService(mockRepo, newRevisionRepo(), memory_repositories.NewMemoryAuthorRepository(), memory_repositories.NewMemoryCommentRepository(), clock.System{}, logrus.New())
+
+			existing, _ := entities.NewPost(1, "Title", "Content", "Author", time.Now())
+			require.NoError(t, existing.SetTags([]string{"go"}))
+			mockRepo.On("GetByID", 1).Return(existing, nil)
+			mockRepo.On("Update", 1, mock.AnythingOfType("*entities.Post"), 1).Return(nil).Maybe()
+
+			result, err := service.UpdatePost(context.Background(), 1, "Title", "New content", "", "Author", 0, tt.tags, nil)
+
+			if tt.wantError != nil {
+				assert.ErrorIs(t, err, tt.wantError)
+				mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantTags, result.Tags)
+		})
+	}
+}
+
+func TestPostService_ContentFormat(t *testing.T) {
+	service := NewPostService(memory_repositories.NewMemoryPostRepository(), memory_repositories.NewMemoryRevisionRepository(),
+		memory_repositories.NewMemoryAuthorRepository(), memory_repositories.NewMemoryCommentRepository(), clock.System{}, logrus.New())
+	ctx := principal.NewContext(context.Background(), principal.Principal{Name: "Author", Role: entities.RoleAuthor})
+
+	post, err := service.CreatePost(ctx, "Title", "*Hello* <b>", entities.FormatMarkdown, "Author", 0, nil)
+	require.NoError(t, err)
+	assert.Equal(t, entities.FormatMarkdown, post.ContentFormat)
+	assert.Equal(t, "<p><em>Hello</em> &lt;b&gt;</p>\n", service.RenderContent(post))
+
+	post, err = service.UpdatePost(ctx, post.ID, "Title", "**Hello**", "", "Author", 0, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, entities.FormatMarkdown, post.ContentFormat, "an empty format keeps the current one")
+	assert.Equal(t, "<p><strong>Hello</strong></p>\n", service.RenderContent(post), "the update is rendered afresh")
+
+	post, err = service.UpdatePost(ctx, post.ID, "Title", "**Hello**", entities.FormatPlain, "Author", 0, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "<p>**Hello**</p>\n", service.RenderContent(post))
+
+	_, err = service.UpdatePost(ctx, post.ID, "Title", "Content", "html", "Author", 0, nil, nil)
+	assert.ErrorIs(t, err, entities.ErrInvalidContentFormat)
+	_, err = service.CreatePost(ctx, "Title", "Content", "html", "Author", 0, nil)
+	assert.ErrorIs(t, err, entities.ErrInvalidContentFormat)
+}
+
+func TestPostService_ListTags(t *testing.T) {
+	mockRepo := new(MockPostRepository)
+	service := NewPostService(mockRepo, newRevisionRepo(), memory_repositories.NewMemoryAuthorRepository(), memory_repositories.NewMemoryCommentRepository(), clock.System{}, logrus.New())
+
+	counts := []repositories.TagCount{{Tag: "go", Posts: 2}, {Tag: "rust", Posts: 1}}
+	mockRepo.On("TagCounts").Return(counts, nil).Once()
+
+	result, err := service.ListTags(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, counts, result)
+	mockRepo.AssertExpectations(t)
+}
+
 func TestPostService_GetPostByID(t *testing.T) {
 	mockRepo := new(MockPostRepository)
 	logger := logrus.New()
-	service := NewPostService(mockRepo, logger)
+	service := NewPostService(mockRepo, newRevisionRepo(), memory_repositories.NewMemoryAuthorRepository(), memory_repositories.NewMemoryCommentRepository(), clock.System{}, logger)
 
 	testCases := getGetPostByIDTestCases()
-	testPost, _ := entities.NewPost(1, "Test Title", "Test Content", "Test Author")
+	testPost, _ := entities.NewPost(1, "Test Title", "Test Content", "Test Author", time.Now())
+	testPost.Status = entities.StatusPublished
 
 	for _, tt := range testCases {
 		t.Run(tt.name, func(t *testing.T) {
 			mockRepo.ExpectedCalls = nil
 			tt.mockSetup(mockRepo, testPost)
 
-			result, err := service.GetPostByID(tt.id)
+			result, err := service.GetPostByID(context.Background(), tt.id)
 
 			if tt.wantError {
 				assert.Error(t, err)
@@ -317,10 +491,10 @@ func TestPostService_GetPostByID(t *testing.T) {
 func TestPostService_GetAllPosts(t *testing.T) {
 	mockRepo := new(MockPostRepository)
 	logger := logrus.New()
-	service := NewPostService(mockRepo, logger)
+	service := NewPostService(mockRepo, newRevisionRepo(), memory_repositories.NewMemoryAuthorRepository(), memory_repositories.NewMemoryCommentRepository(), clock.System{}, logger)
 
-	post1, _ := entities.NewPost(1, "Title 1", "Content 1", "Author 1")
-	post2, _ := entities.NewPost(2, "Title 2", "Content 2", "Author 2")
+	post1, _ := entities.NewPost(1, "Title 1", "Content 1", "Author 1", time.Now())
+	post2, _ := entities.NewPost(2, "Title 2", "Content 2", "Author 2", time.Now())
 	posts := []*entities.Post{post1, post2}
 
 	testCases := getGetAllPostsTestCases()
@@ -330,7 +504,7 @@ func TestPostService_GetAllPosts(t *testing.T) {
 			mockRepo.ExpectedCalls = nil
 			tt.mockSetup(mockRepo, posts)
 
-			result, err := service.GetAllPosts()
+			result, err := service.GetAllPosts(context.Background())
 
 			if tt.wantError {
 				assert.Error(t, err)
@@ -345,12 +519,119 @@ func TestPostService_GetAllPosts(t *testing.T) {
 	}
 }
 
+func TestPostService_ListPosts(t *testing.T) {
+	mockRepo := new(MockPostRepository)
+	logger := logrus.New()
+	service := NewPostService(mockRepo, newRevisionRepo(), memory_repositories.NewMemoryAuthorRepository(), memory_repositories.NewMemoryCommentRepository(), clock.System{}, logger)
+
+	post, _ := entities.NewPost(1, "Title", "Content", "Author", time.Now())
+	page := &repositories.PostPage{Posts: []*entities.Post{post}, HasNext: true}
+	after := &repositories.Cursor{ID: 10}
+	filter := repositories.PostFilter{AuthorPrefix: "Au", TitleContains: "Ti", MinID: 1, MaxID: 10}
+	// Anonymous callers only see published posts.
+	visible := func(filter repositories.PostFilter) repositories.PostFilter {
+		filter.Viewer = &repositories.Viewer{}
+		return filter
+	}
+
+	testCases := []struct {
+		name      string
+		query     repositories.PostQuery
+		repoQuery repositories.PostQuery
+		wantError error
+	}{
+		{
+			name:      "default limit",
+			query:     repositories.PostQuery{},
+			repoQuery: repositories.PostQuery{Limit: DefaultPageLimit, Sort: repositories.SortByID, Filter: visible(repositories.PostFilter{})},
+		},
+		{
+			name:      "explicit limit and cursor",
+			query:     repositories.PostQuery{Limit: 5, After: after},
+			repoQuery: repositories.PostQuery{Limit: 5, After: after, Sort: repositories.SortByID, Filter: visible(repositories.PostFilter{})},
+		},
+		{
+			name:      "filter and sort are passed down",
+			query:     repositories.PostQuery{Limit: 5, Filter: filter, Sort: repositories.SortByTitle},
+			repoQuery: repositories.PostQuery{Limit: 5, Filter: visible(filter), Sort: repositories.SortByTitle},
+		},
+		{
+			name:      "unknown sort",
+			query:     repositories.PostQuery{Sort: "date"},
+			wantError: ErrInvalidSort,
+		},
+		{
+			name:      "negative ID bound",
+			query:     repositories.PostQuery{Filter: repositories.PostFilter{MinID: -1}},
+			wantError: ErrInvalidIDRange,
+		},
+		{
+			name:      "inverted ID range",
+			query:     repositories.PostQuery{Filter: repositories.PostFilter{MinID: 5, MaxID: 2}},
+			wantError: ErrInvalidIDRange,
+		},
+		{
+			name: "empty creation window",
+			query: repositories.PostQuery{Filter: repositories.PostFilter{
+				CreatedAfter:  time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+				CreatedBefore: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			}},
+			wantError: ErrInvalidDateRange,
+		},
+		{
+			name:      "unknown status",
+			query:     repositories.PostQuery{Filter: repositories.PostFilter{Status: "deleted"}},
+			wantError: entities.ErrInvalidStatus,
+		},
+		{
+			name:      "tag is normalized",
+			query:     repositories.PostQuery{Filter: repositories.PostFilter{Tag: " Machine Learning"}},
+			repoQuery: repositories.PostQuery{Limit: DefaultPageLimit, Sort: repositories.SortByID, Filter: visible(repositories.PostFilter{Tag: "machine-learning"})},
+		},
+		{
+			name:      "invalid tag",
+			query:     repositories.PostQuery{Filter: repositories.PostFilter{Tag: "a/b"}},
+			wantError: entities.ErrInvalidTag,
+		},
+		{
+			name:      "limit above maximum",
+			query:     repositories.PostQuery{Limit: MaxPageLimit + 1},
+			wantError: ErrInvalidPageLimit,
+		},
+		{
+			name:      "negative limit",
+			query:     repositories.PostQuery{Limit: -1},
+			wantError: ErrInvalidPageLimit,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo.ExpectedCalls = nil
+			if tt.wantError == nil {
+				mockRepo.On("ListPosts", tt.repoQuery).Return(page, nil).Once()
+			}
+
+			result, err := service.ListPosts(context.Background(), tt.query)
+
+			if tt.wantError != nil {
+				assert.ErrorIs(t, err, tt.wantError)
+				assert.Nil(t, result)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, page, result)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
 func TestPostService_UpdatePost(t *testing.T) {
 	mockRepo := new(MockPostRepository)
 	logger := logrus.New()
-	service := NewPostService(mockRepo, logger)
+	service := NewPostService(mockRepo, newRevisionRepo(), memory_repositories.NewMemoryAuthorRepository(), memory_repositories.NewMemoryCommentRepository(), clock.System{}, logger)
 
-	existingPost, _ := entities.NewPost(1, "Original Title", "Original Content", "Original Author")
+	existingPost, _ := entities.NewPost(1, "Original Title", "Original Content", "Original Author", time.Now())
 	testCases := getUpdatePostTestCases()
 
 	for _, tt := range testCases {
@@ -358,7 +639,7 @@ func TestPostService_UpdatePost(t *testing.T) {
 			mockRepo.ExpectedCalls = nil
 			tt.mockSetup(mockRepo, existingPost)
 
-			result, err := service.UpdatePost(tt.id, tt.title, tt.content, tt.author)
+			result, err := service.UpdatePost(context.Background(), tt.id, tt.title, tt.content, "", tt.author, 0, nil, nil)
 
 			if tt.wantError {
 				assert.Error(t, err)
@@ -379,7 +660,7 @@ func TestPostService_UpdatePost(t *testing.T) {
 func TestPostService_DeletePost(t *testing.T) {
 	mockRepo := new(MockPostRepository)
 	logger := logrus.New()
-	service := NewPostService(mockRepo, logger)
+	service := NewPostService(mockRepo, newRevisionRepo(), memory_repositories.NewMemoryAuthorRepository(), memory_repositories.NewMemoryCommentRepository(), clock.System{}, logger)
 
 	testCases := getDeletePostTestCases()
 
@@ -388,7 +669,7 @@ func TestPostService_DeletePost(t *testing.T) {
 			mockRepo.ExpectedCalls = nil
 			tt.mockSetup(mockRepo)
 
-			err := service.DeletePost(tt.id)
+			err := service.DeletePost(context.Background(), tt.id, nil)
 
 			if tt.wantError {
 				assert.Error(t, err)
@@ -400,3 +681,567 @@ func TestPostService_DeletePost(t *testing.T) {
 		})
 	}
 }
+
+func TestPostService_UpdatePost_VersionMatcher(t *testing.T) {
+	mockRepo := new(MockPostRepository)
+	logger := logrus.New()
+	service := NewPostService(mockRepo, newRevisionRepo(), memory_repositories.NewMemoryAuthorRepository(), memory_repositories.NewMemoryCommentRepository(), clock.System{}, logger)
+
+	rejectAll := func(int) bool { return false }
+	acceptV1 := func(version int) bool { return version == 1 }
+
+	t.Run("precondition fails", func(t *testing.T) {
+		mockRepo.ExpectedCalls = nil
+		post, _ := entities.NewPost(1, "Title", "Content", "Author", time.Now())
+		mockRepo.On("GetByID", 1).Return(post, nil).Once()
+
+		result, err := service.UpdatePost(context.Background(), 1, "New", "Content", "", "Author", 0, nil, rejectAll)
+
+		assert.ErrorIs(t, err, repositories.ErrVersionConflict)
+		assert.Nil(t, result)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("precondition holds", func(t *testing.T) {
+		mockRepo.ExpectedCalls = nil
+		post, _ := entities.NewPost(1, "Title", "Content", "Author", time.Now())
+		mockRepo.On("GetByID", 1).Return(post, nil).Once()
+		mockRepo.On("Update", 1, mock.AnythingOfType("*entities.Post"), 1).Return(nil).Once()
+
+		result, err := service.UpdatePost(context.Background(), 1, "New", "Content", "", "Author", 0, nil, acceptV1)
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Version)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("concurrent writer wins", func(t *testing.T) {
+		mockRepo.ExpectedCalls = nil
+		post, _ := entities.NewPost(1, "Title", "Content", "Author", time.Now())
+		mockRepo.On("GetByID", 1).Return(post, nil).Once()
+		mockRepo.On("Update", 1, mock.AnythingOfType("*entities.Post"), 1).Return(repositories.ErrVersionConflict).Once()
+
+		result, err := service.UpdatePost(context.Background(), 1, "New", "Content", "", "Author", 0, nil, nil)
+
+		assert.ErrorIs(t, err, repositories.ErrVersionConflict)
+		assert.Nil(t, result)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestPostService_DeletePost_VersionMatcher(t *testing.T) {
+	mockRepo := new(MockPostRepository)
+	logger := logrus.New()
+	service := NewPostService(mockRepo, newRevisionRepo(), memory_repositories.NewMemoryAuthorRepository(), memory_repositories.NewMemoryCommentRepository(), clock.System{}, logger)
+
+	post, _ := entities.NewPost(1, "Title", "Content", "Author", time.Now())
+
+	t.Run("precondition fails", func(t *testing.T) {
+		mockRepo.ExpectedCalls = nil
+		mockRepo.On("GetByID", 1).Return(post, nil).Once()
+
+		err := service.DeletePost(context.Background(), 1, func(int) bool { return false })
+
+		assert.ErrorIs(t, err, repositories.ErrVersionConflict)
+		mockRepo.AssertNotCalled(t, "Trash", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("precondition holds", func(t *testing.T) {
+		mockRepo.ExpectedCalls = nil
+		mockRepo.On("GetByID", 1).Return(post, nil).Once()
+		mockRepo.On("Trash", 1, post.Version, mock.AnythingOfType("time.Time")).Return(nil).Once()
+
+		err := service.DeletePost(context.Background(), 1, func(int) bool { return true })
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestPostService_RecordsRevisions(t *testing.T) {
+	mockRepo := new(MockPostRepository)
+	revisionRepo := new(MockRevisionRepository)
+	logger := logrus.New()
+	service := NewPostService(mockRepo, revisionRepo, memory_repositories.NewMemoryAuthorRepository(), memory_repositories.NewMemoryCommentRepository(), clock.System{}, logger)
+
+	t.Run("create records the first revision", func(t *testing.T) {
+		mockRepo.ExpectedCalls = nil
+		revisionRepo.ExpectedCalls = nil
+		post, _ := entities.NewPost(1, "Title", "Content", "Author", time.Now())
+		mockRepo.On("CreatePost", "Title", "Content", "Author").Return(post, nil).Once()
+		revisionRepo.On("Append", mock.MatchedBy(func(revision *entities.Revision) bool {
+			return revision.PostID == 1 && revision.Number == 1 &&
+				assert.ObjectsAreEqual([]string{"title", "content", "author"}, revision.Changes)
+		})).Return(nil).Once()
+
+		_, err := service.CreatePost(context.Background(), "Title", "Content", "", "Author", 0, nil)
+
+		require.NoError(t, err)
+		revisionRepo.AssertExpectations(t)
+	})
+
+	t.Run("update records the changed fields", func(t *testing.T) {
+		mockRepo.ExpectedCalls = nil
+		revisionRepo.ExpectedCalls = nil
+		post, _ := entities.NewPost(1, "Title", "Content", "Author", time.Now())
+		first := entities.NewRevision(post, nil, "Author", time.Now())
+		mockRepo.On("GetByID", 1).Return(post, nil).Once()
+		mockRepo.On("Update", 1, mock.AnythingOfType("*entities.Post"), 1).Return(nil).Once()
+		revisionRepo.On("ListByPost", 1).Return([]*entities.Revision{first}, nil).Once()
+		revisionRepo.On("Append", mock.MatchedBy(func(revision *entities.Revision) bool {
+			return revision.Number == 2 && revision.EditedBy == "Editor" &&
+				assert.ObjectsAreEqual([]string{"content", "author"}, revision.Changes)
+		})).Return(nil).Once()
+
+		_, err := service.UpdatePost(context.Background(), 1, "Title", "New Content", "", "Editor", 0, nil, nil)
+
+		require.NoError(t, err)
+		revisionRepo.AssertExpectations(t)
+	})
+	t.Run("update of a post without history records a baseline", func(t *testing.T) {
+		mockRepo.ExpectedCalls = nil
+		revisionRepo.ExpectedCalls = nil
+		post, _ := entities.NewPost(1, "Seeded", "Content", "Author", time.Now())
+		mockRepo.On("GetByID", 1).Return(post, nil).Once()
+		mockRepo.On("Update", 1, mock.AnythingOfType("*entities.Post"), 1).Return(nil).Once()
+		revisionRepo.On("ListByPost", 1).Return([]*entities.Revision{}, nil).Once()
+		revisionRepo.On("Append", mock.MatchedBy(func(revision *entities.Revision) bool {
+			return revision.Number == 1 && revision.Title == "Seeded"
+		})).Return(nil).Once()
+		revisionRepo.On("Append", mock.MatchedBy(func(revision *entities.Revision) bool {
+			return revision.Number == 2 && revision.Title == "Edited" &&
+				assert.ObjectsAreEqual([]string{"title"}, revision.Changes)
+		})).Return(nil).Once()
+
+		_, err := service.UpdatePost(context.Background(), 1, "Edited", "Content", "", "Author", 0, nil, nil)
+
+		require.NoError(t, err)
+		revisionRepo.AssertExpectations(t)
+	})
+
+	t.Run("failed update records nothing", func(t *testing.T) {
+		mockRepo.ExpectedCalls = nil
+		revisionRepo.ExpectedCalls = nil
+		revisionRepo.Calls = nil
+		post, _ := entities.NewPost(1, "Title", "Content", "Author", time.Now())
+		mockRepo.On("GetByID", 1).Return(post, nil).Once()
+		mockRepo.On("Update", 1, mock.AnythingOfType("*entities.Post"), 1).Return(repositories.ErrVersionConflict).Once()
+		revisionRepo.On("ListByPost", 1).Return([]*entities.Revision{}, nil).Once()
+
+		_, err := service.UpdatePost(context.Background(), 1, "Edited", "Content", "", "Author", 0, nil, nil)
+
+		assert.ErrorIs(t, err, repositories.ErrVersionConflict)
+		revisionRepo.AssertNotCalled(t, "Append", mock.Anything)
+	})
+}
+
+func TestPostService_RestoreRevision(t *testing.T) {
+	mockRepo := new(MockPostRepository)
+	revisionRepo := new(MockRevisionRepository)
+	logger := logrus.New()
+	service := NewPostService(mockRepo, revisionRepo, memory_repositories.NewMemoryAuthorRepository(), memory_repositories.NewMemoryCommentRepository(), clock.System{}, logger)
+
+	original, _ := entities.NewPost(1, "Original", "Original Content", "Author", time.Now())
+	first := entities.NewRevision(original, nil, "Author", time.Now())
+
+	t.Run("restores the fields as a new revision", func(t *testing.T) {
+		mockRepo.ExpectedCalls = nil
+		revisionRepo.ExpectedCalls = nil
+		current, _ := entities.NewPost(1, "Original", "Original Content", "Author", time.Now())
+		require.NoError(t, current.Update("Edited", "Edited Content", "Author", time.Now()))
+		second := entities.NewRevision(current, first, "Author", time.Now())
+
+		revisionRepo.On("Get", 1, 1).Return(first, nil).Once()
+		mockRepo.On("GetByID", 1).Return(current, nil).Once()
+		mockRepo.On("Update", 1, mock.AnythingOfType("*entities.Post"), 2).Return(nil).Once()
+		revisionRepo.On("ListByPost", 1).Return([]*entities.Revision{first, second}, nil).Once()
+		revisionRepo.On("Append", mock.MatchedBy(func(revision *entities.Revision) bool {
+			return revision.Number == 3 && revision.RestoredFrom == 1 &&
+				assert.ObjectsAreEqual([]string{"title", "content"}, revision.Changes)
+		})).Return(nil).Once()
+
+		post, err := service.RestoreRevision(context.Background(), 1, 1, nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, "Original", post.Title)
+		assert.Equal(t, "Original Content", post.Content)
+		assert.Equal(t, 3, post.Version)
+		mockRepo.AssertExpectations(t)
+		revisionRepo.AssertExpectations(t)
+	})
+
+	t.Run("unknown revision", func(t *testing.T) {
+		mockRepo.ExpectedCalls = nil
+		mockRepo.Calls = nil
+		revisionRepo.ExpectedCalls = nil
+		revisionRepo.On("Get", 1, 9).Return(nil, repositories.ErrRevisionNotFound).Once()
+
+		post, err := service.RestoreRevision(context.Background(), 1, 9, nil)
+
+		assert.ErrorIs(t, err, repositories.ErrRevisionNotFound)
+		assert.Nil(t, post)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestPostService_DiffRevisions(t *testing.T) {
+	mockRepo := new(MockPostRepository)
+	revisionRepo := new(MockRevisionRepository)
+	logger := logrus.New()
+	service := NewPostService(mockRepo, revisionRepo, memory_repositories.NewMemoryAuthorRepository(), memory_repositories.NewMemoryCommentRepository(), clock.System{}, logger)
+
+	post, _ := entities.NewPost(1, "Title", "one\ntwo\n", "Author", time.Now())
+	post.Status = entities.StatusPublished
+	first := entities.NewRevision(post, nil, "Author", time.Now())
+	require.NoError(t, post.Update("Title", "one\n2\n", "Author", time.Now()))
+	second := entities.NewRevision(post, first, "Author", time.Now())
+
+	mockRepo.On("GetByID", 1).Return(post, nil)
+	revisionRepo.On("Get", 1, 1).Return(first, nil)
+	revisionRepo.On("Get", 1, 2).Return(second, nil)
+
+	result, err := service.DiffRevisions(context.Background(), 1, 1, 2, diff.LineMode)
+
+	require.NoError(t, err)
+	assert.Equal(t, []diff.Edit{{Op: diff.Equal, Text: "Title"}}, result.Title)
+	assert.Equal(t, []diff.Edit{
+		{Op: diff.Equal, Text: "one\n"},
+		{Op: diff.Delete, Text: "two\n"},
+		{Op: diff.Insert, Text: "2\n"},
+	}, result.Content)
+
+	_, err = service.DiffRevisions(context.Background(), 1, 1, 2, "char")
+	assert.ErrorIs(t, err, ErrInvalidDiffMode)
+}
+
+func TestPostService_TransitionPost(t *testing.T) {
+	mockRepo := new(MockPostRepository)
+	revisionRepo := new(MockRevisionRepository)
+	logger := logrus.New()
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	service := NewPostService(mockRepo, revisionRepo, memory_repositories.NewMemoryAuthorRepository(), memory_repositories.NewMemoryCommentRepository(), clock.NewFake(now), logger)
+
+	testCases := []struct {
+		name      string
+		from      entities.PostStatus
+		to        entities.PostStatus
+		match     VersionMatcher
+		wantError error
+	}{
+		{name: "submit for review", from: entities.StatusDraft, to: entities.StatusReview},
+		{name: "publish", from: entities.StatusReview, to: entities.StatusPublished},
+		{name: "skip review", from: entities.StatusDraft, to: entities.StatusPublished, wantError: entities.ErrInvalidTransition},
+		{name: "unknown status", from: entities.StatusDraft, to: "deleted", wantError: entities.ErrInvalidStatus},
+		{
+			name:      "stale version",
+			from:      entities.StatusDraft,
+			to:        entities.StatusReview,
+			match:     func(version int) bool { return version == 7 },
+			wantError: repositories.ErrVersionConflict,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo.ExpectedCalls = nil
+			mockRepo.Calls = nil
+			revisionRepo.ExpectedCalls = nil
+			post, _ := entities.NewPost(1, "Title", "Content", "Author", now.Add(-time.Hour))
+			post.Status = tt.from
+			first := entities.NewRevision(post, nil, "Author", post.CreatedAt)
+
+			mockRepo.On("GetByID", 1).Return(post, nil).Once()
+			revisionRepo.On("ListByPost", 1).Return([]*entities.Revision{first}, nil).Maybe()
+			if tt.wantError == nil {
+				mockRepo.On("Update", 1, mock.AnythingOfType("*entities.Post"), 1).Return(nil).Once()
+				revisionRepo.On("Append", mock.MatchedBy(func(revision *entities.Revision) bool {
+					return revision.Number == 2 && len(revision.Changes) == 0
+				})).Return(nil).Once()
+			}
+
+			result, err := service.TransitionPost(context.Background(), 1, tt.to, tt.match)
+
+			if tt.wantError != nil {
+				assert.ErrorIs(t, err, tt.wantError)
+				assert.Nil(t, result)
+				mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.to, result.Status)
+			assert.Equal(t, 2, result.Version)
+			assert.Equal(t, now, result.UpdatedAt)
+			mockRepo.AssertExpectations(t)
+			revisionRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPostService_SchedulePost(t *testing.T) {
+	mockRepo := new(MockPostRepository)
+	revisionRepo := new(MockRevisionRepository)
+	logger := logrus.New()
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(now)
+	service := NewPostService(mockRepo, revisionRepo, memory_repositories.NewMemoryAuthorRepository(), memory_repositories.NewMemoryCommentRepository(), fake, logger)
+
+	post, _ := entities.NewPost(1, "Title", "Content", "Author", now.Add(-time.Hour))
+	post.Status = entities.StatusReview
+	first := entities.NewRevision(post, nil, "Author", post.CreatedAt)
+
+	mockRepo.On("GetByID", 1).Return(post, nil)
+	mockRepo.On("Update", 1, mock.AnythingOfType("*entities.Post"), mock.AnythingOfType("int")).Return(nil)
+	revisionRepo.On("ListByPost", 1).Return([]*entities.Revision{first}, nil)
+	revisionRepo.On("Append", mock.AnythingOfType("*entities.Revision")).Return(nil)
+
+	_, err := service.SchedulePost(context.Background(), 1, now, nil)
+	assert.ErrorIs(t, err, entities.ErrInvalidSchedule)
+
+	publishAt := now.Add(time.Hour)
+	scheduled, err := service.SchedulePost(context.Background(), 1, publishAt, nil)
+	require.NoError(t, err)
+	assert.Equal(t, publishAt, *scheduled.PublishAt)
+	assert.Equal(t, entities.StatusReview, scheduled.Status)
+	assert.Equal(t, 2, scheduled.Version)
+
+	_, err = service.PublishScheduled(context.Background(), 1)
+	assert.ErrorIs(t, err, entities.ErrPublicationNotDue)
+
+	fake.Set(publishAt)
+	published, err := service.PublishScheduled(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, entities.StatusPublished, published.Status)
+	assert.Nil(t, published.PublishAt)
+	assert.Equal(t, 3, published.Version)
+	assert.Equal(t, publishAt, published.UpdatedAt)
+}
+
+func TestPostService_Visibility(t *testing.T) {
+	mockRepo := new(MockPostRepository)
+	logger := logrus.New()
+	service := NewPostService(mockRepo, newRevisionRepo(), memory_repositories.NewMemoryAuthorRepository(), memory_repositories.NewMemoryCommentRepository(), clock.System{}, logger)
+
+	draft, _ := entities.NewPost(1, "Title", "Content", "alice", time.Now())
+	mockRepo.On("GetByID", 1).Return(draft, nil)
+
+	alice := principal.NewContext(context.Background(), principal.Principal{Name: "alice", Role: entities.RoleAuthor})
+	bob := principal.NewContext(context.Background(), principal.Principal{Name: "bob", Role: entities.RoleAuthor})
+
+	post, err := service.GetPostByID(alice, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, post.ID)
+
+	_, err = service.GetPostByID(bob, 1)
+	assert.ErrorIs(t, err, repositories.ErrPostNotFound)
+	_, err = service.GetPostByID(context.Background(), 1)
+	assert.ErrorIs(t, err, repositories.ErrPostNotFound)
+	_, err = service.ListRevisions(bob, 1)
+	assert.ErrorIs(t, err, repositories.ErrPostNotFound)
+
+	// Owners are matched by name key, and editors read every post, as
+	// they may edit every post.
+	spelledApart := principal.NewContext(context.Background(), principal.Principal{Name: " ALICE ", Role: entities.RoleAuthor})
+	editor := principal.NewContext(context.Background(), principal.Principal{Name: "eve", Role: entities.RoleEditor})
+	_, err = service.GetPostByID(spelledApart, 1)
+	assert.NoError(t, err)
+	_, err = service.GetPostByID(editor, 1)
+	assert.NoError(t, err)
+
+	draft.Slug = "title"
+	mockRepo.On("GetBySlug", "title").Return(draft, nil)
+	post, err = service.GetPostBySlug(alice, "title")
+	require.NoError(t, err)
+	assert.Equal(t, 1, post.ID)
+	_, err = service.GetPostBySlug(bob, "title")
+	assert.ErrorIs(t, err, repositories.ErrPostNotFound)
+
+	page := &repositories.PostPage{Posts: []*entities.Post{draft}}
+	mockRepo.On("ListPosts", repositories.PostQuery{
+		Limit:  DefaultPageLimit,
+		Sort:   repositories.SortByID,
+		Filter: repositories.PostFilter{Viewer: &repositories.Viewer{Author: "alice"}},
+	}).Return(page, nil).Once()
+
+	mockRepo.On("ListPosts", repositories.PostQuery{
+		Limit: DefaultPageLimit,
+		Sort:  repositories.SortByID,
+	}).Return(page, nil).Once()
+
+	result, err := service.ListPosts(alice, repositories.PostQuery{})
+	require.NoError(t, err)
+	assert.Equal(t, page, result)
+	result, err = service.ListPosts(editor, repositories.PostQuery{})
+	require.NoError(t, err)
+	assert.Equal(t, page, result, "editors list posts in every status")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestPostService_Trash(t *testing.T) {
+	mockRepo := new(MockPostRepository)
+	revisionRepo := new(MockRevisionRepository)
+	logger := logrus.New()
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	service := NewPostService(mockRepo, revisionRepo, memory_repositories.NewMemoryAuthorRepository(), memory_repositories.NewMemoryCommentRepository(), clock.NewFake(now), logger)
+
+	t.Run("delete stamps the post with the current time", func(t *testing.T) {
+		mockRepo.ExpectedCalls = nil
+		mockRepo.On("Trash", 1, repositories.AnyVersion, now).Return(nil).Once()
+
+		require.NoError(t, service.DeletePost(context.Background(), 1, nil))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("restore", func(t *testing.T) {
+		mockRepo.ExpectedCalls = nil
+		post, _ := entities.NewPost(1, "Title", "Content", "Author", time.Now())
+		mockRepo.On("Restore", 1).Return(post, nil).Once()
+		mockRepo.On("Restore", 2).Return(nil, repositories.ErrPostNotFound).Once()
+
+		restored, err := service.RestorePost(context.Background(), 1)
+		require.NoError(t, err)
+		assert.Equal(t, 1, restored.ID)
+
+		_, err = service.RestorePost(context.Background(), 2)
+		assert.ErrorIs(t, err, repositories.ErrPostNotFound)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("trash only lists posts the caller may read", func(t *testing.T) {
+		mockRepo.ExpectedCalls = nil
+		draft, _ := entities.NewPost(1, "Draft", "Content", "alice", time.Now())
+		published, _ := entities.NewPost(2, "Published", "Content", "bob", time.Now())
+		published.Status = entities.StatusPublished
+		mockRepo.On("GetTrash").Return([]*entities.Post{draft, published}, nil)
+
+		trash, err := service.GetTrash(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, []*entities.Post{published}, trash)
+		alice := principal.NewContext(context.Background(), principal.Principal{Name: "Alice", Role: entities.RoleAuthor})
+		trash, err = service.GetTrash(alice)
+		require.NoError(t, err)
+		assert.Equal(t, []*entities.Post{draft, published}, trash)
+	})
+
+	t.Run("purge removes expired posts and their history", func(t *testing.T) {
+		mockRepo.ExpectedCalls = nil
+		revisionRepo.ExpectedCalls = nil
+		mockRepo.On("Purge", now.Add(-24*time.Hour)).Return([]int{3, 5}, nil).Once()
+		revisionRepo.On("DeleteByPost", 3).Return(nil).Once()
+		revisionRepo.On("DeleteByPost", 5).Return(nil).Once()
+
+		count, err := service.PurgeTrash(context.Background(), 24*time.Hour)
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, count)
+		mockRepo.AssertExpectations(t)
+		revisionRepo.AssertExpectations(t)
+	})
+
+	t.Run("purge failure", func(t *testing.T) {
+		mockRepo.ExpectedCalls = nil
+		mockRepo.On("Purge", mock.Anything).Return(nil, errors.New("disk full")).Once()
+
+		count, err := service.PurgeTrash(context.Background(), time.Hour)
+
+		assert.Error(t, err)
+		assert.Zero(t, count)
+	})
+}
+
+func TestPostService_Authorization(t *testing.T) {
+	service := NewPostService(memory_repositories.NewMemoryPostRepository(), memory_repositories.NewMemoryRevisionRepository(),
+		memory_repositories.NewMemoryAuthorRepository(), memory_repositories.NewMemoryCommentRepository(), clock.System{}, logrus.New())
+	as := func(name string, role entities.Role) context.Context {
+		return principal.NewContext(context.Background(), principal.Principal{Name: name, Role: role})
+	}
+	alice, bob := as("Alice", entities.RoleAuthor), as("Bob", entities.RoleAuthor)
+	editor, reader := as("Eve", entities.RoleEditor), as("Rita", entities.RoleReader)
+
+	_, err := service.CreatePost(reader, "Title", "Content", "", "Rita", 0, nil)
+	assert.ErrorIs(t, err, policy.ErrReadOnly)
+	_, err = service.CreatePost(alice, "Title", "Content", "", "Bob", 0, nil)
+	assert.ErrorIs(t, err, policy.ErrNotOwner, "authors write under their own name")
+
+	post, err := service.CreatePost(alice, "Title", "Content", "", "Alice", 0, nil)
+	require.NoError(t, err)
+
+	_, err = service.UpdatePost(bob, post.ID, "Taken", "Content", "", "Bob", 0, nil, nil)
+	assert.ErrorIs(t, err, policy.ErrNotOwner)
+	_, err = service.UpdatePost(alice, post.ID, "Given away", "Content", "", "Bob", 0, nil, nil)
+	assert.ErrorIs(t, err, policy.ErrNotOwner, "authors cannot hand their posts to others")
+	_, err = service.TransitionPost(bob, post.ID, entities.StatusReview, nil)
+	assert.ErrorIs(t, err, policy.ErrNotOwner)
+	_, err = service.RestoreRevision(bob, post.ID, 1, nil)
+	assert.ErrorIs(t, err, repositories.ErrPostNotFound, "the draft stays hidden from those who cannot see it")
+	_, err = service.RestoreRevision(reader, post.ID, 1, nil)
+	assert.ErrorIs(t, err, repositories.ErrPostNotFound)
+	assert.ErrorIs(t, service.DeletePost(bob, post.ID, nil), policy.ErrNotOwner)
+
+	post, err = service.UpdatePost(alice, post.ID, "Edited", "Content", "", " alice ", 0, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, post.Version, "refused writes leave the post alone")
+	post, err = service.UpdatePost(editor, post.ID, "Edited by an editor", "Content", "", "Alice", 0, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", post.Author)
+	revisions, err := service.ListRevisions(editor, post.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Alice", "Alice", "Eve"}, editedBy(revisions), "revisions credit the caller")
+
+	require.NoError(t, service.DeletePost(alice, post.ID, nil))
+	_, err = service.RestorePost(bob, post.ID)
+	assert.ErrorIs(t, err, policy.ErrNotOwner)
+	_, err = service.RestorePost(editor, post.ID)
+	require.NoError(t, err)
+
+	// Work of the server itself, such as scheduled publications, acts
+	// without a caller and is not checked. It is credited to the author.
+	_, err = service.TransitionPost(context.Background(), post.ID, entities.StatusReview, nil)
+	assert.NoError(t, err)
+	revisions, err = service.ListRevisions(editor, post.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", revisions[len(revisions)-1].EditedBy)
+}
+
+func editedBy(revisions []*entities.Revision) []string {
+	names := make([]string, len(revisions))
+	for i, revision := range revisions {
+		names[i] = revision.EditedBy
+	}
+	return names
+}
+
+func TestTrashPurger_Run(t *testing.T) {
+	mockRepo := new(MockPostRepository)
+	revisionRepo := newRevisionRepo()
+	logger := logrus.New()
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	service := NewPostService(mockRepo, revisionRepo, memory_repositories.NewMemoryAuthorRepository(), memory_repositories.NewMemoryCommentRepository(), clock.NewFake(now), logger)
+
+	purged := make(chan struct{}, 10)
+	mockRepo.On("Purge", now.Add(-time.Hour)).Return([]int{}, nil).Run(func(mock.Arguments) {
+		purged <- struct{}{}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		NewTrashPurger(service, time.Hour, 10*time.Millisecond, logger).Run(ctx)
+		close(done)
+	}()
+
+	// One purge on start and at least one on a tick.
+	for i := 0; i < 2; i++ {
+		select {
+		case <-purged:
+		case <-time.After(time.Second):
+			t.Fatal("purger did not run")
+		}
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("purger did not stop")
+	}
+}
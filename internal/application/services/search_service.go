@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"rakia-tech-test/internal/application/search"
+	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/errdefs"
+	"rakia-tech-test/internal/domain/repositories"
+)
+
+// SnippetLength is the approximate length, in bytes, of the content excerpt
+// returned with every search result.
+const SnippetLength = 160
+
+var ErrEmptyQuery = errdefs.New(errdefs.ErrValidation, "query must not be empty")
+
+// SearchResult is a post matching a search together with its relevance and
+// highlighted excerpts of its title and content.
+type SearchResult struct {
+	Post           *entities.Post
+	Score          float64
+	TitleSnippet   string
+	ContentSnippet string
+}
+
+type SearchService struct {
+	index    *search.Index
+	postRepo repositories.PostRepository
+	logger   *logrus.Logger
+}
+
+func NewSearchService(index *search.Index, postRepo repositories.PostRepository, logger *logrus.Logger) *SearchService {
+	return &SearchService{
+		index:    index,
+		postRepo: postRepo,
+		logger:   logger,
+	}
+}
+
+// Search returns up to limit posts matching text, most relevant first. A
+// zero limit selects DefaultPageLimit. A query made only of stopwords
+// matches nothing.
+func (s *SearchService) Search(ctx context.Context, text string, limit int) ([]SearchResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if limit == 0 {
+		limit = DefaultPageLimit
+	}
+	if limit < 0 || limit > MaxPageLimit {
+		return nil, ErrInvalidPageLimit
+	}
+
+	if strings.TrimSpace(text) == "" {
+		return nil, ErrEmptyQuery
+	}
+	query := search.ParseQuery(text)
+
+	requestLog(ctx, s.logger).WithFields(logrus.Fields{
+		"query": text,
+		"terms": query.Terms(),
+	}).Debug("Searching posts")
+
+	results := make([]SearchResult, 0)
+	for _, hit := range s.index.Search(query, limit) {
+		post, err := s.postRepo.GetByID(ctx, hit.PostID)
+		if errors.Is(err, repositories.ErrPostNotFound) {
+			// Removed since the index was consulted.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, SearchResult{
+			Post:           post,
+			Score:          hit.Score,
+			TitleSnippet:   query.Highlight(post.Title, 0),
+			ContentSnippet: query.Highlight(post.Content, SnippetLength),
+		})
+	}
+
+	requestLog(ctx, s.logger).WithField("count", len(results)).Debug("Search completed")
+	return results, nil
+}
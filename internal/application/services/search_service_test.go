@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"rakia-tech-test/internal/application/search"
+	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/repositories"
+)
+
+func TestSearchService_Search(t *testing.T) {
+	mockRepo := new(MockPostRepository)
+	index := search.NewIndex()
+	service := NewSearchService(index, mockRepo, logrus.New())
+
+	pasta, _ := entities.NewPost(1, "Cooking pasta", "Boil the pasta in salted water.", "Chef", time.Now())
+	gone, _ := entities.NewPost(2, "Pasta history", "Where pasta came from.", "Historian", time.Now())
+	index.Reset([]*entities.Post{pasta, gone})
+
+	testCases := []struct {
+		name      string
+		query     string
+		limit     int
+		mockSetup func(*MockPostRepository)
+		wantIDs   []int
+		wantError error
+	}{
+		{
+			name:  "posts removed since indexing are skipped",
+			query: "pasta",
+			mockSetup: func(m *MockPostRepository) {
+				m.On("GetByID", 1).Return(pasta, nil)
+				m.On("GetByID", 2).Return(nil, repositories.ErrPostNotFound)
+			},
+			wantIDs: []int{1},
+		},
+		{
+			name:    "no match",
+			query:   "rust",
+			wantIDs: []int{},
+		},
+		{
+			name:    "stopwords only",
+			query:   "the of",
+			wantIDs: []int{},
+		},
+		{
+			name:      "empty query",
+			query:     "  ",
+			wantError: ErrEmptyQuery,
+		},
+		{
+			name:      "limit above maximum",
+			query:     "pasta",
+			limit:     MaxPageLimit + 1,
+			wantError: ErrInvalidPageLimit,
+		},
+		{
+			name:  "repository error",
+			query: "boil",
+			mockSetup: func(m *MockPostRepository) {
+				m.On("GetByID", 1).Return(nil, errors.New("database error"))
+			},
+			wantError: errors.New("database error"),
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo.ExpectedCalls = nil
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockRepo)
+			}
+
+			results, err := service.Search(context.Background(), tt.query, tt.limit)
+
+			if tt.wantError != nil {
+				assert.EqualError(t, err, tt.wantError.Error())
+				assert.Nil(t, results)
+				return
+			}
+			require.NoError(t, err)
+			ids := make([]int, 0, len(results))
+			for _, result := range results {
+				ids = append(ids, result.Post.ID)
+			}
+			assert.Equal(t, tt.wantIDs, ids)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSearchService_Snippets(t *testing.T) {
+	mockRepo := new(MockPostRepository)
+	index := search.NewIndex()
+	service := NewSearchService(index, mockRepo, logrus.New())
+
+	post, _ := entities.NewPost(1, "Cooking pasta", "Boil the pasta in salted water.", "Chef", time.Now())
+	index.Put(post)
+	mockRepo.On("GetByID", 1).Return(post, nil)
+
+	results, err := service.Search(context.Background(), "cooked pastas", 0)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Greater(t, results[0].Score, 0.0)
+	assert.Equal(t, "<mark>Cooking</mark> <mark>pasta</mark>", results[0].TitleSnippet)
+	assert.Equal(t, "Boil the <mark>pasta</mark> in salted water.", results[0].ContentSnippet)
+}
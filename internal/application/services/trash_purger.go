@@ -0,0 +1,50 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// TrashPurger periodically removes posts that have been in the trash for
+// longer than the retention period.
+type TrashPurger struct {
+	postService *PostService
+	retention   time.Duration
+	interval    time.Duration
+	logger      *logrus.Logger
+}
+
+func NewTrashPurger(postService *PostService, retention, interval time.Duration, logger *logrus.Logger) *TrashPurger {
+	return &TrashPurger{
+		postService: postService,
+		retention:   retention,
+		interval:    interval,
+		logger:      logger,
+	}
+}
+
+// Run purges once immediately and then every interval until ctx is done.
+func (p *TrashPurger) Run(ctx context.Context) {
+	p.logger.WithFields(logrus.Fields{
+		"retention": p.retention.String(),
+		"interval":  p.interval.String(),
+	}).Info("Trash purger started")
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := p.postService.PurgeTrash(ctx, p.retention); err != nil && ctx.Err() == nil {
+			p.logger.WithError(err).Error("Failed to purge trash")
+		}
+
+		select {
+		case <-ctx.Done():
+			p.logger.Info("Trash purger stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,105 @@
+// Package clock abstracts the current time so time-dependent behaviour can be
+// tested without sleeping.
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+type Clock interface {
+	Now() time.Time
+	// After returns a channel that receives the time once d has elapsed.
+	After(d time.Duration) <-chan time.Time
+}
+
+// System reads the wall clock, in UTC.
+type System struct{}
+
+func (System) Now() time.Time {
+	return time.Now().UTC()
+}
+
+func (System) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// Fake is a manually driven clock for tests. It is safe for concurrent use.
+type Fake struct {
+	now     time.Time
+	waiters []waiter
+	mutex   sync.Mutex
+}
+
+// waiter is a pending After call of a Fake.
+type waiter struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+func (f *Fake) Now() time.Time {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.now
+}
+
+// After returns a channel that receives the fake time once the clock has
+// been moved at least d forward.
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- f.now
+		return ch
+	}
+	f.waiters = append(f.waiters, waiter{deadline: f.now.Add(d), ch: ch})
+	return ch
+}
+
+// Waiters returns the number of After channels that have not fired yet, so
+// tests can wait for the code under test to start waiting.
+func (f *Fake) Waiters() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return len(f.waiters)
+}
+
+// Advance moves the clock forward by d.
+func (f *Fake) Advance(d time.Duration) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.now = f.now.Add(d)
+	f.fire()
+}
+
+// Set moves the clock to now.
+func (f *Fake) Set(now time.Time) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.now = now
+	f.fire()
+}
+
+// fire delivers the current time to the waiters whose deadline has passed.
+// The caller must hold the mutex.
+func (f *Fake) fire() {
+	pending := f.waiters[:0]
+	for _, w := range f.waiters {
+		if w.deadline.After(f.now) {
+			pending = append(pending, w)
+		} else {
+			w.ch <- f.now
+		}
+	}
+	f.waiters = pending
+}
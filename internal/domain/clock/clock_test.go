@@ -0,0 +1,33 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFake_After(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	fake := NewFake(start)
+
+	immediate := fake.After(0)
+	assert.Equal(t, start, <-immediate)
+
+	short := fake.After(time.Minute)
+	long := fake.After(time.Hour)
+	assert.Equal(t, 2, fake.Waiters())
+
+	fake.Advance(30 * time.Second)
+	assert.Empty(t, short)
+	assert.Empty(t, long)
+
+	fake.Advance(30 * time.Second)
+	assert.Equal(t, start.Add(time.Minute), <-short)
+	assert.Empty(t, long)
+	assert.Equal(t, 1, fake.Waiters())
+
+	fake.Set(start.Add(2 * time.Hour))
+	assert.Equal(t, start.Add(2*time.Hour), <-long)
+	assert.Zero(t, fake.Waiters())
+}
@@ -0,0 +1,169 @@
+package entities
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"rakia-tech-test/internal/domain/validation"
+)
+
+// MaxAPIKeyNameLength caps the length of the name of an API key, in bytes.
+const MaxAPIKeyNameLength = 100
+
+// Scope is a permission an API key carries. Keys may only do what their
+// scopes allow.
+type Scope string
+
+const (
+	// ScopePostsRead allows reading posts, including the drafts the key
+	// may see.
+	ScopePostsRead Scope = "posts:read"
+	// ScopePostsWrite allows creating and changing posts, their comments
+	// and authors.
+	ScopePostsWrite Scope = "posts:write"
+	// ScopePostsDelete allows deleting posts, their comments and authors.
+	ScopePostsDelete Scope = "posts:delete"
+	// ScopeAdmin allows managing API keys.
+	ScopeAdmin Scope = "admin"
+)
+
+// Scopes lists every known scope.
+var Scopes = []Scope{ScopePostsRead, ScopePostsWrite, ScopePostsDelete, ScopeAdmin}
+
+// Valid reports whether s is a known scope.
+func (s Scope) Valid() bool {
+	for _, scope := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	ErrAPIKeyNameRequired = validation.New("name", validation.CodeRequired, "name is required", nil)
+	ErrAPIKeyNameTooLong  = validation.New("name", validation.CodeTooLong,
+		fmt.Sprintf("name must be at most %d characters", MaxAPIKeyNameLength),
+		map[string]any{"max": MaxAPIKeyNameLength})
+	ErrAPIKeyScopesRequired = validation.New("scopes", validation.CodeRequired, "scopes are required", nil)
+	ErrInvalidScope         = validation.New("scopes", validation.CodeOneOf,
+		"scopes must be one of posts:read, posts:write, posts:delete, admin",
+		map[string]any{"allowed": []string{string(ScopePostsRead), string(ScopePostsWrite), string(ScopePostsDelete), string(ScopeAdmin)}})
+	ErrAPIKeyExpiryInPast = validation.New("expires_at", validation.CodeInvalid, "expires_at must be in the future", nil)
+)
+
+// APIKey is a credential for machine clients. A key acts for the user who
+// issued it, but only within its scopes.
+//
+// The secret of the key is shown once, when it is issued. Only its hash is
+// stored, along with Prefix, the leading part of the key that identifies it
+// in listings and lookups.
+type APIKey struct {
+	ID int
+	// UserID is the user the key acts for.
+	UserID int
+	// Name describes what the key is for, such as "CI importer".
+	Name   string
+	Prefix string
+	// SecretHash is the hex-encoded SHA-256 hash of the whole key.
+	SecretHash string
+	// Scopes are sorted and unique. The slice is replaced rather than
+	// modified, so copies of a key may share it.
+	Scopes []Scope
+	// CreatedAt is when the key was issued and LastUsedAt when it last
+	// authenticated a request, or nil if it never did. All times are kept
+	// in UTC.
+	CreatedAt  time.Time
+	LastUsedAt *time.Time
+	// ExpiresAt is when the key stops working, or nil if it does not
+	// expire.
+	ExpiresAt *time.Time
+	// RevokedAt is set once the key is revoked. Revoked keys are kept so
+	// listings show them.
+	RevokedAt *time.Time
+}
+
+// NewAPIKey returns a valid key issued at now, with its name trimmed and its
+// scopes sorted and unique.
+func NewAPIKey(id, userID int, name, prefix, secretHash string, scopes []Scope, expiresAt *time.Time, now time.Time) (*APIKey, error) {
+	key := &APIKey{
+		ID:         id,
+		UserID:     userID,
+		Name:       strings.TrimSpace(name),
+		Prefix:     prefix,
+		SecretHash: secretHash,
+		Scopes:     NormalizeScopes(scopes),
+		CreatedAt:  now.UTC(),
+	}
+	if expiresAt != nil {
+		expires := expiresAt.UTC()
+		key.ExpiresAt = &expires
+	}
+
+	if err := key.Validate(); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// Validate reports every problem with the fields of the key at once, as
+// validation.Errors.
+func (k *APIKey) Validate() error {
+	var errs validation.Errors
+	if k.Name == "" {
+		errs = append(errs, ErrAPIKeyNameRequired)
+	} else if len(k.Name) > MaxAPIKeyNameLength {
+		errs = append(errs, ErrAPIKeyNameTooLong)
+	}
+	if len(k.Scopes) == 0 {
+		errs = append(errs, ErrAPIKeyScopesRequired)
+	}
+	for _, scope := range k.Scopes {
+		if !scope.Valid() {
+			errs = append(errs, ErrInvalidScope)
+			break
+		}
+	}
+	if k.ExpiresAt != nil && !k.ExpiresAt.After(k.CreatedAt) {
+		errs = append(errs, ErrAPIKeyExpiryInPast)
+	}
+	return errs.Err()
+}
+
+// HasScope reports whether the key carries scope.
+func (k *APIKey) HasScope(scope Scope) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Active reports whether the key authenticates requests at now: it is
+// neither revoked nor expired.
+func (k *APIKey) Active(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
+
+// NormalizeScopes returns scopes sorted, without duplicates. The result is
+// always a new slice.
+func NormalizeScopes(scopes []Scope) []Scope {
+	seen := make(map[Scope]bool, len(scopes))
+	normalized := make([]Scope, 0, len(scopes))
+	for _, scope := range scopes {
+		scope = Scope(strings.TrimSpace(string(scope)))
+		if !seen[scope] {
+			seen[scope] = true
+			normalized = append(normalized, scope)
+		}
+	}
+	sort.Slice(normalized, func(i, j int) bool { return normalized[i] < normalized[j] })
+	return normalized
+}
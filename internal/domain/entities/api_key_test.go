@@ -0,0 +1,64 @@
+package entities
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAPIKey(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.FixedZone("CET", 3600))
+	expiresAt := now.Add(time.Hour)
+
+	key, err := NewAPIKey(1, 2, " Importer ", "rk_abc", "hash",
+		[]Scope{ScopePostsWrite, " posts:read ", ScopePostsWrite}, &expiresAt, now)
+	require.NoError(t, err)
+	assert.Equal(t, "Importer", key.Name)
+	assert.Equal(t, []Scope{ScopePostsRead, ScopePostsWrite}, key.Scopes)
+	assert.Equal(t, time.UTC, key.CreatedAt.Location())
+	assert.Equal(t, time.UTC, key.ExpiresAt.Location())
+	assert.True(t, key.HasScope(ScopePostsRead))
+	assert.False(t, key.HasScope(ScopeAdmin))
+
+	testCases := []struct {
+		name      string
+		keyName   string
+		scopes    []Scope
+		expiresAt time.Time
+		wantErr   error
+	}{
+		{name: "missing name", keyName: " ", scopes: []Scope{ScopePostsRead}, wantErr: ErrAPIKeyNameRequired},
+		{name: "name too long", keyName: strings.Repeat("a", MaxAPIKeyNameLength+1), scopes: []Scope{ScopePostsRead}, wantErr: ErrAPIKeyNameTooLong},
+		{name: "missing scopes", keyName: "Key", scopes: []Scope{}, wantErr: ErrAPIKeyScopesRequired},
+		{name: "unknown scope", keyName: "Key", scopes: []Scope{ScopePostsRead, "posts:publish"}, wantErr: ErrInvalidScope},
+		{name: "expired", keyName: "Key", scopes: []Scope{ScopePostsRead}, expiresAt: now, wantErr: ErrAPIKeyExpiryInPast},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var expiresAt *time.Time
+			if !tc.expiresAt.IsZero() {
+				expiresAt = &tc.expiresAt
+			}
+			_, err := NewAPIKey(1, 2, tc.keyName, "rk_abc", "hash", tc.scopes, expiresAt, now)
+			assert.ErrorIs(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestAPIKey_Active(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	expiresAt := now.Add(time.Hour)
+
+	key, err := NewAPIKey(1, 2, "Key", "rk_abc", "hash", []Scope{ScopePostsRead}, &expiresAt, now)
+	require.NoError(t, err)
+	assert.True(t, key.Active(now))
+	assert.False(t, key.Active(expiresAt), "keys stop working when they expire")
+
+	key.ExpiresAt = nil
+	assert.True(t, key.Active(expiresAt.Add(24*time.Hour)))
+	key.RevokedAt = &now
+	assert.False(t, key.Active(now))
+}
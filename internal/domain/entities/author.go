@@ -0,0 +1,104 @@
+package entities
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"rakia-tech-test/internal/domain/validation"
+)
+
+const (
+	// MaxAuthorNameLength caps the length of a normalized author name, in
+	// bytes.
+	MaxAuthorNameLength = 100
+	// MaxAuthorBioLength caps the length of an author bio, in bytes.
+	MaxAuthorBioLength = 2000
+)
+
+var (
+	ErrAuthorNameRequired = validation.New("name", validation.CodeRequired, "author name is required", nil)
+	ErrAuthorNameTooLong  = validation.New("name", validation.CodeTooLong,
+		fmt.Sprintf("author name must be at most %d characters", MaxAuthorNameLength),
+		map[string]any{"max": MaxAuthorNameLength})
+	ErrAuthorBioTooLong = validation.New("bio", validation.CodeTooLong,
+		fmt.Sprintf("author bio must be at most %d characters", MaxAuthorBioLength),
+		map[string]any{"max": MaxAuthorBioLength})
+)
+
+// Author is a person writing posts. Posts refer to their author by ID and
+// carry the name of the author at the time of the write.
+type Author struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	Bio  string `json:"bio"`
+	// CreatedAt is when the author was added and UpdatedAt when their name
+	// or bio last changed. Both are kept in UTC.
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NewAuthor returns a valid author created at now, with the name normalized.
+func NewAuthor(id int, name, bio string, now time.Time) (*Author, error) {
+	author := &Author{
+		ID:        id,
+		Name:      NormalizeAuthorName(name),
+		Bio:       strings.TrimSpace(bio),
+		CreatedAt: now.UTC(),
+		UpdatedAt: now.UTC(),
+	}
+
+	if err := author.Validate(); err != nil {
+		return nil, err
+	}
+
+	return author, nil
+}
+
+// Update replaces the name and bio of the author and records now as the
+// time of the change.
+func (a *Author) Update(name, bio string, now time.Time) error {
+	temp := &Author{
+		ID:   a.ID,
+		Name: NormalizeAuthorName(name),
+		Bio:  strings.TrimSpace(bio),
+	}
+
+	if err := temp.Validate(); err != nil {
+		return err
+	}
+
+	a.Name = temp.Name
+	a.Bio = temp.Bio
+	a.UpdatedAt = now.UTC()
+
+	return nil
+}
+
+// Validate reports every problem with the fields of the author at once, as
+// validation.Errors.
+func (a *Author) Validate() error {
+	var errs validation.Errors
+	if a.Name == "" {
+		errs = append(errs, ErrAuthorNameRequired)
+	} else if len(a.Name) > MaxAuthorNameLength {
+		errs = append(errs, ErrAuthorNameTooLong)
+	}
+	if len(a.Bio) > MaxAuthorBioLength {
+		errs = append(errs, ErrAuthorBioTooLong)
+	}
+	return errs.Err()
+}
+
+// NormalizeAuthorName returns the canonical spelling of an author name: the
+// surrounding space trimmed and inner runs of space folded into one.
+func NormalizeAuthorName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// AuthorNameKey returns the key authors are told apart by. Names that differ
+// only in case or spacing, such as "Author 1" and "author 1 ", belong to the
+// same author.
+func AuthorNameKey(name string) string {
+	return strings.ToLower(NormalizeAuthorName(name))
+}
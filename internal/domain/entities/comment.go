@@ -0,0 +1,120 @@
+package entities
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"rakia-tech-test/internal/domain/validation"
+)
+
+// MaxCommentLength caps the length of a comment, in bytes.
+const MaxCommentLength = 5000
+
+var (
+	ErrCommentAuthorRequired  = validation.New("author", validation.CodeRequired, "comment author is required", nil)
+	ErrCommentContentRequired = validation.New("content", validation.CodeRequired, "comment content is required", nil)
+	ErrCommentTooLong         = validation.New("content", validation.CodeTooLong,
+		fmt.Sprintf("comment must be at most %d characters", MaxCommentLength),
+		map[string]any{"max": MaxCommentLength})
+)
+
+// Comment is a reader's comment on a post. Comments form threads: a reply
+// names the comment it answers as its parent.
+type Comment struct {
+	ID     int `json:"id"`
+	PostID int `json:"post_id"`
+	// ParentID is the ID of the comment this one replies to, or 0 for a
+	// comment on the post itself.
+	ParentID int    `json:"parent_id,omitempty"`
+	Author   string `json:"author"`
+	Content  string `json:"content"`
+	// CreatedAt is when the comment was written and UpdatedAt when it was
+	// last edited. Both are kept in UTC.
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NewComment returns a valid comment on the post with the given ID, written
+// at now. parentID is 0 for a top-level comment.
+func NewComment(id, postID, parentID int, author, content string, now time.Time) (*Comment, error) {
+	comment := &Comment{
+		ID:        id,
+		PostID:    postID,
+		ParentID:  parentID,
+		Author:    strings.TrimSpace(author),
+		Content:   strings.TrimSpace(content),
+		CreatedAt: now.UTC(),
+		UpdatedAt: now.UTC(),
+	}
+
+	if err := comment.Validate(); err != nil {
+		return nil, err
+	}
+
+	return comment, nil
+}
+
+// Edit replaces the content of the comment and records now as the time of
+// the change.
+func (c *Comment) Edit(content string, now time.Time) error {
+	content = strings.TrimSpace(content)
+	if err := validateCommentContent(content); err != nil {
+		return err
+	}
+
+	c.Content = content
+	c.UpdatedAt = now.UTC()
+
+	return nil
+}
+
+// Validate reports every problem with the fields of the comment at once, as
+// validation.Errors.
+func (c *Comment) Validate() error {
+	var errs validation.Errors
+	if c.Author == "" {
+		errs = append(errs, ErrCommentAuthorRequired)
+	}
+	if err := validateCommentContent(c.Content); err != nil {
+		errs = append(errs, err)
+	}
+	return errs.Err()
+}
+
+func validateCommentContent(content string) *validation.FieldError {
+	if content == "" {
+		return ErrCommentContentRequired
+	}
+	if len(content) > MaxCommentLength {
+		return ErrCommentTooLong
+	}
+	return nil
+}
+
+// CommentThread is a comment together with the replies below it.
+type CommentThread struct {
+	*Comment
+	Replies []*CommentThread
+}
+
+// CommentTree arranges comments into threads. Comments must be ordered so
+// that every comment comes after its parent, as ascending IDs are; replies
+// whose parent is not among comments are left out. The threads and the
+// replies within each keep the order of comments.
+func CommentTree(comments []*Comment) []*CommentThread {
+	var roots []*CommentThread
+	threads := make(map[int]*CommentThread, len(comments))
+	for _, comment := range comments {
+		thread := &CommentThread{Comment: comment}
+		if comment.ParentID == 0 {
+			roots = append(roots, thread)
+		} else if parent, ok := threads[comment.ParentID]; ok {
+			parent.Replies = append(parent.Replies, thread)
+		} else {
+			continue
+		}
+		threads[comment.ID] = thread
+	}
+	return roots
+}
@@ -0,0 +1,80 @@
+package entities
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewComment(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.FixedZone("CET", 3600))
+
+	comment, err := NewComment(1, 2, 0, " Reader ", " Nice post ", now)
+	require.NoError(t, err)
+	assert.Equal(t, "Reader", comment.Author)
+	assert.Equal(t, "Nice post", comment.Content)
+	assert.Equal(t, time.UTC, comment.CreatedAt.Location())
+	assert.True(t, now.Equal(comment.UpdatedAt))
+
+	testCases := []struct {
+		name    string
+		author  string
+		content string
+		wantErr error
+	}{
+		{name: "missing author", author: " ", content: "Content", wantErr: ErrCommentAuthorRequired},
+		{name: "missing content", author: "Reader", content: "\n", wantErr: ErrCommentContentRequired},
+		{name: "content too long", author: "Reader", content: strings.Repeat("a", MaxCommentLength+1), wantErr: ErrCommentTooLong},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewComment(1, 2, 0, tc.author, tc.content, now)
+			assert.ErrorIs(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestComment_Edit(t *testing.T) {
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	comment, err := NewComment(1, 2, 0, "Reader", "First", createdAt)
+	require.NoError(t, err)
+
+	editedAt := createdAt.Add(time.Hour)
+	require.NoError(t, comment.Edit(" Second ", editedAt))
+	assert.Equal(t, "Second", comment.Content)
+	assert.Equal(t, createdAt, comment.CreatedAt)
+	assert.Equal(t, editedAt, comment.UpdatedAt)
+
+	assert.ErrorIs(t, comment.Edit("", editedAt), ErrCommentContentRequired)
+	assert.Equal(t, "Second", comment.Content, "a rejected edit leaves the comment unchanged")
+}
+
+func TestCommentTree(t *testing.T) {
+	comment := func(id, parentID int) *Comment {
+		return &Comment{ID: id, PostID: 1, ParentID: parentID}
+	}
+
+	threads := CommentTree([]*Comment{
+		comment(1, 0),
+		comment(2, 1),
+		comment(3, 0),
+		comment(4, 2),
+		comment(5, 1),
+		comment(6, 9),
+	})
+
+	require.Len(t, threads, 2)
+	assert.Equal(t, 1, threads[0].ID)
+	require.Len(t, threads[0].Replies, 2)
+	assert.Equal(t, 2, threads[0].Replies[0].ID)
+	assert.Equal(t, 5, threads[0].Replies[1].ID)
+	require.Len(t, threads[0].Replies[0].Replies, 1)
+	assert.Equal(t, 4, threads[0].Replies[0].Replies[0].ID)
+	assert.Equal(t, 3, threads[1].ID)
+	assert.Empty(t, threads[1].Replies)
+
+	assert.Empty(t, CommentTree(nil))
+}
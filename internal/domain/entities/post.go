@@ -1,23 +1,72 @@
 package entities
 
 import (
-	"errors"
 	"strings"
+	"time"
+
+	"rakia-tech-test/internal/domain/validation"
+)
+
+const (
+	// MaxTitleLength caps the length of a post title, in bytes.
+	MaxTitleLength = 255
+	// MaxContentLength caps the length of the content of a post, in bytes.
+	MaxContentLength = 100000
 )
 
 type Post struct {
 	ID      int    `json:"id"`
 	Title   string `json:"title"`
 	Content string `json:"content"`
-	Author  string `json:"author"`
+	// ContentFormat is the markup Content is written in. New posts are
+	// plain text.
+	ContentFormat ContentFormat `json:"content_format,omitempty"`
+	Author        string        `json:"author"`
+	// AuthorID refers to the Author who wrote the post, whose name Author
+	// holds. It is zero for posts not linked to an author record.
+	AuthorID int `json:"author_id,omitempty"`
+	// Slug names the post in URLs. It is derived from the title and unique
+	// among the current and previous slugs of all posts; the repository
+	// assigns it when the post is stored.
+	Slug string `json:"slug,omitempty"`
+	// PreviousSlugs are the slugs the post had under earlier titles, oldest
+	// first. They stay reserved for the post so old links can redirect.
+	PreviousSlugs []string `json:"previous_slugs,omitempty"`
+	// Version starts at 1 and is incremented by every Update and status
+	// transition, so writers can detect that a post changed since they read
+	// it.
+	Version int `json:"version"`
+	// Status is the stage of the post in the editorial workflow. New posts
+	// start as drafts.
+	Status PostStatus `json:"status"`
+	// Tags classify the post. They are normalized, sorted and unique; the
+	// slice is replaced rather than modified, so copies of a post may share
+	// it.
+	Tags []string `json:"tags,omitempty"`
+	// PublishAt is when a post in review is due to be published
+	// automatically, or nil when no publication is scheduled.
+	PublishAt *time.Time `json:"publish_at,omitempty"`
+	// CreatedAt is when the post was written and UpdatedAt when its fields
+	// last changed. Both are kept in UTC.
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// DeletedAt is set while the post is in the trash. Trashed posts are
+	// hidden from regular reads until they are restored or purged.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 }
 
-func NewPost(id int, title, content, author string) (*Post, error) {
+// NewPost returns a valid draft at version 1, created at now.
+func NewPost(id int, title, content, author string, now time.Time) (*Post, error) {
 	post := &Post{
-		ID:      id,
-		Title:   title,
-		Content: content,
-		Author:  author,
+		ID:            id,
+		Title:         title,
+		Content:       content,
+		ContentFormat: FormatPlain,
+		Author:        author,
+		Version:       1,
+		Status:        StatusDraft,
+		CreatedAt:     now.UTC(),
+		UpdatedAt:     now.UTC(),
 	}
 
 	if err := post.Validate(); err != nil {
@@ -27,7 +76,9 @@ func NewPost(id int, title, content, author string) (*Post, error) {
 	return post, nil
 }
 
-func (p *Post) Update(title, content, author string) error {
+// Update replaces the fields of the post, bumps its version and records now
+// as the time of the change.
+func (p *Post) Update(title, content, author string, now time.Time) error {
 	temp := &Post{
 		ID:      p.ID,
 		Title:   title,
@@ -42,22 +93,35 @@ func (p *Post) Update(title, content, author string) error {
 	p.Title = title
 	p.Content = content
 	p.Author = author
+	p.Version++
+	p.UpdatedAt = now.UTC()
 
 	return nil
 }
 
+// IsDeleted reports whether the post is in the trash.
+func (p *Post) IsDeleted() bool {
+	return p.DeletedAt != nil
+}
+
+// Validate reports every problem with the fields of the post at once, as
+// validation.Errors.
 func (p *Post) Validate() error {
+	var errs validation.Errors
 	if strings.TrimSpace(p.Title) == "" {
-		return errors.New("title is required")
+		errs = append(errs, validation.New("title", validation.CodeRequired, "title is required", nil))
+	} else if len(p.Title) > MaxTitleLength {
+		errs = append(errs, validation.New("title", validation.CodeTooLong, "title must be less than 255 characters",
+			map[string]any{"max": MaxTitleLength}))
 	}
 	if strings.TrimSpace(p.Content) == "" {
-		return errors.New("content is required")
+		errs = append(errs, validation.New("content", validation.CodeRequired, "content is required", nil))
+	} else if len(p.Content) > MaxContentLength {
+		errs = append(errs, validation.New("content", validation.CodeTooLong, "content must be at most 100000 characters",
+			map[string]any{"max": MaxContentLength}))
 	}
 	if strings.TrimSpace(p.Author) == "" {
-		return errors.New("author is required")
+		errs = append(errs, validation.New("author", validation.CodeRequired, "author is required", nil))
 	}
-	if len(p.Title) > 255 {
-		return errors.New("title must be less than 255 characters")
-	}
-	return nil
+	return errs.Err()
 }
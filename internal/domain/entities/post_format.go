@@ -0,0 +1,33 @@
+package entities
+
+import "rakia-tech-test/internal/domain/validation"
+
+var ErrInvalidContentFormat = validation.New("content_format", validation.CodeOneOf, "content format must be plain or markdown",
+	map[string]any{"allowed": []string{string(FormatPlain), string(FormatMarkdown)}})
+
+// ContentFormat is the markup the content of a post is written in.
+type ContentFormat string
+
+const (
+	// FormatPlain content is shown as written.
+	FormatPlain ContentFormat = "plain"
+	// FormatMarkdown content is rendered as Markdown.
+	FormatMarkdown ContentFormat = "markdown"
+)
+
+// Valid reports whether f is a known format.
+func (f ContentFormat) Valid() bool {
+	return f == FormatPlain || f == FormatMarkdown
+}
+
+// SetContentFormat changes the format the content of the post is written
+// in. Like SetTags it does not bump the version; it is meant to be combined
+// with Update or used on a new post.
+func (p *Post) SetContentFormat(format ContentFormat) error {
+	if !format.Valid() {
+		return ErrInvalidContentFormat
+	}
+
+	p.ContentFormat = format
+	return nil
+}
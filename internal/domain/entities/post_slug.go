@@ -0,0 +1,162 @@
+package entities
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	// MaxSlugLength caps the length of the slug derived from a title, before
+	// any suffix that makes it unique.
+	MaxSlugLength = 80
+	// fallbackSlug is used for titles without a single letter or digit that
+	// can be spelled in ASCII.
+	fallbackSlug = "post"
+)
+
+// transliterations spells the letters that do not decompose into an ASCII
+// letter and a combining mark. Everything else that is neither an ASCII
+// letter nor a digit after decomposition separates words.
+var transliterations = map[rune]string{
+	'ß': "ss", 'æ': "ae", 'œ': "oe", 'ø': "o", 'å': "a", 'ð': "d", 'đ': "d",
+	'þ': "th", 'ł': "l", 'ı': "i", 'ħ': "h", 'ŀ': "l", 'ŧ': "t", 'ŋ': "ng",
+
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'ґ': "g", 'д': "d", 'е': "e",
+	'ё': "yo", 'є': "ye", 'ж': "zh", 'з': "z", 'и': "i", 'і': "i", 'ї': "yi",
+	'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p",
+	'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e",
+	'ю': "yu", 'я': "ya",
+
+	'α': "a", 'β': "v", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "i",
+	'θ': "th", 'ι': "i", 'κ': "k", 'λ': "l", 'μ': "m", 'ν': "n", 'ξ': "x",
+	'ο': "o", 'π': "p", 'ρ': "r", 'σ': "s", 'ς': "s", 'τ': "t", 'υ': "y",
+	'φ': "f", 'χ': "ch", 'ψ': "ps", 'ω': "o",
+
+	// Apostrophes join the parts of a word rather than separate words.
+	'\'': "", '’': "",
+}
+
+// Slugify derives the URL-safe slug of a title: lower-case ASCII letters and
+// digits, with the words separated by single hyphens. Accents are dropped
+// and other alphabets transliterated, so "Crème Brûlée" becomes
+// "creme-brulee". The result is never empty.
+func Slugify(title string) string {
+	var b strings.Builder
+	separator := false
+	for _, r := range title {
+		spelled, ok := transliterations[unicode.ToLower(r)]
+		if !ok {
+			spelled = spell(r)
+		}
+		if spelled == "" {
+			// A letter without a spelling, such as the hard sign, is
+			// silent; anything else separates words.
+			separator = separator || !ok
+			continue
+		}
+		if separator && b.Len() > 0 {
+			b.WriteByte('-')
+		}
+		separator = false
+		b.WriteString(spelled)
+	}
+
+	slug := b.String()
+	if len(slug) > MaxSlugLength {
+		slug = slug[:MaxSlugLength]
+		if cut := strings.LastIndexByte(slug, '-'); cut > 0 {
+			slug = slug[:cut]
+		}
+	}
+	if slug == "" {
+		return fallbackSlug
+	}
+	return slug
+}
+
+// spell returns the ASCII letters and digits r decomposes into, in lower
+// case, or an empty string when there are none.
+func spell(r rune) string {
+	if r < utf8.RuneSelf {
+		if isSlugByte(byte(r)) || 'A' <= r && r <= 'Z' {
+			return string(unicode.ToLower(r))
+		}
+		return ""
+	}
+
+	var b strings.Builder
+	for _, d := range norm.NFKD.String(string(r)) {
+		d = unicode.ToLower(d)
+		if spelled, ok := transliterations[d]; ok {
+			b.WriteString(spelled)
+		} else if d < utf8.RuneSelf && isSlugByte(byte(d)) {
+			b.WriteRune(d)
+		}
+	}
+	return b.String()
+}
+
+func isSlugByte(c byte) bool {
+	return 'a' <= c && c <= 'z' || '0' <= c && c <= '9'
+}
+
+// AssignSlug gives the post a slug derived from its title, using the first
+// of base, base-2, base-3 and so on that taken does not report as belonging
+// to another post. A post keeps its current slug while it still derives
+// from the title; when the title moves on, the current slug is retired to
+// PreviousSlugs so links to it keep working. A retired slug of the post
+// itself is reclaimed when the title returns to it.
+func (p *Post) AssignSlug(taken func(slug string) bool) {
+	base := Slugify(p.Title)
+	if p.Slug != "" && slugDerivesFrom(p.Slug, base) {
+		return
+	}
+
+	slug := base
+	for n := 2; taken(slug); n++ {
+		slug = base + "-" + strconv.Itoa(n)
+	}
+
+	previous := make([]string, 0, len(p.PreviousSlugs)+1)
+	for _, s := range p.PreviousSlugs {
+		if s != slug {
+			previous = append(previous, s)
+		}
+	}
+	if p.Slug != "" {
+		previous = append(previous, p.Slug)
+	}
+	if len(previous) == 0 {
+		previous = nil
+	}
+
+	p.Slug = slug
+	p.PreviousSlugs = previous
+}
+
+// Slugs returns the current slug of the post followed by its retired ones.
+func (p *Post) Slugs() []string {
+	if p.Slug == "" {
+		return p.PreviousSlugs
+	}
+	return append([]string{p.Slug}, p.PreviousSlugs...)
+}
+
+// slugDerivesFrom reports whether slug is base, possibly with the numeric
+// suffix AssignSlug adds to make it unique.
+func slugDerivesFrom(slug, base string) bool {
+	if slug == base {
+		return true
+	}
+	suffix, found := strings.CutPrefix(slug, base+"-")
+	if !found {
+		return false
+	}
+	n, err := strconv.Atoi(suffix)
+	return err == nil && n >= 2 && strconv.Itoa(n) == suffix
+}
@@ -0,0 +1,74 @@
+package entities
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlugify(t *testing.T) {
+	testCases := []struct {
+		title string
+		want  string
+	}{
+		{title: "Hello, World!", want: "hello-world"},
+		{title: "  Go 1.21 -- what's new?  ", want: "go-1-21-whats-new"},
+		{title: "Crème Brûlée", want: "creme-brulee"},
+		{title: "Straße nach Łódź", want: "strasse-nach-lodz"},
+		{title: "Привет, мир", want: "privet-mir"},
+		{title: "Ελληνικά", want: "ellinika"},
+		{title: "ﬁne ＡＢＣ", want: "fine-abc"},
+		{title: "日本語", want: "post"},
+		{title: "!!!", want: "post"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			assert.Equal(t, tc.want, Slugify(tc.title))
+		})
+	}
+}
+
+func TestSlugify_CutsLongTitlesBetweenWords(t *testing.T) {
+	slug := Slugify(strings.Repeat("word ", 30))
+
+	assert.LessOrEqual(t, len(slug), MaxSlugLength)
+	assert.True(t, strings.HasSuffix(slug, "-word"), slug)
+}
+
+func TestPost_AssignSlug(t *testing.T) {
+	owners := map[string]int{"hello-world": 2, "hello-world-2": 3}
+	taken := func(slug string) bool {
+		owner, exists := owners[slug]
+		return exists && owner != 1
+	}
+
+	post := &Post{ID: 1, Title: "Hello World"}
+	post.AssignSlug(taken)
+	assert.Equal(t, "hello-world-3", post.Slug)
+	assert.Nil(t, post.PreviousSlugs)
+	owners[post.Slug] = 1
+
+	post.Title = "Hello, world!"
+	post.AssignSlug(taken)
+	assert.Equal(t, "hello-world-3", post.Slug, "a slug that still derives from the title is kept")
+
+	post.Title = "Goodbye"
+	post.AssignSlug(taken)
+	assert.Equal(t, "goodbye", post.Slug)
+	assert.Equal(t, []string{"hello-world-3"}, post.PreviousSlugs)
+	assert.Equal(t, []string{"goodbye", "hello-world-3"}, post.Slugs())
+
+	delete(owners, "hello-world")
+	owners["goodbye"] = 1
+	post.Title = "Hello World"
+	post.AssignSlug(taken)
+	assert.Equal(t, "hello-world", post.Slug, "the first free slug is taken")
+	assert.Equal(t, []string{"hello-world-3", "goodbye"}, post.PreviousSlugs)
+
+	post.Title = "Goodbye"
+	post.AssignSlug(taken)
+	assert.Equal(t, "goodbye", post.Slug, "a retired slug of the post is reclaimed")
+	assert.Equal(t, []string{"hello-world-3", "hello-world"}, post.PreviousSlugs)
+}
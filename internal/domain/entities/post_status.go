@@ -0,0 +1,121 @@
+package entities
+
+import (
+	"time"
+
+	"rakia-tech-test/internal/domain/errdefs"
+	"rakia-tech-test/internal/domain/validation"
+)
+
+var (
+	ErrInvalidStatus = validation.New("status", validation.CodeOneOf, "status must be one of draft, review, published or archived",
+		map[string]any{"allowed": []string{string(StatusDraft), string(StatusReview), string(StatusPublished), string(StatusArchived)}})
+	ErrInvalidTransition = errdefs.New(errdefs.ErrConflict, "post cannot move to the requested status from its current one")
+	ErrInvalidSchedule   = validation.New("publish_at", validation.CodeInvalid, "publication can only be scheduled in the future", nil)
+	ErrPublicationNotDue = errdefs.New(errdefs.ErrConflict, "post has no scheduled publication due")
+)
+
+// PostStatus is the stage of a post in the editorial workflow. Only
+// published posts are public.
+type PostStatus string
+
+const (
+	StatusDraft     PostStatus = "draft"
+	StatusReview    PostStatus = "review"
+	StatusPublished PostStatus = "published"
+	StatusArchived  PostStatus = "archived"
+)
+
+// transitions lists the states each state may move to. A post normally
+// moves draft → review → published → archived; review can send it back to
+// draft, and an archived post can be reopened as a draft.
+var transitions = map[PostStatus][]PostStatus{
+	StatusDraft:     {StatusReview},
+	StatusReview:    {StatusDraft, StatusPublished},
+	StatusPublished: {StatusArchived},
+	StatusArchived:  {StatusDraft},
+}
+
+// Valid reports whether s is a known status.
+func (s PostStatus) Valid() bool {
+	_, ok := transitions[s]
+	return ok
+}
+
+// CanTransitionTo reports whether a post in state s may move to next.
+func (s PostStatus) CanTransitionTo(next PostStatus) bool {
+	for _, allowed := range transitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// Transitions returns the states a post in state s may move to.
+func (s PostStatus) Transitions() []PostStatus {
+	return append([]PostStatus(nil), transitions[s]...)
+}
+
+// TransitionTo moves the post to status next, bumps its version and records
+// now as the time of the change. Any scheduled publication is cancelled.
+func (p *Post) TransitionTo(next PostStatus, now time.Time) error {
+	if !next.Valid() {
+		return ErrInvalidStatus
+	}
+	if !p.Status.CanTransitionTo(next) {
+		return ErrInvalidTransition
+	}
+
+	p.Status = next
+	p.PublishAt = nil
+	p.Version++
+	p.UpdatedAt = now.UTC()
+
+	return nil
+}
+
+// SchedulePublication arranges for a post that may be published to go live
+// at a later time, replacing any earlier schedule. It bumps the version and
+// records now as the time of the change.
+func (p *Post) SchedulePublication(at, now time.Time) error {
+	if !p.Status.CanTransitionTo(StatusPublished) {
+		return ErrInvalidTransition
+	}
+	if !at.After(now) {
+		return ErrInvalidSchedule
+	}
+
+	at = at.UTC()
+	p.PublishAt = &at
+	p.Version++
+	p.UpdatedAt = now.UTC()
+
+	return nil
+}
+
+// IsScheduled reports whether the post is waiting for a scheduled
+// publication.
+func (p *Post) IsScheduled() bool {
+	return p.PublishAt != nil && p.Status.CanTransitionTo(StatusPublished)
+}
+
+// PublicationDue reports whether the scheduled publication of the post is
+// due at now.
+func (p *Post) PublicationDue(now time.Time) bool {
+	return p.IsScheduled() && !p.PublishAt.After(now)
+}
+
+// PublishScheduled publishes the post if its scheduled publication is due
+// at now.
+func (p *Post) PublishScheduled(now time.Time) error {
+	if !p.PublicationDue(now) {
+		return ErrPublicationNotDue
+	}
+	return p.TransitionTo(StatusPublished, now)
+}
+
+// IsPublished reports whether the post is public.
+func (p *Post) IsPublished() bool {
+	return p.Status == StatusPublished
+}
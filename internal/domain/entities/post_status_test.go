@@ -0,0 +1,89 @@
+package entities
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPost_TransitionTo(t *testing.T) {
+	testCases := []struct {
+		name    string
+		from    PostStatus
+		to      PostStatus
+		wantErr error
+	}{
+		{name: "submit draft for review", from: StatusDraft, to: StatusReview},
+		{name: "send review back to draft", from: StatusReview, to: StatusDraft},
+		{name: "publish reviewed post", from: StatusReview, to: StatusPublished},
+		{name: "archive published post", from: StatusPublished, to: StatusArchived},
+		{name: "reopen archived post", from: StatusArchived, to: StatusDraft},
+		{name: "publish draft without review", from: StatusDraft, to: StatusPublished, wantErr: ErrInvalidTransition},
+		{name: "archive draft", from: StatusDraft, to: StatusArchived, wantErr: ErrInvalidTransition},
+		{name: "unpublish to draft", from: StatusPublished, to: StatusDraft, wantErr: ErrInvalidTransition},
+		{name: "republish archived post", from: StatusArchived, to: StatusPublished, wantErr: ErrInvalidTransition},
+		{name: "stay in the same state", from: StatusReview, to: StatusReview, wantErr: ErrInvalidTransition},
+		{name: "unknown status", from: StatusDraft, to: "deleted", wantErr: ErrInvalidStatus},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			changedAt := createdAt.Add(time.Hour)
+			post, err := NewPost(1, "Title", "Content", "Author", createdAt)
+			require.NoError(t, err)
+			post.Status = tt.from
+
+			err = post.TransitionTo(tt.to, changedAt)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Equal(t, tt.from, post.Status)
+				assert.Equal(t, 1, post.Version)
+				assert.Equal(t, createdAt, post.UpdatedAt)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.to, post.Status)
+				assert.Equal(t, 2, post.Version)
+				assert.Equal(t, changedAt, post.UpdatedAt)
+			}
+		})
+	}
+}
+
+func TestPost_SchedulePublication(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	later := now.Add(time.Hour)
+
+	post, err := NewPost(1, "Title", "Content", "Author", now)
+	require.NoError(t, err)
+	assert.ErrorIs(t, post.SchedulePublication(later, now), ErrInvalidTransition, "drafts must be reviewed first")
+
+	require.NoError(t, post.TransitionTo(StatusReview, now))
+	assert.ErrorIs(t, post.SchedulePublication(now, now), ErrInvalidSchedule)
+	assert.False(t, post.IsScheduled())
+
+	require.NoError(t, post.SchedulePublication(later.In(time.FixedZone("CET", 3600)), now))
+	assert.Equal(t, later, *post.PublishAt)
+	assert.Equal(t, time.UTC, post.PublishAt.Location())
+	assert.Equal(t, 3, post.Version)
+	assert.True(t, post.IsScheduled())
+	assert.False(t, post.PublicationDue(now))
+	assert.True(t, post.PublicationDue(later))
+	assert.ErrorIs(t, post.PublishScheduled(now), ErrPublicationNotDue)
+
+	// Any transition cancels the schedule.
+	require.NoError(t, post.TransitionTo(StatusDraft, now))
+	assert.Nil(t, post.PublishAt)
+	assert.False(t, post.PublicationDue(later))
+	assert.ErrorIs(t, post.PublishScheduled(later), ErrPublicationNotDue)
+
+	require.NoError(t, post.TransitionTo(StatusReview, now))
+	require.NoError(t, post.SchedulePublication(later, now))
+	require.NoError(t, post.PublishScheduled(later))
+	assert.Equal(t, StatusPublished, post.Status)
+	assert.Nil(t, post.PublishAt)
+	assert.Equal(t, later, post.UpdatedAt)
+}
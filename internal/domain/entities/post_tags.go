@@ -0,0 +1,100 @@
+package entities
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"rakia-tech-test/internal/domain/validation"
+)
+
+const (
+	// MaxTags caps the number of tags on a post.
+	MaxTags = 10
+	// MaxTagLength caps the length of a normalized tag, in bytes.
+	MaxTagLength = 50
+)
+
+var (
+	ErrInvalidTag = validation.New("tags", validation.CodeInvalid,
+		fmt.Sprintf("tags must be 1 to %d letters, digits, spaces, hyphens or underscores", MaxTagLength),
+		map[string]any{"max": MaxTagLength})
+	ErrTooManyTags = validation.New("tags", validation.CodeTooMany,
+		fmt.Sprintf("a post can have at most %d tags", MaxTags),
+		map[string]any{"max": MaxTags})
+)
+
+// NormalizeTag returns the canonical form of a tag: lower case, with the
+// surrounding space trimmed and inner runs of spaces, hyphens and
+// underscores folded into one hyphen. Tags that differ only in case or
+// spacing are the same tag.
+func NormalizeTag(tag string) (string, error) {
+	var b strings.Builder
+	separator := false
+	for _, r := range strings.TrimSpace(tag) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if separator && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			separator = false
+			b.WriteRune(unicode.ToLower(r))
+		case unicode.IsSpace(r) || r == '-' || r == '_':
+			separator = true
+		default:
+			return "", ErrInvalidTag
+		}
+	}
+
+	normalized := b.String()
+	if normalized == "" || len(normalized) > MaxTagLength {
+		return "", ErrInvalidTag
+	}
+	return normalized, nil
+}
+
+// NormalizeTags normalizes every tag and returns them sorted, without
+// duplicates. The result is always a new slice.
+func NormalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		n, err := NormalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[n] {
+			seen[n] = true
+			normalized = append(normalized, n)
+		}
+	}
+	if len(normalized) > MaxTags {
+		return nil, ErrTooManyTags
+	}
+
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+// SetTags replaces the tags of the post with their normalized form. It does
+// not bump the version; it is meant to be combined with Update or used on a
+// new post.
+func (p *Post) SetTags(tags []string) error {
+	normalized, err := NormalizeTags(tags)
+	if err != nil {
+		return err
+	}
+	if len(normalized) == 0 {
+		normalized = nil
+	}
+
+	p.Tags = normalized
+	return nil
+}
+
+// HasTag reports whether the post carries the normalized tag.
+func (p *Post) HasTag(tag string) bool {
+	i := sort.SearchStrings(p.Tags, tag)
+	return i < len(p.Tags) && p.Tags[i] == tag
+}
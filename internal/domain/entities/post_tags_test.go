@@ -0,0 +1,61 @@
+package entities
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeTag(t *testing.T) {
+	testCases := []struct {
+		tag       string
+		want      string
+		wantError error
+	}{
+		{tag: "Go", want: "go"},
+		{tag: "  Machine   Learning ", want: "machine-learning"},
+		{tag: "web_dev", want: "web-dev"},
+		{tag: "--c++--", wantError: ErrInvalidTag},
+		{tag: "-rust-", want: "rust"},
+		{tag: "Éclair", want: "éclair"},
+		{tag: "   ", wantError: ErrInvalidTag},
+		{tag: "a/b", wantError: ErrInvalidTag},
+		{tag: strings.Repeat("x", MaxTagLength+1), wantError: ErrInvalidTag},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.tag, func(t *testing.T) {
+			got, err := NormalizeTag(tc.tag)
+			if tc.wantError != nil {
+				assert.ErrorIs(t, err, tc.wantError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestPost_SetTags(t *testing.T) {
+	post := &Post{}
+
+	require.NoError(t, post.SetTags([]string{"Go", "testing", "go ", "API"}))
+	assert.Equal(t, []string{"api", "go", "testing"}, post.Tags)
+	assert.True(t, post.HasTag("go"))
+	assert.False(t, post.HasTag("Go"), "lookups take normalized tags")
+
+	require.NoError(t, post.SetTags([]string{}))
+	assert.Nil(t, post.Tags)
+
+	tooMany := make([]string, MaxTags+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("tag%d", i)
+	}
+	post.Tags = []string{"kept"}
+	assert.ErrorIs(t, post.SetTags(tooMany), ErrTooManyTags)
+	assert.ErrorIs(t, post.SetTags([]string{"ok", "not ok!"}), ErrInvalidTag)
+	assert.Equal(t, []string{"kept"}, post.Tags, "a rejected change leaves the tags alone")
+}
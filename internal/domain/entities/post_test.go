@@ -3,9 +3,12 @@ package entities
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"rakia-tech-test/internal/domain/validation"
 )
 
 // Test fixtures and data providers
@@ -284,6 +287,17 @@ func getValidateTestCases() []validateTestCase {
 			wantErr:       true,
 			expectedError: "title must be less than 255 characters",
 		},
+		{
+			name: "content too long validation",
+			post: Post{
+				ID:      10,
+				Title:   "Valid Title",
+				Content: strings.Repeat("a", MaxContentLength+1),
+				Author:  "Valid Author",
+			},
+			wantErr:       true,
+			expectedError: "content must be at most 100000 characters",
+		},
 		{
 			name: "title exactly 255 characters (valid)",
 			post: Post{
@@ -303,7 +317,8 @@ func TestNewPost(t *testing.T) {
 
 	for _, tt := range testCases {
 		t.Run(tt.name, func(t *testing.T) {
-			post, err := NewPost(tt.id, tt.title, tt.content, tt.author)
+			now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.FixedZone("CET", 3600))
+			post, err := NewPost(tt.id, tt.title, tt.content, tt.author, now)
 
 			if tt.wantError {
 				require.Error(t, err)
@@ -316,6 +331,11 @@ func TestNewPost(t *testing.T) {
 				assert.Equal(t, tt.title, post.Title)
 				assert.Equal(t, tt.content, post.Content)
 				assert.Equal(t, tt.author, post.Author)
+				assert.Equal(t, 1, post.Version)
+				assert.Equal(t, StatusDraft, post.Status)
+				assert.Equal(t, now.UTC(), post.CreatedAt)
+				assert.Equal(t, time.UTC, post.CreatedAt.Location())
+				assert.Equal(t, post.CreatedAt, post.UpdatedAt)
 			}
 		})
 	}
@@ -326,10 +346,12 @@ func TestPost_Update(t *testing.T) {
 
 	for _, tt := range testCases {
 		t.Run(tt.name, func(t *testing.T) {
-			post, err := NewPost(1, "Original Title", "Original Content", "Original Author")
+			createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			updatedAt := createdAt.Add(time.Hour)
+			post, err := NewPost(1, "Original Title", "Original Content", "Original Author", createdAt)
 			require.NoError(t, err)
 
-			err = post.Update(tt.title, tt.content, tt.author)
+			err = post.Update(tt.title, tt.content, tt.author, updatedAt)
 
 			if tt.wantError {
 				require.Error(t, err)
@@ -337,11 +359,16 @@ func TestPost_Update(t *testing.T) {
 				assert.Equal(t, "Original Title", post.Title)
 				assert.Equal(t, "Original Content", post.Content)
 				assert.Equal(t, "Original Author", post.Author)
+				assert.Equal(t, 1, post.Version)
+				assert.Equal(t, createdAt, post.UpdatedAt)
 			} else {
 				require.NoError(t, err)
 				assert.Equal(t, tt.title, post.Title)
 				assert.Equal(t, tt.content, post.Content)
 				assert.Equal(t, tt.author, post.Author)
+				assert.Equal(t, 2, post.Version)
+				assert.Equal(t, createdAt, post.CreatedAt)
+				assert.Equal(t, updatedAt, post.UpdatedAt)
 			}
 		})
 	}
@@ -362,3 +389,19 @@ func TestPost_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestPost_ValidateReportsEveryField(t *testing.T) {
+	post := &Post{Title: strings.Repeat("a", MaxTitleLength+1), Content: "  "}
+
+	fields, ok := validation.Fields(post.Validate())
+	require.True(t, ok)
+	require.Len(t, fields, 3)
+
+	assert.Equal(t, "title", fields[0].Field)
+	assert.Equal(t, validation.CodeTooLong, fields[0].Code)
+	assert.Equal(t, map[string]any{"max": MaxTitleLength}, fields[0].Params)
+	assert.Equal(t, "content", fields[1].Field)
+	assert.Equal(t, validation.CodeRequired, fields[1].Code)
+	assert.Equal(t, "author", fields[2].Field)
+	assert.Equal(t, validation.CodeRequired, fields[2].Code)
+}
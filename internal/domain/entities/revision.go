@@ -0,0 +1,50 @@
+package entities
+
+import "time"
+
+// Revision is an immutable record of a post as it was at one version. The
+// revision number equals the post version it captures.
+type Revision struct {
+	PostID   int       `json:"post_id"`
+	Number   int       `json:"number"`
+	Title    string    `json:"title"`
+	Content  string    `json:"content"`
+	Author   string    `json:"author"`
+	EditedBy string    `json:"edited_by"`
+	EditedAt time.Time `json:"edited_at"`
+	// Changes lists the fields that differ from the previous revision; the
+	// first revision of a post lists every field.
+	Changes []string `json:"changes"`
+	// RestoredFrom is the number of the revision this one was restored from,
+	// or 0 for regular edits.
+	RestoredFrom int `json:"restored_from,omitempty"`
+}
+
+// NewRevision captures the current state of post. previous is the latest
+// revision already recorded for the post, or nil.
+func NewRevision(post *Post, previous *Revision, editedBy string, editedAt time.Time) *Revision {
+	revision := &Revision{
+		PostID:   post.ID,
+		Number:   post.Version,
+		Title:    post.Title,
+		Content:  post.Content,
+		Author:   post.Author,
+		EditedBy: editedBy,
+		EditedAt: editedAt,
+	}
+
+	if previous == nil || previous.Title != post.Title {
+		revision.Changes = append(revision.Changes, "title")
+	}
+	if previous == nil || previous.Content != post.Content {
+		revision.Changes = append(revision.Changes, "content")
+	}
+	if previous == nil || previous.Author != post.Author {
+		revision.Changes = append(revision.Changes, "author")
+	}
+	if revision.Changes == nil {
+		revision.Changes = []string{}
+	}
+
+	return revision
+}
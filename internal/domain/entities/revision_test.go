@@ -0,0 +1,34 @@
+package entities
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRevision(t *testing.T) {
+	editedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	post, err := NewPost(1, "Title", "Content", "Author", editedAt)
+	require.NoError(t, err)
+
+	first := NewRevision(post, nil, "Author", editedAt)
+	assert.Equal(t, 1, first.PostID)
+	assert.Equal(t, 1, first.Number)
+	assert.Equal(t, "Author", first.EditedBy)
+	assert.Equal(t, editedAt, first.EditedAt)
+	assert.Equal(t, []string{"title", "content", "author"}, first.Changes)
+
+	require.NoError(t, post.Update("Title", "New Content", "Editor", editedAt))
+	second := NewRevision(post, first, "Editor", editedAt)
+	assert.Equal(t, 2, second.Number)
+	assert.Equal(t, "New Content", second.Content)
+	assert.Equal(t, []string{"content", "author"}, second.Changes)
+
+	require.NoError(t, post.Update("Title", "New Content", "Editor", editedAt))
+	third := NewRevision(post, second, "Editor", editedAt)
+	assert.NotNil(t, third.Changes)
+	assert.Empty(t, third.Changes)
+}
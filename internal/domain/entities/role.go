@@ -0,0 +1,32 @@
+package entities
+
+import "rakia-tech-test/internal/domain/validation"
+
+var ErrInvalidRole = validation.New("role", validation.CodeOneOf, "role must be one of reader, author, editor or admin",
+	map[string]any{"allowed": []string{string(RoleReader), string(RoleAuthor), string(RoleEditor), string(RoleAdmin)}})
+
+// Role says how far a user reaches. Each role may do what the ones before
+// it may, and more.
+type Role string
+
+const (
+	// RoleReader users read and comment, but write no posts.
+	RoleReader Role = "reader"
+	// RoleAuthor users write posts under their own name and change only
+	// those.
+	RoleAuthor Role = "author"
+	// RoleEditor users change the posts of every author.
+	RoleEditor Role = "editor"
+	// RoleAdmin users also manage API keys.
+	RoleAdmin Role = "admin"
+)
+
+// Valid reports whether r is a known role.
+func (r Role) Valid() bool {
+	switch r {
+	case RoleReader, RoleAuthor, RoleEditor, RoleAdmin:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,93 @@
+package entities
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"rakia-tech-test/internal/domain/validation"
+)
+
+// MaxUsernameLength caps the length of a username, in bytes.
+const MaxUsernameLength = 64
+
+var (
+	ErrUsernameRequired = validation.New("username", validation.CodeRequired, "username is required", nil)
+	ErrUsernameTooLong  = validation.New("username", validation.CodeTooLong,
+		fmt.Sprintf("username must be at most %d characters", MaxUsernameLength),
+		map[string]any{"max": MaxUsernameLength})
+	ErrUsernameInvalid = validation.New("username", validation.CodeInvalid,
+		"username must not contain spaces", nil)
+	ErrUserNameRequired = validation.New("name", validation.CodeRequired, "name is required", nil)
+	ErrUserNameTooLong  = validation.New("name", validation.CodeTooLong,
+		fmt.Sprintf("name must be at most %d characters", MaxAuthorNameLength),
+		map[string]any{"max": MaxAuthorNameLength})
+)
+
+// User is an account that can sign in to the API. Users sign in with their
+// username, which is case-insensitive, and write under Name, the author name
+// their posts and drafts are attributed to. Role decides which posts they
+// may change.
+type User struct {
+	ID       int
+	Username string
+	Name     string
+	Role     Role
+	// PasswordHash is the bcrypt hash of the password. The password itself
+	// is never stored.
+	PasswordHash string
+	// CreatedAt is when the user was added and UpdatedAt when they last
+	// changed. Both are kept in UTC.
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// NewUser returns a valid user created at now, with the username and name
+// normalized.
+func NewUser(id int, username, name string, role Role, passwordHash string, now time.Time) (*User, error) {
+	user := &User{
+		ID:           id,
+		Username:     NormalizeUsername(username),
+		Name:         NormalizeAuthorName(name),
+		Role:         role,
+		PasswordHash: passwordHash,
+		CreatedAt:    now.UTC(),
+		UpdatedAt:    now.UTC(),
+	}
+
+	if err := user.Validate(); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// Validate reports every problem with the fields of the user at once, as
+// validation.Errors.
+func (u *User) Validate() error {
+	var errs validation.Errors
+	switch {
+	case u.Username == "":
+		errs = append(errs, ErrUsernameRequired)
+	case len(u.Username) > MaxUsernameLength:
+		errs = append(errs, ErrUsernameTooLong)
+	case strings.ContainsFunc(u.Username, unicode.IsSpace):
+		errs = append(errs, ErrUsernameInvalid)
+	}
+	if u.Name == "" {
+		errs = append(errs, ErrUserNameRequired)
+	} else if len(u.Name) > MaxAuthorNameLength {
+		errs = append(errs, ErrUserNameTooLong)
+	}
+	if !u.Role.Valid() {
+		errs = append(errs, ErrInvalidRole)
+	}
+	return errs.Err()
+}
+
+// NormalizeUsername returns the canonical spelling of a username: lower
+// case, with the surrounding space trimmed.
+func NormalizeUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
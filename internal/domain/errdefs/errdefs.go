@@ -0,0 +1,51 @@
+// Package errdefs classifies domain errors by what went wrong, so outer
+// layers can react to a kind of failure without knowing every error that
+// belongs to it.
+package errdefs
+
+import "errors"
+
+// Kinds of failure. Errors of a kind report it through errors.Is, for
+// example errors.Is(repositories.ErrPostNotFound, ErrNotFound).
+var (
+	// ErrNotFound means the requested resource does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict means the request clashes with the current state of the
+	// resource, such as a duplicate or a concurrent change.
+	ErrConflict = errors.New("conflict")
+	// ErrValidation means the input is invalid. Retrying it unchanged fails
+	// again.
+	ErrValidation = errors.New("validation failed")
+	// ErrUnauthenticated means the caller has to prove who it is first, or
+	// the credentials it presented were rejected.
+	ErrUnauthenticated = errors.New("unauthenticated")
+	// ErrForbidden means the caller may not do what it asked for.
+	ErrForbidden = errors.New("forbidden")
+	// ErrUnavailable means the service cannot handle the request right now;
+	// a later retry may succeed.
+	ErrUnavailable = errors.New("unavailable")
+	// ErrRateLimited means the caller has used up its budget of requests
+	// and has to slow down before retrying.
+	ErrRateLimited = errors.New("rate limited")
+)
+
+// kindError is an error with its own message that belongs to a kind.
+type kindError struct {
+	kind    error
+	message string
+}
+
+// New returns an error with the given message and kind, which is one of the
+// Err variables of this package. Each call returns a distinct error, so it
+// can be declared once and matched with errors.Is as well.
+func New(kind error, message string) error {
+	return &kindError{kind: kind, message: message}
+}
+
+func (e *kindError) Error() string {
+	return e.message
+}
+
+func (e *kindError) Unwrap() error {
+	return e.kind
+}
@@ -0,0 +1,21 @@
+package errdefs
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	errMissing := New(ErrNotFound, "post not found")
+	errOther := New(ErrNotFound, "post not found")
+
+	assert.EqualError(t, errMissing, "post not found")
+	assert.ErrorIs(t, errMissing, ErrNotFound)
+	assert.ErrorIs(t, fmt.Errorf("get post: %w", errMissing), ErrNotFound)
+	assert.NotErrorIs(t, errMissing, ErrConflict)
+	assert.NotErrorIs(t, errMissing, errOther, "errors of the same kind stay distinct")
+	assert.NotErrorIs(t, errors.New("post not found"), ErrNotFound)
+}
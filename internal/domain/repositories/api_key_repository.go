@@ -0,0 +1,41 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/errdefs"
+)
+
+var (
+	ErrAPIKeyNotFound = errdefs.New(errdefs.ErrNotFound, "API key not found")
+	ErrAPIKeyExists   = errdefs.New(errdefs.ErrConflict, "API key prefix already taken")
+)
+
+// APIKeyRepository stores API keys. Prefixes are unique, so a key can be
+// looked up by the prefix it starts with.
+type APIKeyRepository interface {
+	// Create stores key under the next free ID, which it assigns to key. It
+	// fails with ErrAPIKeyExists if the prefix is taken.
+	Create(ctx context.Context, key *entities.APIKey) error
+
+	// GetByID returns the key with the given ID.
+	GetByID(ctx context.Context, id int) (*entities.APIKey, error)
+
+	// GetByPrefix returns the key with the given prefix.
+	GetByPrefix(ctx context.Context, prefix string) (*entities.APIKey, error)
+
+	// List returns every key, revoked and expired ones included, ordered
+	// by ID.
+	List(ctx context.Context) ([]*entities.APIKey, error)
+
+	// Revoke records at as the time the key with the given ID was revoked
+	// and returns the key. Keys that are revoked already keep their
+	// original time.
+	Revoke(ctx context.Context, id int, at time.Time) (*entities.APIKey, error)
+
+	// MarkUsed records at as the time the key with the given ID last
+	// authenticated a request.
+	MarkUsed(ctx context.Context, id int, at time.Time) error
+}
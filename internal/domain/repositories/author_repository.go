@@ -0,0 +1,44 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/errdefs"
+)
+
+var (
+	ErrAuthorNotFound = errdefs.New(errdefs.ErrNotFound, "author not found")
+	ErrAuthorExists   = errdefs.New(errdefs.ErrConflict, "author already exists")
+)
+
+// AuthorRepository stores the authors of posts. Author names are unique up
+// to case and spacing, as compared by entities.AuthorNameKey.
+type AuthorRepository interface {
+	// CreateAuthor stores a new author under the next free ID, stamped with
+	// createdAt. It fails with ErrAuthorExists if the name is taken.
+	CreateAuthor(ctx context.Context, name, bio string, createdAt time.Time) (*entities.Author, error)
+
+	// Create stores author under its own ID, so that authors kept elsewhere
+	// can be restored. It fails with ErrAuthorExists if the ID or the name
+	// is taken.
+	Create(ctx context.Context, author *entities.Author) error
+
+	// GetByID returns the author with the given ID.
+	GetByID(ctx context.Context, id int) (*entities.Author, error)
+
+	// GetByName returns the author whose name matches name up to case and
+	// spacing.
+	GetByName(ctx context.Context, name string) (*entities.Author, error)
+
+	// List returns every author, ordered by ID.
+	List(ctx context.Context) ([]*entities.Author, error)
+
+	// Update replaces the stored author with the same ID. It fails with
+	// ErrAuthorExists if the new name belongs to another author.
+	Update(ctx context.Context, author *entities.Author) error
+
+	// Delete removes the author with the given ID. Its ID is not reused.
+	Delete(ctx context.Context, id int) error
+}
@@ -0,0 +1,58 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/errdefs"
+)
+
+var (
+	ErrCommentNotFound = errdefs.New(errdefs.ErrNotFound, "comment not found")
+	ErrParentNotFound  = errdefs.New(errdefs.ErrValidation, "parent comment not found on this post")
+)
+
+// CommentQuery selects one page of the threads on a post. Threads are
+// ordered by the ID of their top-level comment.
+type CommentQuery struct {
+	// Limit is the maximum number of threads in the page and must be
+	// positive.
+	Limit int
+	// After selects the threads whose top-level comment has a greater ID;
+	// zero starts at the beginning.
+	After int
+}
+
+// CommentPage is one page of threads: their top-level comments and every
+// reply below them, ordered by ID, so parents precede their replies.
+type CommentPage struct {
+	Comments []*entities.Comment
+	HasNext  bool
+}
+
+// CommentRepository stores the comments on posts.
+type CommentRepository interface {
+	// CreateComment stores a new comment under the next free ID, stamped
+	// with createdAt. A reply fails with ErrParentNotFound unless its parent
+	// is a comment on the same post.
+	CreateComment(ctx context.Context, postID, parentID int, author, content string, createdAt time.Time) (*entities.Comment, error)
+
+	// GetByID returns the comment with the given ID.
+	GetByID(ctx context.Context, id int) (*entities.Comment, error)
+
+	// ListThreads returns one page of the threads on the post with the given
+	// ID.
+	ListThreads(ctx context.Context, postID int, query CommentQuery) (*CommentPage, error)
+
+	// Update replaces the stored comment with the same ID.
+	Update(ctx context.Context, comment *entities.Comment) error
+
+	// Delete removes the comment with the given ID together with every reply
+	// below it, and returns the IDs of the removed comments.
+	Delete(ctx context.Context, id int) ([]int, error)
+
+	// DeleteByPost removes every comment on a post that has been removed for
+	// good. Deleting the comments of a post without any is not an error.
+	DeleteByPost(ctx context.Context, postID int) error
+}
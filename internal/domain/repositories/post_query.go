@@ -0,0 +1,163 @@
+package repositories
+
+import (
+	"cmp"
+	"strings"
+	"time"
+
+	"rakia-tech-test/internal/domain/entities"
+)
+
+// PostSort is the order of a post listing. Every order breaks ties by
+// ascending ID, so it is total and pages never overlap.
+type PostSort string
+
+const (
+	SortByID     PostSort = "id"
+	SortByIDDesc PostSort = "-id"
+	SortByTitle  PostSort = "title"
+	SortByAuthor PostSort = "author"
+)
+
+// Valid reports whether s is one of the supported orders. The zero value is
+// valid and means SortByID.
+func (s PostSort) Valid() bool {
+	switch s {
+	case "", SortByID, SortByIDDesc, SortByTitle, SortByAuthor:
+		return true
+	}
+	return false
+}
+
+// CursorFor returns the position of post in the order.
+func (s PostSort) CursorFor(post *entities.Post) Cursor {
+	switch s {
+	case SortByTitle:
+		return Cursor{ID: post.ID, Key: post.Title}
+	case SortByAuthor:
+		return Cursor{ID: post.ID, Key: post.Author}
+	default:
+		return Cursor{ID: post.ID}
+	}
+}
+
+// Compare returns a negative number when position a comes before b in the
+// order, a positive number when it comes after and zero when they are equal.
+func (s PostSort) Compare(a, b Cursor) int {
+	switch s {
+	case SortByIDDesc:
+		return cmp.Compare(b.ID, a.ID)
+	case SortByTitle, SortByAuthor:
+		if c := strings.Compare(a.Key, b.Key); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(a.ID, b.ID)
+}
+
+// Cursor marks a position in a post order. Pages are defined relative to the
+// position rather than by offset, so posts created or deleted between two
+// requests neither shift nor repeat the following pages.
+type Cursor struct {
+	ID int
+	// Key is the sort key of the post at the position, such as its title,
+	// and is empty for the ID orders.
+	Key string
+}
+
+// PostFilter restricts a listing to the posts matching every set field.
+// Matching is case-sensitive; tags are compared in their normalized form.
+type PostFilter struct {
+	// Author selects the posts by exactly this author.
+	Author string
+	// AuthorID selects the posts linked to the author record with this ID.
+	AuthorID int
+	// AuthorPrefix selects the posts whose author starts with the prefix.
+	AuthorPrefix string
+	// TitleContains and ContentContains select the posts whose title or
+	// content contain the substring.
+	TitleContains   string
+	ContentContains string
+	// MinID and MaxID bound the post IDs, inclusively. Zero leaves the
+	// bound open.
+	MinID int
+	MaxID int
+	// CreatedAfter and CreatedBefore select the posts created strictly
+	// after or before the instant, and UpdatedSince the posts updated at or
+	// after it. The zero time leaves the bound open.
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	UpdatedSince  time.Time
+	// Status selects the posts in that stage of the editorial workflow.
+	Status entities.PostStatus
+	// Tag selects the posts carrying the tag, which must be normalized.
+	Tag string
+	// Viewer, when set, selects the posts the viewer may read: published
+	// posts and, for a named viewer, their own posts in any state.
+	Viewer *Viewer
+}
+
+// Viewer is the caller a listing is shown to. An empty Author is an
+// anonymous caller. Posts belong to the viewer when they name Author, as
+// matched by entities.AuthorNameKey.
+type Viewer struct {
+	Author string
+}
+
+// Sees reports whether post is listed to the viewer.
+func (v Viewer) Sees(post *entities.Post) bool {
+	return post.IsPublished() || (v.Author != "" && entities.AuthorNameKey(post.Author) == entities.AuthorNameKey(v.Author))
+}
+
+// IsZero reports whether the filter matches every post.
+func (f PostFilter) IsZero() bool {
+	return f.Author == "" && f.AuthorID == 0 && f.AuthorPrefix == "" && f.TitleContains == "" && f.ContentContains == "" &&
+		f.MinID == 0 && f.MaxID == 0 &&
+		f.CreatedAfter.IsZero() && f.CreatedBefore.IsZero() && f.UpdatedSince.IsZero() &&
+		f.Status == "" && f.Tag == "" && f.Viewer == nil
+}
+
+// Match reports whether post satisfies the filter.
+func (f PostFilter) Match(post *entities.Post) bool {
+	switch {
+	case f.Author != "" && post.Author != f.Author,
+		f.AuthorID != 0 && post.AuthorID != f.AuthorID,
+		f.AuthorPrefix != "" && !strings.HasPrefix(post.Author, f.AuthorPrefix),
+		f.TitleContains != "" && !strings.Contains(post.Title, f.TitleContains),
+		f.ContentContains != "" && !strings.Contains(post.Content, f.ContentContains),
+		f.MinID != 0 && post.ID < f.MinID,
+		f.MaxID != 0 && post.ID > f.MaxID,
+		!f.CreatedAfter.IsZero() && !post.CreatedAt.After(f.CreatedAfter),
+		!f.CreatedBefore.IsZero() && !post.CreatedAt.Before(f.CreatedBefore),
+		!f.UpdatedSince.IsZero() && post.UpdatedAt.Before(f.UpdatedSince),
+		f.Status != "" && post.Status != f.Status,
+		f.Tag != "" && !post.HasTag(f.Tag),
+		f.Viewer != nil && !f.Viewer.Sees(post):
+		return false
+	}
+	return true
+}
+
+// PostQuery selects one page of posts. At most one of After and Before may be
+// set; without either the page starts at the beginning. Cursors are only
+// meaningful for the Sort they were taken from.
+type PostQuery struct {
+	// Limit is the maximum number of posts in the page and must be positive.
+	Limit int
+	// After selects the posts that follow the cursor.
+	After *Cursor
+	// Before selects the posts that precede the cursor, that is the page
+	// before the one starting at the cursor.
+	Before *Cursor
+	Filter PostFilter
+	// Sort is the order of the listing; the zero value means SortByID.
+	Sort PostSort
+}
+
+// PostPage is one page of posts together with whether posts exist on either
+// side of it.
+type PostPage struct {
+	Posts   []*entities.Post
+	HasPrev bool
+	HasNext bool
+}
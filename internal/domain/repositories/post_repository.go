@@ -1,29 +1,117 @@
 package repositories
 
 import (
-	"errors"
+	"context"
 	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/errdefs"
+	"sort"
+	"time"
 )
 
 var (
-	ErrPostNotFound = errors.New("post not found")
-	ErrPostExists   = errors.New("post already exists")
+	ErrPostNotFound    = errdefs.New(errdefs.ErrNotFound, "post not found")
+	ErrPostExists      = errdefs.New(errdefs.ErrConflict, "post already exists")
+	ErrVersionConflict = errdefs.New(errdefs.ErrConflict, "post version conflict")
+	ErrSlugExists      = errdefs.New(errdefs.ErrConflict, "slug already in use")
 )
 
+// AnyVersion disables the version check of Update, Delete and Trash.
+const AnyVersion = 0
+
+// PostRepository stores posts. Every method honours cancellation and
+// deadlines of the context it is given and returns the context's error when
+// the work was abandoned.
+//
+// Trashed posts keep their ID, which is neither reused nor accepted by
+// Create, but are invisible to every method except GetTrash, Restore and
+// Purge: the others treat them as missing.
+//
+// Slugs are managed by the repository. A post stored without a slug gets
+// one derived from its title, and Update re-derives it from the new title
+// with entities.Post.AssignSlug, whatever slug the given post carries. The
+// choice and the write happen atomically, so no two posts ever share a
+// current or previous slug. Writes that assign a slug set it, together
+// with the previous slugs, on the post they are given. The slugs of
+// trashed posts stay reserved; permanently removed posts release theirs.
 type PostRepository interface {
-	CreatePost(title, content, author string) (*entities.Post, error)
+	// CreatePost stores a new post with the given tags under the next free
+	// ID, stamped with createdAt, and gives it a unique slug.
+	CreatePost(ctx context.Context, title, content string, format entities.ContentFormat, author string, authorID int, tags []string, createdAt time.Time) (*entities.Post, error)
+
+	// Create stores post under its own ID. A slug it already carries is
+	// kept, but fails with ErrSlugExists if it, or one of its previous
+	// slugs, belongs to another post.
+	Create(ctx context.Context, post *entities.Post) error
+
+	GetByID(ctx context.Context, id int) (*entities.Post, error)
+
+	// GetBySlug returns the post whose current or previous slug is slug;
+	// callers tell the two apart by comparing it with the post's Slug.
+	GetBySlug(ctx context.Context, slug string) (*entities.Post, error)
+
+	GetAll(ctx context.Context) ([]*entities.Post, error)
+
+	// ListPosts returns one page of the posts matching query.Filter, in the
+	// order of query.Sort.
+	ListPosts(ctx context.Context, query PostQuery) (*PostPage, error)
+
+	// Update replaces the stored post with the given ID. Unless
+	// expectedVersion is AnyVersion, the stored post must still be at
+	// expectedVersion or ErrVersionConflict is returned; the check and the
+	// write happen atomically.
+	Update(ctx context.Context, id int, post *entities.Post, expectedVersion int) error
 
-	Create(post *entities.Post) error
+	// Delete permanently removes the post with the given ID, with the same
+	// version check as Update.
+	Delete(ctx context.Context, id int, expectedVersion int) error
 
-	GetByID(id int) (*entities.Post, error)
+	Exists(ctx context.Context, id int) bool
 
-	GetAll() ([]*entities.Post, error)
+	// LoadData stores posts under their own IDs, replacing existing ones.
+	// Posts without a slug keep the slug of the post they replace while it
+	// still derives from the title; slugs they carry are checked like in
+	// Create.
+	LoadData(ctx context.Context, posts []*entities.Post) error
 
-	Update(id int, post *entities.Post) error
+	// Trash moves the post with the given ID to the trash, stamping it with
+	// deletedAt, with the same version check as Update.
+	Trash(ctx context.Context, id int, expectedVersion int, deletedAt time.Time) error
 
-	Delete(id int) error
+	// GetTrash returns the trashed posts ordered by ID.
+	GetTrash(ctx context.Context) ([]*entities.Post, error)
 
-	Exists(id int) bool
+	// Restore takes the post with the given ID out of the trash and returns
+	// it. It fails with ErrPostNotFound unless the post is trashed.
+	Restore(ctx context.Context, id int) (*entities.Post, error)
+
+	// Purge permanently removes the posts that were trashed before
+	// deletedBefore and returns their IDs in ascending order.
+	Purge(ctx context.Context, deletedBefore time.Time) ([]int, error)
+
+	// TagCounts returns every tag carried by at least one published post
+	// that is not trashed, with the number of such posts, most used first
+	// and then by tag. The counts are kept up to date by the writes rather
+	// than computed from the posts on every call.
+	TagCounts(ctx context.Context) ([]TagCount, error)
+}
+
+// TagCount is the number of public posts carrying a tag.
+type TagCount struct {
+	Tag   string
+	Posts int
+}
+
+// SortTagCounts orders counts the way TagCounts returns them.
+func SortTagCounts(counts []TagCount) {
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Posts != counts[j].Posts {
+			return counts[i].Posts > counts[j].Posts
+		}
+		return counts[i].Tag < counts[j].Tag
+	})
+}
 
-	LoadData(posts []*entities.Post) error
+// CountsTowardsTags reports whether post is counted by TagCounts.
+func CountsTowardsTags(post *entities.Post) bool {
+	return post != nil && post.IsPublished() && !post.IsDeleted()
 }
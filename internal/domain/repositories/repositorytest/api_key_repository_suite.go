@@ -0,0 +1,180 @@
+package repositorytest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/repositories"
+)
+
+// APIKeyFactory returns a new, empty API key repository.
+type APIKeyFactory func(t *testing.T) repositories.APIKeyRepository
+
+// RunAPIKeyRepositoryTests runs the APIKeyRepository contract against
+// repositories produced by newRepo.
+func RunAPIKeyRepositoryTests(t *testing.T, newRepo APIKeyFactory) {
+	t.Run("Create allocates sequential IDs", func(t *testing.T) {
+		testAPIKeyCreate(t, newRepo(t))
+	})
+	t.Run("prefixes are unique", func(t *testing.T) {
+		testAPIKeyPrefixUniqueness(t, newRepo(t))
+	})
+	t.Run("List orders by ID", func(t *testing.T) {
+		testAPIKeyList(t, newRepo(t))
+	})
+	t.Run("Revoke keeps the first time", func(t *testing.T) {
+		testAPIKeyRevoke(t, newRepo(t))
+	})
+	t.Run("MarkUsed records the last use", func(t *testing.T) {
+		testAPIKeyMarkUsed(t, newRepo(t))
+	})
+	t.Run("cancelled context", func(t *testing.T) {
+		testAPIKeyCancelledContext(t, newRepo(t))
+	})
+}
+
+func mustAPIKey(t *testing.T, prefix string) *entities.APIKey {
+	t.Helper()
+
+	key, err := entities.NewAPIKey(0, 1, "Key "+prefix, prefix, "hash of "+prefix,
+		[]entities.Scope{entities.ScopePostsRead}, nil, createdAt)
+	require.NoError(t, err)
+	return key
+}
+
+func testAPIKeyCreate(t *testing.T, repo repositories.APIKeyRepository) {
+	ctx := context.Background()
+
+	for want := 1; want <= 3; want++ {
+		key := mustAPIKey(t, fmt.Sprintf("rk_%d", want))
+		require.NoError(t, repo.Create(ctx, key))
+		assert.Equal(t, want, key.ID)
+	}
+
+	stored, err := repo.GetByID(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "rk_2", stored.Prefix)
+	assert.Equal(t, "Key rk_2", stored.Name)
+	assert.Equal(t, "hash of rk_2", stored.SecretHash)
+	assert.Equal(t, []entities.Scope{entities.ScopePostsRead}, stored.Scopes)
+	assert.True(t, createdAt.Equal(stored.CreatedAt))
+	assert.Nil(t, stored.LastUsedAt)
+	assert.Nil(t, stored.RevokedAt)
+
+	_, err = repo.GetByID(ctx, 99)
+	assert.ErrorIs(t, err, repositories.ErrAPIKeyNotFound)
+
+	invalid := &entities.APIKey{Name: "", Prefix: "rk_invalid", Scopes: []entities.Scope{entities.ScopePostsRead}}
+	assert.ErrorIs(t, repo.Create(ctx, invalid), entities.ErrAPIKeyNameRequired)
+}
+
+func testAPIKeyPrefixUniqueness(t *testing.T, repo repositories.APIKeyRepository) {
+	ctx := context.Background()
+
+	created := mustAPIKey(t, "rk_taken")
+	require.NoError(t, repo.Create(ctx, created))
+	assert.ErrorIs(t, repo.Create(ctx, mustAPIKey(t, "rk_taken")), repositories.ErrAPIKeyExists)
+
+	found, err := repo.GetByPrefix(ctx, "rk_taken")
+	require.NoError(t, err)
+	assert.Equal(t, created, found)
+
+	_, err = repo.GetByPrefix(ctx, "rk_other")
+	assert.ErrorIs(t, err, repositories.ErrAPIKeyNotFound)
+
+	next := mustAPIKey(t, "rk_other")
+	require.NoError(t, repo.Create(ctx, next))
+	assert.Equal(t, 2, next.ID, "a rejected key must not consume an ID")
+}
+
+func testAPIKeyList(t *testing.T, repo repositories.APIKeyRepository) {
+	ctx := context.Background()
+
+	keys, err := repo.List(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	for i := 1; i <= 5; i++ {
+		require.NoError(t, repo.Create(ctx, mustAPIKey(t, fmt.Sprintf("rk_%d", i))))
+	}
+	_, err = repo.Revoke(ctx, 3, createdAt)
+	require.NoError(t, err)
+
+	keys, err = repo.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, keys, 5, "revoked keys stay listed")
+	for i, key := range keys {
+		assert.Equal(t, i+1, key.ID)
+	}
+
+	keys[0].Name = "Mutated"
+	stored, err := repo.GetByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Key rk_1", stored.Name, "listed keys must be copies")
+}
+
+func testAPIKeyRevoke(t *testing.T, repo repositories.APIKeyRepository) {
+	ctx := context.Background()
+
+	key := mustAPIKey(t, "rk_revoked")
+	require.NoError(t, repo.Create(ctx, key))
+
+	first := createdAt.Add(time.Hour)
+	revoked, err := repo.Revoke(ctx, key.ID, first)
+	require.NoError(t, err)
+	require.NotNil(t, revoked.RevokedAt)
+	assert.True(t, first.Equal(*revoked.RevokedAt))
+
+	revoked, err = repo.Revoke(ctx, key.ID, first.Add(time.Hour))
+	require.NoError(t, err)
+	assert.True(t, first.Equal(*revoked.RevokedAt))
+
+	stored, err := repo.GetByPrefix(ctx, "rk_revoked")
+	require.NoError(t, err)
+	require.NotNil(t, stored.RevokedAt)
+	assert.True(t, first.Equal(*stored.RevokedAt))
+	assert.False(t, stored.Active(first.Add(time.Minute)))
+
+	_, err = repo.Revoke(ctx, 99, first)
+	assert.ErrorIs(t, err, repositories.ErrAPIKeyNotFound)
+}
+
+func testAPIKeyMarkUsed(t *testing.T, repo repositories.APIKeyRepository) {
+	ctx := context.Background()
+
+	key := mustAPIKey(t, "rk_used")
+	require.NoError(t, repo.Create(ctx, key))
+
+	for _, at := range []time.Time{createdAt.Add(time.Minute), createdAt.Add(time.Hour)} {
+		require.NoError(t, repo.MarkUsed(ctx, key.ID, at))
+
+		stored, err := repo.GetByID(ctx, key.ID)
+		require.NoError(t, err)
+		require.NotNil(t, stored.LastUsedAt)
+		assert.True(t, at.Equal(*stored.LastUsedAt))
+	}
+
+	assert.ErrorIs(t, repo.MarkUsed(ctx, 99, createdAt), repositories.ErrAPIKeyNotFound)
+}
+
+func testAPIKeyCancelledContext(t *testing.T, repo repositories.APIKeyRepository) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.ErrorIs(t, repo.Create(ctx, mustAPIKey(t, "rk_key")), context.Canceled)
+	_, err := repo.GetByID(ctx, 1)
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = repo.GetByPrefix(ctx, "rk_key")
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = repo.List(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = repo.Revoke(ctx, 1, createdAt)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorIs(t, repo.MarkUsed(ctx, 1, createdAt), context.Canceled)
+}
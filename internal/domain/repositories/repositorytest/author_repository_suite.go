@@ -0,0 +1,229 @@
+package repositorytest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/repositories"
+)
+
+// AuthorFactory returns a new, empty author repository.
+type AuthorFactory func(t *testing.T) repositories.AuthorRepository
+
+// RunAuthorRepositoryTests runs the AuthorRepository contract against
+// repositories produced by newRepo.
+func RunAuthorRepositoryTests(t *testing.T, newRepo AuthorFactory) {
+	t.Run("CreateAuthor allocates sequential IDs", func(t *testing.T) {
+		testAuthorCreate(t, newRepo(t))
+	})
+	t.Run("Create keeps the ID of the author", func(t *testing.T) {
+		testAuthorCreateWithID(t, newRepo(t))
+	})
+	t.Run("names are unique up to case and spacing", func(t *testing.T) {
+		testAuthorNameUniqueness(t, newRepo(t))
+	})
+	t.Run("Update renames and rejects taken names", func(t *testing.T) {
+		testAuthorUpdate(t, newRepo(t))
+	})
+	t.Run("Delete releases the name but not the ID", func(t *testing.T) {
+		testAuthorDelete(t, newRepo(t))
+	})
+	t.Run("concurrent CreateAuthor with one name", func(t *testing.T) {
+		testAuthorConcurrentCreate(t, newRepo(t))
+	})
+	t.Run("returned authors are copies", func(t *testing.T) {
+		testAuthorCopyIsolation(t, newRepo(t))
+	})
+	t.Run("cancelled context", func(t *testing.T) {
+		testAuthorCancelledContext(t, newRepo(t))
+	})
+}
+
+func testAuthorCreate(t *testing.T, repo repositories.AuthorRepository) {
+	ctx := context.Background()
+
+	for want := 1; want <= 3; want++ {
+		author, err := repo.CreateAuthor(ctx, fmt.Sprintf("  Author   %d ", want), " Bio ", createdAt)
+		require.NoError(t, err)
+		assert.Equal(t, want, author.ID)
+		assert.Equal(t, fmt.Sprintf("Author %d", want), author.Name, "names are normalized")
+		assert.Equal(t, "Bio", author.Bio)
+		assert.True(t, createdAt.Equal(author.CreatedAt))
+	}
+
+	_, err := repo.CreateAuthor(ctx, "   ", "", createdAt)
+	assert.ErrorIs(t, err, entities.ErrAuthorNameRequired)
+
+	authors, err := repo.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, authors, 3)
+	for i, author := range authors {
+		assert.Equal(t, i+1, author.ID)
+	}
+
+	stored, err := repo.GetByID(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, authors[1], stored)
+
+	_, err = repo.GetByID(ctx, 99)
+	assert.ErrorIs(t, err, repositories.ErrAuthorNotFound)
+}
+
+func testAuthorCreateWithID(t *testing.T, repo repositories.AuthorRepository) {
+	ctx := context.Background()
+
+	restored, err := entities.NewAuthor(5, "Restored", "", createdAt)
+	require.NoError(t, err)
+	require.NoError(t, repo.Create(ctx, restored))
+
+	stored, err := repo.GetByID(ctx, 5)
+	require.NoError(t, err)
+	assert.Equal(t, restored, stored)
+
+	clash, err := entities.NewAuthor(5, "Other", "", createdAt)
+	require.NoError(t, err)
+	assert.ErrorIs(t, repo.Create(ctx, clash), repositories.ErrAuthorExists)
+	clash, err = entities.NewAuthor(6, "restored", "", createdAt)
+	require.NoError(t, err)
+	assert.ErrorIs(t, repo.Create(ctx, clash), repositories.ErrAuthorExists)
+
+	next, err := repo.CreateAuthor(ctx, "Next", "", createdAt)
+	require.NoError(t, err)
+	assert.Equal(t, 6, next.ID, "IDs continue after the highest stored one")
+}
+
+func testAuthorNameUniqueness(t *testing.T, repo repositories.AuthorRepository) {
+	ctx := context.Background()
+
+	created, err := repo.CreateAuthor(ctx, "Author 1", "", createdAt)
+	require.NoError(t, err)
+
+	_, err = repo.CreateAuthor(ctx, "author 1 ", "", createdAt)
+	assert.ErrorIs(t, err, repositories.ErrAuthorExists)
+
+	found, err := repo.GetByName(ctx, " AUTHOR  1")
+	require.NoError(t, err)
+	assert.Equal(t, created, found)
+
+	_, err = repo.GetByName(ctx, "Author 2")
+	assert.ErrorIs(t, err, repositories.ErrAuthorNotFound)
+
+	next, err := repo.CreateAuthor(ctx, "Author 2", "", createdAt)
+	require.NoError(t, err)
+	assert.Equal(t, 2, next.ID, "a rejected author must not consume an ID")
+}
+
+func testAuthorUpdate(t *testing.T, repo repositories.AuthorRepository) {
+	ctx := context.Background()
+
+	first, err := repo.CreateAuthor(ctx, "First", "", createdAt)
+	require.NoError(t, err)
+	_, err = repo.CreateAuthor(ctx, "Second", "", createdAt)
+	require.NoError(t, err)
+
+	require.NoError(t, first.Update("Renamed", "New bio", updatedAt))
+	require.NoError(t, repo.Update(ctx, first))
+
+	stored, err := repo.GetByID(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Renamed", stored.Name)
+	assert.Equal(t, "New bio", stored.Bio)
+	assert.True(t, updatedAt.Equal(stored.UpdatedAt))
+
+	_, err = repo.GetByName(ctx, "First")
+	assert.ErrorIs(t, err, repositories.ErrAuthorNotFound, "the old name is released")
+	_, err = repo.CreateAuthor(ctx, "First", "", createdAt)
+	assert.NoError(t, err)
+
+	// Changing the case of the own name is allowed.
+	require.NoError(t, first.Update("RENAMED", "", updatedAt))
+	require.NoError(t, repo.Update(ctx, first))
+
+	require.NoError(t, first.Update("second", "", updatedAt))
+	assert.ErrorIs(t, repo.Update(ctx, first), repositories.ErrAuthorExists)
+
+	missing, err := entities.NewAuthor(99, "Missing", "", createdAt)
+	require.NoError(t, err)
+	assert.ErrorIs(t, repo.Update(ctx, missing), repositories.ErrAuthorNotFound)
+}
+
+func testAuthorDelete(t *testing.T, repo repositories.AuthorRepository) {
+	ctx := context.Background()
+
+	author, err := repo.CreateAuthor(ctx, "Gone", "", createdAt)
+	require.NoError(t, err)
+
+	require.NoError(t, repo.Delete(ctx, author.ID))
+	assert.ErrorIs(t, repo.Delete(ctx, author.ID), repositories.ErrAuthorNotFound)
+	_, err = repo.GetByID(ctx, author.ID)
+	assert.ErrorIs(t, err, repositories.ErrAuthorNotFound)
+
+	again, err := repo.CreateAuthor(ctx, "Gone", "", createdAt)
+	require.NoError(t, err)
+	assert.Equal(t, 2, again.ID)
+}
+
+func testAuthorConcurrentCreate(t *testing.T, repo repositories.AuthorRepository) {
+	ctx := context.Background()
+	const writers = 20
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	created, exists := 0, 0
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := repo.CreateAuthor(ctx, "Popular", "", createdAt)
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				created++
+			} else if assert.ErrorIs(t, err, repositories.ErrAuthorExists) {
+				exists++
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, created)
+	assert.Equal(t, writers-1, exists)
+}
+
+func testAuthorCopyIsolation(t *testing.T, repo repositories.AuthorRepository) {
+	ctx := context.Background()
+
+	created, err := repo.CreateAuthor(ctx, "Original", "", createdAt)
+	require.NoError(t, err)
+	created.Name = "Mutated"
+
+	stored, err := repo.GetByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Original", stored.Name)
+	stored.Name = "Mutated"
+
+	authors, err := repo.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "Original", authors[0].Name)
+}
+
+func testAuthorCancelledContext(t *testing.T, repo repositories.AuthorRepository) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := repo.CreateAuthor(ctx, "Author", "", createdAt)
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = repo.GetByID(ctx, 1)
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = repo.GetByName(ctx, "Author")
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = repo.List(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorIs(t, repo.Delete(ctx, 1), context.Canceled)
+}
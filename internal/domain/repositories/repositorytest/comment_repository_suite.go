@@ -0,0 +1,272 @@
+package repositorytest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/repositories"
+)
+
+// CommentFactory returns a new, empty comment repository.
+type CommentFactory func(t *testing.T) repositories.CommentRepository
+
+// RunCommentRepositoryTests runs the CommentRepository contract against
+// repositories produced by newRepo.
+func RunCommentRepositoryTests(t *testing.T, newRepo CommentFactory) {
+	t.Run("CreateComment allocates sequential IDs", func(t *testing.T) {
+		testCommentCreate(t, newRepo(t))
+	})
+	t.Run("replies need a parent on the same post", func(t *testing.T) {
+		testCommentParents(t, newRepo(t))
+	})
+	t.Run("ListThreads pages by top-level comment", func(t *testing.T) {
+		testCommentThreads(t, newRepo(t))
+	})
+	t.Run("Update edits the content only", func(t *testing.T) {
+		testCommentUpdate(t, newRepo(t))
+	})
+	t.Run("Delete removes the replies below", func(t *testing.T) {
+		testCommentDelete(t, newRepo(t))
+	})
+	t.Run("DeleteByPost removes the comments of one post", func(t *testing.T) {
+		testCommentDeleteByPost(t, newRepo(t))
+	})
+	t.Run("concurrent replies", func(t *testing.T) {
+		testCommentConcurrentReplies(t, newRepo(t))
+	})
+	t.Run("returned comments are copies", func(t *testing.T) {
+		testCommentCopyIsolation(t, newRepo(t))
+	})
+	t.Run("cancelled context", func(t *testing.T) {
+		testCommentCancelledContext(t, newRepo(t))
+	})
+}
+
+func commentIDs(comments []*entities.Comment) []int {
+	ids := make([]int, len(comments))
+	for i, comment := range comments {
+		ids[i] = comment.ID
+	}
+	return ids
+}
+
+func mustComment(t *testing.T, repo repositories.CommentRepository, postID, parentID int) *entities.Comment {
+	t.Helper()
+	comment, err := repo.CreateComment(context.Background(), postID, parentID, "Reader", fmt.Sprintf("Reply to %d", parentID), createdAt)
+	require.NoError(t, err)
+	return comment
+}
+
+func testCommentCreate(t *testing.T, repo repositories.CommentRepository) {
+	ctx := context.Background()
+
+	for want := 1; want <= 3; want++ {
+		comment, err := repo.CreateComment(ctx, 1, 0, " Reader ", " Content ", createdAt)
+		require.NoError(t, err)
+		assert.Equal(t, want, comment.ID)
+		assert.Equal(t, 1, comment.PostID)
+		assert.Equal(t, "Reader", comment.Author)
+		assert.Equal(t, "Content", comment.Content)
+		assert.True(t, createdAt.Equal(comment.CreatedAt))
+	}
+
+	_, err := repo.CreateComment(ctx, 1, 0, "Reader", " ", createdAt)
+	assert.ErrorIs(t, err, entities.ErrCommentContentRequired)
+
+	next := mustComment(t, repo, 1, 0)
+	assert.Equal(t, 4, next.ID, "a rejected comment must not consume an ID")
+
+	stored, err := repo.GetByID(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stored.ID)
+	assert.Equal(t, "Content", stored.Content)
+
+	_, err = repo.GetByID(ctx, 99)
+	assert.ErrorIs(t, err, repositories.ErrCommentNotFound)
+}
+
+func testCommentParents(t *testing.T, repo repositories.CommentRepository) {
+	ctx := context.Background()
+
+	root := mustComment(t, repo, 1, 0)
+	reply := mustComment(t, repo, 1, root.ID)
+	assert.Equal(t, root.ID, reply.ParentID)
+	nested := mustComment(t, repo, 1, reply.ID)
+	assert.Equal(t, reply.ID, nested.ParentID)
+
+	_, err := repo.CreateComment(ctx, 2, root.ID, "Reader", "Elsewhere", createdAt)
+	assert.ErrorIs(t, err, repositories.ErrParentNotFound, "parents must be on the same post")
+	_, err = repo.CreateComment(ctx, 1, 99, "Reader", "Missing", createdAt)
+	assert.ErrorIs(t, err, repositories.ErrParentNotFound)
+}
+
+func testCommentThreads(t *testing.T, repo repositories.CommentRepository) {
+	ctx := context.Background()
+
+	first := mustComment(t, repo, 1, 0)  // 1
+	second := mustComment(t, repo, 1, 0) // 2
+	mustComment(t, repo, 2, 0)           // 3, other post
+	reply := mustComment(t, repo, 1, 1)  // 4
+	mustComment(t, repo, 1, second.ID)   // 5
+	mustComment(t, repo, 1, reply.ID)    // 6
+	third := mustComment(t, repo, 1, 0)  // 7
+	mustComment(t, repo, 1, first.ID)    // 8
+	assert.Equal(t, 7, third.ID)
+
+	page, err := repo.ListThreads(ctx, 1, repositories.CommentQuery{Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 4, 5, 6, 8}, commentIDs(page.Comments))
+	assert.True(t, page.HasNext)
+
+	page, err = repo.ListThreads(ctx, 1, repositories.CommentQuery{Limit: 2, After: second.ID})
+	require.NoError(t, err)
+	assert.Equal(t, []int{7}, commentIDs(page.Comments))
+	assert.False(t, page.HasNext)
+
+	page, err = repo.ListThreads(ctx, 1, repositories.CommentQuery{Limit: 3})
+	require.NoError(t, err)
+	assert.False(t, page.HasNext, "a page ending at the last thread has no next page")
+
+	page, err = repo.ListThreads(ctx, 9, repositories.CommentQuery{Limit: 2})
+	require.NoError(t, err)
+	assert.NotNil(t, page.Comments)
+	assert.Empty(t, page.Comments)
+	assert.False(t, page.HasNext)
+}
+
+func testCommentUpdate(t *testing.T, repo repositories.CommentRepository) {
+	ctx := context.Background()
+
+	root := mustComment(t, repo, 1, 0)
+	reply := mustComment(t, repo, 1, root.ID)
+
+	require.NoError(t, reply.Edit("Edited", updatedAt))
+	reply.PostID, reply.ParentID = 2, 0
+	require.NoError(t, repo.Update(ctx, reply))
+
+	stored, err := repo.GetByID(ctx, reply.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Edited", stored.Content)
+	assert.True(t, updatedAt.Equal(stored.UpdatedAt))
+	assert.Equal(t, 1, stored.PostID, "comments do not move")
+	assert.Equal(t, root.ID, stored.ParentID)
+
+	stored.Content = ""
+	assert.ErrorIs(t, repo.Update(ctx, stored), entities.ErrCommentContentRequired)
+
+	missing, err := entities.NewComment(99, 1, 0, "Reader", "Missing", createdAt)
+	require.NoError(t, err)
+	assert.ErrorIs(t, repo.Update(ctx, missing), repositories.ErrCommentNotFound)
+}
+
+func testCommentDelete(t *testing.T, repo repositories.CommentRepository) {
+	ctx := context.Background()
+
+	root := mustComment(t, repo, 1, 0)        // 1
+	reply := mustComment(t, repo, 1, root.ID) // 2
+	other := mustComment(t, repo, 1, root.ID) // 3
+	mustComment(t, repo, 1, reply.ID)         // 4
+	sibling := mustComment(t, repo, 1, 0)     // 5
+
+	deleted, err := repo.Delete(ctx, reply.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 4}, deleted)
+
+	page, err := repo.ListThreads(ctx, 1, repositories.CommentQuery{Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []int{root.ID, other.ID, sibling.ID}, commentIDs(page.Comments))
+
+	_, err = repo.GetByID(ctx, 4)
+	assert.ErrorIs(t, err, repositories.ErrCommentNotFound)
+	_, err = repo.Delete(ctx, reply.ID)
+	assert.ErrorIs(t, err, repositories.ErrCommentNotFound)
+	_, err = repo.CreateComment(ctx, 1, reply.ID, "Reader", "Too late", createdAt)
+	assert.ErrorIs(t, err, repositories.ErrParentNotFound)
+
+	next := mustComment(t, repo, 1, 0)
+	assert.Equal(t, 6, next.ID, "IDs are not reused")
+}
+
+func testCommentDeleteByPost(t *testing.T, repo repositories.CommentRepository) {
+	ctx := context.Background()
+
+	root := mustComment(t, repo, 1, 0)
+	mustComment(t, repo, 1, root.ID)
+	kept := mustComment(t, repo, 2, 0)
+
+	require.NoError(t, repo.DeleteByPost(ctx, 1))
+	require.NoError(t, repo.DeleteByPost(ctx, 1))
+
+	page, err := repo.ListThreads(ctx, 1, repositories.CommentQuery{Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, page.Comments)
+	_, err = repo.GetByID(ctx, root.ID)
+	assert.ErrorIs(t, err, repositories.ErrCommentNotFound)
+
+	page, err = repo.ListThreads(ctx, 2, repositories.CommentQuery{Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []int{kept.ID}, commentIDs(page.Comments))
+}
+
+func testCommentConcurrentReplies(t *testing.T, repo repositories.CommentRepository) {
+	ctx := context.Background()
+	const writers = 20
+
+	root := mustComment(t, repo, 1, 0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := repo.CreateComment(ctx, 1, root.ID, "Reader", "Reply", createdAt)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	page, err := repo.ListThreads(ctx, 1, repositories.CommentQuery{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, page.Comments, writers+1)
+	for i, comment := range page.Comments {
+		assert.Equal(t, i+1, comment.ID, "IDs are distinct and sequential")
+	}
+}
+
+func testCommentCopyIsolation(t *testing.T, repo repositories.CommentRepository) {
+	ctx := context.Background()
+
+	created := mustComment(t, repo, 1, 0)
+	original := created.Content
+	created.Content = "Mutated"
+
+	stored, err := repo.GetByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, original, stored.Content)
+	stored.Content = "Mutated"
+
+	page, err := repo.ListThreads(ctx, 1, repositories.CommentQuery{Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, original, page.Comments[0].Content)
+}
+
+func testCommentCancelledContext(t *testing.T, repo repositories.CommentRepository) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := repo.CreateComment(ctx, 1, 0, "Reader", "Content", createdAt)
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = repo.GetByID(ctx, 1)
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = repo.ListThreads(ctx, 1, repositories.CommentQuery{Limit: 10})
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = repo.Delete(ctx, 1)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorIs(t, repo.DeleteByPost(ctx, 1), context.Canceled)
+}
@@ -0,0 +1,1425 @@
+// Package repositorytest holds the behavioural contract every
+// repositories.PostRepository implementation must satisfy, packaged as a test
+// suite that each backend runs against its own factory.
+package repositorytest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/repositories"
+)
+
+// Factory returns a new, empty repository. It is called once per subtest, so
+// implementations can rely on t.TempDir and t.Cleanup for isolation.
+type Factory func(t *testing.T) repositories.PostRepository
+
+// RunPostRepositoryTests runs the full PostRepository contract against
+// repositories produced by newRepo.
+func RunPostRepositoryTests(t *testing.T, newRepo Factory) {
+	t.Run("CreatePost allocates sequential IDs", func(t *testing.T) {
+		testCreatePostAllocatesIDs(t, newRepo(t))
+	})
+	t.Run("CreatePost rejects invalid posts without consuming an ID", func(t *testing.T) {
+		testCreatePostValidation(t, newRepo(t))
+	})
+	t.Run("IDs are not reused after delete", func(t *testing.T) {
+		testIDsNotReused(t, newRepo(t))
+	})
+	t.Run("Create keeps explicit IDs and advances the sequence", func(t *testing.T) {
+		testCreateExplicitID(t, newRepo(t))
+	})
+	t.Run("Create rejects duplicate IDs", func(t *testing.T) {
+		testCreateDuplicate(t, newRepo(t))
+	})
+	t.Run("GetByID of a missing post", func(t *testing.T) {
+		testGetByIDNotFound(t, newRepo(t))
+	})
+	t.Run("GetAll returns posts ordered by ID", func(t *testing.T) {
+		testGetAllOrdering(t, newRepo(t))
+	})
+	t.Run("timestamps are stored exactly", func(t *testing.T) {
+		testTimestamps(t, newRepo(t))
+	})
+	t.Run("returned posts are copies", func(t *testing.T) {
+		testCopyIsolation(t, newRepo(t))
+	})
+	t.Run("Update", func(t *testing.T) {
+		testUpdate(t, newRepo(t))
+	})
+	t.Run("Delete", func(t *testing.T) {
+		testDelete(t, newRepo(t))
+	})
+	t.Run("Exists", func(t *testing.T) {
+		testExists(t, newRepo(t))
+	})
+	t.Run("LoadData", func(t *testing.T) {
+		testLoadData(t, newRepo(t))
+	})
+	t.Run("concurrent CreatePost", func(t *testing.T) {
+		testConcurrentCreatePost(t, newRepo(t))
+	})
+	t.Run("concurrent reads and writes", func(t *testing.T) {
+		testConcurrentReadWrite(t, newRepo(t))
+	})
+	t.Run("Update and Delete check the expected version", func(t *testing.T) {
+		testVersionCheck(t, newRepo(t))
+	})
+	t.Run("concurrent compare-and-swap updates", func(t *testing.T) {
+		testConcurrentCompareAndSwap(t, newRepo(t))
+	})
+	t.Run("ListPosts pages forward and backward", func(t *testing.T) {
+		testListPosts(t, newRepo(t))
+	})
+	t.Run("ListPosts cursors survive concurrent writes", func(t *testing.T) {
+		testListPostsStableCursors(t, newRepo(t))
+	})
+	t.Run("ListPosts filters", func(t *testing.T) {
+		testListPostsFilter(t, newRepo(t))
+	})
+	t.Run("ListPosts filters by date", func(t *testing.T) {
+		testListPostsDateFilter(t, newRepo(t))
+	})
+	t.Run("ListPosts filters by status and viewer", func(t *testing.T) {
+		testListPostsStatusFilter(t, newRepo(t))
+	})
+	t.Run("ListPosts pages for a viewer", func(t *testing.T) {
+		testListPostsViewerPaging(t, newRepo(t))
+	})
+	t.Run("status transitions and schedules are stored", func(t *testing.T) {
+		testStatus(t, newRepo(t))
+	})
+	t.Run("tags are stored and filtered", func(t *testing.T) {
+		testTags(t, newRepo(t))
+	})
+	t.Run("TagCounts follows every write", func(t *testing.T) {
+		testTagCounts(t, newRepo(t))
+	})
+	t.Run("author IDs are stored and filtered", func(t *testing.T) {
+		testAuthorIDs(t, newRepo(t))
+	})
+	t.Run("content formats are stored", func(t *testing.T) {
+		testContentFormats(t, newRepo(t))
+	})
+	t.Run("slugs are unique and follow the title", func(t *testing.T) {
+		testSlugs(t, newRepo(t))
+	})
+	t.Run("slugs are reserved until the post is removed", func(t *testing.T) {
+		testSlugReservation(t, newRepo(t))
+	})
+	t.Run("concurrent CreatePost gets distinct slugs", func(t *testing.T) {
+		testConcurrentSlugs(t, newRepo(t))
+	})
+	t.Run("ListPosts sorts with stable tiebreaks", func(t *testing.T) {
+		testListPostsSort(t, newRepo(t))
+	})
+	t.Run("Trash hides posts from regular reads", func(t *testing.T) {
+		testTrashHidesPost(t, newRepo(t))
+	})
+	t.Run("Trash checks the expected version", func(t *testing.T) {
+		testTrashVersionCheck(t, newRepo(t))
+	})
+	t.Run("Restore keeps the original ID", func(t *testing.T) {
+		testRestore(t, newRepo(t))
+	})
+	t.Run("Purge removes posts trashed before the cutoff", func(t *testing.T) {
+		testPurge(t, newRepo(t))
+	})
+	t.Run("cancelled context", func(t *testing.T) {
+		testCancelledContext(t, newRepo(t))
+	})
+}
+
+func mustPost(t *testing.T, id int, title string) *entities.Post {
+	t.Helper()
+
+	post, err := entities.NewPost(id, title, "Content of "+title, "Author of "+title, createdAt)
+	require.NoError(t, err)
+	return post
+}
+
+func postIDs(posts []*entities.Post) []int {
+	ids := make([]int, len(posts))
+	for i, post := range posts {
+		ids[i] = post.ID
+	}
+	return ids
+}
+
+func testCreatePostAllocatesIDs(t *testing.T, repo repositories.PostRepository) {
+	ctx := context.Background()
+	for want := 1; want <= 3; want++ {
+		title := fmt.Sprintf("Title %d", want)
+		post, err := repo.CreatePost(ctx, title, "Content", "", "Author", 0, nil, createdAt)
+		require.NoError(t, err)
+		require.NotNil(t, post)
+
+		assert.Equal(t, want, post.ID)
+		assert.Equal(t, title, post.Title)
+		assert.Equal(t, "Content", post.Content)
+		assert.Equal(t, "Author", post.Author)
+
+		stored, err := repo.GetByID(ctx, post.ID)
+		require.NoError(t, err)
+		assert.Equal(t, post, stored)
+	}
+}
+
+func testCreatePostValidation(t *testing.T, repo repositories.PostRepository) {
+	ctx := context.Background()
+	_, err := repo.CreatePost(ctx, "", "Content", "", "Author", 0, nil, createdAt)
+	assert.ErrorContains(t, err, "title is required")
+	_, err = repo.CreatePost(ctx, "Title", " ", "", "Author", 0, nil, createdAt)
+	assert.ErrorContains(t, err, "content is required")
+	_, err = repo.CreatePost(ctx, "Title", "Content", "", "", 0, nil, createdAt)
+	assert.ErrorContains(t, err, "author is required")
+
+	post, err := repo.CreatePost(ctx, "Title", "Content", "", "Author", 0, nil, createdAt)
+	require.NoError(t, err)
+	assert.Equal(t, 1, post.ID)
+
+	posts, err := repo.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Len(t, posts, 1)
+}
+
+func testIDsNotReused(t *testing.T, repo repositories.PostRepository) {
+	ctx := context.Background()
+	_, err := repo.CreatePost(ctx, "Title 1", "Content", "", "Author", 0, nil, createdAt)
+	require.NoError(t, err)
+	post2, err := repo.CreatePost(ctx, "Title 2", "Content", "", "Author", 0, nil, createdAt)
+	require.NoError(t, err)
+
+	require.NoError(t, repo.Delete(ctx, post2.ID, repositories.AnyVersion))
+
+	post3, err := repo.CreatePost(ctx, "Title 3", "Content", "", "Author", 0, nil, createdAt)
+	require.NoError(t, err)
+	assert.Equal(t, 3, post3.ID)
+}
+
+func testCreateExplicitID(t *testing.T, repo repositories.PostRepository) {
+	ctx := context.Background()
+	require.NoError(t, repo.Create(ctx, mustPost(t, 10, "Ten")))
+
+	stored, err := repo.GetByID(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, "Ten", stored.Title)
+
+	next, err := repo.CreatePost(ctx, "Next", "Content", "", "Author", 0, nil, createdAt)
+	require.NoError(t, err)
+	assert.Equal(t, 11, next.ID)
+
+	// A lower explicit ID fills the gap without moving the sequence back.
+	require.NoError(t, repo.Create(ctx, mustPost(t, 5, "Five")))
+	after, err := repo.CreatePost(ctx, "After", "Content", "", "Author", 0, nil, createdAt)
+	require.NoError(t, err)
+	assert.Equal(t, 12, after.ID)
+}
+
+func testCreateDuplicate(t *testing.T, repo repositories.PostRepository) {
+	ctx := context.Background()
+	require.NoError(t, repo.Create(ctx, mustPost(t, 1, "Original")))
+
+	err := repo.Create(ctx, mustPost(t, 1, "Duplicate"))
+	assert.ErrorIs(t, err, repositories.ErrPostExists)
+
+	stored, err := repo.GetByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Original", stored.Title)
+}
+
+func testGetByIDNotFound(t *testing.T, repo repositories.PostRepository) {
+	ctx := context.Background()
+	post, err := repo.GetByID(ctx, 42)
+	assert.ErrorIs(t, err, repositories.ErrPostNotFound)
+	assert.Nil(t, post)
+}
+
+func testGetAllOrdering(t *testing.T, repo repositories.PostRepository) {
+	ctx := context.Background()
+	posts, err := repo.GetAll(ctx)
+	require.NoError(t, err)
+	assert.NotNil(t, posts)
+	assert.Empty(t, posts)
+
+	for _, id := range []int{3, 1, 2} {
+		require.NoError(t, repo.Create(ctx, mustPost(t, id, fmt.Sprintf("Title %d", id))))
+	}
+
+	posts, err = repo.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, postIDs(posts))
+}
+
+func testCopyIsolation(t *testing.T, repo repositories.PostRepository) {
+	ctx := context.Background()
+	input := mustPost(t, 1, "Original")
+	require.NoError(t, repo.Create(ctx, input))
+	input.Title = "Changed after Create"
+
+	created, err := repo.CreatePost(ctx, "Created", "Content", "", "Author", 0, nil, createdAt)
+	require.NoError(t, err)
+	created.Title = "Changed after CreatePost"
+
+	fetched, err := repo.GetByID(ctx, 1)
+	require.NoError(t, err)
+	fetched.Title = "Changed after GetByID"
+
+	all, err := repo.GetAll(ctx)
+	require.NoError(t, err)
+	for _, post := range all {
+		post.Title = "Changed after GetAll"
+	}
+
+	updated := mustPost(t, 1, "Updated")
+	require.NoError(t, repo.Update(ctx, 1, updated, repositories.AnyVersion))
+	updated.Title = "Changed after Update"
+
+	loaded := []*entities.Post{mustPost(t, 5, "Loaded")}
+	require.NoError(t, repo.LoadData(ctx, loaded))
+	loaded[0].Title = "Changed after LoadData"
+
+	stored, err := repo.GetAll(ctx)
+	require.NoError(t, err)
+	require.Len(t, stored, 3)
+	assert.Equal(t, "Updated", stored[0].Title)
+	assert.Equal(t, "Created", stored[1].Title)
+	assert.Equal(t, "Loaded", stored[2].Title)
+}
+
+func testUpdate(t *testing.T, repo repositories.PostRepository) {
+	ctx := context.Background()
+	err := repo.Update(ctx, 1, mustPost(t, 1, "Missing"), repositories.AnyVersion)
+	assert.ErrorIs(t, err, repositories.ErrPostNotFound)
+
+	require.NoError(t, repo.Create(ctx, mustPost(t, 1, "Original")))
+	require.NoError(t, repo.Create(ctx, mustPost(t, 2, "Other")))
+
+	// The id argument wins over the ID carried by the post.
+	require.NoError(t, repo.Update(ctx, 1, mustPost(t, 2, "Updated"), repositories.AnyVersion))
+
+	stored, err := repo.GetByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stored.ID)
+	assert.Equal(t, "Updated", stored.Title)
+	assert.Equal(t, "Content of Updated", stored.Content)
+	assert.Equal(t, "Author of Updated", stored.Author)
+
+	other, err := repo.GetByID(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "Other", other.Title)
+}
+
+func testDelete(t *testing.T, repo repositories.PostRepository) {
+	ctx := context.Background()
+	assert.ErrorIs(t, repo.Delete(ctx, 1, repositories.AnyVersion), repositories.ErrPostNotFound)
+
+	require.NoError(t, repo.Create(ctx, mustPost(t, 1, "First")))
+	require.NoError(t, repo.Create(ctx, mustPost(t, 2, "Second")))
+
+	require.NoError(t, repo.Delete(ctx, 1, repositories.AnyVersion))
+	assert.ErrorIs(t, repo.Delete(ctx, 1, repositories.AnyVersion), repositories.ErrPostNotFound)
+
+	_, err := repo.GetByID(ctx, 1)
+	assert.ErrorIs(t, err, repositories.ErrPostNotFound)
+
+	posts, err := repo.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int{2}, postIDs(posts))
+}
+
+func testExists(t *testing.T, repo repositories.PostRepository) {
+	ctx := context.Background()
+	assert.False(t, repo.Exists(ctx, 1))
+
+	require.NoError(t, repo.Create(ctx, mustPost(t, 1, "First")))
+	assert.True(t, repo.Exists(ctx, 1))
+
+	require.NoError(t, repo.Delete(ctx, 1, repositories.AnyVersion))
+	assert.False(t, repo.Exists(ctx, 1))
+}
+
+func testLoadData(t *testing.T, repo repositories.PostRepository) {
+	ctx := context.Background()
+	require.NoError(t, repo.LoadData(ctx, nil))
+
+	require.NoError(t, repo.Create(ctx, mustPost(t, 2, "Existing")))
+
+	require.NoError(t, repo.LoadData(ctx, []*entities.Post{
+		mustPost(t, 3, "Three"),
+		mustPost(t, 1, "One"),
+		mustPost(t, 2, "Replaced"),
+	}))
+
+	posts, err := repo.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, postIDs(posts))
+	assert.Equal(t, "Replaced", posts[1].Title, "LoadData overwrites posts with the same ID")
+
+	next, err := repo.CreatePost(ctx, "Next", "Content", "", "Author", 0, nil, createdAt)
+	require.NoError(t, err)
+	assert.Equal(t, 4, next.ID)
+}
+
+func testConcurrentCreatePost(t *testing.T, repo repositories.PostRepository) {
+	ctx := context.Background()
+	const workers = 20
+
+	var wg sync.WaitGroup
+	ids := make(chan int, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			post, err := repo.CreatePost(ctx, fmt.Sprintf("Title %d", i), "Content", "", "Author", 0, nil, createdAt)
+			if assert.NoError(t, err) {
+				ids <- post.ID
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(ids)
+
+	seen := make(map[int]bool)
+	for id := range ids {
+		assert.False(t, seen[id], "duplicate ID %d", id)
+		seen[id] = true
+	}
+	assert.Len(t, seen, workers)
+
+	posts, err := repo.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Len(t, posts, workers)
+}
+
+func testConcurrentReadWrite(t *testing.T, repo repositories.PostRepository) {
+	ctx := context.Background()
+	for i := 1; i <= 5; i++ {
+		require.NoError(t, repo.Create(ctx, mustPost(t, i, fmt.Sprintf("Title %d", i))))
+	}
+
+	var wg sync.WaitGroup
+	for i := 1; i <= 5; i++ {
+		wg.Add(3)
+		go func(id int) {
+			defer wg.Done()
+			assert.NoError(t, repo.Update(ctx, id, mustPost(t, id, fmt.Sprintf("Updated %d", id)), repositories.AnyVersion))
+		}(i)
+		go func(id int) {
+			defer wg.Done()
+			post, err := repo.GetByID(ctx, id)
+			if assert.NoError(t, err) {
+				assert.Equal(t, id, post.ID)
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			_, err := repo.GetAll(ctx)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	posts, err := repo.GetAll(ctx)
+	require.NoError(t, err)
+	require.Len(t, posts, 5)
+	for _, post := range posts {
+		assert.Equal(t, fmt.Sprintf("Updated %d", post.ID), post.Title)
+	}
+}
+
+func testCancelledContext(t *testing.T, repo repositories.PostRepository) {
+	require.NoError(t, repo.Create(context.Background(), mustPost(t, 1, "Existing")))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := repo.CreatePost(ctx, "Title", "Content", "", "Author", 0, nil, createdAt)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorIs(t, repo.Create(ctx, mustPost(t, 2, "New")), context.Canceled)
+	_, err = repo.GetByID(ctx, 1)
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = repo.GetAll(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = repo.ListPosts(ctx, repositories.PostQuery{Limit: 10})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorIs(t, repo.Update(ctx, 1, mustPost(t, 1, "Updated"), repositories.AnyVersion), context.Canceled)
+	assert.ErrorIs(t, repo.Delete(ctx, 1, repositories.AnyVersion), context.Canceled)
+	assert.ErrorIs(t, repo.LoadData(ctx, []*entities.Post{mustPost(t, 3, "Loaded")}), context.Canceled)
+	assert.ErrorIs(t, repo.Trash(ctx, 1, repositories.AnyVersion, time.Now()), context.Canceled)
+	_, err = repo.GetTrash(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = repo.Restore(ctx, 1)
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = repo.Purge(ctx, time.Now())
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, repo.Exists(ctx, 1))
+
+	posts, err := repo.GetAll(context.Background())
+	require.NoError(t, err)
+	require.Len(t, posts, 1, "abandoned calls must not change the repository")
+	assert.Equal(t, "Existing", posts[0].Title)
+}
+
+func testVersionCheck(t *testing.T, repo repositories.PostRepository) {
+	ctx := context.Background()
+
+	post, err := repo.CreatePost(ctx, "Title", "Content", "", "Author", 0, nil, createdAt)
+	require.NoError(t, err)
+	assert.Equal(t, 1, post.Version)
+
+	assert.ErrorIs(t, repo.Update(ctx, 99, post, 1), repositories.ErrPostNotFound)
+	assert.ErrorIs(t, repo.Delete(ctx, 99, 1), repositories.ErrPostNotFound)
+
+	require.NoError(t, post.Update("Second", "Content", "Author", updatedAt))
+	require.NoError(t, repo.Update(ctx, post.ID, post, 1))
+
+	stored, err := repo.GetByID(ctx, post.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stored.Version)
+	assert.Equal(t, "Second", stored.Title)
+
+	stale := *stored
+	require.NoError(t, stale.Update("Stale", "Content", "Author", updatedAt))
+	assert.ErrorIs(t, repo.Update(ctx, post.ID, &stale, 1), repositories.ErrVersionConflict)
+	assert.ErrorIs(t, repo.Delete(ctx, post.ID, 1), repositories.ErrVersionConflict)
+
+	stored, err = repo.GetByID(ctx, post.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Second", stored.Title, "a rejected update must not be written")
+
+	require.NoError(t, repo.Delete(ctx, post.ID, 2))
+	assert.False(t, repo.Exists(ctx, post.ID))
+}
+
+func testConcurrentCompareAndSwap(t *testing.T, repo repositories.PostRepository) {
+	ctx := context.Background()
+
+	post, err := repo.CreatePost(ctx, "Title", "Content", "", "Author", 0, nil, createdAt)
+	require.NoError(t, err)
+
+	const writers = 10
+
+	var wg sync.WaitGroup
+	results := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			edit := *post
+			if !assert.NoError(t, edit.Update(fmt.Sprintf("Edit %d", i), "Content", "Author", updatedAt)) {
+				return
+			}
+			results <- repo.Update(ctx, post.ID, &edit, post.Version)
+		}(i)
+	}
+	wg.Wait()
+	close(results)
+
+	succeeded := 0
+	for err := range results {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		assert.ErrorIs(t, err, repositories.ErrVersionConflict)
+	}
+	assert.Equal(t, 1, succeeded, "exactly one writer may win")
+
+	stored, err := repo.GetByID(ctx, post.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stored.Version)
+}
+
+// trashedAt is a fixed point in time for trash tests; sub-second precision is
+// avoided so every backend stores it exactly.
+var trashedAt = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// createdAt and updatedAt stamp the posts of the suite. Unlike trashedAt they
+// carry nanoseconds, which every backend must keep.
+var (
+	createdAt = time.Date(2024, 2, 1, 9, 30, 0, 123456789, time.UTC)
+	updatedAt = createdAt.Add(time.Hour)
+)
+
+func testTrashHidesPost(t *testing.T, repo repositories.PostRepository) {
+	ctx := context.Background()
+
+	require.NoError(t, repo.Create(ctx, mustPost(t, 1, "Trashed")))
+	require.NoError(t, repo.Create(ctx, mustPost(t, 2, "Kept")))
+	require.NoError(t, repo.Trash(ctx, 1, repositories.AnyVersion, trashedAt))
+
+	_, err := repo.GetByID(ctx, 1)
+	assert.ErrorIs(t, err, repositories.ErrPostNotFound)
+	assert.False(t, repo.Exists(ctx, 1))
+
+	posts, err := repo.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int{2}, postIDs(posts))
+
+	assert.ErrorIs(t, repo.Update(ctx, 1, mustPost(t, 1, "Updated"), repositories.AnyVersion), repositories.ErrPostNotFound)
+	assert.ErrorIs(t, repo.Delete(ctx, 1, repositories.AnyVersion), repositories.ErrPostNotFound)
+	assert.ErrorIs(t, repo.Trash(ctx, 1, repositories.AnyVersion, trashedAt), repositories.ErrPostNotFound)
+	assert.ErrorIs(t, repo.Trash(ctx, 99, repositories.AnyVersion, trashedAt), repositories.ErrPostNotFound)
+
+	// The ID stays taken while the post is in the trash.
+	assert.ErrorIs(t, repo.Create(ctx, mustPost(t, 1, "Reused")), repositories.ErrPostExists)
+	created, err := repo.CreatePost(ctx, "New", "Content", "", "Author", 0, nil, createdAt)
+	require.NoError(t, err)
+	assert.Equal(t, 3, created.ID)
+
+	trash, err := repo.GetTrash(ctx)
+	require.NoError(t, err)
+	require.Len(t, trash, 1)
+	assert.Equal(t, 1, trash[0].ID)
+	assert.Equal(t, "Trashed", trash[0].Title)
+	require.NotNil(t, trash[0].DeletedAt)
+	assert.True(t, trashedAt.Equal(*trash[0].DeletedAt), "deleted at %v", *trash[0].DeletedAt)
+}
+
+func testTrashVersionCheck(t *testing.T, repo repositories.PostRepository) {
+	ctx := context.Background()
+
+	post, err := repo.CreatePost(ctx, "Title", "Content", "", "Author", 0, nil, createdAt)
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, repo.Trash(ctx, post.ID, post.Version+1, trashedAt), repositories.ErrVersionConflict)
+	assert.True(t, repo.Exists(ctx, post.ID))
+
+	require.NoError(t, repo.Trash(ctx, post.ID, post.Version, trashedAt))
+	assert.False(t, repo.Exists(ctx, post.ID))
+}
+
+func testRestore(t *testing.T, repo repositories.PostRepository) {
+	ctx := context.Background()
+
+	empty, err := repo.GetTrash(ctx)
+	require.NoError(t, err)
+	assert.NotNil(t, empty)
+	assert.Empty(t, empty)
+
+	require.NoError(t, repo.Create(ctx, mustPost(t, 7, "Seven")))
+
+	_, err = repo.Restore(ctx, 7)
+	assert.ErrorIs(t, err, repositories.ErrPostNotFound, "only trashed posts can be restored")
+	_, err = repo.Restore(ctx, 99)
+	assert.ErrorIs(t, err, repositories.ErrPostNotFound)
+
+	require.NoError(t, repo.Trash(ctx, 7, repositories.AnyVersion, trashedAt))
+	restored, err := repo.Restore(ctx, 7)
+	require.NoError(t, err)
+	assert.Equal(t, 7, restored.ID)
+	assert.Equal(t, "Seven", restored.Title)
+	assert.Nil(t, restored.DeletedAt)
+
+	stored, err := repo.GetByID(ctx, 7)
+	require.NoError(t, err)
+	assert.Equal(t, "Seven", stored.Title)
+	assert.Nil(t, stored.DeletedAt)
+
+	trash, err := repo.GetTrash(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, trash)
+}
+
+func testPurge(t *testing.T, repo repositories.PostRepository) {
+	ctx := context.Background()
+
+	for id := 1; id <= 4; id++ {
+		require.NoError(t, repo.Create(ctx, mustPost(t, id, fmt.Sprintf("Title %d", id))))
+	}
+	require.NoError(t, repo.Trash(ctx, 3, repositories.AnyVersion, trashedAt.Add(-2*time.Hour)))
+	require.NoError(t, repo.Trash(ctx, 1, repositories.AnyVersion, trashedAt.Add(-time.Hour)))
+	require.NoError(t, repo.Trash(ctx, 2, repositories.AnyVersion, trashedAt))
+
+	purged, err := repo.Purge(ctx, trashedAt)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 3}, purged)
+
+	trash, err := repo.GetTrash(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int{2}, postIDs(trash), "posts trashed at the cutoff are kept")
+
+	posts, err := repo.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int{4}, postIDs(posts), "live posts are never purged")
+
+	_, err = repo.Restore(ctx, 1)
+	assert.ErrorIs(t, err, repositories.ErrPostNotFound)
+
+	purged, err = repo.Purge(ctx, trashedAt)
+	require.NoError(t, err)
+	assert.NotNil(t, purged)
+	assert.Empty(t, purged)
+
+	// Purged IDs are not handed out again.
+	created, err := repo.CreatePost(ctx, "New", "Content", "", "Author", 0, nil, createdAt)
+	require.NoError(t, err)
+	assert.Equal(t, 5, created.ID)
+}
+
+// collectPages follows the next (or, backwards, the previous) cursors from
+// the first page of query, keeping its filter and order, and returns the IDs
+// of every page.
+func collectPages(t *testing.T, repo repositories.PostRepository, query repositories.PostQuery, backwards bool) [][]int {
+	t.Helper()
+
+	var pages [][]int
+	for i := 0; i < 100; i++ {
+		page, err := repo.ListPosts(context.Background(), query)
+		require.NoError(t, err)
+		pages = append(pages, postIDs(page.Posts))
+
+		more := page.HasNext
+		if backwards {
+			more = page.HasPrev
+		}
+		if !more {
+			return pages
+		}
+		require.NotEmpty(t, page.Posts, "a page with more posts beyond it must not be empty")
+
+		query.After, query.Before = nil, nil
+		if backwards {
+			cursor := query.Sort.CursorFor(page.Posts[0])
+			query.Before = &cursor
+		} else {
+			cursor := query.Sort.CursorFor(page.Posts[len(page.Posts)-1])
+			query.After = &cursor
+		}
+	}
+
+	t.Fatal("pagination did not terminate")
+	return nil
+}
+
+func testListPosts(t *testing.T, repo repositories.PostRepository) {
+	ctx := context.Background()
+
+	page, err := repo.ListPosts(ctx, repositories.PostQuery{Limit: 3})
+	require.NoError(t, err)
+	assert.NotNil(t, page.Posts)
+	assert.Empty(t, page.Posts)
+	assert.False(t, page.HasPrev)
+	assert.False(t, page.HasNext)
+
+	for id := 1; id <= 8; id++ {
+		require.NoError(t, repo.Create(ctx, mustPost(t, id, fmt.Sprintf("Title %d", id))))
+	}
+	require.NoError(t, repo.Trash(ctx, 4, repositories.AnyVersion, trashedAt))
+
+	page, err = repo.ListPosts(ctx, repositories.PostQuery{Limit: 3})
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, postIDs(page.Posts))
+	assert.Equal(t, "Title 1", page.Posts[0].Title)
+	assert.False(t, page.HasPrev)
+	assert.True(t, page.HasNext)
+
+	page, err = repo.ListPosts(ctx, repositories.PostQuery{Limit: 3, After: &repositories.Cursor{ID: 3}})
+	require.NoError(t, err)
+	assert.Equal(t, []int{5, 6, 7}, postIDs(page.Posts), "trashed posts are skipped")
+	assert.True(t, page.HasPrev)
+	assert.True(t, page.HasNext)
+
+	page, err = repo.ListPosts(ctx, repositories.PostQuery{Limit: 3, Before: &repositories.Cursor{ID: 6}})
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 3, 5}, postIDs(page.Posts))
+	assert.True(t, page.HasPrev)
+	assert.True(t, page.HasNext)
+
+	page, err = repo.ListPosts(ctx, repositories.PostQuery{Limit: 3, Before: &repositories.Cursor{ID: 3}})
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, postIDs(page.Posts))
+	assert.False(t, page.HasPrev)
+	assert.True(t, page.HasNext)
+
+	page, err = repo.ListPosts(ctx, repositories.PostQuery{Limit: 3, After: &repositories.Cursor{ID: 8}})
+	require.NoError(t, err)
+	assert.Empty(t, page.Posts)
+	assert.True(t, page.HasPrev)
+	assert.False(t, page.HasNext)
+
+	assert.Equal(t, [][]int{{1, 2, 3}, {5, 6, 7}, {8}},
+		collectPages(t, repo, repositories.PostQuery{Limit: 3}, false))
+	assert.Equal(t, [][]int{{6, 7, 8}, {2, 3, 5}, {1}},
+		collectPages(t, repo, repositories.PostQuery{Limit: 3, Before: &repositories.Cursor{ID: 9}}, true))
+}
+
+func testListPostsStableCursors(t *testing.T, repo repositories.PostRepository) {
+	ctx := context.Background()
+
+	for id := 1; id <= 6; id++ {
+		require.NoError(t, repo.Create(ctx, mustPost(t, id, fmt.Sprintf("Title %d", id))))
+	}
+
+	first, err := repo.ListPosts(ctx, repositories.PostQuery{Limit: 2})
+	require.NoError(t, err)
+	require.Equal(t, []int{1, 2}, postIDs(first.Posts))
+
+	// The post the cursor points at and a post on the current page go away,
+	// and a new post is appended, between two page requests.
+	require.NoError(t, repo.Delete(ctx, 2, repositories.AnyVersion))
+	require.NoError(t, repo.Trash(ctx, 1, repositories.AnyVersion, trashedAt))
+	created, err := repo.CreatePost(ctx, "New", "Content", "", "Author", 0, nil, createdAt)
+	require.NoError(t, err)
+
+	next := &repositories.Cursor{ID: first.Posts[len(first.Posts)-1].ID}
+	assert.Equal(t, [][]int{{3, 4}, {5, 6}, {created.ID}},
+		collectPages(t, repo, repositories.PostQuery{Limit: 2, After: next}, false),
+		"no post is skipped or repeated")
+}
+
+// createListingFixture stores posts with repeated titles and authors, so
+// sorting needs the ID tiebreak, and trashes one of them.
+func createListingFixture(t *testing.T, repo repositories.PostRepository) {
+	t.Helper()
+	ctx := context.Background()
+
+	fixture := []struct{ title, content, author string }{
+		{"Go tips", "Use gofmt", "alice"},
+		{"Rust tips", "Use cargo fmt", "bob"},
+		{"Go tips", "Use go vet", "alicia"},
+		{"Trashed Go post", "Use gofmt", "alice"},
+		{"About me", "Hello from Alice", "Alice"},
+		{"Go tips", "Use golint", "bob"},
+	}
+	for i, f := range fixture {
+		post, err := entities.NewPost(i+1, f.title, f.content, f.author, createdAt)
+		require.NoError(t, err)
+		require.NoError(t, repo.Create(ctx, post))
+	}
+	require.NoError(t, repo.Trash(ctx, 4, repositories.AnyVersion, trashedAt))
+}
+
+func testListPostsFilter(t *testing.T, repo repositories.PostRepository) {
+	createListingFixture(t, repo)
+
+	testCases := []struct {
+		name     string
+		filter   repositories.PostFilter
+		expected []int
+	}{
+		{name: "no filter", filter: repositories.PostFilter{}, expected: []int{1, 2, 3, 5, 6}},
+		{name: "exact author", filter: repositories.PostFilter{Author: "alice"}, expected: []int{1}},
+		{name: "author prefix", filter: repositories.PostFilter{AuthorPrefix: "ali"}, expected: []int{1, 3}},
+		{name: "title substring", filter: repositories.PostFilter{TitleContains: "Go"}, expected: []int{1, 3, 6}},
+		{name: "content substring", filter: repositories.PostFilter{ContentContains: "fmt"}, expected: []int{1, 2}},
+		{name: "case-sensitive", filter: repositories.PostFilter{ContentContains: "alice"}, expected: []int{}},
+		{name: "ID range", filter: repositories.PostFilter{MinID: 2, MaxID: 5}, expected: []int{2, 3, 5}},
+		{name: "open ID range", filter: repositories.PostFilter{MinID: 3}, expected: []int{3, 5, 6}},
+		{name: "combined", filter: repositories.PostFilter{TitleContains: "tips", Author: "bob", MaxID: 5}, expected: []int{2}},
+		{name: "empty ID range", filter: repositories.PostFilter{MinID: 7}, expected: []int{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			page, err := repo.ListPosts(context.Background(), repositories.PostQuery{Limit: 10, Filter: tc.filter})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, postIDs(page.Posts))
+			assert.False(t, page.HasPrev)
+			assert.False(t, page.HasNext)
+		})
+	}
+
+	// Paging keeps to the filter on both sides of the cursor.
+	filter := repositories.PostFilter{TitleContains: "Go"}
+	assert.Equal(t, [][]int{{1, 3}, {6}},
+		collectPages(t, repo, repositories.PostQuery{Limit: 2, Filter: filter}, false))
+
+	page, err := repo.ListPosts(context.Background(), repositories.PostQuery{
+		Limit: 2, Filter: filter, Before: &repositories.Cursor{ID: 6},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 3}, postIDs(page.Posts))
+	assert.False(t, page.HasPrev)
+	assert.True(t, page.HasNext)
+}
+
+func testListPostsSort(t *testing.T, repo repositories.PostRepository) {
+	createListingFixture(t, repo)
+
+	testCases := []struct {
+		sort     repositories.PostSort
+		expected [][]int
+	}{
+		{sort: "", expected: [][]int{{1, 2}, {3, 5}, {6}}},
+		{sort: repositories.SortByID, expected: [][]int{{1, 2}, {3, 5}, {6}}},
+		{sort: repositories.SortByIDDesc, expected: [][]int{{6, 5}, {3, 2}, {1}}},
+		// Ties on the key are broken by ascending ID, also across pages.
+		{sort: repositories.SortByTitle, expected: [][]int{{5, 1}, {3, 6}, {2}}},
+		{sort: repositories.SortByAuthor, expected: [][]int{{5, 1}, {3, 2}, {6}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.sort), func(t *testing.T) {
+			query := repositories.PostQuery{Limit: 2, Sort: tc.sort}
+			forward := collectPages(t, repo, query, false)
+			assert.Equal(t, tc.expected, forward)
+
+			// Walking back from the last page yields the same pages.
+			last, err := repo.ListPosts(context.Background(), repositories.PostQuery{
+				Limit: 2, Sort: tc.sort,
+				After: cursorAt(t, repo, tc.sort, forward[len(forward)-2]),
+			})
+			require.NoError(t, err)
+			require.NotEmpty(t, last.Posts)
+			first := tc.sort.CursorFor(last.Posts[0])
+			backward := collectPages(t, repo, repositories.PostQuery{Limit: 2, Sort: tc.sort, Before: &first}, true)
+			assert.Equal(t, [][]int{tc.expected[1], tc.expected[0]}, backward)
+		})
+	}
+}
+
+// cursorAt returns the cursor of the last post of ids in the given order.
+func cursorAt(t *testing.T, repo repositories.PostRepository, sort repositories.PostSort, ids []int) *repositories.Cursor {
+	t.Helper()
+
+	post, err := repo.GetByID(context.Background(), ids[len(ids)-1])
+	require.NoError(t, err)
+	cursor := sort.CursorFor(post)
+	return &cursor
+}
+
+func testTimestamps(t *testing.T, repo repositories.PostRepository) {
+	ctx := context.Background()
+
+	created, err := repo.CreatePost(ctx, "Title", "Content", "", "Author", 0, nil, createdAt)
+	require.NoError(t, err)
+	assert.Equal(t, createdAt, created.CreatedAt)
+	assert.Equal(t, createdAt, created.UpdatedAt)
+
+	stored, err := repo.GetByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, createdAt, stored.CreatedAt)
+	assert.Equal(t, createdAt, stored.UpdatedAt)
+
+	require.NoError(t, stored.Update("Edited", "Content", "Author", updatedAt))
+	require.NoError(t, repo.Update(ctx, stored.ID, stored, 1))
+	stored, err = repo.GetByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, createdAt, stored.CreatedAt)
+	assert.Equal(t, updatedAt, stored.UpdatedAt)
+
+	loaded := mustPost(t, 10, "Loaded")
+	loaded.CreatedAt = time.Date(2020, 5, 6, 7, 8, 9, 10, time.UTC)
+	loaded.UpdatedAt = loaded.CreatedAt.Add(time.Minute)
+	require.NoError(t, repo.LoadData(ctx, []*entities.Post{loaded}))
+	stored, err = repo.GetByID(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, loaded.CreatedAt, stored.CreatedAt)
+	assert.Equal(t, loaded.UpdatedAt, stored.UpdatedAt)
+
+	// Posts from before timestamps were recorded have zero times.
+	legacy := mustPost(t, 11, "Legacy")
+	legacy.CreatedAt, legacy.UpdatedAt = time.Time{}, time.Time{}
+	require.NoError(t, repo.Create(ctx, legacy))
+	stored, err = repo.GetByID(ctx, 11)
+	require.NoError(t, err)
+	assert.True(t, stored.CreatedAt.IsZero())
+	assert.True(t, stored.UpdatedAt.IsZero())
+}
+
+func testListPostsDateFilter(t *testing.T, repo repositories.PostRepository) {
+	ctx := context.Background()
+	day := func(d int) time.Time {
+		return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	// Post i is created on day i and updated on day 10-i.
+	for id := 1; id <= 5; id++ {
+		post := mustPost(t, id, fmt.Sprintf("Title %d", id))
+		post.CreatedAt = day(id)
+		post.UpdatedAt = day(10 - id)
+		require.NoError(t, repo.Create(ctx, post))
+	}
+	legacy := mustPost(t, 6, "Legacy")
+	legacy.CreatedAt, legacy.UpdatedAt = time.Time{}, time.Time{}
+	require.NoError(t, repo.Create(ctx, legacy))
+
+	testCases := []struct {
+		name     string
+		filter   repositories.PostFilter
+		expected []int
+	}{
+		{name: "created after is exclusive", filter: repositories.PostFilter{CreatedAfter: day(3)}, expected: []int{4, 5}},
+		{name: "created before is exclusive", filter: repositories.PostFilter{CreatedBefore: day(3)}, expected: []int{1, 2, 6}},
+		{name: "created between", filter: repositories.PostFilter{CreatedAfter: day(1), CreatedBefore: day(5)}, expected: []int{2, 3, 4}},
+		{name: "updated since is inclusive", filter: repositories.PostFilter{UpdatedSince: day(7)}, expected: []int{1, 2, 3}},
+		{name: "sub-second bound", filter: repositories.PostFilter{UpdatedSince: day(7).Add(time.Nanosecond)}, expected: []int{1, 2}},
+		{name: "combined with other filters", filter: repositories.PostFilter{UpdatedSince: day(6), MinID: 2}, expected: []int{2, 3, 4}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			page, err := repo.ListPosts(ctx, repositories.PostQuery{Limit: 10, Filter: tc.filter})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, postIDs(page.Posts))
+		})
+	}
+}
+
+func testStatus(t *testing.T, repo repositories.PostRepository) {
+	ctx := context.Background()
+
+	created, err := repo.CreatePost(ctx, "Title", "Content", "", "Author", 0, nil, createdAt)
+	require.NoError(t, err)
+	assert.Equal(t, entities.StatusDraft, created.Status)
+
+	stored, err := repo.GetByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, entities.StatusDraft, stored.Status)
+
+	require.NoError(t, stored.TransitionTo(entities.StatusReview, updatedAt))
+	require.NoError(t, repo.Update(ctx, stored.ID, stored, 1))
+	stored, err = repo.GetByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, entities.StatusReview, stored.Status)
+	assert.Equal(t, 2, stored.Version)
+	assert.Nil(t, stored.PublishAt)
+
+	publishAt := updatedAt.Add(24*time.Hour + time.Nanosecond)
+	require.NoError(t, stored.SchedulePublication(publishAt, updatedAt))
+	require.NoError(t, repo.Update(ctx, stored.ID, stored, 2))
+	stored, err = repo.GetByID(ctx, created.ID)
+	require.NoError(t, err)
+	require.NotNil(t, stored.PublishAt)
+	assert.Equal(t, publishAt, *stored.PublishAt)
+
+	require.NoError(t, stored.TransitionTo(entities.StatusPublished, publishAt))
+	require.NoError(t, repo.Update(ctx, stored.ID, stored, 3))
+	stored, err = repo.GetByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, entities.StatusPublished, stored.Status)
+	assert.Nil(t, stored.PublishAt)
+
+	loaded := mustPost(t, 10, "Loaded")
+	loaded.Status = entities.StatusArchived
+	require.NoError(t, repo.LoadData(ctx, []*entities.Post{loaded}))
+	stored, err = repo.GetByID(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, entities.StatusArchived, stored.Status)
+}
+
+func testTags(t *testing.T, repo repositories.PostRepository) {
+	ctx := context.Background()
+
+	created, err := repo.CreatePost(ctx, "Tagged", "Content", "", "Author", 0, []string{"Go", "Testing", "go"}, createdAt)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"go", "testing"}, created.Tags)
+
+	_, err = repo.CreatePost(ctx, "Bad", "Content", "", "Author", 0, []string{"not/ok"}, createdAt)
+	assert.ErrorIs(t, err, entities.ErrInvalidTag)
+
+	stored, err := repo.GetByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, created, stored)
+
+	require.NoError(t, stored.SetTags([]string{"go", "rust"}))
+	require.NoError(t, repo.Update(ctx, stored.ID, stored, repositories.AnyVersion))
+
+	other := mustPost(t, 10, "Other")
+	require.NoError(t, other.SetTags([]string{"rust"}))
+	require.NoError(t, repo.Create(ctx, other))
+	require.NoError(t, repo.Create(ctx, mustPost(t, 11, "Untagged")))
+
+	for tag, want := range map[string][]int{
+		"go":      {created.ID},
+		"rust":    {created.ID, 10},
+		"testing": {},
+	} {
+		page, err := repo.ListPosts(ctx, repositories.PostQuery{Limit: 10, Filter: repositories.PostFilter{Tag: tag}})
+		require.NoError(t, err)
+		assert.Equal(t, want, postIDs(page.Posts), tag)
+		for _, post := range page.Posts {
+			assert.True(t, post.HasTag(tag), "listed posts carry their tags")
+		}
+	}
+
+	all, err := repo.GetAll(ctx)
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.Equal(t, []string{"go", "rust"}, all[0].Tags)
+	assert.Equal(t, []string{"rust"}, all[1].Tags)
+	assert.Empty(t, all[2].Tags)
+
+	require.NoError(t, repo.Trash(ctx, 10, repositories.AnyVersion, updatedAt))
+	trash, err := repo.GetTrash(ctx)
+	require.NoError(t, err)
+	require.Len(t, trash, 1)
+	assert.Equal(t, []string{"rust"}, trash[0].Tags)
+	restored, err := repo.Restore(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"rust"}, restored.Tags)
+}
+
+func testAuthorIDs(t *testing.T, repo repositories.PostRepository) {
+	ctx := context.Background()
+
+	created, err := repo.CreatePost(ctx, "Linked", "Content", "", "Author", 7, nil, createdAt)
+	require.NoError(t, err)
+	assert.Equal(t, 7, created.AuthorID)
+
+	stored, err := repo.GetByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, created, stored)
+
+	other := mustPost(t, 10, "Other")
+	other.AuthorID = 8
+	require.NoError(t, repo.Create(ctx, other))
+	require.NoError(t, repo.Create(ctx, mustPost(t, 11, "Unlinked")))
+
+	stored.AuthorID = 8
+	require.NoError(t, repo.Update(ctx, stored.ID, stored, repositories.AnyVersion))
+
+	for authorID, want := range map[int][]int{
+		7: {},
+		8: {created.ID, 10},
+	} {
+		page, err := repo.ListPosts(ctx, repositories.PostQuery{Limit: 10, Filter: repositories.PostFilter{AuthorID: authorID}})
+		require.NoError(t, err)
+		assert.Equal(t, want, postIDs(page.Posts), authorID)
+	}
+
+	require.NoError(t, repo.LoadData(ctx, []*entities.Post{other}))
+	require.NoError(t, repo.Trash(ctx, 10, repositories.AnyVersion, updatedAt))
+	trash, err := repo.GetTrash(ctx)
+	require.NoError(t, err)
+	require.Len(t, trash, 1)
+	assert.Equal(t, 8, trash[0].AuthorID)
+}
+
+func testContentFormats(t *testing.T, repo repositories.PostRepository) {
+	ctx := context.Background()
+
+	plain, err := repo.CreatePost(ctx, "Plain", "Content", "", "Author", 0, nil, createdAt)
+	require.NoError(t, err)
+	assert.Equal(t, entities.FormatPlain, plain.ContentFormat, "posts are plain text by default")
+
+	created, err := repo.CreatePost(ctx, "Markdown", "*Content*", entities.FormatMarkdown, "Author", 0, nil, createdAt)
+	require.NoError(t, err)
+	assert.Equal(t, entities.FormatMarkdown, created.ContentFormat)
+
+	stored, err := repo.GetByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, created, stored)
+
+	_, err = repo.CreatePost(ctx, "HTML", "Content", "html", "Author", 0, nil, createdAt)
+	assert.ErrorIs(t, err, entities.ErrInvalidContentFormat)
+
+	require.NoError(t, stored.SetContentFormat(entities.FormatPlain))
+	require.NoError(t, repo.Update(ctx, stored.ID, stored, repositories.AnyVersion))
+	stored, err = repo.GetByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, entities.FormatPlain, stored.ContentFormat)
+
+	loaded := mustPost(t, 10, "Loaded")
+	require.NoError(t, loaded.SetContentFormat(entities.FormatMarkdown))
+	require.NoError(t, repo.LoadData(ctx, []*entities.Post{loaded}))
+	stored, err = repo.GetByID(ctx, loaded.ID)
+	require.NoError(t, err)
+	assert.Equal(t, entities.FormatMarkdown, stored.ContentFormat)
+}
+
+func testTagCounts(t *testing.T, repo repositories.PostRepository) {
+	ctx := context.Background()
+	counts := func() map[string]int {
+		t.Helper()
+		tagCounts, err := repo.TagCounts(ctx)
+		require.NoError(t, err)
+		m := make(map[string]int, len(tagCounts))
+		for _, count := range tagCounts {
+			m[count.Tag] = count.Posts
+		}
+		return m
+	}
+
+	draft, err := repo.CreatePost(ctx, "Draft", "Content", "", "Author", 0, []string{"go"}, createdAt)
+	require.NoError(t, err)
+	assert.Empty(t, counts(), "drafts are not counted")
+
+	published := func(id int, tags ...string) *entities.Post {
+		post := mustPost(t, id, fmt.Sprintf("Post %d", id))
+		post.Status = entities.StatusPublished
+		require.NoError(t, post.SetTags(tags))
+		return post
+	}
+	require.NoError(t, repo.Create(ctx, published(10, "go", "testing")))
+	require.NoError(t, repo.Create(ctx, published(11, "go")))
+	require.NoError(t, repo.LoadData(ctx, []*entities.Post{published(12, "rust", "go")}))
+	assert.Equal(t, map[string]int{"go": 3, "testing": 1, "rust": 1}, counts())
+
+	tagCounts, err := repo.TagCounts(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []repositories.TagCount{{Tag: "go", Posts: 3}, {Tag: "rust", Posts: 1}, {Tag: "testing", Posts: 1}},
+		tagCounts, "most used first, then by tag")
+
+	require.NoError(t, draft.TransitionTo(entities.StatusReview, updatedAt))
+	require.NoError(t, draft.TransitionTo(entities.StatusPublished, updatedAt))
+	require.NoError(t, repo.Update(ctx, draft.ID, draft, 1))
+	assert.Equal(t, 4, counts()["go"], "publishing counts the post")
+
+	retagged := published(10, "rust")
+	require.NoError(t, repo.Update(ctx, 10, retagged, repositories.AnyVersion))
+	assert.Equal(t, map[string]int{"go": 3, "rust": 2}, counts())
+
+	// A rejected write leaves the counts alone.
+	assert.ErrorIs(t, repo.Update(ctx, 10, published(10, "java"), 99), repositories.ErrVersionConflict)
+	assert.NotContains(t, counts(), "java")
+
+	require.NoError(t, repo.Trash(ctx, 11, repositories.AnyVersion, updatedAt))
+	assert.Equal(t, 2, counts()["go"], "trashed posts are not counted")
+	_, err = repo.Restore(ctx, 11)
+	require.NoError(t, err)
+	assert.Equal(t, 3, counts()["go"])
+
+	require.NoError(t, repo.Trash(ctx, 11, repositories.AnyVersion, updatedAt))
+	_, err = repo.Purge(ctx, updatedAt.Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, 2, counts()["go"])
+
+	require.NoError(t, repo.Delete(ctx, 12, repositories.AnyVersion))
+	assert.Equal(t, map[string]int{"go": 1, "rust": 1}, counts())
+
+	archived := published(10, "rust")
+	archived.Status = entities.StatusArchived
+	require.NoError(t, repo.Update(ctx, 10, archived, repositories.AnyVersion))
+	assert.Equal(t, map[string]int{"go": 1}, counts())
+}
+
+func testSlugs(t *testing.T, repo repositories.PostRepository) {
+	ctx := context.Background()
+
+	first, err := repo.CreatePost(ctx, "Hello, World", "Content", "", "Author", 0, nil, createdAt)
+	require.NoError(t, err)
+	assert.Equal(t, "hello-world", first.Slug)
+	second, err := repo.CreatePost(ctx, "Hello World!", "Content", "", "Author", 0, nil, createdAt)
+	require.NoError(t, err)
+	assert.Equal(t, "hello-world-2", second.Slug)
+
+	explicit := mustPost(t, 10, "Crème Brûlée")
+	require.NoError(t, repo.Create(ctx, explicit))
+	assert.Equal(t, "creme-brulee", explicit.Slug, "Create sets the slug on the given post")
+
+	stored, err := repo.GetBySlug(ctx, "hello-world-2")
+	require.NoError(t, err)
+	assert.Equal(t, second, stored)
+	_, err = repo.GetBySlug(ctx, "missing")
+	assert.ErrorIs(t, err, repositories.ErrPostNotFound)
+
+	// A title that still yields the slug keeps it.
+	stored.Title = "Hello world"
+	require.NoError(t, repo.Update(ctx, stored.ID, stored, repositories.AnyVersion))
+	assert.Equal(t, "hello-world-2", stored.Slug)
+	assert.Empty(t, stored.PreviousSlugs)
+
+	stored.Title = "Goodbye"
+	stored.Slug = "ignored"
+	require.NoError(t, repo.Update(ctx, stored.ID, stored, repositories.AnyVersion))
+	assert.Equal(t, "goodbye", stored.Slug)
+	assert.Equal(t, []string{"hello-world-2"}, stored.PreviousSlugs)
+
+	for _, slug := range []string{"goodbye", "hello-world-2"} {
+		found, err := repo.GetBySlug(ctx, slug)
+		require.NoError(t, err, slug)
+		assert.Equal(t, stored, found, "%s finds the post under its current slug", slug)
+	}
+
+	// Retired slugs stay with their post.
+	third, err := repo.CreatePost(ctx, "Hello World", "Content", "", "Author", 0, nil, createdAt)
+	require.NoError(t, err)
+	assert.Equal(t, "hello-world-3", third.Slug)
+
+	page, err := repo.ListPosts(ctx, repositories.PostQuery{Limit: 10})
+	require.NoError(t, err)
+	slugs := make([]string, len(page.Posts))
+	for i, post := range page.Posts {
+		slugs[i] = post.Slug
+	}
+	assert.Equal(t, []string{"hello-world", "goodbye", "creme-brulee", "hello-world-3"}, slugs)
+	assert.Equal(t, []string{"hello-world-2"}, page.Posts[1].PreviousSlugs)
+
+	// Slugs that are already taken are rejected, and nothing is stored.
+	clash := mustPost(t, 20, "Clash")
+	clash.Slug = "hello-world-2"
+	assert.ErrorIs(t, repo.Create(ctx, clash), repositories.ErrSlugExists)
+	assert.False(t, repo.Exists(ctx, 20))
+
+	loaded := mustPost(t, 21, "Loaded")
+	loaded.Slug, loaded.PreviousSlugs = "loaded", []string{"creme-brulee"}
+	assert.ErrorIs(t, repo.LoadData(ctx, []*entities.Post{mustPost(t, 22, "Fine"), loaded}), repositories.ErrSlugExists)
+	assert.False(t, repo.Exists(ctx, 22), "a failed load stores nothing")
+
+	loaded.PreviousSlugs = []string{"old-loaded"}
+	require.NoError(t, repo.LoadData(ctx, []*entities.Post{loaded}))
+	found, err := repo.GetBySlug(ctx, "old-loaded")
+	require.NoError(t, err)
+	assert.Equal(t, 21, found.ID)
+	assert.Equal(t, "loaded", found.Slug)
+}
+
+func testSlugReservation(t *testing.T, repo repositories.PostRepository) {
+	ctx := context.Background()
+
+	trashed, err := repo.CreatePost(ctx, "Same Title", "Content", "", "Author", 0, nil, createdAt)
+	require.NoError(t, err)
+	require.NoError(t, repo.Trash(ctx, trashed.ID, repositories.AnyVersion, trashedAt))
+
+	_, err = repo.GetBySlug(ctx, "same-title")
+	assert.ErrorIs(t, err, repositories.ErrPostNotFound, "trashed posts are not found by slug")
+
+	live, err := repo.CreatePost(ctx, "Same Title", "Content", "", "Author", 0, nil, createdAt)
+	require.NoError(t, err)
+	assert.Equal(t, "same-title-2", live.Slug, "trashed posts keep their slug")
+
+	restored, err := repo.Restore(ctx, trashed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "same-title", restored.Slug)
+	found, err := repo.GetBySlug(ctx, "same-title")
+	require.NoError(t, err)
+	assert.Equal(t, trashed.ID, found.ID)
+
+	require.NoError(t, repo.Delete(ctx, trashed.ID, repositories.AnyVersion))
+	again, err := repo.CreatePost(ctx, "Same Title", "Content", "", "Author", 0, nil, createdAt)
+	require.NoError(t, err)
+	assert.Equal(t, "same-title", again.Slug, "deleted posts release their slug")
+
+	require.NoError(t, repo.Trash(ctx, again.ID, repositories.AnyVersion, trashedAt))
+	_, err = repo.Purge(ctx, trashedAt.Add(time.Second))
+	require.NoError(t, err)
+	last, err := repo.CreatePost(ctx, "Same Title", "Content", "", "Author", 0, nil, createdAt)
+	require.NoError(t, err)
+	assert.Equal(t, "same-title", last.Slug, "purged posts release their slug")
+}
+
+func testConcurrentSlugs(t *testing.T, repo repositories.PostRepository) {
+	ctx := context.Background()
+	const writers = 20
+
+	slugs := make([]string, writers)
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			post, err := repo.CreatePost(ctx, "Popular Title", "Content", "", "Author", 0, nil, createdAt)
+			if assert.NoError(t, err) {
+				slugs[i] = post.Slug
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	seen := make(map[string]bool, writers)
+	for _, slug := range slugs {
+		assert.False(t, seen[slug], "slug %q handed out twice", slug)
+		seen[slug] = true
+	}
+	assert.True(t, seen["popular-title"])
+	assert.True(t, seen[fmt.Sprintf("popular-title-%d", writers)])
+}
+
+func testListPostsStatusFilter(t *testing.T, repo repositories.PostRepository) {
+	ctx := context.Background()
+
+	for id, fixture := range map[int]struct {
+		author string
+		status entities.PostStatus
+	}{
+		1: {"alice", entities.StatusPublished},
+		2: {"alice", entities.StatusDraft},
+		3: {"bob", entities.StatusReview},
+		4: {"bob", entities.StatusPublished},
+		5: {"alice", entities.StatusArchived},
+	} {
+		post, err := entities.NewPost(id, fmt.Sprintf("Title %d", id), "Content", fixture.author, createdAt)
+		require.NoError(t, err)
+		post.Status = fixture.status
+		require.NoError(t, repo.Create(ctx, post))
+	}
+
+	testCases := []struct {
+		name     string
+		filter   repositories.PostFilter
+		expected []int
+	}{
+		{name: "status", filter: repositories.PostFilter{Status: entities.StatusPublished}, expected: []int{1, 4}},
+		{name: "anonymous viewer", filter: repositories.PostFilter{Viewer: &repositories.Viewer{}}, expected: []int{1, 4}},
+		{name: "named viewer", filter: repositories.PostFilter{Viewer: &repositories.Viewer{Author: "alice"}}, expected: []int{1, 2, 4, 5}},
+		{name: "viewer spelled differently", filter: repositories.PostFilter{Viewer: &repositories.Viewer{Author: " ALICE "}}, expected: []int{1, 2, 4, 5}},
+		{
+			name:     "viewer and status",
+			filter:   repositories.PostFilter{Viewer: &repositories.Viewer{Author: "alice"}, Status: entities.StatusDraft},
+			expected: []int{2},
+		},
+		{
+			name:     "viewer cannot see drafts of others",
+			filter:   repositories.PostFilter{Viewer: &repositories.Viewer{Author: "alice"}, Status: entities.StatusReview},
+			expected: []int{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			page, err := repo.ListPosts(ctx, repositories.PostQuery{Limit: 10, Filter: tc.filter})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, postIDs(page.Posts))
+		})
+	}
+}
+
+func testListPostsViewerPaging(t *testing.T, repo repositories.PostRepository) {
+	ctx := context.Background()
+
+	// Published posts alternate with unpublished ones of alice and bob.
+	for id := 1; id <= 9; id++ {
+		author, status := "bob", entities.StatusDraft
+		switch id % 3 {
+		case 1:
+			status = entities.StatusPublished
+		case 2:
+			author = "alice"
+		}
+		post, err := entities.NewPost(id, fmt.Sprintf("Title %d", id), "Content", author, createdAt)
+		require.NoError(t, err)
+		post.Status = status
+		require.NoError(t, repo.Create(ctx, post))
+	}
+	alice := repositories.PostQuery{Limit: 2, Filter: repositories.PostFilter{Viewer: &repositories.Viewer{Author: "Alice"}}}
+
+	assert.Equal(t, [][]int{{1, 2}, {4, 5}, {7, 8}},
+		collectPages(t, repo, alice, false))
+	before := alice
+	before.Before = &repositories.Cursor{ID: 10}
+	assert.Equal(t, [][]int{{7, 8}, {4, 5}, {1, 2}},
+		collectPages(t, repo, before, true))
+
+	page, err := repo.ListPosts(ctx, repositories.PostQuery{Limit: 2, After: &repositories.Cursor{ID: 4}, Filter: alice.Filter})
+	require.NoError(t, err)
+	assert.Equal(t, []int{5, 7}, postIDs(page.Posts))
+	assert.True(t, page.HasPrev)
+	assert.True(t, page.HasNext)
+
+	// Listings follow publications, changes of author and the trash.
+	published, err := repo.GetByID(ctx, 3)
+	require.NoError(t, err)
+	published.Status = entities.StatusPublished
+	require.NoError(t, repo.Update(ctx, 3, published, repositories.AnyVersion))
+	given, err := repo.GetByID(ctx, 6)
+	require.NoError(t, err)
+	given.Author = "alice"
+	require.NoError(t, repo.Update(ctx, 6, given, repositories.AnyVersion))
+	require.NoError(t, repo.Trash(ctx, 2, repositories.AnyVersion, trashedAt))
+
+	assert.Equal(t, [][]int{{1, 3}, {4, 5}, {6, 7}, {8}},
+		collectPages(t, repo, alice, false))
+	anonymous := repositories.PostQuery{Limit: 3, Filter: repositories.PostFilter{Viewer: &repositories.Viewer{}}}
+	assert.Equal(t, [][]int{{1, 3, 4}, {7}},
+		collectPages(t, repo, anonymous, false))
+}
@@ -0,0 +1,175 @@
+package repositorytest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/repositories"
+)
+
+// RevisionFactory returns a new, empty revision repository.
+type RevisionFactory func(t *testing.T) repositories.RevisionRepository
+
+// RunRevisionRepositoryTests runs the RevisionRepository contract against
+// repositories produced by newRepo.
+func RunRevisionRepositoryTests(t *testing.T, newRepo RevisionFactory) {
+	t.Run("Append and Get round-trip every field", func(t *testing.T) {
+		testRevisionRoundTrip(t, newRepo(t))
+	})
+	t.Run("Append rejects a recorded number", func(t *testing.T) {
+		testRevisionDuplicate(t, newRepo(t))
+	})
+	t.Run("Get of a missing revision", func(t *testing.T) {
+		_, err := newRepo(t).Get(context.Background(), 1, 1)
+		assert.ErrorIs(t, err, repositories.ErrRevisionNotFound)
+	})
+	t.Run("ListByPost returns one post's revisions oldest first", func(t *testing.T) {
+		testRevisionListing(t, newRepo(t))
+	})
+	t.Run("DeleteByPost drops one post's history", func(t *testing.T) {
+		testRevisionDeleteByPost(t, newRepo(t))
+	})
+	t.Run("returned revisions are copies", func(t *testing.T) {
+		testRevisionCopyIsolation(t, newRepo(t))
+	})
+	t.Run("cancelled context", func(t *testing.T) {
+		testRevisionCancelledContext(t, newRepo(t))
+	})
+}
+
+func mustRevision(t *testing.T, postID, number int, title string) *entities.Revision {
+	t.Helper()
+
+	post := mustPost(t, postID, title)
+	post.Version = number
+	return entities.NewRevision(post, nil, "Editor of "+title, time.Date(2024, 1, number, 12, 0, 0, 0, time.UTC))
+}
+
+func testRevisionRoundTrip(t *testing.T, repo repositories.RevisionRepository) {
+	ctx := context.Background()
+
+	revision := mustRevision(t, 1, 3, "Third")
+	revision.Changes = []string{"content"}
+	revision.RestoredFrom = 1
+	require.NoError(t, repo.Append(ctx, revision))
+
+	stored, err := repo.Get(ctx, 1, 3)
+	require.NoError(t, err)
+	assert.Equal(t, revision.PostID, stored.PostID)
+	assert.Equal(t, revision.Number, stored.Number)
+	assert.Equal(t, revision.Title, stored.Title)
+	assert.Equal(t, revision.Content, stored.Content)
+	assert.Equal(t, revision.Author, stored.Author)
+	assert.Equal(t, revision.EditedBy, stored.EditedBy)
+	assert.True(t, revision.EditedAt.Equal(stored.EditedAt), "edited at %v, stored %v", revision.EditedAt, stored.EditedAt)
+	assert.Equal(t, []string{"content"}, stored.Changes)
+	assert.Equal(t, 1, stored.RestoredFrom)
+
+	unchanged := mustRevision(t, 1, 4, "Third")
+	unchanged.Changes = []string{}
+	require.NoError(t, repo.Append(ctx, unchanged))
+
+	stored, err = repo.Get(ctx, 1, 4)
+	require.NoError(t, err)
+	assert.NotNil(t, stored.Changes)
+	assert.Empty(t, stored.Changes)
+}
+
+func testRevisionDuplicate(t *testing.T, repo repositories.RevisionRepository) {
+	ctx := context.Background()
+
+	require.NoError(t, repo.Append(ctx, mustRevision(t, 1, 1, "First")))
+	assert.ErrorIs(t, repo.Append(ctx, mustRevision(t, 1, 1, "Overwrite")), repositories.ErrRevisionExists)
+
+	stored, err := repo.Get(ctx, 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "First", stored.Title, "revisions are immutable")
+
+	// The same number on another post is a different revision.
+	assert.NoError(t, repo.Append(ctx, mustRevision(t, 2, 1, "Other")))
+}
+
+func testRevisionListing(t *testing.T, repo repositories.RevisionRepository) {
+	ctx := context.Background()
+
+	empty, err := repo.ListByPost(ctx, 1)
+	require.NoError(t, err)
+	assert.NotNil(t, empty)
+	assert.Empty(t, empty)
+
+	require.NoError(t, repo.Append(ctx, mustRevision(t, 1, 2, "Second")))
+	require.NoError(t, repo.Append(ctx, mustRevision(t, 2, 1, "Other")))
+	require.NoError(t, repo.Append(ctx, mustRevision(t, 1, 1, "First")))
+	require.NoError(t, repo.Append(ctx, mustRevision(t, 1, 3, "Third")))
+
+	revisions, err := repo.ListByPost(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, revisions, 3)
+	for i, revision := range revisions {
+		assert.Equal(t, 1, revision.PostID)
+		assert.Equal(t, i+1, revision.Number)
+	}
+}
+
+func testRevisionDeleteByPost(t *testing.T, repo repositories.RevisionRepository) {
+	ctx := context.Background()
+
+	require.NoError(t, repo.Append(ctx, mustRevision(t, 1, 1, "First")))
+	require.NoError(t, repo.Append(ctx, mustRevision(t, 1, 2, "Second")))
+	require.NoError(t, repo.Append(ctx, mustRevision(t, 2, 1, "Other")))
+
+	require.NoError(t, repo.DeleteByPost(ctx, 1))
+	require.NoError(t, repo.DeleteByPost(ctx, 99))
+
+	revisions, err := repo.ListByPost(ctx, 1)
+	require.NoError(t, err)
+	assert.Empty(t, revisions)
+	_, err = repo.Get(ctx, 1, 1)
+	assert.ErrorIs(t, err, repositories.ErrRevisionNotFound)
+
+	revisions, err = repo.ListByPost(ctx, 2)
+	require.NoError(t, err)
+	assert.Len(t, revisions, 1)
+}
+
+func testRevisionCopyIsolation(t *testing.T, repo repositories.RevisionRepository) {
+	ctx := context.Background()
+
+	revision := mustRevision(t, 1, 1, "First")
+	require.NoError(t, repo.Append(ctx, revision))
+	revision.Title = "Mutated after append"
+	revision.Changes[0] = "mutated"
+
+	stored, err := repo.Get(ctx, 1, 1)
+	require.NoError(t, err)
+	stored.Changes[0] = "mutated"
+
+	listed, err := repo.ListByPost(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	assert.Equal(t, "First", listed[0].Title)
+	assert.Equal(t, "title", listed[0].Changes[0])
+}
+
+func testRevisionCancelledContext(t *testing.T, repo repositories.RevisionRepository) {
+	require.NoError(t, repo.Append(context.Background(), mustRevision(t, 1, 1, "First")))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.ErrorIs(t, repo.Append(ctx, mustRevision(t, 1, 2, "Second")), context.Canceled)
+	_, err := repo.Get(ctx, 1, 1)
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = repo.ListByPost(ctx, 1)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorIs(t, repo.DeleteByPost(ctx, 1), context.Canceled)
+
+	revisions, err := repo.ListByPost(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Len(t, revisions, 1, "abandoned calls must not change the repository")
+}
@@ -0,0 +1,190 @@
+package repositorytest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/repositories"
+)
+
+// UserFactory returns a new, empty user repository.
+type UserFactory func(t *testing.T) repositories.UserRepository
+
+// RunUserRepositoryTests runs the UserRepository contract against
+// repositories produced by newRepo.
+func RunUserRepositoryTests(t *testing.T, newRepo UserFactory) {
+	t.Run("Create allocates sequential IDs", func(t *testing.T) {
+		testUserCreate(t, newRepo(t))
+	})
+	t.Run("usernames are unique up to case", func(t *testing.T) {
+		testUsernameUniqueness(t, newRepo(t))
+	})
+	t.Run("concurrent Create with one username", func(t *testing.T) {
+		testUserConcurrentCreate(t, newRepo(t))
+	})
+	t.Run("returned users are copies", func(t *testing.T) {
+		testUserCopyIsolation(t, newRepo(t))
+	})
+	t.Run("refresh tokens are revoked once", func(t *testing.T) {
+		testRevokeToken(t, newRepo(t))
+	})
+	t.Run("concurrent RevokeToken with one ID", func(t *testing.T) {
+		testConcurrentRevokeToken(t, newRepo(t))
+	})
+	t.Run("cancelled context", func(t *testing.T) {
+		testUserCancelledContext(t, newRepo(t))
+	})
+}
+
+func mustUser(t *testing.T, username string) *entities.User {
+	t.Helper()
+
+	user, err := entities.NewUser(0, username, "Name of "+username, entities.RoleAuthor, "hash", createdAt)
+	require.NoError(t, err)
+	return user
+}
+
+func testUserCreate(t *testing.T, repo repositories.UserRepository) {
+	ctx := context.Background()
+
+	for want := 1; want <= 3; want++ {
+		user := mustUser(t, fmt.Sprintf("user%d", want))
+		require.NoError(t, repo.Create(ctx, user))
+		assert.Equal(t, want, user.ID)
+	}
+
+	stored, err := repo.GetByID(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "user2", stored.Username)
+	assert.Equal(t, "Name of user2", stored.Name)
+	assert.Equal(t, entities.RoleAuthor, stored.Role)
+	assert.Equal(t, "hash", stored.PasswordHash)
+	assert.True(t, createdAt.Equal(stored.CreatedAt))
+
+	_, err = repo.GetByID(ctx, 99)
+	assert.ErrorIs(t, err, repositories.ErrUserNotFound)
+
+	invalid := &entities.User{Username: "", Name: "Name", Role: entities.RoleAuthor}
+	assert.ErrorIs(t, repo.Create(ctx, invalid), entities.ErrUsernameRequired)
+}
+
+func testUsernameUniqueness(t *testing.T, repo repositories.UserRepository) {
+	ctx := context.Background()
+
+	created := mustUser(t, "alice")
+	require.NoError(t, repo.Create(ctx, created))
+
+	assert.ErrorIs(t, repo.Create(ctx, &entities.User{Username: "ALICE", Name: "Other", Role: entities.RoleAuthor}), repositories.ErrUserExists)
+
+	found, err := repo.GetByUsername(ctx, " Alice ")
+	require.NoError(t, err)
+	assert.Equal(t, created, found)
+
+	_, err = repo.GetByUsername(ctx, "bob")
+	assert.ErrorIs(t, err, repositories.ErrUserNotFound)
+
+	next := mustUser(t, "bob")
+	require.NoError(t, repo.Create(ctx, next))
+	assert.Equal(t, 2, next.ID, "a rejected user must not consume an ID")
+}
+
+func testUserConcurrentCreate(t *testing.T, repo repositories.UserRepository) {
+	ctx := context.Background()
+	const writers = 20
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	created, exists := 0, 0
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := repo.Create(ctx, mustUser(t, "popular"))
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				created++
+			} else if assert.ErrorIs(t, err, repositories.ErrUserExists) {
+				exists++
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, created)
+	assert.Equal(t, writers-1, exists)
+}
+
+func testUserCopyIsolation(t *testing.T, repo repositories.UserRepository) {
+	ctx := context.Background()
+
+	created := mustUser(t, "original")
+	require.NoError(t, repo.Create(ctx, created))
+	created.Name = "Mutated"
+
+	stored, err := repo.GetByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Name of original", stored.Name)
+	stored.Name = "Mutated"
+
+	stored, err = repo.GetByUsername(ctx, "original")
+	require.NoError(t, err)
+	assert.Equal(t, "Name of original", stored.Name)
+}
+
+func testRevokeToken(t *testing.T, repo repositories.UserRepository) {
+	ctx := context.Background()
+	expiresAt := createdAt.Add(time.Hour)
+
+	require.NoError(t, repo.RevokeToken(ctx, "first", expiresAt, createdAt))
+	require.NoError(t, repo.RevokeToken(ctx, "second", expiresAt, createdAt))
+	assert.ErrorIs(t, repo.RevokeToken(ctx, "first", expiresAt, createdAt.Add(time.Minute)), repositories.ErrTokenRevoked)
+	assert.ErrorIs(t, repo.RevokeToken(ctx, "second", expiresAt, expiresAt.Add(-time.Second)), repositories.ErrTokenRevoked,
+		"tokens stay revoked until they expire")
+}
+
+func testConcurrentRevokeToken(t *testing.T, repo repositories.UserRepository) {
+	ctx := context.Background()
+	const callers = 20
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	revoked, rejected := 0, 0
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := repo.RevokeToken(ctx, "token", createdAt.Add(time.Hour), createdAt)
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				revoked++
+			} else if assert.ErrorIs(t, err, repositories.ErrTokenRevoked) {
+				rejected++
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, revoked)
+	assert.Equal(t, callers-1, rejected)
+}
+
+func testUserCancelledContext(t *testing.T, repo repositories.UserRepository) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.ErrorIs(t, repo.Create(ctx, mustUser(t, "user")), context.Canceled)
+	_, err := repo.GetByID(ctx, 1)
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = repo.GetByUsername(ctx, "user")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorIs(t, repo.RevokeToken(ctx, "token", createdAt.Add(time.Hour), createdAt), context.Canceled)
+}
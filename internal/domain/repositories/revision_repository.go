@@ -0,0 +1,30 @@
+package repositories
+
+import (
+	"context"
+	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/errdefs"
+)
+
+var (
+	ErrRevisionNotFound = errdefs.New(errdefs.ErrNotFound, "revision not found")
+	ErrRevisionExists   = errdefs.New(errdefs.ErrConflict, "revision already exists")
+)
+
+// RevisionRepository is an append-only store of post revisions.
+type RevisionRepository interface {
+	// Append records a new revision. Revisions are immutable, so appending a
+	// number that is already recorded for the post fails with
+	// ErrRevisionExists.
+	Append(ctx context.Context, revision *entities.Revision) error
+
+	// Get returns one revision of a post.
+	Get(ctx context.Context, postID, number int) (*entities.Revision, error)
+
+	// ListByPost returns every revision of a post, oldest first.
+	ListByPost(ctx context.Context, postID int) ([]*entities.Revision, error)
+
+	// DeleteByPost drops the history of a post that has been removed for
+	// good. Deleting the history of a post without revisions is not an error.
+	DeleteByPost(ctx context.Context, postID int) error
+}
@@ -0,0 +1,39 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/errdefs"
+)
+
+var (
+	ErrUserNotFound = errdefs.New(errdefs.ErrNotFound, "user not found")
+	ErrUserExists   = errdefs.New(errdefs.ErrConflict, "username already taken")
+	// ErrTokenRevoked is returned when revoking a refresh token that was
+	// revoked before.
+	ErrTokenRevoked = errdefs.New(errdefs.ErrConflict, "refresh token already revoked")
+)
+
+// UserRepository stores the accounts that can sign in. Usernames are
+// unique, as compared by entities.NormalizeUsername. It also keeps the IDs
+// of the refresh tokens that may no longer be used.
+type UserRepository interface {
+	// Create stores user under the next free ID, which it assigns to user.
+	// It fails with ErrUserExists if the username is taken.
+	Create(ctx context.Context, user *entities.User) error
+
+	// GetByID returns the user with the given ID.
+	GetByID(ctx context.Context, id int) (*entities.User, error)
+
+	// GetByUsername returns the user with the given username.
+	GetByUsername(ctx context.Context, username string) (*entities.User, error)
+
+	// RevokeToken records that the refresh token with the given ID, which
+	// expires at expiresAt, may no longer be used. It fails with
+	// ErrTokenRevoked if the token was revoked before, so of concurrent
+	// attempts to use a token only one succeeds. Tokens that expired by now
+	// are rejected anyway and may be forgotten.
+	RevokeToken(ctx context.Context, id string, expiresAt, now time.Time) error
+}
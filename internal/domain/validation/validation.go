@@ -0,0 +1,107 @@
+// Package validation describes invalid input field by field, so callers can
+// tell which field each problem is about.
+package validation
+
+import (
+	"errors"
+	"strings"
+
+	"rakia-tech-test/internal/domain/errdefs"
+)
+
+// Codes name the kinds of problems in a machine-readable way. They are
+// shared by every layer, so a field reports the same code whether the
+// entity or the HTTP binding caught the problem.
+const (
+	// CodeRequired means the field is missing or blank.
+	CodeRequired = "required"
+	// CodeTooShort means the field is shorter than Params["min"].
+	CodeTooShort = "too_short"
+	// CodeTooLong means the field exceeds Params["max"].
+	CodeTooLong = "too_long"
+	// CodeTooMany means the list holds more than Params["max"] items.
+	CodeTooMany = "too_many"
+	// CodeOneOf means the field is not one of Params["allowed"].
+	CodeOneOf = "one_of"
+	// CodeInvalidType means the field has the wrong JSON type; Params["type"]
+	// names the expected one.
+	CodeInvalidType = "invalid_type"
+	// CodeInvalid means the field is malformed in a way the message
+	// describes.
+	CodeInvalid = "invalid"
+)
+
+// FieldError is one problem with one field. It is comparable by identity,
+// so fixed problems can be declared once and matched with errors.Is, and it
+// is of the errdefs.ErrValidation kind.
+type FieldError struct {
+	// Field names the field as clients send it, such as "title".
+	Field string
+	// Code is one of the Code constants.
+	Code string
+	// Params holds the limits the field was checked against, such as
+	// {"max": 255}, or nil when the code needs none.
+	Params map[string]any
+	// Message describes the problem for people.
+	Message string
+}
+
+func New(field, code, message string, params map[string]any) *FieldError {
+	return &FieldError{
+		Field:   field,
+		Code:    code,
+		Params:  params,
+		Message: message,
+	}
+}
+
+func (e *FieldError) Error() string {
+	return e.Message
+}
+
+func (e *FieldError) Is(target error) bool {
+	return target == errdefs.ErrValidation
+}
+
+// Errors collects every problem found in one input. errors.Is and errors.As
+// see each of the field errors in it.
+type Errors []*FieldError
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e Errors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+// Err returns e as an error, or nil when it holds no problems.
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Fields returns the field errors err is made of: the ones it holds when it
+// is, or wraps, Errors, or err itself when it is a single FieldError. It
+// reports false for errors that are not about input fields.
+func Fields(err error) (Errors, bool) {
+	var errs Errors
+	if errors.As(err, &errs) {
+		return errs, true
+	}
+	var fieldErr *FieldError
+	if errors.As(err, &fieldErr) {
+		return Errors{fieldErr}, true
+	}
+	return nil, false
+}
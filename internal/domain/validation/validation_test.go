@@ -0,0 +1,50 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"rakia-tech-test/internal/domain/errdefs"
+)
+
+func TestErrors(t *testing.T) {
+	required := New("title", CodeRequired, "title is required", nil)
+	tooLong := New("content", CodeTooLong, "content is too long", map[string]any{"max": 10})
+	other := New("author", CodeRequired, "author is required", nil)
+
+	errs := Errors{required, tooLong}
+	assert.EqualError(t, errs, "title is required; content is too long")
+	assert.ErrorIs(t, errs, required)
+	assert.ErrorIs(t, errs, tooLong)
+	assert.NotErrorIs(t, errs, other)
+	assert.ErrorIs(t, errs, errdefs.ErrValidation)
+	assert.ErrorIs(t, required, errdefs.ErrValidation)
+
+	var fieldErr *FieldError
+	require.ErrorAs(t, errs, &fieldErr)
+	assert.Equal(t, "title", fieldErr.Field)
+
+	assert.NoError(t, Errors(nil).Err())
+	assert.Equal(t, errs, errs.Err())
+}
+
+func TestFields(t *testing.T) {
+	required := New("title", CodeRequired, "title is required", nil)
+
+	fields, ok := Fields(fmt.Errorf("create post: %w", Errors{required}))
+	assert.True(t, ok)
+	assert.Equal(t, Errors{required}, fields)
+
+	fields, ok = Fields(required)
+	assert.True(t, ok)
+	assert.Equal(t, Errors{required}, fields)
+
+	_, ok = Fields(errors.New("disk full"))
+	assert.False(t, ok)
+	_, ok = Fields(nil)
+	assert.False(t, ok)
+}
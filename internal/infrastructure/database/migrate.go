@@ -0,0 +1,96 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Migration is one versioned schema change. Versions must be unique and are
+// applied in ascending order; an applied migration is never run again, so
+// existing migrations must not be edited once released.
+type Migration struct {
+	Version    int
+	Name       string
+	Statements []string
+}
+
+const createMigrationsTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version    INTEGER PRIMARY KEY,
+	name       TEXT NOT NULL,
+	applied_at TIMESTAMP NOT NULL
+)`
+
+// Migrate applies every migration that has not been recorded in the
+// schema_migrations table yet. Each migration runs in its own transaction
+// together with its bookkeeping row, so a failed migration leaves the schema
+// at the previous version.
+func Migrate(ctx context.Context, db *sql.DB, migrations []Migration) error {
+	sorted := make([]Migration, len(migrations))
+	copy(sorted, migrations)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Version < sorted[j].Version
+	})
+
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i].Version == sorted[i-1].Version {
+			return fmt.Errorf("duplicate migration version %d", sorted[i].Version)
+		}
+	}
+
+	if _, err := db.ExecContext(ctx, createMigrationsTable); err != nil {
+		return fmt.Errorf("create schema_migrations table: %w", err)
+	}
+
+	current, err := CurrentVersion(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range sorted {
+		if migration.Version <= current {
+			continue
+		}
+		if err := apply(ctx, db, migration); err != nil {
+			return fmt.Errorf("migration %d (%s): %w", migration.Version, migration.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// CurrentVersion returns the highest applied migration version, or 0 for a
+// fresh database.
+func CurrentVersion(ctx context.Context, db *sql.DB) (int, error) {
+	var version sql.NullInt64
+	if err := db.QueryRowContext(ctx, `SELECT MAX(version) FROM schema_migrations`).Scan(&version); err != nil {
+		return 0, fmt.Errorf("read schema version: %w", err)
+	}
+
+	return int(version.Int64), nil
+}
+
+func apply(ctx context.Context, db *sql.DB, migration Migration) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, statement := range migration.Statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`,
+		migration.Version, migration.Name, time.Now().UTC(),
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,90 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+func TestMigrate_AppliesPendingMigrationsInOrder(t *testing.T) {
+	db := openTestDB(t)
+
+	v1 := Migration{Version: 1, Name: "create_items", Statements: []string{
+		`CREATE TABLE items (id INTEGER PRIMARY KEY)`,
+	}}
+	v2 := Migration{Version: 2, Name: "add_name", Statements: []string{
+		`ALTER TABLE items ADD COLUMN name TEXT NOT NULL DEFAULT ''`,
+	}}
+
+	// Out of order on purpose: versions decide the order, not slice position.
+	require.NoError(t, Migrate(context.Background(), db, []Migration{v2, v1}))
+
+	version, err := CurrentVersion(context.Background(), db)
+	require.NoError(t, err)
+	assert.Equal(t, 2, version)
+
+	_, err = db.Exec(`INSERT INTO items (id, name) VALUES (1, 'one')`)
+	require.NoError(t, err)
+
+	// Running again is a no-op.
+	require.NoError(t, Migrate(context.Background(), db, []Migration{v1, v2}))
+
+	v3 := Migration{Version: 3, Name: "add_index", Statements: []string{
+		`CREATE INDEX items_name ON items (name)`,
+	}}
+	require.NoError(t, Migrate(context.Background(), db, []Migration{v1, v2, v3}))
+
+	version, err = CurrentVersion(context.Background(), db)
+	require.NoError(t, err)
+	assert.Equal(t, 3, version)
+}
+
+func TestMigrate_FailedMigrationIsRolledBack(t *testing.T) {
+	db := openTestDB(t)
+
+	migrations := []Migration{
+		{Version: 1, Name: "create_items", Statements: []string{
+			`CREATE TABLE items (id INTEGER PRIMARY KEY)`,
+		}},
+		{Version: 2, Name: "broken", Statements: []string{
+			`CREATE TABLE other (id INTEGER PRIMARY KEY)`,
+			`THIS IS NOT SQL`,
+		}},
+	}
+
+	err := Migrate(context.Background(), db, migrations)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "migration 2 (broken)")
+
+	version, err := CurrentVersion(context.Background(), db)
+	require.NoError(t, err)
+	assert.Equal(t, 1, version)
+
+	_, err = db.Exec(`SELECT 1 FROM other`)
+	assert.Error(t, err, "statements of a failed migration must be rolled back")
+}
+
+func TestMigrate_DuplicateVersions(t *testing.T) {
+	db := openTestDB(t)
+
+	err := Migrate(context.Background(), db, []Migration{
+		{Version: 1, Name: "a"},
+		{Version: 1, Name: "b"},
+	})
+	assert.ErrorContains(t, err, "duplicate migration version 1")
+}
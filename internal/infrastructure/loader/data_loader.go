@@ -1,10 +1,14 @@
 package loader
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"os"
+	"rakia-tech-test/internal/domain/clock"
 	"rakia-tech-test/internal/domain/entities"
 	"rakia-tech-test/internal/domain/repositories"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
@@ -13,26 +17,43 @@ type PostData struct {
 	ID      int    `json:"id"`
 	Title   string `json:"title"`
 	Content string `json:"content"`
-	Author  string `json:"author"`
+	// ContentFormat is optional and defaults to plain.
+	ContentFormat entities.ContentFormat `json:"content_format"`
+	Author        string                 `json:"author"`
+	// CreatedAt and UpdatedAt are optional. Posts without them are stamped
+	// with the load time, and UpdatedAt defaults to CreatedAt.
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// Status is optional and defaults to published, since seed data is
+	// meant to be read.
+	Status entities.PostStatus `json:"status"`
+	// Tags are optional.
+	Tags []string `json:"tags"`
 }
 
 type BlogData struct {
 	Posts []PostData `json:"posts"`
 }
 
+// DataLoader seeds the repositories and turns the free-form author names
+// posts used to carry into author records.
 type DataLoader struct {
-	postRepo repositories.PostRepository
-	logger   *logrus.Logger
+	postRepo   repositories.PostRepository
+	authorRepo repositories.AuthorRepository
+	clock      clock.Clock
+	logger     *logrus.Logger
 }
 
-func NewDataLoader(postRepo repositories.PostRepository, logger *logrus.Logger) *DataLoader {
+func NewDataLoader(postRepo repositories.PostRepository, authorRepo repositories.AuthorRepository, clock clock.Clock, logger *logrus.Logger) *DataLoader {
 	return &DataLoader{
-		postRepo: postRepo,
-		logger:   logger,
+		postRepo:   postRepo,
+		authorRepo: authorRepo,
+		clock:      clock,
+		logger:     logger,
 	}
 }
 
-func (dl *DataLoader) LoadFromFile(filename string) error {
+func (dl *DataLoader) LoadFromFile(ctx context.Context, filename string) error {
 	dl.logger.WithField("filename", filename).Info("Loading blog data from file")
 
 	data, err := os.ReadFile(filename)
@@ -47,17 +68,49 @@ func (dl *DataLoader) LoadFromFile(filename string) error {
 		return err
 	}
 
+	now := dl.clock.Now()
 	posts := make([]*entities.Post, len(blogData.Posts))
 	for i, postData := range blogData.Posts {
-		post, err := entities.NewPost(postData.ID, postData.Title, postData.Content, postData.Author)
+		createdAt := postData.CreatedAt
+		if createdAt.IsZero() {
+			createdAt = now
+		}
+		author, err := dl.authorNamed(ctx, postData.Author, now)
+		if err != nil {
+			dl.logger.WithError(err).WithField("post_id", postData.ID).Error("Failed to resolve post author")
+			return err
+		}
+		post, err := entities.NewPost(postData.ID, postData.Title, postData.Content, author.Name, createdAt)
 		if err != nil {
 			dl.logger.WithError(err).WithField("post_id", postData.ID).Error("Failed to create post entity")
 			return err
 		}
+		post.AuthorID = author.ID
+		if !postData.UpdatedAt.IsZero() {
+			post.UpdatedAt = postData.UpdatedAt.UTC()
+		}
+		post.Status = entities.StatusPublished
+		if postData.Status != "" {
+			if !postData.Status.Valid() {
+				dl.logger.WithField("post_id", postData.ID).Error("Invalid post status")
+				return entities.ErrInvalidStatus
+			}
+			post.Status = postData.Status
+		}
+		if postData.ContentFormat != "" {
+			if err := post.SetContentFormat(postData.ContentFormat); err != nil {
+				dl.logger.WithError(err).WithField("post_id", postData.ID).Error("Invalid post content format")
+				return err
+			}
+		}
+		if err := post.SetTags(postData.Tags); err != nil {
+			dl.logger.WithError(err).WithField("post_id", postData.ID).Error("Invalid post tags")
+			return err
+		}
 		posts[i] = post
 	}
 
-	if err := dl.postRepo.LoadData(posts); err != nil {
+	if err := dl.postRepo.LoadData(ctx, posts); err != nil {
 		dl.logger.WithError(err).Error("Failed to load data into repository")
 		return err
 	}
@@ -65,3 +118,76 @@ func (dl *DataLoader) LoadFromFile(filename string) error {
 	dl.logger.WithField("count", len(posts)).Info("Successfully loaded blog posts")
 	return nil
 }
+
+// LinkAuthors brings the author records in line with the stored posts. The
+// authors of posts kept by durable storage are recreated under the IDs the
+// posts refer to, and live posts stored before authors existed are linked to
+// the author record matching their author name. The linked posts keep their
+// version, as their content does not change.
+func (dl *DataLoader) LinkAuthors(ctx context.Context) error {
+	posts, err := dl.postRepo.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+	trash, err := dl.postRepo.GetTrash(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, post := range append(posts, trash...) {
+		if post.AuthorID == 0 {
+			continue
+		}
+		if _, err := dl.authorRepo.GetByID(ctx, post.AuthorID); !errors.Is(err, repositories.ErrAuthorNotFound) {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		author, err := entities.NewAuthor(post.AuthorID, post.Author, "", post.CreatedAt)
+		if err == nil {
+			err = dl.authorRepo.Create(ctx, author)
+		}
+		if err != nil {
+			dl.logger.WithError(err).WithFields(logrus.Fields{
+				"post_id":   post.ID,
+				"author_id": post.AuthorID,
+			}).Warn("Failed to restore post author")
+			continue
+		}
+	}
+
+	linked := 0
+	for _, post := range posts {
+		if post.AuthorID != 0 || entities.NormalizeAuthorName(post.Author) == "" {
+			continue
+		}
+
+		author, err := dl.authorNamed(ctx, post.Author, dl.clock.Now())
+		if err != nil {
+			return err
+		}
+		post.Author, post.AuthorID = author.Name, author.ID
+		if err := dl.postRepo.Update(ctx, post.ID, post, post.Version); err != nil {
+			dl.logger.WithError(err).WithField("post_id", post.ID).Warn("Failed to link post to its author")
+			continue
+		}
+		linked++
+	}
+
+	if linked > 0 {
+		dl.logger.WithField("count", linked).Info("Linked posts to their authors")
+	}
+	return nil
+}
+
+// authorNamed returns the author with the given name, adding one if there
+// is none yet.
+func (dl *DataLoader) authorNamed(ctx context.Context, name string, now time.Time) (*entities.Author, error) {
+	author, err := dl.authorRepo.GetByName(ctx, name)
+	if errors.Is(err, repositories.ErrAuthorNotFound) {
+		author, err = dl.authorRepo.CreateAuthor(ctx, name, "", now)
+	}
+	return author, err
+}
@@ -0,0 +1,597 @@
+package repositories
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/errdefs"
+	"rakia-tech-test/internal/domain/repositories"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	walFileName      = "posts.wal"
+	snapshotFileName = "posts.snapshot.json"
+
+	// DefaultCompactEvery is the number of log records after which the log is
+	// folded into a fresh snapshot.
+	DefaultCompactEvery = 1000
+)
+
+var ErrRepositoryClosed = errdefs.New(errdefs.ErrUnavailable, "repository is closed")
+
+const (
+	walOpPut    = "put"
+	walOpDelete = "delete"
+	walOpLoad   = "load"
+	walOpPurge  = "purge"
+)
+
+// walRecord is one line of the write-ahead log. Records describe the state a
+// mutation produced rather than the request that caused it, so replaying a
+// record twice is harmless.
+type walRecord struct {
+	Op     string           `json:"op"`
+	ID     int              `json:"id,omitempty"`
+	Post   *entities.Post   `json:"post,omitempty"`
+	Posts  []*entities.Post `json:"posts,omitempty"`
+	IDs    []int            `json:"ids,omitempty"`
+	NextID int              `json:"next_id"`
+}
+
+type snapshotFile struct {
+	NextID int              `json:"next_id"`
+	Posts  []*entities.Post `json:"posts"`
+}
+
+// FilePostRepository keeps posts in memory and makes every mutation durable by
+// appending it to an fsync'd write-ahead log inside dir. The log is
+// periodically compacted into a snapshot, and snapshot plus log are replayed
+// when the repository is opened.
+//
+// Cancellation is checked before a mutation starts. Once a record is being
+// written it is always completed, so the log never holds half a mutation.
+type FilePostRepository struct {
+	mem          *MemoryPostRepository
+	dir          string
+	wal          *os.File
+	walSize      int64
+	compactEvery int
+	pending      int
+	closed       bool
+	mutex        sync.Mutex
+}
+
+func NewFilePostRepository(dir string, compactEvery int) (*FilePostRepository, error) {
+	if compactEvery <= 0 {
+		compactEvery = DefaultCompactEvery
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create data directory: %w", err)
+	}
+
+	r := &FilePostRepository{
+		mem:          NewMemoryPostRepository(),
+		dir:          dir,
+		compactEvery: compactEvery,
+	}
+
+	if err := r.loadSnapshot(); err != nil {
+		return nil, err
+	}
+	if err := r.replayLog(); err != nil {
+		return nil, err
+	}
+
+	wal, err := os.OpenFile(r.walPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open write-ahead log: %w", err)
+	}
+
+	info, err := wal.Stat()
+	if err != nil {
+		wal.Close()
+		return nil, fmt.Errorf("stat write-ahead log: %w", err)
+	}
+	r.wal = wal
+	r.walSize = info.Size()
+
+	// Posts written before slugs existed get theirs now; a compaction makes
+	// the choice stick, so their URLs do not change between restarts.
+	if r.mem.assignMissingSlugs() {
+		if err := r.compact(); err != nil {
+			wal.Close()
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+func (r *FilePostRepository) Create(ctx context.Context, post *entities.Post) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.closed {
+		return ErrRepositoryClosed
+	}
+
+	prev, nextID := r.mem.state(post.ID)
+	if err := r.mem.Create(ctx, post); err != nil {
+		return err
+	}
+
+	return r.commitPut(post.ID, prev, nextID)
+}
+
+func (r *FilePostRepository) CreatePost(ctx context.Context, title, content string, format entities.ContentFormat, author string, authorID int, tags []string, createdAt time.Time) (*entities.Post, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.closed {
+		return nil, ErrRepositoryClosed
+	}
+
+	_, nextID := r.mem.state(0)
+	post, err := r.mem.CreatePost(ctx, title, content, format, author, authorID, tags, createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.commitPut(post.ID, nil, nextID); err != nil {
+		return nil, err
+	}
+
+	return post, nil
+}
+
+func (r *FilePostRepository) GetByID(ctx context.Context, id int) (*entities.Post, error) {
+	return r.mem.GetByID(ctx, id)
+}
+
+func (r *FilePostRepository) GetBySlug(ctx context.Context, slug string) (*entities.Post, error) {
+	return r.mem.GetBySlug(ctx, slug)
+}
+
+func (r *FilePostRepository) GetAll(ctx context.Context) ([]*entities.Post, error) {
+	return r.mem.GetAll(ctx)
+}
+
+func (r *FilePostRepository) ListPosts(ctx context.Context, query repositories.PostQuery) (*repositories.PostPage, error) {
+	return r.mem.ListPosts(ctx, query)
+}
+
+func (r *FilePostRepository) Update(ctx context.Context, id int, post *entities.Post, expectedVersion int) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.closed {
+		return ErrRepositoryClosed
+	}
+
+	prev, nextID := r.mem.state(id)
+	if err := r.mem.Update(ctx, id, post, expectedVersion); err != nil {
+		return err
+	}
+
+	return r.commitPut(id, prev, nextID)
+}
+
+func (r *FilePostRepository) Delete(ctx context.Context, id int, expectedVersion int) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.closed {
+		return ErrRepositoryClosed
+	}
+
+	prev, nextID := r.mem.state(id)
+	if err := r.mem.Delete(ctx, id, expectedVersion); err != nil {
+		return err
+	}
+
+	if err := r.append(walRecord{Op: walOpDelete, ID: id, NextID: nextID}); err != nil {
+		r.mem.revert(id, prev, nextID)
+		return err
+	}
+
+	return nil
+}
+
+func (r *FilePostRepository) Exists(ctx context.Context, id int) bool {
+	return r.mem.Exists(ctx, id)
+}
+
+func (r *FilePostRepository) LoadData(ctx context.Context, posts []*entities.Post) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.closed {
+		return ErrRepositoryClosed
+	}
+
+	prevPosts, prevNextID := r.mem.dump()
+	if err := r.mem.LoadData(ctx, posts); err != nil {
+		return err
+	}
+
+	_, nextID := r.mem.state(0)
+	if err := r.append(walRecord{Op: walOpLoad, Posts: posts, NextID: nextID}); err != nil {
+		r.mem.reset(prevPosts, prevNextID)
+		return err
+	}
+
+	return nil
+}
+
+func (r *FilePostRepository) Trash(ctx context.Context, id int, expectedVersion int, deletedAt time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.closed {
+		return ErrRepositoryClosed
+	}
+
+	prev, nextID := r.mem.state(id)
+	if err := r.mem.Trash(ctx, id, expectedVersion, deletedAt); err != nil {
+		return err
+	}
+
+	return r.commitPut(id, prev, nextID)
+}
+
+func (r *FilePostRepository) GetTrash(ctx context.Context) ([]*entities.Post, error) {
+	return r.mem.GetTrash(ctx)
+}
+
+func (r *FilePostRepository) TagCounts(ctx context.Context) ([]repositories.TagCount, error) {
+	return r.mem.TagCounts(ctx)
+}
+
+func (r *FilePostRepository) Restore(ctx context.Context, id int) (*entities.Post, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.closed {
+		return nil, ErrRepositoryClosed
+	}
+
+	prev, nextID := r.mem.state(id)
+	post, err := r.mem.Restore(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.commitPut(id, prev, nextID); err != nil {
+		return nil, err
+	}
+
+	return post, nil
+}
+
+func (r *FilePostRepository) Purge(ctx context.Context, deletedBefore time.Time) ([]int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.closed {
+		return nil, ErrRepositoryClosed
+	}
+
+	prevPosts, nextID := r.mem.dump()
+	purged, err := r.mem.Purge(ctx, deletedBefore)
+	if err != nil || len(purged) == 0 {
+		return purged, err
+	}
+
+	if err := r.append(walRecord{Op: walOpPurge, IDs: purged, NextID: nextID}); err != nil {
+		r.mem.reset(prevPosts, nextID)
+		return nil, err
+	}
+
+	return purged, nil
+}
+
+// Compact writes the current state to a new snapshot and truncates the log.
+func (r *FilePostRepository) Compact() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.closed {
+		return ErrRepositoryClosed
+	}
+
+	return r.compact()
+}
+
+// Close compacts the log one last time and releases the log file. Further
+// writes fail with ErrRepositoryClosed.
+func (r *FilePostRepository) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+
+	compactErr := r.compact()
+	if err := r.wal.Close(); err != nil && compactErr == nil {
+		return fmt.Errorf("close write-ahead log: %w", err)
+	}
+
+	return compactErr
+}
+
+// commitPut logs the stored state of the post with the given ID. If the log
+// write fails the in-memory change is rolled back to prev.
+func (r *FilePostRepository) commitPut(id int, prev *entities.Post, prevNextID int) error {
+	post, nextID := r.mem.state(id)
+	if err := r.append(walRecord{Op: walOpPut, Post: post, NextID: nextID}); err != nil {
+		r.mem.revert(id, prev, prevNextID)
+		return err
+	}
+
+	return nil
+}
+
+// append writes one checksummed record to the log, fsyncs it and compacts the
+// log once enough records have accumulated.
+func (r *FilePostRepository) append(rec walRecord) error {
+	payload, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("encode log record: %w", err)
+	}
+
+	line := make([]byte, 0, len(payload)+10)
+	line = append(line, fmt.Sprintf("%08x ", crc32.ChecksumIEEE(payload))...)
+	line = append(line, payload...)
+	line = append(line, '\n')
+
+	if _, err := r.wal.Write(line); err != nil {
+		r.discardTail()
+		return fmt.Errorf("append to write-ahead log: %w", err)
+	}
+	if err := r.wal.Sync(); err != nil {
+		r.discardTail()
+		return fmt.Errorf("sync write-ahead log: %w", err)
+	}
+
+	r.walSize += int64(len(line))
+	r.pending++
+	if r.pending >= r.compactEvery {
+		// The record is already durable, so a failed compaction only means the
+		// log keeps growing until the next attempt.
+		_ = r.compact()
+	}
+
+	return nil
+}
+
+// compact replaces the snapshot with the current state and empties the log.
+// The new snapshot is written to a temporary file and renamed into place, so
+// a crash at any point leaves either the old or the new snapshot together
+// with a log that is safe to replay on top of it.
+func (r *FilePostRepository) compact() error {
+	posts, nextID := r.mem.dump()
+
+	data, err := json.Marshal(snapshotFile{NextID: nextID, Posts: posts})
+	if err != nil {
+		return fmt.Errorf("encode snapshot: %w", err)
+	}
+
+	tmpPath := r.snapshotPath() + ".tmp"
+	if err := writeFileSync(tmpPath, data); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, r.snapshotPath()); err != nil {
+		return fmt.Errorf("install snapshot: %w", err)
+	}
+	if err := syncDir(r.dir); err != nil {
+		return err
+	}
+
+	if err := r.wal.Truncate(0); err != nil {
+		return fmt.Errorf("truncate write-ahead log: %w", err)
+	}
+	if err := r.wal.Sync(); err != nil {
+		return fmt.Errorf("sync write-ahead log: %w", err)
+	}
+
+	r.walSize = 0
+	r.pending = 0
+	return nil
+}
+
+// discardTail cuts off whatever part of a failed append reached the log, so
+// the next record is not written behind a torn one.
+func (r *FilePostRepository) discardTail() {
+	_ = r.wal.Truncate(r.walSize)
+}
+
+func (r *FilePostRepository) loadSnapshot() error {
+	data, err := os.ReadFile(r.snapshotPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read snapshot: %w", err)
+	}
+
+	var snapshot snapshotFile
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return fmt.Errorf("decode snapshot: %w", err)
+	}
+
+	upgradeLegacyPosts(snapshot.Posts...)
+	r.mem.reset(snapshot.Posts, snapshot.NextID)
+	return nil
+}
+
+// replayLog applies every record in the log on top of the loaded snapshot. A
+// damaged final record is the signature of a crash in the middle of an
+// append; it was never acknowledged, so it is cut off. Damage anywhere else
+// means the log cannot be trusted and opening fails.
+func (r *FilePostRepository) replayLog() error {
+	file, err := os.OpenFile(r.walPath(), os.O_RDWR, 0)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("open write-ahead log: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	var offset int64
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if len(line) == 0 && readErr == io.EOF {
+			break
+		}
+		if readErr != nil && readErr != io.EOF {
+			return fmt.Errorf("read write-ahead log: %w", readErr)
+		}
+
+		rec, decodeErr := decodeWALRecord(line)
+		if decodeErr != nil {
+			if _, err := reader.Peek(1); err != io.EOF {
+				return fmt.Errorf("write-ahead log corrupted at offset %d: %w", offset, decodeErr)
+			}
+			if err := file.Truncate(offset); err != nil {
+				return fmt.Errorf("truncate torn write-ahead log record: %w", err)
+			}
+			if err := file.Sync(); err != nil {
+				return fmt.Errorf("sync write-ahead log: %w", err)
+			}
+			break
+		}
+
+		if err := r.apply(rec); err != nil {
+			return fmt.Errorf("replay write-ahead log at offset %d: %w", offset, err)
+		}
+		r.pending++
+		offset += int64(len(line))
+	}
+
+	return nil
+}
+
+func (r *FilePostRepository) apply(rec walRecord) error {
+	switch rec.Op {
+	case walOpPut:
+		if rec.Post == nil {
+			return errors.New("put record without post")
+		}
+		r.mem.revert(rec.Post.ID, rec.Post, rec.NextID)
+	case walOpDelete:
+		r.mem.revert(rec.ID, nil, rec.NextID)
+	case walOpPurge:
+		for _, id := range rec.IDs {
+			r.mem.revert(id, nil, rec.NextID)
+		}
+	case walOpLoad:
+		if err := r.mem.LoadData(context.Background(), rec.Posts); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown record op %q", rec.Op)
+	}
+
+	return nil
+}
+
+func decodeWALRecord(line []byte) (walRecord, error) {
+	var rec walRecord
+
+	if len(line) == 0 || line[len(line)-1] != '\n' {
+		return rec, errors.New("incomplete record")
+	}
+
+	checksum, payload, found := bytes.Cut(bytes.TrimSuffix(line, []byte{'\n'}), []byte{' '})
+	if !found {
+		return rec, errors.New("malformed record")
+	}
+
+	want, err := strconv.ParseUint(string(checksum), 16, 32)
+	if err != nil {
+		return rec, errors.New("malformed record checksum")
+	}
+	if crc32.ChecksumIEEE(payload) != uint32(want) {
+		return rec, errors.New("record checksum mismatch")
+	}
+
+	if err := json.Unmarshal(payload, &rec); err != nil {
+		return rec, fmt.Errorf("decode record: %w", err)
+	}
+	if rec.Post != nil {
+		upgradeLegacyPosts(rec.Post)
+	}
+	upgradeLegacyPosts(rec.Posts...)
+
+	return rec, nil
+}
+
+// upgradeLegacyPosts marks the posts written before the editorial workflow
+// existed as published, which is what they were, and those written before
+// content formats existed as plain text.
+func upgradeLegacyPosts(posts ...*entities.Post) {
+	for _, post := range posts {
+		if post.Status == "" {
+			post.Status = entities.StatusPublished
+		}
+		if post.ContentFormat == "" {
+			post.ContentFormat = entities.FormatPlain
+		}
+	}
+}
+
+func (r *FilePostRepository) walPath() string {
+	return filepath.Join(r.dir, walFileName)
+}
+
+func (r *FilePostRepository) snapshotPath() string {
+	return filepath.Join(r.dir, snapshotFileName)
+}
+
+func writeFileSync(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", filepath.Base(path), err)
+	}
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return fmt.Errorf("write %s: %w", filepath.Base(path), err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("sync %s: %w", filepath.Base(path), err)
+	}
+
+	return file.Close()
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("open data directory: %w", err)
+	}
+	defer d.Close()
+
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("sync data directory: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,293 @@
+package repositories
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/repositories"
+	"rakia-tech-test/internal/domain/repositories/repositorytest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openFileRepo(t *testing.T, dir string, compactEvery int) *FilePostRepository {
+	t.Helper()
+
+	repo, err := NewFilePostRepository(dir, compactEvery)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = repo.Close() })
+
+	return repo
+}
+
+func TestFilePostRepository_SurvivesReopen(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	repo := openFileRepo(t, dir, 100)
+
+	post1, err := repo.CreatePost(ctx, "Title 1", "Content 1", "", "Author 1", 0, nil, time.Now())
+	require.NoError(t, err)
+	post2, err := repo.CreatePost(ctx, "Title 2", "Content 2", "", "Author 2", 0, nil, time.Now())
+	require.NoError(t, err)
+
+	updated := *post1
+	updated.Title = "Updated Title"
+	require.NoError(t, repo.Update(ctx, post1.ID, &updated, repositories.AnyVersion))
+	require.NoError(t, repo.Delete(ctx, post2.ID, repositories.AnyVersion))
+
+	// Simulate a crash: drop the repository without compacting.
+	require.NoError(t, repo.wal.Close())
+	repo.closed = true
+
+	reopened := openFileRepo(t, dir, 100)
+
+	posts, err := reopened.GetAll(ctx)
+	require.NoError(t, err)
+	require.Len(t, posts, 1)
+	assert.Equal(t, "Updated Title", posts[0].Title)
+
+	_, err = reopened.GetByID(ctx, post2.ID)
+	assert.ErrorIs(t, err, repositories.ErrPostNotFound)
+
+	// Deleted IDs are never handed out again.
+	post3, err := reopened.CreatePost(ctx, "Title 3", "Content 3", "", "Author 3", 0, nil, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 3, post3.ID)
+}
+
+func TestFilePostRepository_Compaction(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	repo := openFileRepo(t, dir, 3)
+
+	for i := 0; i < 4; i++ {
+		_, err := repo.CreatePost(ctx, "Title", "Content", "", "Author", 0, nil, time.Now())
+		require.NoError(t, err)
+	}
+
+	_, err := os.Stat(filepath.Join(dir, snapshotFileName))
+	require.NoError(t, err, "snapshot should be written after compactEvery records")
+	assert.Equal(t, 1, repo.pending)
+
+	require.NoError(t, repo.Close())
+
+	info, err := os.Stat(filepath.Join(dir, walFileName))
+	require.NoError(t, err)
+	assert.Zero(t, info.Size(), "close should fold the log into the snapshot")
+
+	reopened := openFileRepo(t, dir, 3)
+	posts, err := reopened.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Len(t, posts, 4)
+}
+
+func TestFilePostRepository_LoadData(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	repo := openFileRepo(t, dir, 100)
+
+	posts := []*entities.Post{
+		{ID: 1, Title: "Post 1", Content: "Content 1", Author: "Author 1"},
+		{ID: 5, Title: "Post 5", Content: "Content 5", Author: "Author 5"},
+	}
+	require.NoError(t, repo.LoadData(ctx, posts))
+	require.NoError(t, repo.Close())
+
+	reopened := openFileRepo(t, dir, 100)
+	all, err := reopened.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+
+	newPost, err := reopened.CreatePost(ctx, "New Title", "New Content", "", "New Author", 0, nil, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 6, newPost.ID)
+}
+
+func TestFilePostRepository_TrashSurvivesReopen(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	repo := openFileRepo(t, dir, 100)
+
+	deletedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		_, err := repo.CreatePost(ctx, "Title", "Content", "", "Author", 0, nil, time.Now())
+		require.NoError(t, err)
+	}
+	require.NoError(t, repo.Trash(ctx, 1, repositories.AnyVersion, deletedAt))
+	require.NoError(t, repo.Trash(ctx, 2, repositories.AnyVersion, deletedAt))
+	require.NoError(t, repo.Trash(ctx, 3, repositories.AnyVersion, deletedAt.Add(time.Hour)))
+	_, err := repo.Restore(ctx, 2)
+	require.NoError(t, err)
+	purged, err := repo.Purge(ctx, deletedAt.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, []int{1}, purged)
+
+	// Simulate a crash: drop the repository without compacting.
+	require.NoError(t, repo.wal.Close())
+	repo.closed = true
+
+	reopened := openFileRepo(t, dir, 100)
+
+	posts, err := reopened.GetAll(ctx)
+	require.NoError(t, err)
+	require.Len(t, posts, 1)
+	assert.Equal(t, 2, posts[0].ID)
+
+	trash, err := reopened.GetTrash(ctx)
+	require.NoError(t, err)
+	require.Len(t, trash, 1)
+	assert.Equal(t, 3, trash[0].ID)
+	assert.True(t, deletedAt.Add(time.Hour).Equal(*trash[0].DeletedAt))
+}
+
+func TestFilePostRepository_LegacyPostsArePublished(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	// A snapshot written before posts had a status or a content format.
+	snapshot := `{"next_id":2,"posts":[{"id":1,"title":"Title","content":"Content","author":"Author","version":1}]}`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, snapshotFileName), []byte(snapshot), 0o644))
+
+	repo := openFileRepo(t, dir, 100)
+
+	post, err := repo.GetByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, entities.StatusPublished, post.Status)
+	assert.Equal(t, entities.FormatPlain, post.ContentFormat)
+
+	created, err := repo.CreatePost(ctx, "Draft", "Content", "", "Author", 0, nil, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, entities.StatusDraft, created.Status)
+}
+
+func TestFilePostRepository_LegacyPostsGetSlugs(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	// A snapshot written before posts had slugs.
+	snapshot := `{"next_id":3,"posts":[` +
+		`{"id":1,"title":"Same","content":"Content","author":"Author","version":1,"status":"published"},` +
+		`{"id":2,"title":"Same","content":"Content","author":"Author","version":1,"status":"published"}]}`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, snapshotFileName), []byte(snapshot), 0o644))
+
+	repo := openFileRepo(t, dir, 100)
+	post, err := repo.GetBySlug(ctx, "same-2")
+	require.NoError(t, err)
+	assert.Equal(t, 2, post.ID)
+	require.NoError(t, repo.Close())
+
+	// The slugs are written back, so they do not change on the next open.
+	reopened := openFileRepo(t, dir, 100)
+	post, err = reopened.GetByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "same", post.Slug)
+}
+
+func TestFilePostRepository_TagCountsSurviveReopen(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	// Compacting every other write leaves posts in both the snapshot and
+	// the log.
+	repo := openFileRepo(t, dir, 2)
+
+	for _, tags := range [][]string{{"Go", "testing"}, {"go"}, {"rust"}} {
+		post, err := repo.CreatePost(ctx, "Title", "Content", "", "Author", 0, tags, time.Now())
+		require.NoError(t, err)
+		post.Status = entities.StatusPublished
+		require.NoError(t, repo.Update(ctx, post.ID, post, repositories.AnyVersion))
+	}
+	require.NoError(t, repo.Close())
+
+	reopened := openFileRepo(t, dir, 2)
+	counts, err := reopened.TagCounts(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []repositories.TagCount{{Tag: "go", Posts: 2}, {Tag: "rust", Posts: 1}, {Tag: "testing", Posts: 1}}, counts)
+}
+
+func TestFilePostRepository_TornTailIsDiscarded(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	repo := openFileRepo(t, dir, 100)
+
+	_, err := repo.CreatePost(ctx, "Title 1", "Content 1", "", "Author 1", 0, nil, time.Now())
+	require.NoError(t, err)
+	require.NoError(t, repo.wal.Close())
+	repo.closed = true
+
+	walPath := filepath.Join(dir, walFileName)
+	before, err := os.ReadFile(walPath)
+	require.NoError(t, err)
+
+	f, err := os.OpenFile(walPath, os.O_WRONLY|os.O_APPEND, 0)
+	require.NoError(t, err)
+	_, err = f.WriteString(`1234abcd {"op":"put","post":{"id":2,"ti`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	reopened := openFileRepo(t, dir, 100)
+	posts, err := reopened.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Len(t, posts, 1)
+
+	after, err := os.ReadFile(walPath)
+	require.NoError(t, err)
+	assert.Equal(t, before, after)
+}
+
+func TestFilePostRepository_CorruptedLogFailsToOpen(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	repo := openFileRepo(t, dir, 100)
+
+	_, err := repo.CreatePost(ctx, "Title 1", "Content 1", "", "Author 1", 0, nil, time.Now())
+	require.NoError(t, err)
+	_, err = repo.CreatePost(ctx, "Title 2", "Content 2", "", "Author 2", 0, nil, time.Now())
+	require.NoError(t, err)
+	require.NoError(t, repo.wal.Close())
+	repo.closed = true
+
+	walPath := filepath.Join(dir, walFileName)
+	data, err := os.ReadFile(walPath)
+	require.NoError(t, err)
+	data[12] ^= 0xff
+	require.NoError(t, os.WriteFile(walPath, data, 0o644))
+
+	_, err = NewFilePostRepository(dir, 100)
+	assert.ErrorContains(t, err, "corrupted")
+}
+
+func TestFilePostRepository_ErrorsAreNotLogged(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	repo := openFileRepo(t, dir, 100)
+
+	err := repo.Update(ctx, 42, &entities.Post{ID: 42, Title: "T", Content: "C", Author: "A"}, repositories.AnyVersion)
+	assert.ErrorIs(t, err, repositories.ErrPostNotFound)
+	assert.ErrorIs(t, repo.Delete(ctx, 42, repositories.AnyVersion), repositories.ErrPostNotFound)
+
+	_, err = repo.CreatePost(ctx, "", "Content", "", "Author", 0, nil, time.Now())
+	require.Error(t, err)
+
+	assert.Zero(t, repo.pending)
+}
+
+func TestFilePostRepository_Closed(t *testing.T) {
+	ctx := context.Background()
+	repo := openFileRepo(t, t.TempDir(), 100)
+	require.NoError(t, repo.Close())
+
+	_, err := repo.CreatePost(ctx, "Title", "Content", "", "Author", 0, nil, time.Now())
+	assert.ErrorIs(t, err, ErrRepositoryClosed)
+	assert.ErrorIs(t, repo.Delete(ctx, 1, repositories.AnyVersion), ErrRepositoryClosed)
+	assert.NoError(t, repo.Close())
+}
+
+func TestFilePostRepository_Contract(t *testing.T) {
+	repositorytest.RunPostRepositoryTests(t, func(t *testing.T) repositories.PostRepository {
+		return openFileRepo(t, t.TempDir(), 5)
+	})
+}
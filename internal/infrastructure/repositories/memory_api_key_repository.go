@@ -0,0 +1,143 @@
+package repositories
+
+import (
+	"context"
+	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/repositories"
+	"sort"
+	"sync"
+	"time"
+)
+
+type MemoryAPIKeyRepository struct {
+	keys map[int]*entities.APIKey
+	// prefixes maps the prefix of every stored key to its ID.
+	prefixes map[string]int
+	nextID   int
+	mutex    sync.RWMutex
+}
+
+func NewMemoryAPIKeyRepository() *MemoryAPIKeyRepository {
+	return &MemoryAPIKeyRepository{
+		keys:     make(map[int]*entities.APIKey),
+		prefixes: make(map[string]int),
+		nextID:   1,
+	}
+}
+
+func (r *MemoryAPIKeyRepository) Create(ctx context.Context, key *entities.APIKey) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := key.Validate(); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, taken := r.prefixes[key.Prefix]; taken {
+		return repositories.ErrAPIKeyExists
+	}
+	key.ID = r.nextID
+	r.nextID++
+
+	keyCopy := *key
+	r.keys[key.ID] = &keyCopy
+	r.prefixes[key.Prefix] = key.ID
+
+	return nil
+}
+
+func (r *MemoryAPIKeyRepository) GetByID(ctx context.Context, id int) (*entities.APIKey, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	key, exists := r.keys[id]
+	if !exists {
+		return nil, repositories.ErrAPIKeyNotFound
+	}
+
+	keyCopy := *key
+	return &keyCopy, nil
+}
+
+func (r *MemoryAPIKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*entities.APIKey, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	id, exists := r.prefixes[prefix]
+	if !exists {
+		return nil, repositories.ErrAPIKeyNotFound
+	}
+
+	keyCopy := *r.keys[id]
+	return &keyCopy, nil
+}
+
+func (r *MemoryAPIKeyRepository) List(ctx context.Context) ([]*entities.APIKey, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	keys := make([]*entities.APIKey, 0, len(r.keys))
+	for _, key := range r.keys {
+		keyCopy := *key
+		keys = append(keys, &keyCopy)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].ID < keys[j].ID
+	})
+
+	return keys, nil
+}
+
+func (r *MemoryAPIKeyRepository) Revoke(ctx context.Context, id int, at time.Time) (*entities.APIKey, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	key, exists := r.keys[id]
+	if !exists {
+		return nil, repositories.ErrAPIKeyNotFound
+	}
+	if key.RevokedAt == nil {
+		revokedAt := at.UTC()
+		key.RevokedAt = &revokedAt
+	}
+
+	keyCopy := *key
+	return &keyCopy, nil
+}
+
+func (r *MemoryAPIKeyRepository) MarkUsed(ctx context.Context, id int, at time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	key, exists := r.keys[id]
+	if !exists {
+		return repositories.ErrAPIKeyNotFound
+	}
+	usedAt := at.UTC()
+	key.LastUsedAt = &usedAt
+
+	return nil
+}
@@ -0,0 +1,13 @@
+package repositories
+
+import (
+	"rakia-tech-test/internal/domain/repositories"
+	"rakia-tech-test/internal/domain/repositories/repositorytest"
+	"testing"
+)
+
+func TestMemoryAPIKeyRepository_Contract(t *testing.T) {
+	repositorytest.RunAPIKeyRepositoryTests(t, func(t *testing.T) repositories.APIKeyRepository {
+		return NewMemoryAPIKeyRepository()
+	})
+}
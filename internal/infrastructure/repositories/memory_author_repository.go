@@ -0,0 +1,182 @@
+package repositories
+
+import (
+	"context"
+	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/repositories"
+	"sort"
+	"sync"
+	"time"
+)
+
+type MemoryAuthorRepository struct {
+	authors map[int]*entities.Author
+	// names maps the entities.AuthorNameKey of every stored author to its
+	// ID.
+	names  map[string]int
+	nextID int
+	mutex  sync.RWMutex
+}
+
+func NewMemoryAuthorRepository() *MemoryAuthorRepository {
+	return &MemoryAuthorRepository{
+		authors: make(map[int]*entities.Author),
+		names:   make(map[string]int),
+		nextID:  1,
+	}
+}
+
+func (r *MemoryAuthorRepository) CreateAuthor(ctx context.Context, name, bio string, createdAt time.Time) (*entities.Author, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	author, err := entities.NewAuthor(r.nextID, name, bio, createdAt)
+	if err != nil {
+		return nil, err
+	}
+	key := entities.AuthorNameKey(author.Name)
+	if _, taken := r.names[key]; taken {
+		return nil, repositories.ErrAuthorExists
+	}
+	r.nextID++
+
+	authorCopy := *author
+	r.authors[author.ID] = &authorCopy
+	r.names[key] = author.ID
+
+	return author, nil
+}
+
+func (r *MemoryAuthorRepository) Create(ctx context.Context, author *entities.Author) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := author.Validate(); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	key := entities.AuthorNameKey(author.Name)
+	if _, taken := r.authors[author.ID]; taken {
+		return repositories.ErrAuthorExists
+	}
+	if _, taken := r.names[key]; taken {
+		return repositories.ErrAuthorExists
+	}
+	if author.ID >= r.nextID {
+		r.nextID = author.ID + 1
+	}
+
+	authorCopy := *author
+	r.authors[author.ID] = &authorCopy
+	r.names[key] = author.ID
+
+	return nil
+}
+
+func (r *MemoryAuthorRepository) GetByID(ctx context.Context, id int) (*entities.Author, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	author, exists := r.authors[id]
+	if !exists {
+		return nil, repositories.ErrAuthorNotFound
+	}
+
+	authorCopy := *author
+	return &authorCopy, nil
+}
+
+func (r *MemoryAuthorRepository) GetByName(ctx context.Context, name string) (*entities.Author, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	id, exists := r.names[entities.AuthorNameKey(name)]
+	if !exists {
+		return nil, repositories.ErrAuthorNotFound
+	}
+
+	authorCopy := *r.authors[id]
+	return &authorCopy, nil
+}
+
+func (r *MemoryAuthorRepository) List(ctx context.Context) ([]*entities.Author, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	authors := make([]*entities.Author, 0, len(r.authors))
+	for _, author := range r.authors {
+		authorCopy := *author
+		authors = append(authors, &authorCopy)
+	}
+	sort.Slice(authors, func(i, j int) bool {
+		return authors[i].ID < authors[j].ID
+	})
+
+	return authors, nil
+}
+
+func (r *MemoryAuthorRepository) Update(ctx context.Context, author *entities.Author) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := author.Validate(); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stored, exists := r.authors[author.ID]
+	if !exists {
+		return repositories.ErrAuthorNotFound
+	}
+	key := entities.AuthorNameKey(author.Name)
+	if owner, taken := r.names[key]; taken && owner != author.ID {
+		return repositories.ErrAuthorExists
+	}
+
+	delete(r.names, entities.AuthorNameKey(stored.Name))
+	authorCopy := *author
+	r.authors[author.ID] = &authorCopy
+	r.names[key] = author.ID
+
+	return nil
+}
+
+func (r *MemoryAuthorRepository) Delete(ctx context.Context, id int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	author, exists := r.authors[id]
+	if !exists {
+		return repositories.ErrAuthorNotFound
+	}
+
+	delete(r.names, entities.AuthorNameKey(author.Name))
+	delete(r.authors, id)
+
+	return nil
+}
@@ -0,0 +1,13 @@
+package repositories
+
+import (
+	"rakia-tech-test/internal/domain/repositories"
+	"rakia-tech-test/internal/domain/repositories/repositorytest"
+	"testing"
+)
+
+func TestMemoryAuthorRepository_Contract(t *testing.T) {
+	repositorytest.RunAuthorRepositoryTests(t, func(t *testing.T) repositories.AuthorRepository {
+		return NewMemoryAuthorRepository()
+	})
+}
@@ -0,0 +1,176 @@
+package repositories
+
+import (
+	"context"
+	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/repositories"
+	"sync"
+	"time"
+)
+
+type MemoryCommentRepository struct {
+	comments map[int]*entities.Comment
+	// byPost lists the IDs of the comments on each post in ascending order,
+	// which puts every reply after its parent.
+	byPost map[int][]int
+	nextID int
+	mutex  sync.RWMutex
+}
+
+func NewMemoryCommentRepository() *MemoryCommentRepository {
+	return &MemoryCommentRepository{
+		comments: make(map[int]*entities.Comment),
+		byPost:   make(map[int][]int),
+		nextID:   1,
+	}
+}
+
+func (r *MemoryCommentRepository) CreateComment(ctx context.Context, postID, parentID int, author, content string, createdAt time.Time) (*entities.Comment, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if parentID != 0 {
+		parent, exists := r.comments[parentID]
+		if !exists || parent.PostID != postID {
+			return nil, repositories.ErrParentNotFound
+		}
+	}
+
+	comment, err := entities.NewComment(r.nextID, postID, parentID, author, content, createdAt)
+	if err != nil {
+		return nil, err
+	}
+	r.nextID++
+
+	commentCopy := *comment
+	r.comments[comment.ID] = &commentCopy
+	r.byPost[postID] = append(r.byPost[postID], comment.ID)
+
+	return comment, nil
+}
+
+func (r *MemoryCommentRepository) GetByID(ctx context.Context, id int) (*entities.Comment, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	comment, exists := r.comments[id]
+	if !exists {
+		return nil, repositories.ErrCommentNotFound
+	}
+
+	commentCopy := *comment
+	return &commentCopy, nil
+}
+
+func (r *MemoryCommentRepository) ListThreads(ctx context.Context, postID int, query repositories.CommentQuery) (*repositories.CommentPage, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	page := &repositories.CommentPage{Comments: []*entities.Comment{}}
+	threads, selected := 0, make(map[int]bool)
+	for _, id := range r.byPost[postID] {
+		comment := r.comments[id]
+		if comment.ParentID == 0 {
+			if comment.ID <= query.After {
+				continue
+			}
+			// Replies to the selected threads may come after later
+			// threads, so the scan goes on past the page.
+			if threads == query.Limit {
+				page.HasNext = true
+				continue
+			}
+			threads++
+		} else if !selected[comment.ParentID] {
+			continue
+		}
+
+		selected[comment.ID] = true
+		commentCopy := *comment
+		page.Comments = append(page.Comments, &commentCopy)
+	}
+
+	return page, nil
+}
+
+func (r *MemoryCommentRepository) Update(ctx context.Context, comment *entities.Comment) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := comment.Validate(); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stored, exists := r.comments[comment.ID]
+	if !exists {
+		return repositories.ErrCommentNotFound
+	}
+
+	// A comment stays where it was written.
+	commentCopy := *comment
+	commentCopy.PostID, commentCopy.ParentID = stored.PostID, stored.ParentID
+	r.comments[comment.ID] = &commentCopy
+
+	return nil
+}
+
+func (r *MemoryCommentRepository) Delete(ctx context.Context, id int) ([]int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	comment, exists := r.comments[id]
+	if !exists {
+		return nil, repositories.ErrCommentNotFound
+	}
+
+	removed := make(map[int]bool)
+	var deleted []int
+	kept := make([]int, 0, len(r.byPost[comment.PostID]))
+	for _, otherID := range r.byPost[comment.PostID] {
+		if otherID == id || removed[r.comments[otherID].ParentID] {
+			removed[otherID] = true
+			deleted = append(deleted, otherID)
+			delete(r.comments, otherID)
+			continue
+		}
+		kept = append(kept, otherID)
+	}
+	r.byPost[comment.PostID] = kept
+
+	return deleted, nil
+}
+
+func (r *MemoryCommentRepository) DeleteByPost(ctx context.Context, postID int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, id := range r.byPost[postID] {
+		delete(r.comments, id)
+	}
+	delete(r.byPost, postID)
+
+	return nil
+}
@@ -0,0 +1,13 @@
+package repositories
+
+import (
+	"rakia-tech-test/internal/domain/repositories"
+	"rakia-tech-test/internal/domain/repositories/repositorytest"
+	"testing"
+)
+
+func TestMemoryCommentRepository_Contract(t *testing.T) {
+	repositorytest.RunCommentRepositoryTests(t, func(t *testing.T) repositories.CommentRepository {
+		return NewMemoryCommentRepository()
+	})
+}
@@ -1,27 +1,51 @@
 package repositories
 
 import (
+	"context"
 	"rakia-tech-test/internal/domain/entities"
 	"rakia-tech-test/internal/domain/repositories"
 	"sort"
 	"sync"
+	"time"
 )
 
 type MemoryPostRepository struct {
-	posts  map[int]*entities.Post
+	posts map[int]*entities.Post
+	// liveIDs holds the IDs of the posts that are not trashed, in ascending
+	// order, so pages can be cut without scanning or copying the whole map.
+	liveIDs []int
+	// publishedIDs holds the IDs of the live posts that are published, and
+	// unpublishedIDs those of the other live posts by author, keyed by
+	// entities.AuthorNameKey, all in ascending order. Listings shown to a
+	// viewer are cut from them like from liveIDs.
+	publishedIDs   []int
+	unpublishedIDs map[string][]int
+	// tagCounts holds the number of posts counting towards each tag, kept
+	// up to date by store.
+	tagCounts map[string]int
+	// slugs maps the current and previous slugs of every stored post,
+	// trashed ones included, to its ID. It is kept up to date by store.
+	slugs  map[string]int
 	nextID int
 	mutex  sync.RWMutex
 }
 
 func NewMemoryPostRepository() *MemoryPostRepository {
 	return &MemoryPostRepository{
-		posts:  make(map[int]*entities.Post),
-		nextID: 1,
-		mutex:  sync.RWMutex{},
+		posts:          make(map[int]*entities.Post),
+		unpublishedIDs: make(map[string][]int),
+		tagCounts:      make(map[string]int),
+		slugs:          make(map[string]int),
+		nextID:         1,
+		mutex:          sync.RWMutex{},
 	}
 }
 
-func (r *MemoryPostRepository) Create(post *entities.Post) error {
+func (r *MemoryPostRepository) Create(ctx context.Context, post *entities.Post) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
@@ -31,7 +55,11 @@ func (r *MemoryPostRepository) Create(post *entities.Post) error {
 
 	// Create a copy to avoid external modifications
 	postCopy := *post
-	r.posts[post.ID] = &postCopy
+	if err := r.settleSlug(&postCopy); err != nil {
+		return err
+	}
+	r.store(post.ID, &postCopy)
+	post.Slug, post.PreviousSlugs = postCopy.Slug, postCopy.PreviousSlugs
 
 	if post.ID >= r.nextID {
 		r.nextID = post.ID + 1
@@ -40,30 +68,48 @@ func (r *MemoryPostRepository) Create(post *entities.Post) error {
 	return nil
 }
 
-func (r *MemoryPostRepository) CreatePost(title, content, author string) (*entities.Post, error) {
+func (r *MemoryPostRepository) CreatePost(ctx context.Context, title, content string, format entities.ContentFormat, author string, authorID int, tags []string, createdAt time.Time) (*entities.Post, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	id := r.nextID
-	r.nextID++
-
-	post, err := entities.NewPost(id, title, content, author)
+	post, err := entities.NewPost(r.nextID, title, content, author, createdAt)
 	if err != nil {
 		return nil, err
 	}
+	post.AuthorID = authorID
+	if format != "" {
+		if err := post.SetContentFormat(format); err != nil {
+			return nil, err
+		}
+	}
+	if err := post.SetTags(tags); err != nil {
+		return nil, err
+	}
+	if err := r.settleSlug(post); err != nil {
+		return nil, err
+	}
+	r.nextID++
 
 	postCopy := *post
-	r.posts[post.ID] = &postCopy
+	r.store(post.ID, &postCopy)
 
 	resultCopy := *post
 	return &resultCopy, nil
 }
 
-func (r *MemoryPostRepository) GetByID(id int) (*entities.Post, error) {
+func (r *MemoryPostRepository) GetByID(ctx context.Context, id int) (*entities.Post, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
-	post, exists := r.posts[id]
+	post, exists := r.live(id)
 	if !exists {
 		return nil, repositories.ErrPostNotFound
 	}
@@ -72,17 +118,296 @@ func (r *MemoryPostRepository) GetByID(id int) (*entities.Post, error) {
 	return &postCopy, nil
 }
 
-func (r *MemoryPostRepository) GetAll() ([]*entities.Post, error) {
+func (r *MemoryPostRepository) GetBySlug(ctx context.Context, slug string) (*entities.Post, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
-	posts := make([]*entities.Post, 0, len(r.posts))
+	id, exists := r.slugs[slug]
+	if !exists {
+		return nil, repositories.ErrPostNotFound
+	}
+	post, exists := r.live(id)
+	if !exists {
+		return nil, repositories.ErrPostNotFound
+	}
+
+	postCopy := *post
+	return &postCopy, nil
+}
+
+func (r *MemoryPostRepository) GetAll(ctx context.Context) ([]*entities.Post, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.copyPosts(r.liveIDs), nil
+}
+
+func (r *MemoryPostRepository) ListPosts(ctx context.Context, query repositories.PostQuery) (*repositories.PostPage, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	// Listings in ID order filtered by viewer at most are cut straight off
+	// the indexes.
+	rest := query.Filter
+	rest.Viewer = nil
+	if rest.IsZero() && (query.Sort == "" || query.Sort == repositories.SortByID) {
+		if viewer := query.Filter.Viewer; viewer != nil {
+			return r.listByID(query, r.publishedIDs, r.unpublishedIDs[entities.AuthorNameKey(viewer.Author)]), nil
+		}
+		return r.listByID(query, r.liveIDs), nil
+	}
+
+	// Collect the matching posts, narrowed to the ID range through the
+	// index, and order them; only the page itself is copied.
+	lo := sort.SearchInts(r.liveIDs, query.Filter.MinID)
+	hi := len(r.liveIDs)
+	if query.Filter.MaxID != 0 {
+		hi = sort.SearchInts(r.liveIDs, query.Filter.MaxID+1)
+	}
+	matches := make([]*entities.Post, 0)
+	for _, id := range r.liveIDs[lo:max(lo, hi)] {
+		if post := r.posts[id]; query.Filter.Match(post) {
+			matches = append(matches, post)
+		}
+	}
+
+	position := func(i int) repositories.Cursor {
+		return query.Sort.CursorFor(matches[i])
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return query.Sort.Compare(position(i), position(j)) < 0
+	})
+
+	var start, end int
+	if query.Before != nil {
+		end = sort.Search(len(matches), func(i int) bool {
+			return query.Sort.Compare(position(i), *query.Before) >= 0
+		})
+		start = max(0, end-query.Limit)
+	} else {
+		if query.After != nil {
+			start = sort.Search(len(matches), func(i int) bool {
+				return query.Sort.Compare(position(i), *query.After) > 0
+			})
+		}
+		end = min(len(matches), start+query.Limit)
+	}
+
+	posts := make([]*entities.Post, 0, end-start)
+	for _, post := range matches[start:end] {
+		postCopy := *post
+		posts = append(posts, &postCopy)
+	}
+
+	return &repositories.PostPage{
+		Posts:   posts,
+		HasPrev: start > 0,
+		HasNext: end < len(matches),
+	}, nil
+}
+
+// listByID pages in ID order through the posts whose IDs are in lists,
+// which are ascending and disjoint, merging them as it goes. The caller must
+// hold the lock.
+func (r *MemoryPostRepository) listByID(query repositories.PostQuery, lists ...[]int) *repositories.PostPage {
+	// bounds[i] is where the page starts in lists[i], or where it ends when
+	// paging backwards.
+	bounds := make([]int, len(lists))
+	ids := make([]int, 0, query.Limit)
+	var hasPrev, hasNext bool
+
+	if query.Before != nil {
+		for i, list := range lists {
+			bounds[i] = sort.SearchInts(list, query.Before.ID)
+			hasNext = hasNext || bounds[i] < len(list)
+		}
+		for len(ids) < query.Limit {
+			next := -1
+			for i, list := range lists {
+				if bounds[i] > 0 && (next < 0 || list[bounds[i]-1] > lists[next][bounds[next]-1]) {
+					next = i
+				}
+			}
+			if next < 0 {
+				break
+			}
+			bounds[next]--
+			ids = append(ids, lists[next][bounds[next]])
+		}
+		for i, j := 0, len(ids)-1; i < j; i, j = i+1, j-1 {
+			ids[i], ids[j] = ids[j], ids[i]
+		}
+		for _, bound := range bounds {
+			hasPrev = hasPrev || bound > 0
+		}
+	} else {
+		for i, list := range lists {
+			if query.After != nil {
+				bounds[i] = sort.SearchInts(list, query.After.ID+1)
+			}
+			hasPrev = hasPrev || bounds[i] > 0
+		}
+		for len(ids) < query.Limit {
+			next := -1
+			for i, list := range lists {
+				if bounds[i] < len(list) && (next < 0 || list[bounds[i]] < lists[next][bounds[next]]) {
+					next = i
+				}
+			}
+			if next < 0 {
+				break
+			}
+			ids = append(ids, lists[next][bounds[next]])
+			bounds[next]++
+		}
+		for i, bound := range bounds {
+			hasNext = hasNext || bound < len(lists[i])
+		}
+	}
+
+	return &repositories.PostPage{
+		Posts:   r.copyPosts(ids),
+		HasPrev: hasPrev,
+		HasNext: hasNext,
+	}
+}
+
+func (r *MemoryPostRepository) Update(ctx context.Context, id int, post *entities.Post, expectedVersion int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if err := r.checkVersion(id, expectedVersion); err != nil {
+		return err
+	}
+
+	postCopy := *post
+	postCopy.ID = id
+	postCopy.Slug = ""
+	if err := r.settleSlug(&postCopy); err != nil {
+		return err
+	}
+	r.store(id, &postCopy)
+	post.Slug, post.PreviousSlugs = postCopy.Slug, postCopy.PreviousSlugs
+
+	return nil
+}
+
+func (r *MemoryPostRepository) Delete(ctx context.Context, id int, expectedVersion int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if err := r.checkVersion(id, expectedVersion); err != nil {
+		return err
+	}
+
+	r.store(id, nil)
+	return nil
+}
+
+func (r *MemoryPostRepository) Exists(ctx context.Context, id int) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	_, exists := r.live(id)
+	return exists
+}
+
+func (r *MemoryPostRepository) LoadData(ctx context.Context, posts []*entities.Post) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	// A slug clash rolls back the posts stored so far, so the load happens
+	// entirely or not at all.
+	replaced := make(map[int]*entities.Post)
+	nextID := r.nextID
+	for _, post := range posts {
+		if _, seen := replaced[post.ID]; !seen {
+			replaced[post.ID] = r.posts[post.ID]
+		}
+
+		postCopy := *post
+		if err := r.settleSlug(&postCopy); err != nil {
+			for id, prev := range replaced {
+				r.store(id, prev)
+			}
+			r.nextID = nextID
+			return err
+		}
+		r.store(post.ID, &postCopy)
+		post.Slug, post.PreviousSlugs = postCopy.Slug, postCopy.PreviousSlugs
+
+		if post.ID >= r.nextID {
+			r.nextID = post.ID + 1
+		}
+	}
+
+	return nil
+}
+
+func (r *MemoryPostRepository) Trash(ctx context.Context, id int, expectedVersion int, deletedAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if err := r.checkVersion(id, expectedVersion); err != nil {
+		return err
+	}
+
+	postCopy := *r.posts[id]
+	postCopy.DeletedAt = &deletedAt
+	r.store(id, &postCopy)
+
+	return nil
+}
+
+func (r *MemoryPostRepository) GetTrash(ctx context.Context) ([]*entities.Post, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	posts := make([]*entities.Post, 0)
 	for _, post := range r.posts {
+		if !post.IsDeleted() {
+			continue
+		}
 		postCopy := *post
 		posts = append(posts, &postCopy)
 	}
 
-	// Sort posts by ID in ascending order
 	sort.Slice(posts, func(i, j int) bool {
 		return posts[i].ID < posts[j].ID
 	})
@@ -90,53 +415,303 @@ func (r *MemoryPostRepository) GetAll() ([]*entities.Post, error) {
 	return posts, nil
 }
 
-func (r *MemoryPostRepository) Update(id int, post *entities.Post) error {
+func (r *MemoryPostRepository) Restore(ctx context.Context, id int) (*entities.Post, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	if _, exists := r.posts[id]; !exists {
-		return repositories.ErrPostNotFound
+	post, exists := r.posts[id]
+	if !exists || !post.IsDeleted() {
+		return nil, repositories.ErrPostNotFound
 	}
 
 	postCopy := *post
-	postCopy.ID = id
-	r.posts[id] = &postCopy
+	postCopy.DeletedAt = nil
+	r.store(id, &postCopy)
 
-	return nil
+	resultCopy := postCopy
+	return &resultCopy, nil
 }
 
-func (r *MemoryPostRepository) Delete(id int) error {
+func (r *MemoryPostRepository) Purge(ctx context.Context, deletedBefore time.Time) ([]int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	if _, exists := r.posts[id]; !exists {
+	purged := make([]int, 0)
+	for id, post := range r.posts {
+		if post.IsDeleted() && post.DeletedAt.Before(deletedBefore) {
+			r.store(id, nil)
+			purged = append(purged, id)
+		}
+	}
+	sort.Ints(purged)
+
+	return purged, nil
+}
+
+func (r *MemoryPostRepository) TagCounts(ctx context.Context) ([]repositories.TagCount, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	counts := make([]repositories.TagCount, 0, len(r.tagCounts))
+	for tag, posts := range r.tagCounts {
+		counts = append(counts, repositories.TagCount{Tag: tag, Posts: posts})
+	}
+	repositories.SortTagCounts(counts)
+
+	return counts, nil
+}
+
+// store replaces the stored post with the given ID, or removes it when post
+// is nil, and keeps the ID indexes, tagCounts and slugs in sync. The caller
+// must hold the write lock.
+func (r *MemoryPostRepository) store(id int, post *entities.Post) {
+	old := r.posts[id]
+	r.countTags(old, -1)
+	r.countTags(post, 1)
+	r.indexSlugs(id, old, post)
+	r.indexIDs(id, old, -1)
+	r.indexIDs(id, post, 1)
+
+	if post == nil {
+		delete(r.posts, id)
+	} else {
+		r.posts[id] = post
+	}
+}
+
+// indexIDs adds the ID of post to the ID indexes it belongs in when delta is
+// positive, and removes it when it is negative. Trashed posts and nil are in
+// none. The caller must hold the write lock.
+func (r *MemoryPostRepository) indexIDs(id int, post *entities.Post, delta int) {
+	if post == nil || post.IsDeleted() {
+		return
+	}
+
+	update := func(ids []int) []int {
+		i := sort.SearchInts(ids, id)
+		indexed := i < len(ids) && ids[i] == id
+		switch {
+		case delta > 0 && !indexed:
+			ids = append(ids, 0)
+			copy(ids[i+1:], ids[i:])
+			ids[i] = id
+		case delta < 0 && indexed:
+			ids = append(ids[:i], ids[i+1:]...)
+		}
+		return ids
+	}
+
+	r.liveIDs = update(r.liveIDs)
+	if post.IsPublished() {
+		r.publishedIDs = update(r.publishedIDs)
+		return
+	}
+	key := entities.AuthorNameKey(post.Author)
+	if ids := update(r.unpublishedIDs[key]); len(ids) > 0 {
+		r.unpublishedIDs[key] = ids
+	} else {
+		delete(r.unpublishedIDs, key)
+	}
+}
+
+// countTags adds delta to the count of every tag of post, if the post counts
+// towards its tags. The caller must hold the write lock.
+func (r *MemoryPostRepository) countTags(post *entities.Post, delta int) {
+	if !repositories.CountsTowardsTags(post) {
+		return
+	}
+	for _, tag := range post.Tags {
+		r.tagCounts[tag] += delta
+		if r.tagCounts[tag] == 0 {
+			delete(r.tagCounts, tag)
+		}
+	}
+}
+
+// indexSlugs moves the slugs of the post with the given ID in the slug index
+// from those of old to those of post; either may be nil. The caller must
+// hold the write lock.
+func (r *MemoryPostRepository) indexSlugs(id int, old, post *entities.Post) {
+	if old != nil {
+		for _, slug := range old.Slugs() {
+			if r.slugs[slug] == id {
+				delete(r.slugs, slug)
+			}
+		}
+	}
+	if post != nil {
+		for _, slug := range post.Slugs() {
+			r.slugs[slug] = id
+		}
+	}
+}
+
+// settleSlug gives post, which is about to be stored, its slug. A post
+// without one keeps the slugs of the stored post with its ID, if any, and
+// AssignSlug brings them in line with its title. Slugs the post carries are
+// kept unless another post holds one of them. The caller must hold the
+// write lock.
+func (r *MemoryPostRepository) settleSlug(post *entities.Post) error {
+	taken := func(slug string) bool {
+		owner, exists := r.slugs[slug]
+		return exists && owner != post.ID
+	}
+
+	if post.Slug != "" {
+		for _, slug := range post.Slugs() {
+			if taken(slug) {
+				return repositories.ErrSlugExists
+			}
+		}
+		return nil
+	}
+
+	if stored, exists := r.posts[post.ID]; exists {
+		post.Slug, post.PreviousSlugs = stored.Slug, stored.PreviousSlugs
+	}
+	post.AssignSlug(taken)
+	return nil
+}
+
+// copyPosts returns copies of the stored posts with the given IDs. The
+// caller must hold the lock.
+func (r *MemoryPostRepository) copyPosts(ids []int) []*entities.Post {
+	posts := make([]*entities.Post, 0, len(ids))
+	for _, id := range ids {
+		postCopy := *r.posts[id]
+		posts = append(posts, &postCopy)
+	}
+	return posts
+}
+
+// live returns the stored post with the given ID unless it is missing or
+// trashed. The caller must hold the lock.
+func (r *MemoryPostRepository) live(id int) (*entities.Post, bool) {
+	post, exists := r.posts[id]
+	if !exists || post.IsDeleted() {
+		return nil, false
+	}
+	return post, true
+}
+
+// checkVersion verifies that the post exists, is not trashed and is at
+// expectedVersion. The caller must hold the write lock so the check and the
+// write are atomic.
+func (r *MemoryPostRepository) checkVersion(id int, expectedVersion int) error {
+	post, exists := r.live(id)
+	if !exists {
 		return repositories.ErrPostNotFound
 	}
+	if expectedVersion != repositories.AnyVersion && post.Version != expectedVersion {
+		return repositories.ErrVersionConflict
+	}
 
-	delete(r.posts, id)
 	return nil
 }
 
-func (r *MemoryPostRepository) Exists(id int) bool {
+// state returns a copy of the stored post with the given ID (nil when absent)
+// together with the next ID to allocate, so callers can roll a change back.
+func (r *MemoryPostRepository) state(id int) (*entities.Post, int) {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
-	_, exists := r.posts[id]
-	return exists
+	var postCopy *entities.Post
+	if post, exists := r.posts[id]; exists {
+		p := *post
+		postCopy = &p
+	}
+	return postCopy, r.nextID
 }
 
-func (r *MemoryPostRepository) LoadData(posts []*entities.Post) error {
+// revert puts the stored post with the given ID back to prev (removing it
+// when prev is nil) and resets the ID sequence.
+func (r *MemoryPostRepository) revert(id int, prev *entities.Post, nextID int) {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	for _, post := range posts {
+	if prev == nil {
+		r.store(id, nil)
+	} else {
+		postCopy := *prev
+		r.store(id, &postCopy)
+	}
+	r.nextID = nextID
+}
+
+// dump returns copies of every stored post, sorted by ID, and the next ID to
+// allocate.
+func (r *MemoryPostRepository) dump() ([]*entities.Post, int) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	posts := make([]*entities.Post, 0, len(r.posts))
+	for _, post := range r.posts {
 		postCopy := *post
-		r.posts[post.ID] = &postCopy
+		posts = append(posts, &postCopy)
+	}
+
+	sort.Slice(posts, func(i, j int) bool {
+		return posts[i].ID < posts[j].ID
+	})
+
+	return posts, r.nextID
+}
 
+// reset replaces the whole repository state.
+func (r *MemoryPostRepository) reset(posts []*entities.Post, nextID int) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.posts = make(map[int]*entities.Post, len(posts))
+	r.liveIDs = nil
+	r.publishedIDs = nil
+	r.unpublishedIDs = make(map[string][]int)
+	r.tagCounts = make(map[string]int)
+	r.slugs = make(map[string]int)
+	r.nextID = 1
+	for _, post := range posts {
+		postCopy := *post
+		r.store(post.ID, &postCopy)
 		if post.ID >= r.nextID {
 			r.nextID = post.ID + 1
 		}
 	}
+	if nextID > r.nextID {
+		r.nextID = nextID
+	}
+}
 
-	return nil
+// assignMissingSlugs gives a slug to every stored post that has none, in ID
+// order, and reports whether there were any.
+func (r *MemoryPostRepository) assignMissingSlugs() bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	missing := make([]int, 0)
+	for id, post := range r.posts {
+		if post.Slug == "" {
+			missing = append(missing, id)
+		}
+	}
+	sort.Ints(missing)
+
+	for _, id := range missing {
+		postCopy := *r.posts[id]
+		_ = r.settleSlug(&postCopy)
+		r.store(id, &postCopy)
+	}
+
+	return len(missing) > 0
 }
@@ -1,40 +1,46 @@
 package repositories
 
 import (
+	"context"
 	"fmt"
+	"math/rand"
 	"rakia-tech-test/internal/domain/entities"
 	"rakia-tech-test/internal/domain/repositories"
+	"rakia-tech-test/internal/domain/repositories/repositorytest"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 func TestMemoryPostRepository_Create(t *testing.T) {
+	ctx := context.Background()
 	repo := NewMemoryPostRepository()
 
-	post, err := entities.NewPost(1, "Test Title", "Test Content", "Test Author")
+	post, err := entities.NewPost(1, "Test Title", "Test Content", "Test Author", time.Now())
 	require.NoError(t, err)
 
-	err = repo.Create(post)
+	err = repo.Create(ctx, post)
 	assert.NoError(t, err)
 
-	retrievedPost, err := repo.GetByID(1)
+	retrievedPost, err := repo.GetByID(ctx, 1)
 	require.NoError(t, err)
 	assert.Equal(t, post.ID, retrievedPost.ID)
 	assert.Equal(t, post.Title, retrievedPost.Title)
 	assert.Equal(t, post.Content, retrievedPost.Content)
 	assert.Equal(t, post.Author, retrievedPost.Author)
 
-	err = repo.Create(post)
+	err = repo.Create(ctx, post)
 	assert.ErrorIs(t, err, repositories.ErrPostExists)
 }
 
 func TestMemoryPostRepository_CreatePost(t *testing.T) {
+	ctx := context.Background()
 	repo := NewMemoryPostRepository()
 
-	post, err := repo.CreatePost("Test Title", "Test Content", "Test Author")
+	post, err := repo.CreatePost(ctx, "Test Title", "Test Content", "", "Test Author", 0, nil, time.Now())
 	require.NoError(t, err)
 	require.NotNil(t, post)
 
@@ -43,18 +49,18 @@ func TestMemoryPostRepository_CreatePost(t *testing.T) {
 	assert.Equal(t, "Test Content", post.Content)
 	assert.Equal(t, "Test Author", post.Author)
 
-	retrievedPost, err := repo.GetByID(post.ID)
+	retrievedPost, err := repo.GetByID(ctx, post.ID)
 	require.NoError(t, err)
 	assert.Equal(t, post.ID, retrievedPost.ID)
 	assert.Equal(t, post.Title, retrievedPost.Title)
 	assert.Equal(t, post.Content, retrievedPost.Content)
 	assert.Equal(t, post.Author, retrievedPost.Author)
 
-	post2, err := repo.CreatePost("Second Title", "Second Content", "Second Author")
+	post2, err := repo.CreatePost(ctx, "Second Title", "Second Content", "", "Second Author", 0, nil, time.Now())
 	require.NoError(t, err)
 	assert.Equal(t, 2, post2.ID)
 
-	_, err = repo.CreatePost("", "Content", "Author")
+	_, err = repo.CreatePost(ctx, "", "Content", "", "Author", 0, nil, time.Now())
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "title is required")
 
@@ -66,10 +72,14 @@ func TestMemoryPostRepository_CreatePost(t *testing.T) {
 		wg.Add(1)
 		go func(i int) {
 			defer wg.Done()
-			post, err := repo.CreatePost(
+			post, err := repo.CreatePost(ctx,
 				fmt.Sprintf("Title %d", i),
 				fmt.Sprintf("Content %d", i),
+				"",
 				fmt.Sprintf("Author %d", i),
+				0,
+				nil,
+				time.Now(),
 			)
 			if err == nil {
 				mu.Lock()
@@ -90,18 +100,19 @@ func TestMemoryPostRepository_CreatePost(t *testing.T) {
 }
 
 func TestMemoryPostRepository_GetByID(t *testing.T) {
+	ctx := context.Background()
 	repo := NewMemoryPostRepository()
 
-	post, err := entities.NewPost(1, "Test Title", "Test Content", "Test Author")
+	post, err := entities.NewPost(1, "Test Title", "Test Content", "Test Author", time.Now())
 	require.NoError(t, err)
 
-	_, err = repo.GetByID(post.ID)
+	_, err = repo.GetByID(ctx, post.ID)
 	assert.Equal(t, repositories.ErrPostNotFound, err)
 
-	err = repo.Create(post)
+	err = repo.Create(ctx, post)
 	require.NoError(t, err)
 
-	retrievedPost, err := repo.GetByID(post.ID)
+	retrievedPost, err := repo.GetByID(ctx, post.ID)
 	require.NoError(t, err)
 	assert.Equal(t, post.ID, retrievedPost.ID)
 	assert.Equal(t, post.Title, retrievedPost.Title)
@@ -109,35 +120,36 @@ func TestMemoryPostRepository_GetByID(t *testing.T) {
 	assert.Equal(t, post.Author, retrievedPost.Author)
 
 	retrievedPost.Title = "Modified Title"
-	originalPost, err := repo.GetByID(post.ID)
+	originalPost, err := repo.GetByID(ctx, post.ID)
 	require.NoError(t, err)
 	assert.Equal(t, post.Title, originalPost.Title)
 }
 
 func TestMemoryPostRepository_GetAll(t *testing.T) {
+	ctx := context.Background()
 	repo := NewMemoryPostRepository()
 
-	posts, err := repo.GetAll()
+	posts, err := repo.GetAll(ctx)
 	require.NoError(t, err)
 	assert.Empty(t, posts)
 
 	// Create posts with IDs out of order to test sorting
-	post3, err := entities.NewPost(3, "Title 3", "Content 3", "Author 3")
+	post3, err := entities.NewPost(3, "Title 3", "Content 3", "Author 3", time.Now())
 	require.NoError(t, err)
-	post1, err := entities.NewPost(1, "Title 1", "Content 1", "Author 1")
+	post1, err := entities.NewPost(1, "Title 1", "Content 1", "Author 1", time.Now())
 	require.NoError(t, err)
-	post2, err := entities.NewPost(2, "Title 2", "Content 2", "Author 2")
+	post2, err := entities.NewPost(2, "Title 2", "Content 2", "Author 2", time.Now())
 	require.NoError(t, err)
 
 	// Add posts in random order
-	err = repo.Create(post3)
+	err = repo.Create(ctx, post3)
 	require.NoError(t, err)
-	err = repo.Create(post1)
+	err = repo.Create(ctx, post1)
 	require.NoError(t, err)
-	err = repo.Create(post2)
+	err = repo.Create(ctx, post2)
 	require.NoError(t, err)
 
-	posts, err = repo.GetAll()
+	posts, err = repo.GetAll(ctx)
 	require.NoError(t, err)
 	assert.Len(t, posts, 3)
 
@@ -148,7 +160,7 @@ func TestMemoryPostRepository_GetAll(t *testing.T) {
 
 	// Test defensive copying
 	posts[0].Title = "Modified Title"
-	originalPosts, err := repo.GetAll()
+	originalPosts, err := repo.GetAll(ctx)
 	require.NoError(t, err)
 	assert.NotEqual(t, "Modified Title", originalPosts[0].Title)
 	assert.NotEqual(t, "Modified Title", originalPosts[1].Title)
@@ -156,62 +168,66 @@ func TestMemoryPostRepository_GetAll(t *testing.T) {
 }
 
 func TestMemoryPostRepository_Update(t *testing.T) {
+	ctx := context.Background()
 	repo := NewMemoryPostRepository()
 
-	post, err := entities.NewPost(1, "Test Title", "Test Content", "Test Author")
+	post, err := entities.NewPost(1, "Test Title", "Test Content", "Test Author", time.Now())
 	require.NoError(t, err)
 
-	err = repo.Update(post.ID, post)
+	err = repo.Update(ctx, post.ID, post, repositories.AnyVersion)
 	assert.Equal(t, repositories.ErrPostNotFound, err)
 
-	err = repo.Create(post)
+	err = repo.Create(ctx, post)
 	require.NoError(t, err)
 
 	updatedPost := *post
 	updatedPost.Title = "Updated Title"
-	err = repo.Update(post.ID, &updatedPost)
+	err = repo.Update(ctx, post.ID, &updatedPost, repositories.AnyVersion)
 	require.NoError(t, err)
 
-	retrievedPost, err := repo.GetByID(post.ID)
+	retrievedPost, err := repo.GetByID(ctx, post.ID)
 	require.NoError(t, err)
 	assert.Equal(t, "Updated Title", retrievedPost.Title)
 	assert.Equal(t, post.ID, retrievedPost.ID) // ID should remain the same
 }
 
 func TestMemoryPostRepository_Delete(t *testing.T) {
+	ctx := context.Background()
 	repo := NewMemoryPostRepository()
 
-	post, err := entities.NewPost(1, "Test Title", "Test Content", "Test Author")
+	post, err := entities.NewPost(1, "Test Title", "Test Content", "Test Author", time.Now())
 	require.NoError(t, err)
 
-	err = repo.Delete(post.ID)
+	err = repo.Delete(ctx, post.ID, repositories.AnyVersion)
 	assert.Equal(t, repositories.ErrPostNotFound, err)
 
-	err = repo.Create(post)
+	err = repo.Create(ctx, post)
 	require.NoError(t, err)
 
-	err = repo.Delete(post.ID)
+	err = repo.Delete(ctx, post.ID, repositories.AnyVersion)
 	require.NoError(t, err)
 
-	_, err = repo.GetByID(post.ID)
+	_, err = repo.GetByID(ctx, post.ID)
 	assert.Equal(t, repositories.ErrPostNotFound, err)
 }
 
 func TestMemoryPostRepository_Exists(t *testing.T) {
+	ctx := context.Background()
 	repo := NewMemoryPostRepository()
 
-	post, err := entities.NewPost(1, "Test Title", "Test Content", "Test Author")
+	post, err := entities.NewPost(1, "Test Title", "Test Content", "Test Author", time.Now())
 	require.NoError(t, err)
 
-	assert.False(t, repo.Exists(post.ID))
+	assert.False(t, repo.Exists(ctx, post.ID))
 
-	err = repo.Create(post)
+	err = repo.Create(ctx, post)
 	require.NoError(t, err)
 
-	assert.True(t, repo.Exists(post.ID))
+	assert.True(t, repo.Exists(ctx, post.ID))
 }
 
 func TestMemoryPostRepository_LoadData(t *testing.T) {
+	ctx := context.Background()
 	repo := NewMemoryPostRepository()
 
 	posts := []*entities.Post{
@@ -220,21 +236,22 @@ func TestMemoryPostRepository_LoadData(t *testing.T) {
 		{ID: 2, Title: "Post 2", Content: "Content 2", Author: "Author 2"},
 	}
 
-	err := repo.LoadData(posts)
+	err := repo.LoadData(ctx, posts)
 	assert.NoError(t, err)
 
-	allPosts, err := repo.GetAll()
+	allPosts, err := repo.GetAll(ctx)
 	require.NoError(t, err)
 	assert.Len(t, allPosts, 3)
 
 	// Verify nextID was updated correctly (should be max ID + 1)
 	// Create a new post to check nextID
-	newPost, err := repo.CreatePost("New Title", "New Content", "New Author")
+	newPost, err := repo.CreatePost(ctx, "New Title", "New Content", "", "New Author", 0, nil, time.Now())
 	require.NoError(t, err)
 	assert.Equal(t, 4, newPost.ID) // Should be 4 (max loaded ID 3 + 1)
 }
 
 func TestMemoryPostRepository_ConcurrentAccess(t *testing.T) {
+	ctx := context.Background()
 	repo := NewMemoryPostRepository()
 	var wg sync.WaitGroup
 
@@ -251,14 +268,15 @@ func TestMemoryPostRepository_ConcurrentAccess(t *testing.T) {
 				fmt.Sprintf("Title %d", index),
 				fmt.Sprintf("Content %d", index),
 				fmt.Sprintf("Author %d", index),
+				time.Now(),
 			)
 			require.NoError(t, err)
 
-			err = repo.Create(post)
+			err = repo.Create(ctx, post)
 			assert.NoError(t, err)
 
 			// Try to read the post
-			retrievedPost, err := repo.GetByID(post.ID)
+			retrievedPost, err := repo.GetByID(ctx, post.ID)
 			assert.NoError(t, err)
 			assert.Equal(t, post.ID, retrievedPost.ID)
 		}(i)
@@ -267,7 +285,65 @@ func TestMemoryPostRepository_ConcurrentAccess(t *testing.T) {
 	wg.Wait()
 
 	// Verify all posts were created
-	posts, err := repo.GetAll()
+	posts, err := repo.GetAll(ctx)
 	require.NoError(t, err)
 	assert.Len(t, posts, numGoroutines)
 }
+
+func TestMemoryPostRepository_ViewerListingsUseIndex(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryPostRepository()
+	random := rand.New(rand.NewSource(1))
+	authors := []string{"alice", "Alice", "bob", "carol"}
+	statuses := []entities.PostStatus{entities.StatusDraft, entities.StatusReview, entities.StatusPublished, entities.StatusArchived}
+
+	for id := 1; id <= 200; id++ {
+		post, err := entities.NewPost(id, fmt.Sprintf("Title %d", id), "Content", authors[random.Intn(len(authors))], time.Now())
+		require.NoError(t, err)
+		post.Status = statuses[random.Intn(len(statuses))]
+		require.NoError(t, repo.Create(ctx, post))
+	}
+	for i := 0; i < 100; i++ {
+		id := 1 + random.Intn(200)
+		post, err := repo.GetByID(ctx, id)
+		if err != nil {
+			continue
+		}
+		switch random.Intn(3) {
+		case 0:
+			post.Status = statuses[random.Intn(len(statuses))]
+			require.NoError(t, repo.Update(ctx, id, post, repositories.AnyVersion))
+		case 1:
+			post.Author = authors[random.Intn(len(authors))]
+			require.NoError(t, repo.Update(ctx, id, post, repositories.AnyVersion))
+		case 2:
+			require.NoError(t, repo.Trash(ctx, id, repositories.AnyVersion, time.Now()))
+		}
+	}
+
+	// MinID 1 matches every post but takes the listing off the index, so
+	// both paths have to agree.
+	for _, viewer := range []string{"", "alice", "bob", "dave"} {
+		for _, query := range []repositories.PostQuery{
+			{Limit: 7},
+			{Limit: 7, After: &repositories.Cursor{ID: 50}},
+			{Limit: 7, Before: &repositories.Cursor{ID: 150}},
+			{Limit: 300},
+		} {
+			query.Filter.Viewer = &repositories.Viewer{Author: viewer}
+			indexed, err := repo.ListPosts(ctx, query)
+			require.NoError(t, err)
+
+			query.Filter.MinID = 1
+			scanned, err := repo.ListPosts(ctx, query)
+			require.NoError(t, err)
+			assert.Equal(t, scanned, indexed, "viewer %q", viewer)
+		}
+	}
+}
+
+func TestMemoryPostRepository_Contract(t *testing.T) {
+	repositorytest.RunPostRepositoryTests(t, func(t *testing.T) repositories.PostRepository {
+		return NewMemoryPostRepository()
+	})
+}
@@ -0,0 +1,97 @@
+package repositories
+
+import (
+	"context"
+	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/repositories"
+	"sort"
+	"sync"
+)
+
+type MemoryRevisionRepository struct {
+	revisions map[int][]*entities.Revision
+	mutex     sync.RWMutex
+}
+
+func NewMemoryRevisionRepository() *MemoryRevisionRepository {
+	return &MemoryRevisionRepository{
+		revisions: make(map[int][]*entities.Revision),
+	}
+}
+
+func (r *MemoryRevisionRepository) Append(ctx context.Context, revision *entities.Revision) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	history := r.revisions[revision.PostID]
+	for _, existing := range history {
+		if existing.Number == revision.Number {
+			return repositories.ErrRevisionExists
+		}
+	}
+
+	history = append(history, copyRevision(revision))
+	sort.Slice(history, func(i, j int) bool {
+		return history[i].Number < history[j].Number
+	})
+	r.revisions[revision.PostID] = history
+
+	return nil
+}
+
+func (r *MemoryRevisionRepository) Get(ctx context.Context, postID, number int) (*entities.Revision, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, revision := range r.revisions[postID] {
+		if revision.Number == number {
+			return copyRevision(revision), nil
+		}
+	}
+
+	return nil, repositories.ErrRevisionNotFound
+}
+
+func (r *MemoryRevisionRepository) ListByPost(ctx context.Context, postID int) ([]*entities.Revision, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	history := r.revisions[postID]
+	revisions := make([]*entities.Revision, len(history))
+	for i, revision := range history {
+		revisions[i] = copyRevision(revision)
+	}
+
+	return revisions, nil
+}
+
+func (r *MemoryRevisionRepository) DeleteByPost(ctx context.Context, postID int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.revisions, postID)
+	return nil
+}
+
+func copyRevision(revision *entities.Revision) *entities.Revision {
+	revisionCopy := *revision
+	revisionCopy.Changes = make([]string, len(revision.Changes))
+	copy(revisionCopy.Changes, revision.Changes)
+	return &revisionCopy
+}
@@ -0,0 +1,13 @@
+package repositories
+
+import (
+	"rakia-tech-test/internal/domain/repositories"
+	"rakia-tech-test/internal/domain/repositories/repositorytest"
+	"testing"
+)
+
+func TestMemoryRevisionRepository_Contract(t *testing.T) {
+	repositorytest.RunRevisionRepositoryTests(t, func(t *testing.T) repositories.RevisionRepository {
+		return NewMemoryRevisionRepository()
+	})
+}
@@ -0,0 +1,124 @@
+package repositories
+
+import (
+	"context"
+	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/repositories"
+	"sync"
+	"time"
+)
+
+// revokedSweepInterval is how often the IDs of expired refresh tokens are
+// forgotten.
+const revokedSweepInterval = time.Minute
+
+type MemoryUserRepository struct {
+	users map[int]*entities.User
+	// usernames maps the normalized username of every stored user to its
+	// ID.
+	usernames map[string]int
+	// revoked maps the ID of every revoked refresh token to when it
+	// expires.
+	revoked   map[string]time.Time
+	lastSweep time.Time
+	nextID    int
+	mutex     sync.RWMutex
+}
+
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{
+		users:     make(map[int]*entities.User),
+		usernames: make(map[string]int),
+		revoked:   make(map[string]time.Time),
+		nextID:    1,
+	}
+}
+
+func (r *MemoryUserRepository) Create(ctx context.Context, user *entities.User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := user.Validate(); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	username := entities.NormalizeUsername(user.Username)
+	if _, taken := r.usernames[username]; taken {
+		return repositories.ErrUserExists
+	}
+	user.ID = r.nextID
+	r.nextID++
+
+	userCopy := *user
+	r.users[user.ID] = &userCopy
+	r.usernames[username] = user.ID
+
+	return nil
+}
+
+func (r *MemoryUserRepository) GetByID(ctx context.Context, id int) (*entities.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	user, exists := r.users[id]
+	if !exists {
+		return nil, repositories.ErrUserNotFound
+	}
+
+	userCopy := *user
+	return &userCopy, nil
+}
+
+func (r *MemoryUserRepository) GetByUsername(ctx context.Context, username string) (*entities.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	id, exists := r.usernames[entities.NormalizeUsername(username)]
+	if !exists {
+		return nil, repositories.ErrUserNotFound
+	}
+
+	userCopy := *r.users[id]
+	return &userCopy, nil
+}
+
+func (r *MemoryUserRepository) RevokeToken(ctx context.Context, id string, expiresAt, now time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.sweep(now)
+	if _, revoked := r.revoked[id]; revoked {
+		return repositories.ErrTokenRevoked
+	}
+	r.revoked[id] = expiresAt
+	return nil
+}
+
+// sweep forgets the revoked refresh tokens that expired by now, at most once
+// per revokedSweepInterval. The caller holds the write lock.
+func (r *MemoryUserRepository) sweep(now time.Time) {
+	if now.Sub(r.lastSweep) < revokedSweepInterval {
+		return
+	}
+	r.lastSweep = now
+	for id, expiresAt := range r.revoked {
+		if !now.Before(expiresAt) {
+			delete(r.revoked, id)
+		}
+	}
+}
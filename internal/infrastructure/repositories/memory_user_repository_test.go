@@ -0,0 +1,13 @@
+package repositories
+
+import (
+	"rakia-tech-test/internal/domain/repositories"
+	"rakia-tech-test/internal/domain/repositories/repositorytest"
+	"testing"
+)
+
+func TestMemoryUserRepository_Contract(t *testing.T) {
+	repositorytest.RunUserRepositoryTests(t, func(t *testing.T) repositories.UserRepository {
+		return NewMemoryUserRepository()
+	})
+}
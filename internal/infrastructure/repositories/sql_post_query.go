@@ -0,0 +1,122 @@
+package repositories
+
+import (
+	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/repositories"
+)
+
+// filterConditions translates a filter into WHERE conditions over the live
+// posts, to be joined with AND, and their arguments.
+func filterConditions(filter repositories.PostFilter) ([]string, []any) {
+	conditions := []string{`deleted_at IS NULL`}
+	var args []any
+
+	if filter.Author != "" {
+		conditions = append(conditions, `author = ?`)
+		args = append(args, filter.Author)
+	}
+	if filter.AuthorID != 0 {
+		conditions = append(conditions, `author_id = ?`)
+		args = append(args, filter.AuthorID)
+	}
+	if filter.AuthorPrefix != "" {
+		conditions = append(conditions, `substr(author, 1, length(?)) = ?`)
+		args = append(args, filter.AuthorPrefix, filter.AuthorPrefix)
+	}
+	if filter.TitleContains != "" {
+		conditions = append(conditions, `instr(title, ?) > 0`)
+		args = append(args, filter.TitleContains)
+	}
+	if filter.ContentContains != "" {
+		conditions = append(conditions, `instr(content, ?) > 0`)
+		args = append(args, filter.ContentContains)
+	}
+	if filter.MinID != 0 {
+		conditions = append(conditions, `id >= ?`)
+		args = append(args, filter.MinID)
+	}
+	if filter.MaxID != 0 {
+		conditions = append(conditions, `id <= ?`)
+		args = append(args, filter.MaxID)
+	}
+	if !filter.CreatedAfter.IsZero() {
+		conditions = append(conditions, `created_at > ?`)
+		args = append(args, unixNanos(filter.CreatedAfter))
+	}
+	if !filter.CreatedBefore.IsZero() {
+		conditions = append(conditions, `created_at < ?`)
+		args = append(args, unixNanos(filter.CreatedBefore))
+	}
+	if !filter.UpdatedSince.IsZero() {
+		conditions = append(conditions, `updated_at >= ?`)
+		args = append(args, unixNanos(filter.UpdatedSince))
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, `status = ?`)
+		args = append(args, string(filter.Status))
+	}
+	if filter.Tag != "" {
+		conditions = append(conditions, `id IN (SELECT post_id FROM post_tags WHERE tag = ?)`)
+		args = append(args, filter.Tag)
+	}
+	if filter.Viewer != nil {
+		if filter.Viewer.Author == "" {
+			conditions = append(conditions, `status = ?`)
+			args = append(args, string(entities.StatusPublished))
+		} else {
+			conditions = append(conditions, `(status = ? OR author_key = ?)`)
+			args = append(args, string(entities.StatusPublished), entities.AuthorNameKey(filter.Viewer.Author))
+		}
+	}
+
+	return conditions, args
+}
+
+// sortColumn returns the column holding the sort key of an order, or an
+// empty string for the ID orders.
+func sortColumn(sort repositories.PostSort) string {
+	switch sort {
+	case repositories.SortByTitle:
+		return "title"
+	case repositories.SortByAuthor:
+		return "author"
+	default:
+		return ""
+	}
+}
+
+// positionCondition compares the position of a row with cursor in the given
+// order; op is one of <, <=, > and >= and reads "the row comes op the
+// cursor".
+func positionCondition(sort repositories.PostSort, op string, cursor repositories.Cursor) (string, []any) {
+	if sort == repositories.SortByIDDesc {
+		op = map[string]string{"<": ">", "<=": ">=", ">": "<", ">=": "<="}[op]
+	}
+
+	column := sortColumn(sort)
+	if column == "" {
+		return `id ` + op + ` ?`, []any{cursor.ID}
+	}
+
+	// The key decides unless it ties, in which case the ID does.
+	strict := op[:1]
+	return `(` + column + ` ` + strict + ` ? OR (` + column + ` = ? AND id ` + op + ` ?))`,
+		[]any{cursor.Key, cursor.Key, cursor.ID}
+}
+
+// orderBy returns the ORDER BY clause of an order, or of its reverse.
+func orderBy(sort repositories.PostSort, reverse bool) string {
+	asc, desc := "ASC", "DESC"
+	if reverse {
+		asc, desc = desc, asc
+	}
+
+	switch sort {
+	case repositories.SortByIDDesc:
+		return `id ` + desc
+	case repositories.SortByTitle, repositories.SortByAuthor:
+		return sortColumn(sort) + ` ` + asc + `, id ` + asc
+	default:
+		return `id ` + asc
+	}
+}
@@ -0,0 +1,603 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/repositories"
+	"rakia-tech-test/internal/infrastructure/database"
+	"strings"
+	"time"
+)
+
+const postColumns = `id, title, content, content_format, author, author_id, version, deleted_at, created_at, updated_at, status, publish_at, slug`
+
+// SQLPostRepository stores posts in a SQL database through database/sql. The
+// queries target SQLite.
+type SQLPostRepository struct {
+	db *sql.DB
+}
+
+// NewSQLPostRepository migrates the schema to the latest version and returns
+// a repository backed by db.
+func NewSQLPostRepository(ctx context.Context, db *sql.DB) (*SQLPostRepository, error) {
+	if err := database.Migrate(ctx, db, schemaMigrations); err != nil {
+		return nil, fmt.Errorf("migrate schema: %w", err)
+	}
+	if err := backfillSlugs(ctx, db); err != nil {
+		return nil, fmt.Errorf("backfill slugs: %w", err)
+	}
+	if err := backfillAuthorKeys(ctx, db); err != nil {
+		return nil, fmt.Errorf("backfill author keys: %w", err)
+	}
+
+	return &SQLPostRepository{db: db}, nil
+}
+
+// backfillAuthorKeys gives an author key to every post stored before author
+// keys existed.
+func backfillAuthorKeys(ctx context.Context, db *sql.DB) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `SELECT id, author FROM posts WHERE author_key = ''`)
+	if err != nil {
+		return err
+	}
+	authors := make(map[int]string)
+	for rows.Next() {
+		var id int
+		var author string
+		if err := rows.Scan(&id, &author); err != nil {
+			rows.Close()
+			return err
+		}
+		authors[id] = author
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, author := range authors {
+		if _, err := tx.ExecContext(ctx, `UPDATE posts SET author_key = ? WHERE id = ?`, entities.AuthorNameKey(author), id); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *SQLPostRepository) Create(ctx context.Context, post *entities.Post) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists int
+	err = tx.QueryRowContext(ctx, `SELECT 1 FROM posts WHERE id = ?`, post.ID).Scan(&exists)
+	if err == nil {
+		return repositories.ErrPostExists
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO posts (`+postColumns+`, author_key) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, '', ?)`,
+		post.ID, post.Title, post.Content, string(post.ContentFormat), post.Author, post.AuthorID, post.Version, nullTime(post.DeletedAt),
+		unixNanos(post.CreatedAt), unixNanos(post.UpdatedAt), string(post.Status), scheduleNanos(post.PublishAt), entities.AuthorNameKey(post.Author),
+	); err != nil {
+		return err
+	}
+	if err := syncTags(ctx, tx, post.ID, post); err != nil {
+		return err
+	}
+	stored := *post
+	if err := syncSlugs(ctx, tx, post.ID, &stored); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	post.Slug, post.PreviousSlugs = stored.Slug, stored.PreviousSlugs
+	return nil
+}
+
+func (r *SQLPostRepository) CreatePost(ctx context.Context, title, content string, format entities.ContentFormat, author string, authorID int, tags []string, createdAt time.Time) (*entities.Post, error) {
+	// Validate before touching the database so a rejected post does not
+	// consume an ID from the sequence.
+	post, err := entities.NewPost(0, title, content, author, createdAt)
+	if err != nil {
+		return nil, err
+	}
+	post.AuthorID = authorID
+	if format != "" {
+		if err := post.SetContentFormat(format); err != nil {
+			return nil, err
+		}
+	}
+	if err := post.SetTags(tags); err != nil {
+		return nil, err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO posts (title, content, content_format, author, author_id, version, created_at, updated_at, status, publish_at, author_key) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		post.Title, post.Content, string(post.ContentFormat), post.Author, post.AuthorID, post.Version, unixNanos(post.CreatedAt), unixNanos(post.UpdatedAt),
+		string(post.Status), scheduleNanos(post.PublishAt), entities.AuthorNameKey(post.Author),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	post.ID = int(id)
+	if err := syncTags(ctx, tx, post.ID, post); err != nil {
+		return nil, err
+	}
+	if err := syncSlugs(ctx, tx, post.ID, post); err != nil {
+		return nil, err
+	}
+
+	return post, tx.Commit()
+}
+
+func (r *SQLPostRepository) GetByID(ctx context.Context, id int) (*entities.Post, error) {
+	posts, err := r.queryPosts(ctx, `SELECT `+postColumns+` FROM posts WHERE id = ? AND deleted_at IS NULL`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(posts) == 0 {
+		return nil, repositories.ErrPostNotFound
+	}
+
+	return posts[0], nil
+}
+
+func (r *SQLPostRepository) GetAll(ctx context.Context) ([]*entities.Post, error) {
+	return r.queryPosts(ctx, `SELECT `+postColumns+` FROM posts WHERE deleted_at IS NULL ORDER BY id`)
+}
+
+func (r *SQLPostRepository) ListPosts(ctx context.Context, query repositories.PostQuery) (*repositories.PostPage, error) {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	where, args := filterConditions(query.Filter)
+
+	// One extra row tells whether the page is followed by more posts in the
+	// direction of travel; the other side is checked with an EXISTS query.
+	// Pages before a cursor are read backwards and reversed.
+	backward := query.Before != nil
+	var cursor *repositories.Cursor
+	var travel, opposite string
+	switch {
+	case query.Before != nil:
+		cursor, travel, opposite = query.Before, "<", ">="
+	case query.After != nil:
+		cursor, travel, opposite = query.After, ">", "<="
+	}
+
+	pageWhere, pageArgs := where, args
+	if cursor != nil {
+		condition, conditionArgs := positionCondition(query.Sort, travel, *cursor)
+		pageWhere = append(append([]string{}, where...), condition)
+		pageArgs = append(append([]any{}, args...), conditionArgs...)
+	}
+
+	rows, err := tx.QueryContext(ctx,
+		`SELECT `+postColumns+` FROM posts WHERE `+strings.Join(pageWhere, " AND ")+
+			` ORDER BY `+orderBy(query.Sort, backward)+` LIMIT ?`,
+		append(pageArgs, query.Limit+1)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	posts := make([]*entities.Post, 0, query.Limit)
+	for rows.Next() {
+		post, err := scanPost(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		posts = append(posts, post)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	more := len(posts) > query.Limit
+	if more {
+		posts = posts[:query.Limit]
+	}
+	if err := loadDetails(ctx, tx, posts); err != nil {
+		return nil, err
+	}
+
+	var exists bool
+	if cursor != nil {
+		condition, conditionArgs := positionCondition(query.Sort, opposite, *cursor)
+		if err := tx.QueryRowContext(ctx,
+			`SELECT EXISTS (SELECT 1 FROM posts WHERE `+strings.Join(append(where, condition), " AND ")+`)`,
+			append(args, conditionArgs...)...,
+		).Scan(&exists); err != nil {
+			return nil, err
+		}
+	}
+
+	page := &repositories.PostPage{Posts: posts}
+	if backward {
+		for i, j := 0, len(posts)-1; i < j; i, j = i+1, j-1 {
+			posts[i], posts[j] = posts[j], posts[i]
+		}
+		page.HasPrev, page.HasNext = more, exists
+	} else {
+		page.HasPrev, page.HasNext = exists, more
+	}
+
+	return page, nil
+}
+
+func (r *SQLPostRepository) Update(ctx context.Context, id int, post *entities.Post, expectedVersion int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx,
+		`UPDATE posts SET title = ?, content = ?, content_format = ?, author = ?, author_id = ?, version = ?, created_at = ?, updated_at = ?,
+			status = ?, publish_at = ?, author_key = ?
+		WHERE id = ? AND deleted_at IS NULL AND (? = ? OR version = ?)`,
+		post.Title, post.Content, string(post.ContentFormat), post.Author, post.AuthorID, post.Version, unixNanos(post.CreatedAt), unixNanos(post.UpdatedAt),
+		string(post.Status), scheduleNanos(post.PublishAt), entities.AuthorNameKey(post.Author),
+		id, expectedVersion, repositories.AnyVersion, expectedVersion,
+	)
+	if err != nil {
+		return err
+	}
+	if err := r.requireAffected(ctx, result, id); err != nil {
+		return err
+	}
+	if err := syncTags(ctx, tx, id, post); err != nil {
+		return err
+	}
+	stored := *post
+	stored.ID = id
+	stored.Slug = ""
+	if err := syncSlugs(ctx, tx, id, &stored); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	post.Slug, post.PreviousSlugs = stored.Slug, stored.PreviousSlugs
+	return nil
+}
+
+func (r *SQLPostRepository) Delete(ctx context.Context, id int, expectedVersion int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx,
+		`DELETE FROM posts WHERE id = ? AND deleted_at IS NULL AND (? = ? OR version = ?)`,
+		id, expectedVersion, repositories.AnyVersion, expectedVersion,
+	)
+	if err != nil {
+		return err
+	}
+	if err := r.requireAffected(ctx, result, id); err != nil {
+		return err
+	}
+	if err := syncTags(ctx, tx, id, nil); err != nil {
+		return err
+	}
+	if err := syncSlugs(ctx, tx, id, nil); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *SQLPostRepository) Exists(ctx context.Context, id int) bool {
+	var exists int
+	err := r.db.QueryRowContext(ctx, `SELECT 1 FROM posts WHERE id = ? AND deleted_at IS NULL`, id).Scan(&exists)
+	return err == nil
+}
+
+func (r *SQLPostRepository) LoadData(ctx context.Context, posts []*entities.Post) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx,
+		`INSERT INTO posts (`+postColumns+`, author_key) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, '', ?)
+		ON CONFLICT (id) DO UPDATE SET title = excluded.title, content = excluded.content, content_format = excluded.content_format,
+			author = excluded.author, author_id = excluded.author_id, version = excluded.version,
+			deleted_at = excluded.deleted_at, created_at = excluded.created_at, updated_at = excluded.updated_at,
+			status = excluded.status, publish_at = excluded.publish_at, author_key = excluded.author_key`,
+	)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	stored := make([]entities.Post, len(posts))
+	for i, post := range posts {
+		if _, err := stmt.ExecContext(ctx,
+			post.ID, post.Title, post.Content, string(post.ContentFormat), post.Author, post.AuthorID, post.Version, nullTime(post.DeletedAt),
+			unixNanos(post.CreatedAt), unixNanos(post.UpdatedAt), string(post.Status), scheduleNanos(post.PublishAt), entities.AuthorNameKey(post.Author),
+		); err != nil {
+			return err
+		}
+		if err := syncTags(ctx, tx, post.ID, post); err != nil {
+			return err
+		}
+		stored[i] = *post
+		if err := syncSlugs(ctx, tx, post.ID, &stored[i]); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	for i, post := range posts {
+		post.Slug, post.PreviousSlugs = stored[i].Slug, stored[i].PreviousSlugs
+	}
+	return nil
+}
+
+func (r *SQLPostRepository) Trash(ctx context.Context, id int, expectedVersion int, deletedAt time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx,
+		`UPDATE posts SET deleted_at = ?
+		WHERE id = ? AND deleted_at IS NULL AND (? = ? OR version = ?)`,
+		deletedAt.UTC(), id, expectedVersion, repositories.AnyVersion, expectedVersion,
+	)
+	if err != nil {
+		return err
+	}
+	if err := r.requireAffected(ctx, result, id); err != nil {
+		return err
+	}
+	if _, err := reloadPost(ctx, tx, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *SQLPostRepository) GetTrash(ctx context.Context) ([]*entities.Post, error) {
+	return r.queryPosts(ctx, `SELECT `+postColumns+` FROM posts WHERE deleted_at IS NOT NULL ORDER BY id`)
+}
+
+func (r *SQLPostRepository) Restore(ctx context.Context, id int) (*entities.Post, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx,
+		`UPDATE posts SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL`, id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if affected == 0 {
+		return nil, repositories.ErrPostNotFound
+	}
+
+	post, err := reloadPost(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return post, tx.Commit()
+}
+
+func (r *SQLPostRepository) Purge(ctx context.Context, deletedBefore time.Time) ([]int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// The cutoff is compared in Go: how timestamps are stored is up to the
+	// driver, so comparing them in SQL is not portable.
+	rows, err := tx.QueryContext(ctx, `SELECT id, deleted_at FROM posts WHERE deleted_at IS NOT NULL ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	purged := make([]int, 0)
+	for rows.Next() {
+		var id int
+		var deletedAt time.Time
+		if err := rows.Scan(&id, &deletedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if deletedAt.Before(deletedBefore) {
+			purged = append(purged, id)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, id := range purged {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM posts WHERE id = ?`, id); err != nil {
+			return nil, err
+		}
+		if err := syncTags(ctx, tx, id, nil); err != nil {
+			return nil, err
+		}
+		if err := syncSlugs(ctx, tx, id, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return purged, tx.Commit()
+}
+
+// queryPosts runs a query selecting postColumns and returns the posts with
+// their tags and previous slugs, read consistently in one transaction.
+func (r *SQLPostRepository) queryPosts(ctx context.Context, query string, args ...any) ([]*entities.Post, error) {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	posts := make([]*entities.Post, 0)
+	for rows.Next() {
+		post, err := scanPost(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		posts = append(posts, post)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := loadDetails(ctx, tx, posts); err != nil {
+		return nil, err
+	}
+	return posts, tx.Commit()
+}
+
+// reloadPost reads back the post with the given ID after a write to its row
+// alone, which leaves its tags unchanged, and brings the tag counts in line
+// with its new state.
+func reloadPost(ctx context.Context, tx *sql.Tx, id int) (*entities.Post, error) {
+	post, err := scanPost(tx.QueryRowContext(ctx, `SELECT `+postColumns+` FROM posts WHERE id = ?`, id))
+	if err != nil {
+		return nil, err
+	}
+	if err := loadDetails(ctx, tx, []*entities.Post{post}); err != nil {
+		return nil, err
+	}
+	if err := syncTags(ctx, tx, id, post); err != nil {
+		return nil, err
+	}
+	return post, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPost(row rowScanner) (*entities.Post, error) {
+	var post entities.Post
+	var deletedAt sql.NullTime
+	var createdAt, updatedAt, publishAt int64
+	if err := row.Scan(&post.ID, &post.Title, &post.Content, &post.ContentFormat, &post.Author, &post.AuthorID, &post.Version, &deletedAt, &createdAt, &updatedAt,
+		&post.Status, &publishAt, &post.Slug); err != nil {
+		return nil, err
+	}
+	if deletedAt.Valid {
+		post.DeletedAt = &deletedAt.Time
+	}
+	post.CreatedAt = fromUnixNanos(createdAt)
+	post.UpdatedAt = fromUnixNanos(updatedAt)
+	if publishAt != 0 {
+		t := fromUnixNanos(publishAt)
+		post.PublishAt = &t
+	}
+
+	return &post, nil
+}
+
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
+
+// unixNanos encodes a creation or update time as Unix nanoseconds, with 0
+// standing for the zero time. Unlike driver-encoded timestamps, integers
+// compare correctly in SQL, so date filters can run in the database.
+func unixNanos(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+// scheduleNanos encodes an optional publication time like unixNanos, with 0
+// for no schedule.
+func scheduleNanos(t *time.Time) int64 {
+	if t == nil {
+		return 0
+	}
+	return unixNanos(*t)
+}
+
+func fromUnixNanos(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n).UTC()
+}
+
+// requireAffected turns a conditional write that matched no row into
+// ErrPostNotFound or, when the post exists, ErrVersionConflict.
+func (r *SQLPostRepository) requireAffected(ctx context.Context, result sql.Result, id int) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected > 0 {
+		return nil
+	}
+
+	if r.Exists(ctx, id) {
+		return repositories.ErrVersionConflict
+	}
+	return repositories.ErrPostNotFound
+}
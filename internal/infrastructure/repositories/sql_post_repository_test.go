@@ -0,0 +1,219 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/repositories"
+	"rakia-tech-test/internal/domain/repositories/repositorytest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func newSQLRepo(t *testing.T) *SQLPostRepository {
+	t.Helper()
+
+	dsn := "file:" + filepath.Join(t.TempDir(), "posts.db") + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	db, err := sql.Open("sqlite", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	repo, err := NewSQLPostRepository(context.Background(), db)
+	require.NoError(t, err)
+
+	return repo
+}
+
+func TestSQLPostRepository_CreatePost(t *testing.T) {
+	ctx := context.Background()
+	repo := newSQLRepo(t)
+
+	post, err := repo.CreatePost(ctx, "Test Title", "Test Content", "", "Test Author", 0, nil, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, post.ID)
+
+	retrieved, err := repo.GetByID(ctx, post.ID)
+	require.NoError(t, err)
+	assert.Equal(t, post, retrieved)
+
+	_, err = repo.CreatePost(ctx, "", "Content", "", "Author", 0, nil, time.Now())
+	assert.ErrorContains(t, err, "title is required")
+
+	post2, err := repo.CreatePost(ctx, "Second Title", "Second Content", "", "Second Author", 0, nil, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 2, post2.ID, "a rejected post must not consume an ID")
+
+	// IDs are not reused after the newest post is deleted.
+	require.NoError(t, repo.Delete(ctx, post2.ID, repositories.AnyVersion))
+	post3, err := repo.CreatePost(ctx, "Third Title", "Third Content", "", "Third Author", 0, nil, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 3, post3.ID)
+}
+
+func TestSQLPostRepository_Create(t *testing.T) {
+	ctx := context.Background()
+	repo := newSQLRepo(t)
+
+	post, err := entities.NewPost(7, "Test Title", "Test Content", "Test Author", time.Now())
+	require.NoError(t, err)
+
+	require.NoError(t, repo.Create(ctx, post))
+	assert.ErrorIs(t, repo.Create(ctx, post), repositories.ErrPostExists)
+
+	next, err := repo.CreatePost(ctx, "Next", "Content", "", "Author", 0, nil, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 8, next.ID)
+}
+
+func TestSQLPostRepository_GetAllUpdateDelete(t *testing.T) {
+	ctx := context.Background()
+	repo := newSQLRepo(t)
+
+	posts, err := repo.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, posts)
+
+	for _, id := range []int{3, 1, 2} {
+		post, err := entities.NewPost(id, fmt.Sprintf("Title %d", id), "Content", "Author", time.Now())
+		require.NoError(t, err)
+		require.NoError(t, repo.Create(ctx, post))
+	}
+
+	posts, err = repo.GetAll(ctx)
+	require.NoError(t, err)
+	require.Len(t, posts, 3)
+	assert.Equal(t, []int{1, 2, 3}, []int{posts[0].ID, posts[1].ID, posts[2].ID})
+
+	updated := *posts[1]
+	updated.Title = "Updated Title"
+	require.NoError(t, repo.Update(ctx, updated.ID, &updated, repositories.AnyVersion))
+
+	retrieved, err := repo.GetByID(ctx, updated.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Updated Title", retrieved.Title)
+
+	assert.ErrorIs(t, repo.Update(ctx, 99, &updated, repositories.AnyVersion), repositories.ErrPostNotFound)
+
+	assert.True(t, repo.Exists(ctx, 1))
+	require.NoError(t, repo.Delete(ctx, 1, repositories.AnyVersion))
+	assert.False(t, repo.Exists(ctx, 1))
+	assert.ErrorIs(t, repo.Delete(ctx, 1, repositories.AnyVersion), repositories.ErrPostNotFound)
+
+	_, err = repo.GetByID(ctx, 1)
+	assert.ErrorIs(t, err, repositories.ErrPostNotFound)
+}
+
+func TestSQLPostRepository_LoadData(t *testing.T) {
+	ctx := context.Background()
+	repo := newSQLRepo(t)
+
+	posts := []*entities.Post{
+		{ID: 1, Title: "Post 1", Content: "Content 1", Author: "Author 1"},
+		{ID: 3, Title: "Post 3", Content: "Content 3", Author: "Author 3"},
+	}
+	require.NoError(t, repo.LoadData(ctx, posts))
+
+	// Loading again overwrites existing rows instead of failing.
+	posts[0].Title = "Reloaded"
+	require.NoError(t, repo.LoadData(ctx, posts))
+
+	post, err := repo.GetByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Reloaded", post.Title)
+
+	newPost, err := repo.CreatePost(ctx, "New Title", "New Content", "", "New Author", 0, nil, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 4, newPost.ID)
+}
+
+func TestSQLPostRepository_BackfillsSlugs(t *testing.T) {
+	ctx := context.Background()
+	dsn := "file:" + filepath.Join(t.TempDir(), "posts.db")
+	db, err := sql.Open("sqlite", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	_, err = NewSQLPostRepository(ctx, db)
+	require.NoError(t, err)
+
+	// Rows written before slugs existed carry an empty one.
+	for id := 1; id <= 2; id++ {
+		_, err := db.ExecContext(ctx,
+			`INSERT INTO posts (id, title, content, author, version, created_at, updated_at, status, publish_at)
+			VALUES (?, 'Same', 'Content', 'Author', 1, 0, 0, 'published', 0)`, id)
+		require.NoError(t, err)
+	}
+
+	repo, err := NewSQLPostRepository(ctx, db)
+	require.NoError(t, err)
+
+	post, err := repo.GetBySlug(ctx, "same-2")
+	require.NoError(t, err)
+	assert.Equal(t, 2, post.ID)
+	post, err = repo.GetByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "same", post.Slug)
+}
+
+func TestSQLPostRepository_BackfillsAuthorKeys(t *testing.T) {
+	ctx := context.Background()
+	dsn := "file:" + filepath.Join(t.TempDir(), "posts.db")
+	db, err := sql.Open("sqlite", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	_, err = NewSQLPostRepository(ctx, db)
+	require.NoError(t, err)
+
+	// Rows written before author keys existed carry an empty one.
+	_, err = db.ExecContext(ctx,
+		`INSERT INTO posts (id, title, content, author, version, created_at, updated_at, status, publish_at, slug)
+		VALUES (1, 'Draft', 'Content', 'Jane  Doe', 1, 0, 0, 'draft', 0, 'draft')`)
+	require.NoError(t, err)
+
+	repo, err := NewSQLPostRepository(ctx, db)
+	require.NoError(t, err)
+
+	page, err := repo.ListPosts(ctx, repositories.PostQuery{Limit: 10, Filter: repositories.PostFilter{Viewer: &repositories.Viewer{Author: "jane doe"}}})
+	require.NoError(t, err)
+	require.Len(t, page.Posts, 1)
+	assert.Equal(t, 1, page.Posts[0].ID)
+}
+
+func TestSQLPostRepository_ConcurrentCreate(t *testing.T) {
+	ctx := context.Background()
+	repo := newSQLRepo(t)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	ids := make(map[int]bool)
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			post, err := repo.CreatePost(ctx, fmt.Sprintf("Title %d", i), "Content", "", "Author", 0, nil, time.Now())
+			if assert.NoError(t, err) {
+				mu.Lock()
+				ids[post.ID] = true
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Len(t, ids, 10)
+}
+
+func TestSQLPostRepository_Contract(t *testing.T) {
+	repositorytest.RunPostRepositoryTests(t, func(t *testing.T) repositories.PostRepository {
+		return newSQLRepo(t)
+	})
+}
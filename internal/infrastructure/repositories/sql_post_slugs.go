@@ -0,0 +1,156 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/repositories"
+)
+
+func (r *SQLPostRepository) GetBySlug(ctx context.Context, slug string) (*entities.Post, error) {
+	posts, err := r.queryPosts(ctx,
+		`SELECT `+postColumns+` FROM posts
+		WHERE id = (SELECT post_id FROM post_slugs WHERE slug = ?) AND deleted_at IS NULL`,
+		slug,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(posts) == 0 {
+		return nil, repositories.ErrPostNotFound
+	}
+
+	return posts[0], nil
+}
+
+// loadPreviousSlugs fills in the previous slugs of posts.
+func loadPreviousSlugs(ctx context.Context, q queryer, posts []*entities.Post) error {
+	return loadByPost(ctx, q, posts,
+		`SELECT post_id, slug FROM post_slugs WHERE position > 0 AND`, `position`,
+		func(post *entities.Post, slug string) {
+			post.PreviousSlugs = append(post.PreviousSlugs, slug)
+		},
+	)
+}
+
+// syncSlugs settles the slug of the post with the given ID the way
+// MemoryPostRepository.settleSlug does and records it, or releases the
+// slugs of the post when post is nil. Like syncTags it runs in the
+// transaction of the write, after it, and the post_slugs primary key backs
+// the uniqueness checks. A post without a slug takes over the slugs stored
+// for its ID, so the write must leave the slug column alone.
+func syncSlugs(ctx context.Context, tx *sql.Tx, id int, post *entities.Post) error {
+	if post == nil {
+		_, err := tx.ExecContext(ctx, `DELETE FROM post_slugs WHERE post_id = ?`, id)
+		return err
+	}
+
+	if post.Slug != "" {
+		for _, slug := range post.Slugs() {
+			var owner int
+			err := tx.QueryRowContext(ctx, `SELECT post_id FROM post_slugs WHERE slug = ?`, slug).Scan(&owner)
+			if err == nil && owner != id {
+				return repositories.ErrSlugExists
+			}
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+		}
+	} else {
+		stored := []*entities.Post{{ID: id}}
+		err := tx.QueryRowContext(ctx, `SELECT slug FROM posts WHERE id = ?`, id).Scan(&stored[0].Slug)
+		if err != nil {
+			return err
+		}
+		if err := loadPreviousSlugs(ctx, tx, stored); err != nil {
+			return err
+		}
+		post.Slug, post.PreviousSlugs = stored[0].Slug, stored[0].PreviousSlugs
+
+		taken, err := takenSlugs(ctx, tx, id, entities.Slugify(post.Title))
+		if err != nil {
+			return err
+		}
+		post.AssignSlug(func(slug string) bool { return taken[slug] })
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE posts SET slug = ? WHERE id = ?`, post.Slug, id); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM post_slugs WHERE post_id = ?`, id); err != nil {
+		return err
+	}
+	for position, slug := range post.Slugs() {
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO post_slugs (slug, post_id, position) VALUES (?, ?, ?)`, slug, id, position,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// takenSlugs returns the slugs held by posts other than the one with the
+// given ID among those AssignSlug may pick for base: base itself and base
+// with a numeric suffix.
+func takenSlugs(ctx context.Context, tx *sql.Tx, id int, base string) (map[string]bool, error) {
+	// Slugs consist of letters, digits and hyphens only, so base cannot
+	// contain LIKE wildcards.
+	rows, err := tx.QueryContext(ctx,
+		`SELECT slug FROM post_slugs WHERE (slug = ? OR slug LIKE ?) AND post_id <> ?`, base, base+"-%", id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	taken := make(map[string]bool)
+	for rows.Next() {
+		var slug string
+		if err := rows.Scan(&slug); err != nil {
+			return nil, err
+		}
+		taken[slug] = true
+	}
+
+	return taken, rows.Err()
+}
+
+// backfillSlugs gives a slug to every post stored before slugs existed, in
+// ID order.
+func backfillSlugs(ctx context.Context, db *sql.DB) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `SELECT id, title FROM posts WHERE slug = '' ORDER BY id`)
+	if err != nil {
+		return err
+	}
+	missing := make([]*entities.Post, 0)
+	for rows.Next() {
+		var post entities.Post
+		if err := rows.Scan(&post.ID, &post.Title); err != nil {
+			rows.Close()
+			return err
+		}
+		missing = append(missing, &post)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, post := range missing {
+		if err := syncSlugs(ctx, tx, post.ID, post); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
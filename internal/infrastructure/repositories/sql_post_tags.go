@@ -0,0 +1,164 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/repositories"
+)
+
+// postBatchSize bounds the number of post IDs bound to one query when tags
+// or slugs are loaded, well below the parameter limits of SQLite.
+const postBatchSize = 500
+
+// queryer is implemented by *sql.DB and *sql.Tx.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func (r *SQLPostRepository) TagCounts(ctx context.Context) ([]repositories.TagCount, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT name, post_count FROM tags WHERE post_count > 0 ORDER BY post_count DESC, name`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make([]repositories.TagCount, 0)
+	for rows.Next() {
+		var count repositories.TagCount
+		if err := rows.Scan(&count.Tag, &count.Posts); err != nil {
+			return nil, err
+		}
+		counts = append(counts, count)
+	}
+
+	return counts, rows.Err()
+}
+
+// loadTags fills in the tags of posts.
+func loadTags(ctx context.Context, q queryer, posts []*entities.Post) error {
+	return loadByPost(ctx, q, posts, `SELECT post_id, tag FROM post_tags WHERE`, `tag`,
+		func(post *entities.Post, tag string) {
+			post.Tags = append(post.Tags, tag)
+		},
+	)
+}
+
+// loadDetails fills in the tags and previous slugs of posts, which are kept
+// in tables of their own.
+func loadDetails(ctx context.Context, q queryer, posts []*entities.Post) error {
+	if err := loadTags(ctx, q, posts); err != nil {
+		return err
+	}
+	return loadPreviousSlugs(ctx, q, posts)
+}
+
+// loadByPost runs selectWhere, a query selecting a post ID and a value whose
+// WHERE clause ends in AND or WHERE, restricted to the IDs of posts and
+// ordered by ID and then by orderBy, and hands every row to add.
+func loadByPost(ctx context.Context, q queryer, posts []*entities.Post, selectWhere, orderBy string, add func(post *entities.Post, value string)) error {
+	byID := make(map[int]*entities.Post, len(posts))
+	for _, post := range posts {
+		byID[post.ID] = post
+	}
+
+	for start := 0; start < len(posts); start += postBatchSize {
+		batch := posts[start:min(len(posts), start+postBatchSize)]
+		args := make([]any, len(batch))
+		for i, post := range batch {
+			args[i] = post.ID
+		}
+
+		rows, err := q.QueryContext(ctx,
+			selectWhere+` post_id IN (?`+strings.Repeat(`, ?`, len(batch)-1)+`) ORDER BY post_id, `+orderBy,
+			args...,
+		)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			var id int
+			var value string
+			if err := rows.Scan(&id, &value); err != nil {
+				rows.Close()
+				return err
+			}
+			add(byID[id], value)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// syncTags rewrites the tags of the post with the given ID and applies the
+// difference to the tag counts. post is the post as stored by the write, or
+// nil when it was removed. It runs in the transaction of the write, after
+// it, so the transaction already holds the write lock.
+//
+// Every post_tags row records whether its post counted towards the tag when
+// it was written, so the counts can be adjusted without knowing the state
+// of the post before the write.
+func syncTags(ctx context.Context, tx *sql.Tx, id int, post *entities.Post) error {
+	rows, err := tx.QueryContext(ctx, `SELECT tag, counted FROM post_tags WHERE post_id = ?`, id)
+	if err != nil {
+		return err
+	}
+	var previous []string
+	for rows.Next() {
+		var tag string
+		var counted bool
+		if err := rows.Scan(&tag, &counted); err != nil {
+			rows.Close()
+			return err
+		}
+		if counted {
+			previous = append(previous, tag)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, tag := range previous {
+		if _, err := tx.ExecContext(ctx, `UPDATE tags SET post_count = post_count - 1 WHERE name = ?`, tag); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM post_tags WHERE post_id = ?`, id); err != nil {
+		return err
+	}
+	if post == nil {
+		return nil
+	}
+
+	counted := repositories.CountsTowardsTags(post)
+	increment := 0
+	if counted {
+		increment = 1
+	}
+	for _, tag := range post.Tags {
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO tags (name, post_count) VALUES (?, ?)
+			ON CONFLICT (name) DO UPDATE SET post_count = post_count + excluded.post_count`,
+			tag, increment,
+		); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO post_tags (post_id, tag, counted) VALUES (?, ?, ?)`, id, tag, counted,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
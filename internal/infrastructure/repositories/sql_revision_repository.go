@@ -0,0 +1,117 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/repositories"
+	"rakia-tech-test/internal/infrastructure/database"
+	"strings"
+)
+
+const revisionColumns = `post_id, number, title, content, author, edited_by, edited_at, changes, restored_from`
+
+// SQLRevisionRepository stores post revisions next to the posts table.
+// Revisions outlive their post, so there is no foreign key.
+type SQLRevisionRepository struct {
+	db *sql.DB
+}
+
+// NewSQLRevisionRepository migrates the schema to the latest version and
+// returns a repository backed by db.
+func NewSQLRevisionRepository(ctx context.Context, db *sql.DB) (*SQLRevisionRepository, error) {
+	if err := database.Migrate(ctx, db, schemaMigrations); err != nil {
+		return nil, fmt.Errorf("migrate schema: %w", err)
+	}
+
+	return &SQLRevisionRepository{db: db}, nil
+}
+
+func (r *SQLRevisionRepository) Append(ctx context.Context, revision *entities.Revision) error {
+	// The primary key makes the insert fail for a recorded number; ON
+	// CONFLICT DO NOTHING turns that into zero affected rows so it can be
+	// told apart from other errors without parsing driver messages.
+	result, err := r.db.ExecContext(ctx,
+		`INSERT INTO post_revisions (`+revisionColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (post_id, number) DO NOTHING`,
+		revision.PostID, revision.Number, revision.Title, revision.Content, revision.Author,
+		revision.EditedBy, revision.EditedAt.UTC(), strings.Join(revision.Changes, ","), revision.RestoredFrom,
+	)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return repositories.ErrRevisionExists
+	}
+
+	return nil
+}
+
+func (r *SQLRevisionRepository) Get(ctx context.Context, postID, number int) (*entities.Revision, error) {
+	row := r.db.QueryRowContext(ctx,
+		`SELECT `+revisionColumns+` FROM post_revisions WHERE post_id = ? AND number = ?`,
+		postID, number,
+	)
+
+	revision, err := scanRevision(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repositories.ErrRevisionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return revision, nil
+}
+
+func (r *SQLRevisionRepository) ListByPost(ctx context.Context, postID int) ([]*entities.Revision, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+revisionColumns+` FROM post_revisions WHERE post_id = ? ORDER BY number`,
+		postID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := make([]*entities.Revision, 0)
+	for rows.Next() {
+		revision, err := scanRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, rows.Err()
+}
+
+func (r *SQLRevisionRepository) DeleteByPost(ctx context.Context, postID int) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM post_revisions WHERE post_id = ?`, postID)
+	return err
+}
+
+func scanRevision(row rowScanner) (*entities.Revision, error) {
+	var revision entities.Revision
+	var changes string
+	if err := row.Scan(
+		&revision.PostID, &revision.Number, &revision.Title, &revision.Content, &revision.Author,
+		&revision.EditedBy, &revision.EditedAt, &changes, &revision.RestoredFrom,
+	); err != nil {
+		return nil, err
+	}
+
+	revision.Changes = []string{}
+	if changes != "" {
+		revision.Changes = strings.Split(changes, ",")
+	}
+
+	return &revision, nil
+}
@@ -0,0 +1,26 @@
+package repositories
+
+import (
+	"context"
+	"rakia-tech-test/internal/domain/repositories"
+	"rakia-tech-test/internal/domain/repositories/repositorytest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLRevisionRepository_SharesDatabaseWithPosts(t *testing.T) {
+	postRepo := newSQLRepo(t)
+
+	// Migrating again from the revision repository must be a no-op.
+	_, err := NewSQLRevisionRepository(context.Background(), postRepo.db)
+	require.NoError(t, err)
+}
+
+func TestSQLRevisionRepository_Contract(t *testing.T) {
+	repositorytest.RunRevisionRepositoryTests(t, func(t *testing.T) repositories.RevisionRepository {
+		repo, err := NewSQLRevisionRepository(context.Background(), newSQLRepo(t).db)
+		require.NoError(t, err)
+		return repo
+	})
+}
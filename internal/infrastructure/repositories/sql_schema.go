@@ -0,0 +1,157 @@
+package repositories
+
+import "rakia-tech-test/internal/infrastructure/database"
+
+// schemaMigrations evolve the whole database schema together with the
+// entities. Every SQL repository migrates with the full list, since versions
+// are tracked per database rather than per table. Append new migrations;
+// never edit released ones.
+var schemaMigrations = []database.Migration{
+	{
+		Version: 1,
+		Name:    "create_posts",
+		Statements: []string{
+			`CREATE TABLE posts (
+				id      INTEGER PRIMARY KEY AUTOINCREMENT,
+				title   TEXT NOT NULL,
+				content TEXT NOT NULL,
+				author  TEXT NOT NULL
+			)`,
+		},
+	},
+	{
+		Version: 2,
+		Name:    "add_posts_version",
+		Statements: []string{
+			`ALTER TABLE posts ADD COLUMN version INTEGER NOT NULL DEFAULT 1`,
+		},
+	},
+	{
+		Version: 3,
+		Name:    "create_post_revisions",
+		Statements: []string{
+			`CREATE TABLE post_revisions (
+				post_id       INTEGER NOT NULL,
+				number        INTEGER NOT NULL,
+				title         TEXT NOT NULL,
+				content       TEXT NOT NULL,
+				author        TEXT NOT NULL,
+				edited_by     TEXT NOT NULL,
+				edited_at     TIMESTAMP NOT NULL,
+				changes       TEXT NOT NULL,
+				restored_from INTEGER NOT NULL DEFAULT 0,
+				PRIMARY KEY (post_id, number)
+			)`,
+		},
+	},
+	{
+		Version: 4,
+		Name:    "add_posts_deleted_at",
+		Statements: []string{
+			`ALTER TABLE posts ADD COLUMN deleted_at TIMESTAMP`,
+		},
+	},
+	{
+		Version: 5,
+		Name:    "index_posts_sort_keys",
+		Statements: []string{
+			`CREATE INDEX posts_title_id ON posts (title, id)`,
+			`CREATE INDEX posts_author_id ON posts (author, id)`,
+		},
+	},
+	{
+		Version: 6,
+		Name:    "add_posts_timestamps",
+		Statements: []string{
+			// Unix nanoseconds; 0 marks posts written before timestamps
+			// were recorded.
+			`ALTER TABLE posts ADD COLUMN created_at INTEGER NOT NULL DEFAULT 0`,
+			`ALTER TABLE posts ADD COLUMN updated_at INTEGER NOT NULL DEFAULT 0`,
+			`CREATE INDEX posts_created_at ON posts (created_at)`,
+			`CREATE INDEX posts_updated_at ON posts (updated_at)`,
+		},
+	},
+	{
+		Version: 7,
+		Name:    "add_posts_status",
+		Statements: []string{
+			// Every post was public before the editorial workflow existed.
+			`ALTER TABLE posts ADD COLUMN status TEXT NOT NULL DEFAULT 'published'`,
+			`CREATE INDEX posts_status_id ON posts (status, id)`,
+		},
+	},
+	{
+		Version: 8,
+		Name:    "add_posts_publish_at",
+		Statements: []string{
+			// Unix nanoseconds; 0 when no publication is scheduled.
+			`ALTER TABLE posts ADD COLUMN publish_at INTEGER NOT NULL DEFAULT 0`,
+		},
+	},
+	{
+		Version: 9,
+		Name:    "create_tags",
+		Statements: []string{
+			// post_count is the number of published, live posts carrying
+			// the tag. Every post_tags row records whether its post counted
+			// when the row was written, so writes can adjust the counts.
+			`CREATE TABLE tags (
+				name       TEXT PRIMARY KEY,
+				post_count INTEGER NOT NULL DEFAULT 0
+			)`,
+			`CREATE TABLE post_tags (
+				post_id INTEGER NOT NULL,
+				tag     TEXT NOT NULL REFERENCES tags (name),
+				counted INTEGER NOT NULL,
+				PRIMARY KEY (post_id, tag)
+			)`,
+			`CREATE INDEX post_tags_tag ON post_tags (tag, post_id)`,
+		},
+	},
+	{
+		Version: 10,
+		Name:    "create_post_slugs",
+		Statements: []string{
+			// Posts written before slugs existed start with an empty slug
+			// and get one when the repository is opened.
+			`ALTER TABLE posts ADD COLUMN slug TEXT NOT NULL DEFAULT ''`,
+			// Every current and previous slug, so the primary key keeps
+			// them unique across all posts. position is 0 for the current
+			// slug and counts up through the previous ones, oldest first.
+			`CREATE TABLE post_slugs (
+				slug     TEXT PRIMARY KEY,
+				post_id  INTEGER NOT NULL,
+				position INTEGER NOT NULL
+			)`,
+			`CREATE INDEX post_slugs_post_id ON post_slugs (post_id, position)`,
+		},
+	},
+	{
+		Version: 11,
+		Name:    "add_posts_author_id",
+		Statements: []string{
+			// Zero marks posts that are not linked to an author record.
+			`ALTER TABLE posts ADD COLUMN author_id INTEGER NOT NULL DEFAULT 0`,
+			`CREATE INDEX posts_by_author_id ON posts (author_id, id)`,
+		},
+	},
+	{
+		Version: 12,
+		Name:    "add_posts_content_format",
+		Statements: []string{
+			// Posts written before formats existed are plain text.
+			`ALTER TABLE posts ADD COLUMN content_format TEXT NOT NULL DEFAULT 'plain'`,
+		},
+	},
+	{
+		Version: 13,
+		Name:    "add_posts_author_key",
+		Statements: []string{
+			// The entities.AuthorNameKey of author, which listings match
+			// their viewer by. Posts written before it existed get theirs
+			// when the repository is opened.
+			`ALTER TABLE posts ADD COLUMN author_key TEXT NOT NULL DEFAULT ''`,
+			`CREATE INDEX posts_author_key_id ON posts (author_key, id)`,
+		},
+	},
+}
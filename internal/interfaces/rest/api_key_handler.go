@@ -0,0 +1,84 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"rakia-tech-test/internal/application/services"
+	"rakia-tech-test/internal/interfaces/rest/dto"
+)
+
+type APIKeyHandler struct {
+	apiKeyService *services.APIKeyService
+	logger        *logrus.Logger
+}
+
+func NewAPIKeyHandler(apiKeyService *services.APIKeyService, logger *logrus.Logger) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+		logger:        logger,
+	}
+}
+
+// CreateAPIKey handles POST /api-keys. The response is the only one that
+// carries the key itself.
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) error {
+	var req dto.CreateAPIKeyRequest
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+
+	key, plaintext, err := h.apiKeyService.IssueAPIKey(c.Request.Context(), req.Name, req.ScopeList(), req.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusCreated, dto.ToCreatedAPIKeyResponse(key, plaintext))
+	return nil
+}
+
+// GetAllAPIKeys handles GET /api-keys
+func (h *APIKeyHandler) GetAllAPIKeys(c *gin.Context) error {
+	keys, err := h.apiKeyService.ListAPIKeys(c.Request.Context())
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, dto.ToAPIKeysResponse(keys))
+	return nil
+}
+
+// GetAPIKey handles GET /api-keys/:id
+func (h *APIKeyHandler) GetAPIKey(c *gin.Context) error {
+	id, err := intParam(c, "id", "API key ID")
+	if err != nil {
+		return err
+	}
+
+	key, err := h.apiKeyService.GetAPIKey(c.Request.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, dto.ToAPIKeyResponse(key))
+	return nil
+}
+
+// RevokeAPIKey handles DELETE /api-keys/:id. The key is kept, marked as
+// revoked, so listings still show it.
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) error {
+	id, err := intParam(c, "id", "API key ID")
+	if err != nil {
+		return err
+	}
+
+	if _, err := h.apiKeyService.RevokeAPIKey(c.Request.Context(), id); err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+	return nil
+}
@@ -0,0 +1,173 @@
+package rest
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"rakia-tech-test/internal/application/principal"
+	"rakia-tech-test/internal/application/services"
+	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/errdefs"
+	"rakia-tech-test/internal/interfaces/rest/dto"
+)
+
+const (
+	// apiKeyHeader carries the API key of machine clients.
+	apiKeyHeader = "X-API-Key"
+	// authErrorKey holds, in the gin context, why the credentials of a
+	// request did not authenticate.
+	authErrorKey = "auth_error"
+)
+
+var (
+	errAuthRequired        = errdefs.New(errdefs.ErrUnauthenticated, "authentication required")
+	errMalformedAuthHeader = errdefs.New(errdefs.ErrUnauthenticated, "Authorization header must carry a Bearer token")
+	errAmbiguousAuth       = errdefs.New(errdefs.ErrUnauthenticated, "send either a Bearer token or an API key, not both")
+)
+
+type AuthHandler struct {
+	authService *services.AuthService
+	logger      *logrus.Logger
+}
+
+func NewAuthHandler(authService *services.AuthService, logger *logrus.Logger) *AuthHandler {
+	return &AuthHandler{
+		authService: authService,
+		logger:      logger,
+	}
+}
+
+// Login handles POST /auth/login
+func (h *AuthHandler) Login(c *gin.Context) error {
+	var req dto.LoginRequest
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+
+	tokens, err := h.authService.Login(c.Request.Context(), req.Username, req.Password)
+	if err != nil {
+		return err
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, dto.ToTokenResponse(tokens))
+	return nil
+}
+
+// Refresh handles POST /auth/refresh
+func (h *AuthHandler) Refresh(c *gin.Context) error {
+	var req dto.RefreshRequest
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+
+	tokens, err := h.authService.Refresh(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		return err
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, dto.ToTokenResponse(tokens))
+	return nil
+}
+
+// Logout handles POST /auth/logout. The refresh token in the body is
+// revoked; access tokens run out on their own.
+func (h *AuthHandler) Logout(c *gin.Context) error {
+	var req dto.RefreshRequest
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+
+	if err := h.authService.Logout(c.Request.Context(), req.RefreshToken); err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+	return nil
+}
+
+// AuthMiddleware authenticates requests carrying a Bearer access token in
+// the Authorization header or an API key in X-API-Key, and stores the caller
+// in the request context. Requests without either header stay anonymous.
+// A header that does not authenticate leaves the request anonymous too, and
+// AuthFailureMiddleware fails it later, so the rate limiter in between
+// counts failed attempts against the address they came from.
+func AuthMiddleware(authService *services.AuthService, apiKeyService *services.APIKeyService) gin.HandlerFunc {
+	authenticate := func(c *gin.Context) error {
+		header, apiKey := c.GetHeader("Authorization"), c.GetHeader(apiKeyHeader)
+		var (
+			p   principal.Principal
+			err error
+		)
+		switch {
+		case header != "" && apiKey != "":
+			return errAmbiguousAuth
+		case apiKey != "":
+			p, err = apiKeyService.Authenticate(c.Request.Context(), strings.TrimSpace(apiKey))
+		case header != "":
+			scheme, token, ok := strings.Cut(header, " ")
+			if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+				return errMalformedAuthHeader
+			}
+			p, err = authService.Authenticate(c.Request.Context(), strings.TrimSpace(token))
+		default:
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		c.Request = c.Request.WithContext(principal.NewContext(c.Request.Context(), p))
+		return nil
+	}
+	return func(c *gin.Context) {
+		if err := authenticate(c); err != nil {
+			c.Set(authErrorKey, err)
+		}
+	}
+}
+
+// AuthFailureMiddleware fails the requests whose credentials AuthMiddleware
+// could not authenticate.
+func AuthFailureMiddleware() gin.HandlerFunc {
+	return handleMiddleware(func(c *gin.Context) error {
+		if err, ok := c.Get(authErrorKey); ok {
+			return err.(error)
+		}
+		return nil
+	})
+}
+
+// requireScope rejects anonymous requests and callers without scope. It
+// runs after AuthMiddleware.
+func requireScope(scope entities.Scope) gin.HandlerFunc {
+	return handleMiddleware(func(c *gin.Context) error {
+		p, ok := principal.FromContext(c.Request.Context())
+		if !ok {
+			return errAuthRequired
+		}
+		if !p.HasScope(scope) {
+			return missingScope(scope)
+		}
+		return nil
+	})
+}
+
+// checkScope rejects callers without scope but lets anonymous requests
+// through, for routes open to everyone. It runs after AuthMiddleware.
+func checkScope(scope entities.Scope) gin.HandlerFunc {
+	return handleMiddleware(func(c *gin.Context) error {
+		if p, ok := principal.FromContext(c.Request.Context()); ok && !p.HasScope(scope) {
+			return missingScope(scope)
+		}
+		return nil
+	})
+}
+
+func missingScope(scope entities.Scope) error {
+	return errdefs.New(errdefs.ErrForbidden, "API key lacks the "+string(scope)+" scope")
+}
@@ -0,0 +1,104 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"rakia-tech-test/internal/application/services"
+	"rakia-tech-test/internal/interfaces/rest/dto"
+)
+
+type AuthorHandler struct {
+	authorService *services.AuthorService
+	logger        *logrus.Logger
+}
+
+func NewAuthorHandler(authorService *services.AuthorService, logger *logrus.Logger) *AuthorHandler {
+	return &AuthorHandler{
+		authorService: authorService,
+		logger:        logger,
+	}
+}
+
+// CreateAuthor handles POST /authors
+func (h *AuthorHandler) CreateAuthor(c *gin.Context) error {
+	var req dto.CreateAuthorRequest
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+
+	author, err := h.authorService.CreateAuthor(c.Request.Context(), req.Name, req.Bio)
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusCreated, dto.ToAuthorResponse(author))
+	return nil
+}
+
+// GetAllAuthors handles GET /authors
+func (h *AuthorHandler) GetAllAuthors(c *gin.Context) error {
+	authors, err := h.authorService.ListAuthors(c.Request.Context())
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, dto.ToAuthorsResponse(authors))
+	return nil
+}
+
+// GetAuthor handles GET /authors/:id
+func (h *AuthorHandler) GetAuthor(c *gin.Context) error {
+	id, err := intParam(c, "id", "author ID")
+	if err != nil {
+		return err
+	}
+
+	author, err := h.authorService.GetAuthor(c.Request.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, dto.ToAuthorResponse(author))
+	return nil
+}
+
+// UpdateAuthor handles PUT /authors/:id. A new name is carried over to the
+// posts of the author.
+func (h *AuthorHandler) UpdateAuthor(c *gin.Context) error {
+	id, err := intParam(c, "id", "author ID")
+	if err != nil {
+		return err
+	}
+
+	var req dto.UpdateAuthorRequest
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+
+	author, err := h.authorService.UpdateAuthor(c.Request.Context(), id, req.Name, req.Bio)
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, dto.ToAuthorResponse(author))
+	return nil
+}
+
+// DeleteAuthor handles DELETE /authors/:id. Authors with posts, trashed ones
+// included, cannot be deleted.
+func (h *AuthorHandler) DeleteAuthor(c *gin.Context) error {
+	id, err := intParam(c, "id", "author ID")
+	if err != nil {
+		return err
+	}
+
+	if err := h.authorService.DeleteAuthor(c.Request.Context(), id); err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+	return nil
+}
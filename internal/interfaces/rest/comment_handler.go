@@ -0,0 +1,118 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"rakia-tech-test/internal/application/services"
+	"rakia-tech-test/internal/interfaces/rest/dto"
+)
+
+type CommentHandler struct {
+	commentService *services.CommentService
+	logger         *logrus.Logger
+}
+
+func NewCommentHandler(commentService *services.CommentService, logger *logrus.Logger) *CommentHandler {
+	return &CommentHandler{
+		commentService: commentService,
+		logger:         logger,
+	}
+}
+
+// GetComments handles GET /posts/:id/comments?limit=N&cursor=C. Pages hold
+// up to N threads, each with every reply below it.
+func (h *CommentHandler) GetComments(c *gin.Context) error {
+	postID, err := intParam(c, "id", "post ID")
+	if err != nil {
+		return err
+	}
+
+	query, err := commentPageQuery(c)
+	if err != nil {
+		return err
+	}
+
+	page, err := h.commentService.ListComments(c.Request.Context(), postID, query)
+	if err != nil {
+		return err
+	}
+
+	response := dto.ToCommentsResponse(page.Threads)
+	if page.HasNext && len(page.Threads) > 0 {
+		if query.Limit == 0 {
+			query.Limit = services.DefaultPageLimit
+		}
+		response.Next = commentNextLink(c, query.Limit, page.Threads[len(page.Threads)-1].ID)
+	}
+	c.JSON(http.StatusOK, response)
+	return nil
+}
+
+// CreateComment handles POST /posts/:id/comments
+func (h *CommentHandler) CreateComment(c *gin.Context) error {
+	postID, err := intParam(c, "id", "post ID")
+	if err != nil {
+		return err
+	}
+
+	var req dto.CreateCommentRequest
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+
+	comment, err := h.commentService.CreateComment(c.Request.Context(), postID, req.ParentID, req.Content)
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusCreated, dto.ToCommentResponse(comment))
+	return nil
+}
+
+// UpdateComment handles PUT /posts/:id/comments/:comment
+func (h *CommentHandler) UpdateComment(c *gin.Context) error {
+	postID, err := intParam(c, "id", "post ID")
+	if err != nil {
+		return err
+	}
+	id, err := intParam(c, "comment", "comment ID")
+	if err != nil {
+		return err
+	}
+
+	var req dto.UpdateCommentRequest
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+
+	comment, err := h.commentService.UpdateComment(c.Request.Context(), postID, id, req.Content)
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, dto.ToCommentResponse(comment))
+	return nil
+}
+
+// DeleteComment handles DELETE /posts/:id/comments/:comment. The replies
+// below the comment are deleted with it.
+func (h *CommentHandler) DeleteComment(c *gin.Context) error {
+	postID, err := intParam(c, "id", "post ID")
+	if err != nil {
+		return err
+	}
+	id, err := intParam(c, "comment", "comment ID")
+	if err != nil {
+		return err
+	}
+
+	if err := h.commentService.DeleteComment(c.Request.Context(), postID, id); err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+	return nil
+}
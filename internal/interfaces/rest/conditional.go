@@ -0,0 +1,45 @@
+package rest
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"rakia-tech-test/internal/application/services"
+)
+
+// postETag returns the strong entity tag of a post at the given version.
+func postETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// renderedPostETag returns the entity tag of a post at the given version
+// with its content rendered as HTML. It differs from postETag so caches
+// tell the two forms apart, but names the same version for If-Match.
+func renderedPostETag(version int) string {
+	return `"` + strconv.Itoa(version) + `-html"`
+}
+
+// ifMatch turns the If-Match header of the request into a version matcher.
+// It returns nil when the header is absent, so the request is unconditional.
+// Weak tags never match, as required for If-Match.
+func ifMatch(c *gin.Context) services.VersionMatcher {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" {
+		return nil
+	}
+
+	if header == "*" {
+		return func(int) bool { return true }
+	}
+
+	accepted := make(map[string]bool)
+	for _, tag := range strings.Split(header, ",") {
+		accepted[strings.TrimSpace(tag)] = true
+	}
+
+	return func(version int) bool {
+		return accepted[postETag(version)] || accepted[renderedPostETag(version)]
+	}
+}
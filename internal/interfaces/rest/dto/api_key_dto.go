@@ -0,0 +1,89 @@
+package dto
+
+import (
+	"time"
+
+	"rakia-tech-test/internal/domain/entities"
+)
+
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name" binding:"required"`
+	Scopes []string `json:"scopes" binding:"required"`
+	// ExpiresAt is when the key stops working. Keys without it do not
+	// expire.
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// ScopeList returns the requested scopes. Unknown ones are left for the
+// entity to reject.
+func (r CreateAPIKeyRequest) ScopeList() []entities.Scope {
+	scopes := make([]entities.Scope, len(r.Scopes))
+	for i, scope := range r.Scopes {
+		scopes[i] = entities.Scope(scope)
+	}
+	return scopes
+}
+
+// APIKeyResponse describes a key without its secret, which is only
+// returned once, in CreatedAPIKeyResponse.
+type APIKeyResponse struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	UserID     int        `json:"user_id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// CreatedAPIKeyResponse carries the key itself, which cannot be retrieved
+// again, along with its description.
+type CreatedAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
+
+type APIKeysResponse struct {
+	APIKeys []APIKeyResponse `json:"api_keys"`
+	Total   int              `json:"total"`
+}
+
+func ToAPIKeyResponse(key *entities.APIKey) APIKeyResponse {
+	scopes := make([]string, len(key.Scopes))
+	for i, scope := range key.Scopes {
+		scopes[i] = string(scope)
+	}
+
+	return APIKeyResponse{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     scopes,
+		UserID:     key.UserID,
+		CreatedAt:  key.CreatedAt,
+		LastUsedAt: key.LastUsedAt,
+		ExpiresAt:  key.ExpiresAt,
+		RevokedAt:  key.RevokedAt,
+	}
+}
+
+func ToCreatedAPIKeyResponse(key *entities.APIKey, plaintext string) CreatedAPIKeyResponse {
+	return CreatedAPIKeyResponse{
+		APIKeyResponse: ToAPIKeyResponse(key),
+		Key:            plaintext,
+	}
+}
+
+func ToAPIKeysResponse(keys []*entities.APIKey) APIKeysResponse {
+	responses := make([]APIKeyResponse, len(keys))
+	for i, key := range keys {
+		responses[i] = ToAPIKeyResponse(key)
+	}
+
+	return APIKeysResponse{
+		APIKeys: responses,
+		Total:   len(keys),
+	}
+}
@@ -0,0 +1,32 @@
+package dto
+
+import "rakia-tech-test/internal/application/services"
+
+type LoginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// RefreshRequest carries the refresh token to trade in on a refresh, or to
+// revoke on a logout.
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// TokenResponse follows the OAuth 2.0 token response: ExpiresIn is the
+// lifetime of the access token in seconds.
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+func ToTokenResponse(tokens *services.TokenPair) TokenResponse {
+	return TokenResponse{
+		AccessToken:  tokens.AccessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(tokens.ExpiresIn.Seconds()),
+		RefreshToken: tokens.RefreshToken,
+	}
+}
@@ -0,0 +1,52 @@
+package dto
+
+import (
+	"time"
+
+	"rakia-tech-test/internal/domain/entities"
+)
+
+type CreateAuthorRequest struct {
+	Name string `json:"name" binding:"required"`
+	Bio  string `json:"bio"`
+}
+
+type UpdateAuthorRequest struct {
+	Name string `json:"name" binding:"required"`
+	Bio  string `json:"bio"`
+}
+
+type AuthorResponse struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Bio       string    `json:"bio"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type AuthorsResponse struct {
+	Authors []AuthorResponse `json:"authors"`
+	Total   int              `json:"total"`
+}
+
+func ToAuthorResponse(author *entities.Author) AuthorResponse {
+	return AuthorResponse{
+		ID:        author.ID,
+		Name:      author.Name,
+		Bio:       author.Bio,
+		CreatedAt: author.CreatedAt,
+		UpdatedAt: author.UpdatedAt,
+	}
+}
+
+func ToAuthorsResponse(authors []*entities.Author) AuthorsResponse {
+	responses := make([]AuthorResponse, len(authors))
+	for i, author := range authors {
+		responses[i] = ToAuthorResponse(author)
+	}
+
+	return AuthorsResponse{
+		Authors: responses,
+		Total:   len(authors),
+	}
+}
@@ -0,0 +1,72 @@
+package dto
+
+import (
+	"time"
+
+	"rakia-tech-test/internal/domain/entities"
+)
+
+// CreateCommentRequest adds a comment to a post, or a reply to the comment
+// ParentID when it is set. The comment is written under the name of the
+// caller.
+type CreateCommentRequest struct {
+	ParentID int    `json:"parent_id"`
+	Content  string `json:"content" binding:"required"`
+}
+
+type UpdateCommentRequest struct {
+	Content string `json:"content" binding:"required"`
+}
+
+type CommentResponse struct {
+	ID        int       `json:"id"`
+	PostID    int       `json:"post_id"`
+	ParentID  int       `json:"parent_id,omitempty"`
+	Author    string    `json:"author"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// Replies is only set in listings, where every comment carries the
+	// replies below it.
+	Replies []CommentResponse `json:"replies,omitempty"`
+}
+
+type CommentsResponse struct {
+	Comments []CommentResponse `json:"comments"`
+	Total    int               `json:"total"`
+	// Next links to the following page of threads and is omitted on the
+	// last one.
+	Next string `json:"next,omitempty"`
+}
+
+func ToCommentResponse(comment *entities.Comment) CommentResponse {
+	return CommentResponse{
+		ID:        comment.ID,
+		PostID:    comment.PostID,
+		ParentID:  comment.ParentID,
+		Author:    comment.Author,
+		Content:   comment.Content,
+		CreatedAt: comment.CreatedAt,
+		UpdatedAt: comment.UpdatedAt,
+	}
+}
+
+// ToCommentsResponse returns the threads as nested comments. Total counts
+// the threads.
+func ToCommentsResponse(threads []*entities.CommentThread) CommentsResponse {
+	return CommentsResponse{
+		Comments: toCommentTree(threads),
+		Total:    len(threads),
+	}
+}
+
+func toCommentTree(threads []*entities.CommentThread) []CommentResponse {
+	responses := make([]CommentResponse, len(threads))
+	for i, thread := range threads {
+		responses[i] = ToCommentResponse(thread.Comment)
+		if len(thread.Replies) > 0 {
+			responses[i].Replies = toCommentTree(thread.Replies)
+		}
+	}
+	return responses
+}
@@ -1,44 +1,90 @@
 package dto
 
 import (
+	"time"
+
 	"rakia-tech-test/internal/domain/entities"
 )
 
+// CreatePostRequest names the author of the post either by ID or by name. A
+// name that matches no author yet adds one. The content is plain text unless
+// ContentFormat says otherwise.
 type CreatePostRequest struct {
-	Title   string `json:"title" binding:"required,max=255"`
-	Content string `json:"content" binding:"required"`
-	Author  string `json:"author" binding:"required"`
+	Title         string   `json:"title" binding:"required,max=255"`
+	Content       string   `json:"content" binding:"required"`
+	ContentFormat string   `json:"content_format" binding:"omitempty,oneof=plain markdown"`
+	Author        string   `json:"author" binding:"required_without=AuthorID"`
+	AuthorID      int      `json:"author_id"`
+	Tags          []string `json:"tags"`
 }
 
 type UpdatePostRequest struct {
 	Title   string `json:"title" binding:"required,max=255"`
 	Content string `json:"content" binding:"required"`
-	Author  string `json:"author" binding:"required"`
+	// ContentFormat changes the format of the content when present.
+	ContentFormat string `json:"content_format" binding:"omitempty,oneof=plain markdown"`
+	Author        string `json:"author" binding:"required_without=AuthorID"`
+	AuthorID      int    `json:"author_id"`
+	// Tags replace the tags of the post when present; omitting them keeps
+	// the current ones and an empty list removes them.
+	Tags []string `json:"tags"`
 }
 
-type PostResponse struct {
-	ID      int    `json:"id"`
-	Title   string `json:"title"`
-	Content string `json:"content"`
-	Author  string `json:"author"`
+// ChangeStatusRequest moves a post along the editorial workflow. With
+// PublishAt set, a post in review is scheduled for publication at that time
+// instead of being published at once.
+type ChangeStatusRequest struct {
+	Status    string     `json:"status" binding:"required"`
+	PublishAt *time.Time `json:"publish_at"`
 }
 
-type ErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message,omitempty"`
+type PostResponse struct {
+	ID            int    `json:"id"`
+	Slug          string `json:"slug"`
+	Title         string `json:"title"`
+	Content       string `json:"content"`
+	ContentFormat string `json:"content_format"`
+	// ContentHTML is the content rendered as sanitized HTML. It is only set
+	// when the client asks for it with render=html.
+	ContentHTML string    `json:"content_html,omitempty"`
+	Author      string    `json:"author"`
+	AuthorID    int       `json:"author_id,omitempty"`
+	Version     int       `json:"version"`
+	Status      string    `json:"status"`
+	Tags        []string  `json:"tags"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	// PublishAt is only set for posts scheduled for publication.
+	PublishAt *time.Time `json:"publish_at,omitempty"`
+	// DeletedAt is only set for posts in the trash.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 }
 
 type PostsResponse struct {
 	Posts []PostResponse `json:"posts"`
 	Total int            `json:"total"`
+	// Next and Prev link to the neighbouring pages of a paginated listing
+	// and are omitted at either end.
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
 }
 
 func ToPostResponse(post *entities.Post) PostResponse {
 	return PostResponse{
-		ID:      post.ID,
-		Title:   post.Title,
-		Content: post.Content,
-		Author:  post.Author,
+		ID:            post.ID,
+		Slug:          post.Slug,
+		Title:         post.Title,
+		Content:       post.Content,
+		ContentFormat: string(post.ContentFormat),
+		Author:        post.Author,
+		AuthorID:      post.AuthorID,
+		Version:       post.Version,
+		Status:        string(post.Status),
+		Tags:          append([]string{}, post.Tags...),
+		CreatedAt:     post.CreatedAt,
+		UpdatedAt:     post.UpdatedAt,
+		PublishAt:     post.PublishAt,
+		DeletedAt:     post.DeletedAt,
 	}
 }
 
@@ -0,0 +1,41 @@
+package dto
+
+import "rakia-tech-test/internal/domain/validation"
+
+// ProblemResponse is an RFC 7807 problem details document, the body of
+// every error response.
+type ProblemResponse struct {
+	// Type is a URI reference naming the kind of problem.
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	// Instance is the path of the request that failed.
+	Instance string `json:"instance,omitempty"`
+	// Details lists every problem with the fields of the request when it
+	// failed validation.
+	Details []FieldErrorResponse `json:"details,omitempty"`
+}
+
+// FieldErrorResponse is one problem with one field of a request. Code is a
+// stable name of the problem, such as required or too_long, and Params
+// holds the limits the field was checked against.
+type FieldErrorResponse struct {
+	Field   string         `json:"field"`
+	Code    string         `json:"code"`
+	Message string         `json:"message"`
+	Params  map[string]any `json:"params,omitempty"`
+}
+
+func ToFieldErrorResponses(errs validation.Errors) []FieldErrorResponse {
+	responses := make([]FieldErrorResponse, len(errs))
+	for i, err := range errs {
+		responses[i] = FieldErrorResponse{
+			Field:   err.Field,
+			Code:    err.Code,
+			Message: err.Message,
+			Params:  err.Params,
+		}
+	}
+	return responses
+}
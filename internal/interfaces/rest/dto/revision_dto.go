@@ -0,0 +1,92 @@
+package dto
+
+import (
+	"time"
+
+	"rakia-tech-test/internal/application/diff"
+	"rakia-tech-test/internal/application/services"
+	"rakia-tech-test/internal/domain/entities"
+)
+
+// RevisionSummary describes a revision without its text, for history
+// listings.
+type RevisionSummary struct {
+	Number       int       `json:"number"`
+	EditedBy     string    `json:"edited_by"`
+	EditedAt     time.Time `json:"edited_at"`
+	Changes      []string  `json:"changes"`
+	RestoredFrom int       `json:"restored_from,omitempty"`
+}
+
+type RevisionResponse struct {
+	PostID int `json:"post_id"`
+	RevisionSummary
+	Title   string `json:"title"`
+	Content string `json:"content"`
+	Author  string `json:"author"`
+}
+
+type RevisionsResponse struct {
+	Revisions []RevisionSummary `json:"revisions"`
+	Total     int               `json:"total"`
+}
+
+type RevisionDiffResponse struct {
+	PostID int         `json:"post_id"`
+	From   int         `json:"from"`
+	To     int         `json:"to"`
+	Mode   diff.Mode   `json:"mode"`
+	Fields FieldsDiffs `json:"fields"`
+}
+
+type FieldsDiffs struct {
+	Title   []diff.Edit `json:"title"`
+	Content []diff.Edit `json:"content"`
+	Author  []diff.Edit `json:"author"`
+}
+
+func ToRevisionSummary(revision *entities.Revision) RevisionSummary {
+	return RevisionSummary{
+		Number:       revision.Number,
+		EditedBy:     revision.EditedBy,
+		EditedAt:     revision.EditedAt,
+		Changes:      revision.Changes,
+		RestoredFrom: revision.RestoredFrom,
+	}
+}
+
+func ToRevisionResponse(revision *entities.Revision) RevisionResponse {
+	return RevisionResponse{
+		PostID:          revision.PostID,
+		RevisionSummary: ToRevisionSummary(revision),
+		Title:           revision.Title,
+		Content:         revision.Content,
+		Author:          revision.Author,
+	}
+}
+
+func ToRevisionsResponse(revisions []*entities.Revision) RevisionsResponse {
+	summaries := make([]RevisionSummary, len(revisions))
+	for i, revision := range revisions {
+		summaries[i] = ToRevisionSummary(revision)
+	}
+
+	return RevisionsResponse{
+		Revisions: summaries,
+		Total:     len(revisions),
+	}
+}
+
+func ToRevisionDiffResponse(result *services.RevisionDiff) RevisionDiffResponse {
+	return RevisionDiffResponse{
+		PostID: result.PostID,
+		From:   result.From,
+		To:     result.To,
+		Mode:   result.Mode,
+		Fields: FieldsDiffs{
+			Title:   result.Title,
+			Content: result.Content,
+			Author:  result.Author,
+		},
+	}
+}
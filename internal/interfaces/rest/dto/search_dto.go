@@ -0,0 +1,42 @@
+package dto
+
+import "rakia-tech-test/internal/application/services"
+
+type SearchHit struct {
+	Post  PostResponse `json:"post"`
+	Score float64      `json:"score"`
+	// Highlights hold HTML-safe excerpts with the matching words wrapped in
+	// <mark> tags.
+	Highlights SearchHighlights `json:"highlights"`
+}
+
+type SearchHighlights struct {
+	Title   string `json:"title"`
+	Content string `json:"content"`
+}
+
+type SearchResponse struct {
+	Query string      `json:"query"`
+	Hits  []SearchHit `json:"hits"`
+	Total int         `json:"total"`
+}
+
+func ToSearchResponse(query string, results []services.SearchResult) SearchResponse {
+	hits := make([]SearchHit, len(results))
+	for i, result := range results {
+		hits[i] = SearchHit{
+			Post:  ToPostResponse(result.Post),
+			Score: result.Score,
+			Highlights: SearchHighlights{
+				Title:   result.TitleSnippet,
+				Content: result.ContentSnippet,
+			},
+		}
+	}
+
+	return SearchResponse{
+		Query: query,
+		Hits:  hits,
+		Total: len(hits),
+	}
+}
@@ -0,0 +1,25 @@
+package dto
+
+import "rakia-tech-test/internal/domain/repositories"
+
+type TagResponse struct {
+	Tag   string `json:"tag"`
+	Posts int    `json:"posts"`
+}
+
+type TagsResponse struct {
+	Tags  []TagResponse `json:"tags"`
+	Total int           `json:"total"`
+}
+
+func ToTagsResponse(counts []repositories.TagCount) TagsResponse {
+	tags := make([]TagResponse, len(counts))
+	for i, count := range counts {
+		tags[i] = TagResponse{Tag: count.Tag, Posts: count.Posts}
+	}
+
+	return TagsResponse{
+		Tags:  tags,
+		Total: len(tags),
+	}
+}
@@ -0,0 +1,123 @@
+package rest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"rakia-tech-test/internal/application/idempotency"
+)
+
+const (
+	idempotencyKeyHeader = "Idempotency-Key"
+	// maxIdempotencyKeyLength bounds the keys clients choose.
+	maxIdempotencyKeyLength = 255
+)
+
+// replayedHeaders are the headers of a response kept for replays, besides
+// its body.
+var replayedHeaders = []string{"Content-Type", "Location", "ETag"}
+
+// IdempotencyMiddleware makes the requests carrying an Idempotency-Key
+// header safe to retry. The first successful response with a key is
+// replayed, with an Idempotent-Replayed header, to every request with the
+// same key, method, path and body; a retry arriving while the first request
+// is in progress waits for it. Reusing a key for a different request fails
+// with ErrKeyReused. Failed responses are not kept, so their retries run
+// again. Keys belong to the client that sent them, as told apart by
+// clientKey. It runs after AuthMiddleware.
+func IdempotencyMiddleware(store *idempotency.Store) gin.HandlerFunc {
+	return handleMiddleware(func(c *gin.Context) error {
+		key := c.GetHeader(idempotencyKeyHeader)
+		if key == "" {
+			return nil
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			return badRequest("Idempotency-Key must be at most 255 characters")
+		}
+
+		// The body is read whole for its fingerprint, within the limit
+		// the handler would apply, before anything is stored.
+		limitBody(c)
+		body, err := io.ReadAll(c.Request.Body)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return errBodyTooLarge
+		}
+		if err != nil {
+			return badRequest("request body could not be read")
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		key = clientKey(c) + " " + key
+		replay, err := store.Begin(c.Request.Context(), key, fingerprint(c.Request, body))
+		if err != nil {
+			return err
+		}
+		if replay != nil {
+			for name, values := range replay.Header {
+				c.Writer.Header()[name] = values
+			}
+			c.Header("Idempotent-Replayed", "true")
+			c.Status(replay.Status)
+			_, _ = c.Writer.Write(replay.Body)
+			c.Abort()
+			return nil
+		}
+
+		recorder := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		completed := false
+		defer func() {
+			if !completed {
+				store.Release(key)
+			}
+		}()
+
+		c.Next()
+
+		status := recorder.Status()
+		if len(c.Errors) > 0 || status < 200 || status >= 300 {
+			return nil
+		}
+		header := make(http.Header)
+		for _, name := range replayedHeaders {
+			if value := recorder.Header().Get(name); value != "" {
+				header.Set(name, value)
+			}
+		}
+		store.Complete(key, &idempotency.Response{Status: status, Header: header, Body: recorder.body.Bytes()})
+		completed = true
+		return nil
+	})
+}
+
+// fingerprint identifies a request by method, path and body, so a key
+// reused for a different request is told apart from a retry.
+func fingerprint(req *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(req.Method + " " + req.URL.Path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// recordingWriter keeps a copy of the body written through it.
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,237 @@
+package rest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/repositories"
+)
+
+var errInvalidCursor = badRequest("invalid cursor")
+
+const (
+	cursorNext = "next"
+	cursorPrev = "prev"
+)
+
+// cursorToken is the content of the opaque cursor handed to clients. It
+// records a position, the order it belongs to and the direction to page in
+// from there.
+type cursorToken struct {
+	Direction string `json:"d"`
+	Sort      string `json:"s,omitempty"`
+	ID        int    `json:"id"`
+	Key       string `json:"k,omitempty"`
+}
+
+func encodeCursor(direction string, sort repositories.PostSort, cursor repositories.Cursor) string {
+	data, _ := json.Marshal(cursorToken{
+		Direction: direction,
+		Sort:      string(sortOrDefault(sort)),
+		ID:        cursor.ID,
+		Key:       cursor.Key,
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(encoded string) (cursorToken, error) {
+	var token cursorToken
+
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return token, errInvalidCursor
+	}
+	if err := json.Unmarshal(data, &token); err != nil {
+		return token, errInvalidCursor
+	}
+
+	return token, nil
+}
+
+// applyCursor decodes a cursor from the query string into query. The cursor
+// must have been issued for the order of query.
+func applyCursor(query *repositories.PostQuery, encoded string) error {
+	token, err := decodeCursor(encoded)
+	if err != nil {
+		return err
+	}
+	if sortOrDefault(repositories.PostSort(token.Sort)) != sortOrDefault(query.Sort) {
+		return badRequest("cursor does not belong to the requested sort")
+	}
+
+	cursor := &repositories.Cursor{ID: token.ID, Key: token.Key}
+	switch token.Direction {
+	case cursorNext:
+		query.After = cursor
+	case cursorPrev:
+		query.Before = cursor
+	default:
+		return errInvalidCursor
+	}
+
+	return nil
+}
+
+func sortOrDefault(sort repositories.PostSort) repositories.PostSort {
+	if sort == "" {
+		return repositories.SortByID
+	}
+	return sort
+}
+
+// pageQuery reads the limit, cursor, sort and filter query parameters. Date
+// filters are RFC 3339 timestamps.
+func pageQuery(c *gin.Context) (repositories.PostQuery, error) {
+	query := repositories.PostQuery{
+		Sort: repositories.PostSort(c.Query("sort")),
+		Filter: repositories.PostFilter{
+			Status:          entities.PostStatus(c.Query("status")),
+			Tag:             c.Query("tag"),
+			Author:          c.Query("author"),
+			AuthorPrefix:    c.Query("author_prefix"),
+			TitleContains:   c.Query("title_contains"),
+			ContentContains: c.Query("content_contains"),
+		},
+	}
+
+	for _, param := range []struct {
+		name   string
+		target *int
+	}{
+		{name: "limit", target: &query.Limit},
+		{name: "author_id", target: &query.Filter.AuthorID},
+		{name: "min_id", target: &query.Filter.MinID},
+		{name: "max_id", target: &query.Filter.MaxID},
+	} {
+		value := c.Query(param.name)
+		if value == "" {
+			continue
+		}
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return query, badRequest("invalid " + param.name + " format")
+		}
+		*param.target = parsed
+	}
+
+	for _, param := range []struct {
+		name   string
+		target *time.Time
+	}{
+		{name: "created_after", target: &query.Filter.CreatedAfter},
+		{name: "created_before", target: &query.Filter.CreatedBefore},
+		{name: "updated_since", target: &query.Filter.UpdatedSince},
+	} {
+		value := c.Query(param.name)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return query, badRequest("invalid " + param.name + " format, expected RFC 3339")
+		}
+		*param.target = parsed
+	}
+
+	if cursor := c.Query("cursor"); cursor != "" {
+		if err := applyCursor(&query, cursor); err != nil {
+			return query, err
+		}
+	}
+
+	return query, nil
+}
+
+// pageLinks returns the links to the pages around page, keeping every other
+// query parameter of the current request. A link is empty when there is no
+// page in that direction.
+func pageLinks(c *gin.Context, query repositories.PostQuery, page *repositories.PostPage) (next, prev string) {
+	link := func(direction string, cursor repositories.Cursor) string {
+		params := url.Values{}
+		for key, values := range c.Request.URL.Query() {
+			params[key] = values
+		}
+		params.Set("limit", strconv.Itoa(query.Limit))
+		params.Set("cursor", encodeCursor(direction, query.Sort, cursor))
+
+		return c.Request.URL.Path + "?" + params.Encode()
+	}
+
+	// The page boundaries are the first and last post. An empty page falls
+	// back to the position it was requested from, nudged by one ID: IDs
+	// break every tie, so that lands between the cursor and its neighbour.
+	step := 1
+	if query.Sort == repositories.SortByIDDesc {
+		step = -1
+	}
+	var first, last repositories.Cursor
+	switch {
+	case len(page.Posts) > 0:
+		first = query.Sort.CursorFor(page.Posts[0])
+		last = query.Sort.CursorFor(page.Posts[len(page.Posts)-1])
+	case query.After != nil:
+		first, last = *query.After, *query.After
+		first.ID += step
+	case query.Before != nil:
+		first, last = *query.Before, *query.Before
+		last.ID -= step
+	default:
+		return "", ""
+	}
+
+	if page.HasNext {
+		next = link(cursorNext, last)
+	}
+	if page.HasPrev {
+		prev = link(cursorPrev, first)
+	}
+
+	return next, prev
+}
+
+// commentPageQuery reads the limit and cursor query parameters of a comment
+// listing. Comment threads are only paged forward.
+func commentPageQuery(c *gin.Context) (repositories.CommentQuery, error) {
+	var query repositories.CommentQuery
+
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			return query, badRequest("invalid limit format")
+		}
+		query.Limit = limit
+	}
+
+	if encoded := c.Query("cursor"); encoded != "" {
+		token, err := decodeCursor(encoded)
+		if err != nil {
+			return query, err
+		}
+		if token.Direction != cursorNext {
+			return query, errInvalidCursor
+		}
+		query.After = token.ID
+	}
+
+	return query, nil
+}
+
+// commentNextLink returns the link to the page of threads following the one
+// whose last top-level comment has the ID last, keeping every other query
+// parameter of the current request.
+func commentNextLink(c *gin.Context, limit, last int) string {
+	params := url.Values{}
+	for key, values := range c.Request.URL.Query() {
+		params[key] = values
+	}
+	params.Set("limit", strconv.Itoa(limit))
+	params.Set("cursor", encodeCursor(cursorNext, repositories.SortByID, repositories.Cursor{ID: last}))
+
+	return c.Request.URL.Path + "?" + params.Encode()
+}
@@ -2,13 +2,13 @@ package rest
 
 import (
 	"net/http"
-	"strconv"
+	"path"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 
 	"rakia-tech-test/internal/application/services"
-	"rakia-tech-test/internal/domain/repositories"
+	"rakia-tech-test/internal/domain/entities"
 	"rakia-tech-test/internal/interfaces/rest/dto"
 )
 
@@ -25,178 +25,158 @@ func NewPostHandler(postService *services.PostService, logger *logrus.Logger) *P
 }
 
 // CreatePost handles POST /posts
-func (h *PostHandler) CreatePost(c *gin.Context) {
+func (h *PostHandler) CreatePost(c *gin.Context) error {
 	var req dto.CreatePostRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.WithError(err).Error("Invalid request body")
-		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
-			Error:   "validation_error",
-			Message: err.Error(),
-		})
-		return
+	if err := bindJSON(c, &req); err != nil {
+		return err
 	}
 
-	post, err := h.postService.CreatePost(req.Title, req.Content, req.Author)
+	post, err := h.postService.CreatePost(c.Request.Context(), req.Title, req.Content, entities.ContentFormat(req.ContentFormat), req.Author, req.AuthorID, req.Tags)
 	if err != nil {
-		h.logger.WithError(err).Error("Failed to create post")
-		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
-			Error:   "creation_failed",
-			Message: err.Error(),
-		})
-		return
+		return err
 	}
 
 	response := dto.ToPostResponse(post)
+	c.Header("ETag", postETag(post.Version))
 	c.JSON(http.StatusCreated, response)
+	return nil
 }
 
-// GetPost handles GET /posts/:id
-func (h *PostHandler) GetPost(c *gin.Context) {
-	idStr := c.Param("id")
-	if idStr == "" {
-		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
-			Error:   "validation_error",
-			Message: "Post ID is required",
-		})
-		return
+// GetPost handles GET /posts/:id. With render=html the response carries
+// the content rendered as HTML as well.
+func (h *PostHandler) GetPost(c *gin.Context) error {
+	id, err := intParam(c, "id", "post ID")
+	if err != nil {
+		return err
 	}
 
-	id, err := strconv.Atoi(idStr)
+	renderHTML, err := renderParam(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
-			Error:   "validation_error",
-			Message: "Invalid post ID format",
-		})
-		return
+		return err
 	}
 
-	post, err := h.postService.GetPostByID(id)
+	post, err := h.postService.GetPostByID(c.Request.Context(), id)
 	if err != nil {
-		if err == repositories.ErrPostNotFound {
-			c.JSON(http.StatusNotFound, dto.ErrorResponse{
-				Error:   "not_found",
-				Message: "Post not found",
-			})
-			return
-		}
-
-		h.logger.WithError(err).Error("Failed to get post")
-		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
-			Error:   "internal_error",
-			Message: "Failed to retrieve post",
-		})
-		return
+		return err
 	}
 
 	response := dto.ToPostResponse(post)
+	etag := postETag(post.Version)
+	if renderHTML {
+		response.ContentHTML = h.postService.RenderContent(post)
+		etag = renderedPostETag(post.Version)
+	}
+	c.Header("ETag", etag)
 	c.JSON(http.StatusOK, response)
+	return nil
 }
 
-// GetAllPosts handles GET /posts
-func (h *PostHandler) GetAllPosts(c *gin.Context) {
-	posts, err := h.postService.GetAllPosts()
+// GetPostBySlug handles GET /posts/by-slug/:slug, taking render=html like
+// GetPost. A retired slug of a post answers with a permanent redirect to its
+// current one.
+func (h *PostHandler) GetPostBySlug(c *gin.Context) error {
+	slug := c.Param("slug")
+	renderHTML, err := renderParam(c)
 	if err != nil {
-		h.logger.WithError(err).Error("Failed to get posts")
-		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
-			Error:   "internal_error",
-			Message: "Failed to retrieve posts",
-		})
-		return
+		return err
 	}
 
-	response := dto.ToPostsResponse(posts)
+	post, err := h.postService.GetPostBySlug(c.Request.Context(), slug)
+	if err != nil {
+		return err
+	}
+
+	if post.Slug != slug {
+		location := path.Join(path.Dir(c.Request.URL.Path), post.Slug)
+		if c.Request.URL.RawQuery != "" {
+			location += "?" + c.Request.URL.RawQuery
+		}
+		c.Redirect(http.StatusMovedPermanently, location)
+		return nil
+	}
+
+	response := dto.ToPostResponse(post)
+	etag := postETag(post.Version)
+	if renderHTML {
+		response.ContentHTML = h.postService.RenderContent(post)
+		etag = renderedPostETag(post.Version)
+	}
+	c.Header("ETag", etag)
 	c.JSON(http.StatusOK, response)
+	return nil
 }
 
-// UpdatePost handles PUT /posts/:id
-func (h *PostHandler) UpdatePost(c *gin.Context) {
-	idStr := c.Param("id")
-	if idStr == "" {
-		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
-			Error:   "validation_error",
-			Message: "Post ID is required",
-		})
-		return
+// GetAllPosts handles GET /posts?limit=N&cursor=C&sort=S plus the filters
+// that pageQuery reads: by status, tag, author, text, ID range and date.
+func (h *PostHandler) GetAllPosts(c *gin.Context) error {
+	query, err := pageQuery(c)
+	if err != nil {
+		return err
 	}
 
-	id, err := strconv.Atoi(idStr)
+	page, err := h.postService.ListPosts(c.Request.Context(), query)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
-			Error:   "validation_error",
-			Message: "Invalid post ID format",
-		})
-		return
+		return err
 	}
 
-	var req dto.UpdatePostRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.WithError(err).Error("Invalid request body")
-		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
-			Error:   "validation_error",
-			Message: err.Error(),
-		})
-		return
+	if query.Limit == 0 {
+		query.Limit = services.DefaultPageLimit
 	}
 
-	post, err := h.postService.UpdatePost(id, req.Title, req.Content, req.Author)
+	response := dto.ToPostsResponse(page.Posts)
+	response.Next, response.Prev = pageLinks(c, query, page)
+	c.JSON(http.StatusOK, response)
+	return nil
+}
+
+// UpdatePost handles PUT /posts/:id
+func (h *PostHandler) UpdatePost(c *gin.Context) error {
+	id, err := intParam(c, "id", "post ID")
 	if err != nil {
-		if err == repositories.ErrPostNotFound {
-			c.JSON(http.StatusNotFound, dto.ErrorResponse{
-				Error:   "not_found",
-				Message: "Post not found",
-			})
-			return
-		}
+		return err
+	}
 
-		h.logger.WithError(err).Error("Failed to update post")
-		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
-			Error:   "update_failed",
-			Message: err.Error(),
-		})
-		return
+	var req dto.UpdatePostRequest
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+
+	match := ifMatch(c)
+	post, err := h.postService.UpdatePost(c.Request.Context(), id, req.Title, req.Content, entities.ContentFormat(req.ContentFormat), req.Author, req.AuthorID, req.Tags, match)
+	if err != nil {
+		return conditionalError(err, match)
 	}
 
 	response := dto.ToPostResponse(post)
+	c.Header("ETag", postETag(post.Version))
 	c.JSON(http.StatusOK, response)
+	return nil
 }
 
 // DeletePost handles DELETE /posts/:id
-func (h *PostHandler) DeletePost(c *gin.Context) {
-	idStr := c.Param("id")
-	if idStr == "" {
-		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
-			Error:   "validation_error",
-			Message: "Post ID is required",
-		})
-		return
-	}
-
-	id, err := strconv.Atoi(idStr)
+func (h *PostHandler) DeletePost(c *gin.Context) error {
+	id, err := intParam(c, "id", "post ID")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
-			Error:   "validation_error",
-			Message: "Invalid post ID format",
-		})
-		return
+		return err
 	}
 
-	err = h.postService.DeletePost(id)
-	if err != nil {
-		if err == repositories.ErrPostNotFound {
-			c.JSON(http.StatusNotFound, dto.ErrorResponse{
-				Error:   "not_found",
-				Message: "Post not found",
-			})
-			return
-		}
-
-		h.logger.WithError(err).Error("Failed to delete post")
-		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
-			Error:   "internal_error",
-			Message: "Failed to delete post",
-		})
-		return
+	match := ifMatch(c)
+	if err := h.postService.DeletePost(c.Request.Context(), id, match); err != nil {
+		return conditionalError(err, match)
 	}
 
 	c.JSON(http.StatusNoContent, nil)
+	return nil
+}
+
+// renderParam reads the render query parameter, which only takes html.
+func renderParam(c *gin.Context) (bool, error) {
+	switch c.Query("render") {
+	case "":
+		return false, nil
+	case "html":
+		return true, nil
+	default:
+		return false, badRequest("render must be html")
+	}
 }
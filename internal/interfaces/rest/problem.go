@@ -0,0 +1,150 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"rakia-tech-test/internal/application/idempotency"
+	"rakia-tech-test/internal/application/requestid"
+	"rakia-tech-test/internal/application/services"
+	"rakia-tech-test/internal/domain/errdefs"
+	"rakia-tech-test/internal/domain/repositories"
+	"rakia-tech-test/internal/domain/validation"
+	"rakia-tech-test/internal/interfaces/rest/dto"
+)
+
+const problemContentType = "application/problem+json"
+
+// errPreconditionFailed reports a version check that failed because the
+// client sent an If-Match the post no longer matches.
+var errPreconditionFailed = errors.New("post has been modified since it was retrieved")
+
+// problemType describes the response to one kind of error.
+type problemType struct {
+	err    error
+	status int
+	name   string
+	title  string
+}
+
+// problemTypes are matched in order with errors.Is. Errors matching none of
+// them are unexpected and answered as internal errors.
+var problemTypes = []problemType{
+	{err: errPreconditionFailed, status: http.StatusPreconditionFailed, name: "precondition-failed", title: "Precondition failed"},
+	{err: errBodyTooLarge, status: http.StatusRequestEntityTooLarge, name: "body-too-large", title: "Request body too large"},
+	{err: idempotency.ErrKeyReused, status: http.StatusUnprocessableEntity, name: "idempotency-key-reused", title: "Idempotency key reused"},
+	{err: errdefs.ErrValidation, status: http.StatusBadRequest, name: "validation-error", title: "Invalid request"},
+	{err: errdefs.ErrNotFound, status: http.StatusNotFound, name: "not-found", title: "Resource not found"},
+	{err: errdefs.ErrConflict, status: http.StatusConflict, name: "conflict", title: "Conflict with the current state"},
+	{err: errdefs.ErrUnauthenticated, status: http.StatusUnauthorized, name: "unauthenticated", title: "Authentication required"},
+	{err: errdefs.ErrForbidden, status: http.StatusForbidden, name: "forbidden", title: "Forbidden"},
+	{err: errdefs.ErrRateLimited, status: http.StatusTooManyRequests, name: "rate-limited", title: "Too many requests"},
+	{err: errdefs.ErrUnavailable, status: http.StatusServiceUnavailable, name: "unavailable", title: "Service unavailable"},
+	// Requests cut short by their client going away or by the shutdown
+	// deadline are not failures of the server.
+	{err: context.Canceled, status: http.StatusServiceUnavailable, name: "unavailable", title: "Service unavailable"},
+	{err: context.DeadlineExceeded, status: http.StatusServiceUnavailable, name: "unavailable", title: "Service unavailable"},
+}
+
+var internalProblem = problemType{status: http.StatusInternalServerError, name: "internal-error", title: "Internal server error"}
+
+// handlerFunc is a handler that reports failure by returning an error
+// instead of answering it. handle adapts it to gin, and ProblemMiddleware
+// turns the error into the response.
+type handlerFunc func(c *gin.Context) error
+
+func handle(h handlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := h(c); err != nil {
+			_ = c.Error(err)
+		}
+	}
+}
+
+// handleMiddleware adapts a middleware that reports failure by returning an
+// error: the request stops there and ProblemMiddleware answers it.
+func handleMiddleware(h handlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := h(c); err != nil {
+			_ = c.Error(err)
+			c.Abort()
+		}
+	}
+}
+
+// ProblemMiddleware answers requests whose handler failed with an RFC 7807
+// problem document describing the last error. The kind of the error, found
+// with errors.Is, decides the status; unexpected errors and requests that
+// ran out of time are logged, and their message is kept from the client.
+// Requests cancelled by their client are only logged at debug level.
+func ProblemMiddleware(logger *logrus.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		err := c.Errors.Last().Err
+
+		problem := problemFor(err)
+		response := dto.ProblemResponse{
+			Type:     "/problems/" + problem.name,
+			Title:    problem.title,
+			Status:   problem.status,
+			Detail:   err.Error(),
+			Instance: c.Request.URL.Path,
+		}
+		level, logged := logrus.ErrorLevel, problem.status == http.StatusInternalServerError
+		if errors.Is(err, context.Canceled) {
+			level, logged = logrus.DebugLevel, true
+		} else if errors.Is(err, context.DeadlineExceeded) {
+			logged = true
+		}
+		if logged {
+			entry := logger.WithError(err)
+			if id := requestid.FromContext(c.Request.Context()); id != "" {
+				entry = entry.WithField("request_id", id)
+			}
+			entry.Logf(level, "%s %s failed", c.Request.Method, c.Request.URL.Path)
+			response.Detail = "The request could not be completed"
+		}
+		if fields, ok := validation.Fields(err); ok {
+			response.Details = dto.ToFieldErrorResponses(fields)
+		}
+
+		if problem.status == http.StatusUnauthorized {
+			c.Header("WWW-Authenticate", `Bearer realm="api"`)
+		}
+		c.Header("Content-Type", problemContentType)
+		c.JSON(problem.status, response)
+	}
+}
+
+func problemFor(err error) problemType {
+	for _, problem := range problemTypes {
+		if errors.Is(err, problem.err) {
+			return problem
+		}
+	}
+	return internalProblem
+}
+
+// badRequest returns a validation error with message, for requests that are
+// malformed as a whole rather than in one field.
+func badRequest(message string) error {
+	return errdefs.New(errdefs.ErrValidation, message)
+}
+
+// conditionalError reports a failed version check as errPreconditionFailed
+// when the client sent If-Match. Without it a concurrent write won the race,
+// and err stays a conflict.
+func conditionalError(err error, match services.VersionMatcher) error {
+	if match != nil && errors.Is(err, repositories.ErrVersionConflict) {
+		return errPreconditionFailed
+	}
+	return err
+}
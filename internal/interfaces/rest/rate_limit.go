@@ -0,0 +1,81 @@
+package rest
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"rakia-tech-test/internal/application/principal"
+	"rakia-tech-test/internal/application/ratelimit"
+	"rakia-tech-test/internal/domain/errdefs"
+)
+
+var errRateLimited = errdefs.New(errdefs.ErrRateLimited, "rate limit exceeded, retry later")
+
+// RateLimiter holds the request budgets of the API. Reads and writes are
+// counted apart, so a client that used up its writes can still read.
+type RateLimiter struct {
+	reads  *ratelimit.Limiter
+	writes *ratelimit.Limiter
+}
+
+// NewRateLimiter returns a rate limiter with the given budgets. A nil
+// limiter leaves its kind of requests unlimited.
+func NewRateLimiter(reads, writes *ratelimit.Limiter) *RateLimiter {
+	return &RateLimiter{
+		reads:  reads,
+		writes: writes,
+	}
+}
+
+// RateLimitMiddleware holds every client to the budget of its kind of
+// request, and tells it where it stands in the RateLimit-Limit,
+// RateLimit-Remaining and RateLimit-Reset headers. Requests over budget
+// fail with a rate-limited problem and a Retry-After header. Clients are
+// told apart by API key, then by user, and anonymous ones by IP address,
+// as are requests whose credentials did not authenticate. It runs after
+// AuthMiddleware and before AuthFailureMiddleware.
+func RateLimitMiddleware(limiter *RateLimiter) gin.HandlerFunc {
+	return handleMiddleware(func(c *gin.Context) error {
+		budget := limiter.writes
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead:
+			budget = limiter.reads
+		}
+		if budget == nil {
+			return nil
+		}
+
+		decision := budget.Allow(clientKey(c))
+		c.Header("RateLimit-Limit", strconv.Itoa(decision.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+		c.Header("RateLimit-Reset", seconds(decision.ResetAfter))
+		if !decision.Allowed {
+			c.Header("Retry-After", seconds(decision.RetryAfter))
+			return errRateLimited
+		}
+		return nil
+	})
+}
+
+// clientKey identifies the client behind a request. The IP address is
+// the one gin reports, which only trusts X-Forwarded-For from the proxies
+// set with SetTrustedProxies.
+func clientKey(c *gin.Context) string {
+	if p, ok := principal.FromContext(c.Request.Context()); ok {
+		if p.APIKeyID != 0 {
+			return "key:" + strconv.Itoa(p.APIKeyID)
+		}
+		return "user:" + strconv.Itoa(p.UserID)
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// seconds formats d as whole seconds, rounded up, for the rate limit
+// headers.
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
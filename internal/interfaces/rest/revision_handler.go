@@ -0,0 +1,112 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"rakia-tech-test/internal/application/diff"
+	"rakia-tech-test/internal/interfaces/rest/dto"
+)
+
+// GetRevisions handles GET /posts/:id/revisions
+func (h *PostHandler) GetRevisions(c *gin.Context) error {
+	id, err := intParam(c, "id", "post ID")
+	if err != nil {
+		return err
+	}
+
+	revisions, err := h.postService.ListRevisions(c.Request.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, dto.ToRevisionsResponse(revisions))
+	return nil
+}
+
+// GetRevision handles GET /posts/:id/revisions/:rev
+func (h *PostHandler) GetRevision(c *gin.Context) error {
+	id, err := intParam(c, "id", "post ID")
+	if err != nil {
+		return err
+	}
+	number, err := intParam(c, "rev", "revision number")
+	if err != nil {
+		return err
+	}
+
+	revision, err := h.postService.GetRevision(c.Request.Context(), id, number)
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, dto.ToRevisionResponse(revision))
+	return nil
+}
+
+// DiffRevisions handles GET /posts/:id/revisions/:rev/diff?from=N&mode=line|word
+// The diff runs from revision N, by default the one before :rev, to :rev.
+func (h *PostHandler) DiffRevisions(c *gin.Context) error {
+	id, err := intParam(c, "id", "post ID")
+	if err != nil {
+		return err
+	}
+	number, err := intParam(c, "rev", "revision number")
+	if err != nil {
+		return err
+	}
+
+	from := number - 1
+	if fromStr := c.Query("from"); fromStr != "" {
+		parsed, err := strconv.Atoi(fromStr)
+		if err != nil {
+			return badRequest("Invalid from revision format")
+		}
+		from = parsed
+	}
+	if from < 1 {
+		return badRequest("The first revision has nothing to compare against, pass from")
+	}
+
+	mode := diff.Mode(c.DefaultQuery("mode", string(diff.LineMode)))
+	result, err := h.postService.DiffRevisions(c.Request.Context(), id, from, number, mode)
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, dto.ToRevisionDiffResponse(result))
+	return nil
+}
+
+// RestoreRevision handles POST /posts/:id/revisions/:rev/restore
+func (h *PostHandler) RestoreRevision(c *gin.Context) error {
+	id, err := intParam(c, "id", "post ID")
+	if err != nil {
+		return err
+	}
+	number, err := intParam(c, "rev", "revision number")
+	if err != nil {
+		return err
+	}
+
+	match := ifMatch(c)
+	post, err := h.postService.RestoreRevision(c.Request.Context(), id, number, match)
+	if err != nil {
+		return conditionalError(err, match)
+	}
+
+	c.Header("ETag", postETag(post.Version))
+	c.JSON(http.StatusOK, dto.ToPostResponse(post))
+	return nil
+}
+
+// intParam parses a numeric path parameter.
+func intParam(c *gin.Context, name, label string) (int, error) {
+	value, err := strconv.Atoi(c.Param(name))
+	if err != nil {
+		return 0, badRequest("Invalid " + label + " format")
+	}
+	return value, nil
+}
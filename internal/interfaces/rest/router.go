@@ -1,38 +1,116 @@
 package rest
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
+
+	"rakia-tech-test/internal/application/idempotency"
+	"rakia-tech-test/internal/application/requestid"
+	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/errdefs"
 )
 
+const requestIDHeader = "X-Request-ID"
+
 // SetupRouter configures and returns the Gin router
-func SetupRouter(postHandler *PostHandler, logger *logrus.Logger) *gin.Engine {
+func SetupRouter(postHandler *PostHandler, authorHandler *AuthorHandler, commentHandler *CommentHandler, searchHandler *SearchHandler, authHandler *AuthHandler, apiKeyHandler *APIKeyHandler, rateLimiter *RateLimiter, idempotencyStore *idempotency.Store, logger *logrus.Logger) *gin.Engine {
 	// Set Gin mode
 	gin.SetMode(gin.ReleaseMode)
 
 	router := gin.New()
+	// Client addresses come from the connection unless the caller trusts
+	// proxies to report them with SetTrustedProxies.
+	_ = router.SetTrustedProxies(nil)
 
 	// Middleware
 	router.Use(gin.Recovery())
+	router.Use(RequestIDMiddleware())
 	router.Use(LoggerMiddleware(logger))
 	router.Use(CORSMiddleware())
+	router.Use(ProblemMiddleware(logger))
+	router.Use(AuthMiddleware(authHandler.authService, apiKeyHandler.apiKeyService))
 
 	// Health check endpoint
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{"status": "ok"})
 	})
 
-	// API v1 routes
-	v1 := router.Group("/api/v1")
+	router.NoRoute(handle(func(c *gin.Context) error {
+		return errdefs.New(errdefs.ErrNotFound, "no route for "+c.Request.Method+" "+c.Request.URL.Path)
+	}))
+
+	// Scopes guard every route. Reads are open to anonymous callers, and
+	// refused only to API keys without the read scope; every write needs a
+	// signed-in user or an API key with the scope. Signed-in users hold
+	// every scope but admin, which only admins hold, while API keys hold
+	// just the scopes they were issued with. Which posts a caller may
+	// change is then up to the policy.
+	read := checkScope(entities.ScopePostsRead)
+	write := requireScope(entities.ScopePostsWrite)
+	remove := requireScope(entities.ScopePostsDelete)
+	admin := requireScope(entities.ScopeAdmin)
+	// Creating posts is safe to retry with an Idempotency-Key.
+	idempotent := IdempotencyMiddleware(idempotencyStore)
+
+	// API v1 routes, held to the request budgets. The health check is not.
+	// Requests with credentials that do not authenticate are counted
+	// before they fail, so guessing tokens and keys is limited too.
+	v1 := router.Group("/api/v1", RateLimitMiddleware(rateLimiter), AuthFailureMiddleware())
 	{
+		auth := v1.Group("/auth")
+		{
+			auth.POST("/login", handle(authHandler.Login))
+			auth.POST("/refresh", handle(authHandler.Refresh))
+			auth.POST("/logout", handle(authHandler.Logout))
+		}
+
+		apiKeys := v1.Group("/api-keys", admin)
+		{
+			apiKeys.POST("", handle(apiKeyHandler.CreateAPIKey))
+			apiKeys.GET("", handle(apiKeyHandler.GetAllAPIKeys))
+			apiKeys.GET("/:id", handle(apiKeyHandler.GetAPIKey))
+			apiKeys.DELETE("/:id", handle(apiKeyHandler.RevokeAPIKey))
+		}
+
 		posts := v1.Group("/posts")
 		{
-			posts.POST("", postHandler.CreatePost)
-			posts.GET("", postHandler.GetAllPosts)
-			posts.GET("/:id", postHandler.GetPost)
-			posts.PUT("/:id", postHandler.UpdatePost)
-			posts.DELETE("/:id", postHandler.DeletePost)
+			posts.POST("", write, idempotent, handle(postHandler.CreatePost))
+			posts.GET("", read, handle(postHandler.GetAllPosts))
+			posts.GET("/:id", read, handle(postHandler.GetPost))
+			posts.GET("/by-slug/:slug", read, handle(postHandler.GetPostBySlug))
+			posts.PUT("/:id", write, handle(postHandler.UpdatePost))
+			posts.DELETE("/:id", remove, handle(postHandler.DeletePost))
+			posts.PUT("/:id/status", write, handle(postHandler.ChangeStatus))
+			posts.GET("/:id/revisions", read, handle(postHandler.GetRevisions))
+			posts.GET("/:id/revisions/:rev", read, handle(postHandler.GetRevision))
+			posts.GET("/:id/revisions/:rev/diff", read, handle(postHandler.DiffRevisions))
+			posts.POST("/:id/revisions/:rev/restore", write, handle(postHandler.RestoreRevision))
+			posts.GET("/:id/comments", read, handle(commentHandler.GetComments))
+			posts.POST("/:id/comments", write, handle(commentHandler.CreateComment))
+			posts.PUT("/:id/comments/:comment", write, handle(commentHandler.UpdateComment))
+			posts.DELETE("/:id/comments/:comment", remove, handle(commentHandler.DeleteComment))
 		}
+
+		authors := v1.Group("/authors")
+		{
+			authors.POST("", write, handle(authorHandler.CreateAuthor))
+			authors.GET("", read, handle(authorHandler.GetAllAuthors))
+			authors.GET("/:id", read, handle(authorHandler.GetAuthor))
+			authors.PUT("/:id", write, handle(authorHandler.UpdateAuthor))
+			authors.DELETE("/:id", remove, handle(authorHandler.DeleteAuthor))
+		}
+
+		trash := v1.Group("/trash")
+		{
+			trash.GET("", read, handle(postHandler.GetTrash))
+			trash.POST("/:id/restore", write, handle(postHandler.RestorePost))
+		}
+
+		v1.GET("/tags", read, handle(postHandler.GetTags))
+		v1.GET("/search", read, handle(searchHandler.Search))
 	}
 
 	return router
@@ -43,12 +121,38 @@ func LoggerMiddleware(logger *logrus.Logger) gin.HandlerFunc {
 	return gin.LoggerWithWriter(logger.Writer())
 }
 
+// RequestIDMiddleware tags every request with an ID, reusing the caller's
+// X-Request-ID when present, echoes it in the response and stores it in the
+// request context for the lower layers.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestIDHeader)
+		if id == "" {
+			id = newRequestID()
+		}
+
+		c.Header(requestIDHeader, id)
+		c.Request = c.Request.WithContext(requestid.NewContext(c.Request.Context(), id))
+
+		c.Next()
+	}
+}
+
+func newRequestID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(buf)
+}
+
 // CORSMiddleware handles Cross-Origin Resource Sharing
 func CORSMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Credentials", "true")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-API-Key, X-Request-ID, If-Match, Idempotency-Key, accept, origin, Cache-Control, X-Requested-With")
+		c.Header("Access-Control-Expose-Headers", "ETag, X-Request-ID, WWW-Authenticate, RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, Retry-After, Idempotent-Replayed")
 		c.Header("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
 
 		if c.Request.Method == "OPTIONS" {
@@ -0,0 +1,46 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"rakia-tech-test/internal/application/services"
+	"rakia-tech-test/internal/interfaces/rest/dto"
+)
+
+type SearchHandler struct {
+	searchService *services.SearchService
+	logger        *logrus.Logger
+}
+
+func NewSearchHandler(searchService *services.SearchService, logger *logrus.Logger) *SearchHandler {
+	return &SearchHandler{
+		searchService: searchService,
+		logger:        logger,
+	}
+}
+
+// Search handles GET /search?q=text&limit=N
+func (h *SearchHandler) Search(c *gin.Context) error {
+	query := c.Query("q")
+
+	limit := 0
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return badRequest("invalid limit format")
+		}
+		limit = parsed
+	}
+
+	results, err := h.searchService.Search(c.Request.Context(), query, limit)
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, dto.ToSearchResponse(query, results))
+	return nil
+}
@@ -0,0 +1,46 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"rakia-tech-test/internal/domain/entities"
+	"rakia-tech-test/internal/domain/validation"
+	"rakia-tech-test/internal/interfaces/rest/dto"
+)
+
+// ChangeStatus handles PUT /posts/:id/status. A publish_at time along with
+// the published status schedules the publication instead.
+func (h *PostHandler) ChangeStatus(c *gin.Context) error {
+	id, err := intParam(c, "id", "post ID")
+	if err != nil {
+		return err
+	}
+
+	var req dto.ChangeStatusRequest
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+
+	status := entities.PostStatus(req.Status)
+	if req.PublishAt != nil && status != entities.StatusPublished {
+		return validation.New("publish_at", validation.CodeInvalid,
+			"publish_at can only be set with the published status", nil)
+	}
+
+	match := ifMatch(c)
+	var post *entities.Post
+	if req.PublishAt != nil {
+		post, err = h.postService.SchedulePost(c.Request.Context(), id, *req.PublishAt, match)
+	} else {
+		post, err = h.postService.TransitionPost(c.Request.Context(), id, status, match)
+	}
+	if err != nil {
+		return conditionalError(err, match)
+	}
+
+	c.Header("ETag", postETag(post.Version))
+	c.JSON(http.StatusOK, dto.ToPostResponse(post))
+	return nil
+}
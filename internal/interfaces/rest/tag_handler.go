@@ -0,0 +1,20 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"rakia-tech-test/internal/interfaces/rest/dto"
+)
+
+// GetTags handles GET /tags
+func (h *PostHandler) GetTags(c *gin.Context) error {
+	counts, err := h.postService.ListTags(c.Request.Context())
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, dto.ToTagsResponse(counts))
+	return nil
+}
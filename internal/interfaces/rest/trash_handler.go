@@ -0,0 +1,37 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"rakia-tech-test/internal/interfaces/rest/dto"
+)
+
+// GetTrash handles GET /trash
+func (h *PostHandler) GetTrash(c *gin.Context) error {
+	posts, err := h.postService.GetTrash(c.Request.Context())
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, dto.ToPostsResponse(posts))
+	return nil
+}
+
+// RestorePost handles POST /trash/:id/restore
+func (h *PostHandler) RestorePost(c *gin.Context) error {
+	id, err := intParam(c, "id", "post ID")
+	if err != nil {
+		return err
+	}
+
+	post, err := h.postService.RestorePost(c.Request.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	c.Header("ETag", postETag(post.Version))
+	c.JSON(http.StatusOK, dto.ToPostResponse(post))
+	return nil
+}
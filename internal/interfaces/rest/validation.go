@@ -0,0 +1,124 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+
+	"rakia-tech-test/internal/domain/validation"
+)
+
+// maxBodyBytes caps the size of request bodies. It leaves room for the
+// longest post content, escaped as JSON.
+const maxBodyBytes = 1 << 20
+
+// errBodyTooLarge is returned for request bodies over maxBodyBytes.
+var errBodyTooLarge = errors.New("request body must be at most 1 MiB")
+
+func init() {
+	// Binding errors name fields the way clients send them.
+	if engine, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		engine.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				return ""
+			}
+			return name
+		})
+	}
+}
+
+// bindJSON decodes and validates the request body into req, returning every
+// problem found. Bodies over maxBodyBytes fail with errBodyTooLarge.
+func bindJSON(c *gin.Context, req any) error {
+	limitBody(c)
+	if err := c.ShouldBindJSON(req); err != nil {
+		return bindingError(err)
+	}
+	return nil
+}
+
+// bindingError translates the errors of gin's JSON binding into
+// validation.Errors. Bodies that are not a JSON object at all fail as a
+// whole.
+func bindingError(err error) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return errBodyTooLarge
+	}
+
+	var fieldErrs validator.ValidationErrors
+	if errors.As(err, &fieldErrs) {
+		errs := make(validation.Errors, len(fieldErrs))
+		for i, fieldErr := range fieldErrs {
+			errs[i] = ruleError(fieldErr)
+		}
+		return errs
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		expected := jsonType(typeErr.Type)
+		return validation.Errors{validation.New(typeErr.Field, validation.CodeInvalidType,
+			fmt.Sprintf("%s must be of type %s", typeErr.Field, expected), map[string]any{"type": expected})}
+	}
+
+	return badRequest(err.Error())
+}
+
+// limitBody makes reading the request body past maxBodyBytes fail with an
+// http.MaxBytesError.
+func limitBody(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBodyBytes)
+}
+
+// ruleError describes the binding rule a field broke with the codes the
+// entities use for the same problem.
+func ruleError(err validator.FieldError) *validation.FieldError {
+	field := err.Field()
+	switch err.Tag() {
+	case "required", "required_without":
+		return validation.New(field, validation.CodeRequired, field+" is required", nil)
+	case "max":
+		var max int
+		fmt.Sscan(err.Param(), &max)
+		if err.Kind() == reflect.Slice {
+			return validation.New(field, validation.CodeTooMany,
+				fmt.Sprintf("%s must have at most %d items", field, max), map[string]any{"max": max})
+		}
+		return validation.New(field, validation.CodeTooLong,
+			fmt.Sprintf("%s must be at most %d characters", field, max), map[string]any{"max": max})
+	case "oneof":
+		allowed := strings.Fields(err.Param())
+		return validation.New(field, validation.CodeOneOf,
+			fmt.Sprintf("%s must be one of %s", field, strings.Join(allowed, ", ")), map[string]any{"allowed": allowed})
+	default:
+		return validation.New(field, validation.CodeInvalid, field+" is invalid", map[string]any{"rule": err.Tag()})
+	}
+}
+
+// jsonType names the JSON type a Go type is decoded from.
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	default:
+		return "object"
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"rakia-tech-test/internal/infrastructure/repositories"
 	"sync"
@@ -27,10 +28,14 @@ func testConcurrentCreate(repo *repositories.MemoryPostRepository) {
 		go func(i int) {
 			defer wg.Done()
 
-			post, err := repo.CreatePost(
+			post, err := repo.CreatePost(context.Background(),
 				fmt.Sprintf("Title %d", i),
 				fmt.Sprintf("Content %d", i),
+				"",
 				fmt.Sprintf("Author %d", i),
+				0,
+				nil,
+				time.Now(),
 			)
 			if err != nil {
 				fmt.Printf("❌ Error creating post: %v\n", err)
@@ -68,7 +73,7 @@ func testConcurrentCreate(repo *repositories.MemoryPostRepository) {
 		fmt.Printf("❌ Found %d duplicate IDs! Race condition detected.\n", duplicates)
 	}
 
-	allPosts, _ := repo.GetAll()
+	allPosts, _ := repo.GetAll(context.Background())
 	fmt.Printf("✅ Total posts in repository: %d\n", len(allPosts))
 	fmt.Printf("Time taken: %v\n", duration)
 }
@@ -85,7 +90,7 @@ func testConcurrentReadWrite(repo *repositories.MemoryPostRepository) {
 	go func() {
 		defer wg.Done()
 		for i := 0; i < 20; i++ {
-			posts, err := repo.GetAll()
+			posts, err := repo.GetAll(context.Background())
 			if err != nil {
 				fmt.Printf("❌ Read error: %v\n", err)
 			} else {
@@ -102,10 +107,14 @@ func testConcurrentReadWrite(repo *repositories.MemoryPostRepository) {
 	go func() {
 		defer wg.Done()
 		for i := 0; i < 10; i++ {
-			post, err := repo.CreatePost(
+			post, err := repo.CreatePost(context.Background(),
 				fmt.Sprintf("Concurrent Title %d", i),
 				fmt.Sprintf("Concurrent Content %d", i),
+				"",
 				"Concurrent Author",
+				0,
+				nil,
+				time.Now(),
 			)
 			if err != nil {
 				fmt.Printf("❌ Error creating post: %v\n", err)
@@ -124,12 +133,12 @@ func testConcurrentReadWrite(repo *repositories.MemoryPostRepository) {
 		time.Sleep(5 * time.Millisecond)
 
 		for i := 0; i < 5; i++ {
-			posts, _ := repo.GetAll()
+			posts, _ := repo.GetAll(context.Background())
 			if len(posts) > 0 {
 				postToUpdate := posts[i%len(posts)]
 				postToUpdate.Title = fmt.Sprintf("Updated Title %d", i)
 
-				if err := repo.Update(postToUpdate.ID, postToUpdate); err != nil {
+				if err := repo.Update(context.Background(), postToUpdate.ID, postToUpdate, postToUpdate.Version); err != nil {
 					fmt.Printf("❌ Update error: %v\n", err)
 				} else {
 					updateCount++
@@ -143,7 +152,7 @@ func testConcurrentReadWrite(repo *repositories.MemoryPostRepository) {
 	wg.Wait()
 	duration := time.Since(startTime)
 
-	allPosts, _ := repo.GetAll()
+	allPosts, _ := repo.GetAll(context.Background())
 	fmt.Printf("\nResults:\n")
 	fmt.Printf("Reads: %d\n", readCount)
 	fmt.Printf("Writes: %d\n", writeCount)
@@ -2,42 +2,204 @@ package integration
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"rakia-tech-test/internal/application/idempotency"
+	"rakia-tech-test/internal/application/jwt"
+	"rakia-tech-test/internal/application/ratelimit"
+	"rakia-tech-test/internal/application/scheduler"
+	"rakia-tech-test/internal/application/search"
 	"rakia-tech-test/internal/application/services"
+	"rakia-tech-test/internal/domain/clock"
+	"rakia-tech-test/internal/domain/entities"
+	domain_repositories "rakia-tech-test/internal/domain/repositories"
 	"rakia-tech-test/internal/infrastructure/repositories"
 	"rakia-tech-test/internal/interfaces/rest"
 )
 
 type TestSuite struct {
-	router *gin.Engine
-	logger *logrus.Logger
+	router    *gin.Engine
+	logger    *logrus.Logger
+	clock     *clock.Fake
+	scheduler *scheduler.Scheduler
+	auth      *services.AuthService
 }
 
+// testWriter is the user requests are sent as by send when they need
+// authentication and do not say who they are from.
+const testWriter = "Test Writer"
+
+// testTokenTTL outlives every clock advance of the tests, so a token issued
+// in one suite is accepted by all of them.
+const testTokenTTL = 365 * 24 * time.Hour
+
+// Users never change, so every suite shares them along with their tokens,
+// which saves hashing passwords again for every test.
+var (
+	testUsers    = repositories.NewMemoryUserRepository()
+	testSigner   = jwt.NewSigner([]byte("test secret"))
+	testTokens   = make(map[string]string)
+	testTokensMu sync.Mutex
+)
+
 func NewTestSuite() *TestSuite {
+	return newTestSuite(0, 0)
+}
+
+// newTestSuite returns a suite holding every client to reads reads and
+// writes writes per minute of the suite clock. A budget of 0 is unlimited.
+func newTestSuite(reads, writes int) *TestSuite {
 	gin.SetMode(gin.TestMode)
 
 	logger := logrus.New()
 	logger.SetLevel(logrus.FatalLevel) // Suppress logs during tests
 
-	postRepo := repositories.NewMemoryPostRepository()
-	postService := services.NewPostService(postRepo, logger)
+	searchIndex := search.NewIndex()
+	indexedRepo, _ := search.NewIndexedRepository(context.Background(), repositories.NewMemoryPostRepository(), searchIndex)
+	publications := scheduler.NewQueue()
+	postRepo, _ := scheduler.NewScheduledRepository(context.Background(), indexedRepo, publications)
+	revisionRepo := repositories.NewMemoryRevisionRepository()
+	fakeClock := clock.NewFake(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	authorRepo := repositories.NewMemoryAuthorRepository()
+	commentRepo := repositories.NewMemoryCommentRepository()
+	postService := services.NewPostService(postRepo, revisionRepo, authorRepo, commentRepo, fakeClock, logger)
 	postHandler := rest.NewPostHandler(postService, logger)
+	authorHandler := rest.NewAuthorHandler(services.NewAuthorService(authorRepo, postService, fakeClock, logger), logger)
+	commentHandler := rest.NewCommentHandler(services.NewCommentService(commentRepo, postService, fakeClock, logger), logger)
+	searchHandler := rest.NewSearchHandler(services.NewSearchService(searchIndex, postRepo, logger), logger)
+	authService := services.NewAuthService(testUsers, testSigner, testTokenTTL, testTokenTTL, fakeClock, logger)
+	authHandler := rest.NewAuthHandler(authService, logger)
+	apiKeyService := services.NewAPIKeyService(repositories.NewMemoryAPIKeyRepository(), testUsers, fakeClock, logger)
+	apiKeyHandler := rest.NewAPIKeyHandler(apiKeyService, logger)
+
+	limiter := func(requests int) *ratelimit.Limiter {
+		if requests == 0 {
+			return nil
+		}
+		return ratelimit.NewLimiter(ratelimit.Limit{Requests: requests, Period: time.Minute}, fakeClock)
+	}
+	rateLimiter := rest.NewRateLimiter(limiter(reads), limiter(writes))
 
-	r := rest.SetupRouter(postHandler, logger)
+	r := rest.SetupRouter(postHandler, authorHandler, commentHandler, searchHandler, authHandler, apiKeyHandler, rateLimiter, idempotency.NewStore(time.Hour, fakeClock), logger)
 
 	return &TestSuite{
-		router: r,
-		logger: logger,
+		router:    r,
+		logger:    logger,
+		clock:     fakeClock,
+		scheduler: scheduler.NewScheduler(publications, postService, fakeClock, logger),
+		auth:      authService,
+	}
+}
+
+// as returns the headers authenticating a request as the user writing
+// under name, signing the user up on first use. testWriter is an admin and
+// everybody else an author, who only changes their own posts.
+func (s *TestSuite) as(name string) http.Header {
+	role := entities.RoleAuthor
+	if name == testWriter {
+		role = entities.RoleAdmin
+	}
+	return s.asRole(name, role)
+}
+
+// asRole is like as, but signs the user up with role. Users keep the role
+// they were first signed up with.
+func (s *TestSuite) asRole(name string, role entities.Role) http.Header {
+	testTokensMu.Lock()
+	defer testTokensMu.Unlock()
+
+	token, ok := testTokens[name]
+	if !ok {
+		ctx := context.Background()
+		username := "user" + strconv.Itoa(len(testTokens)+1)
+		if _, err := s.auth.CreateUser(ctx, username, name, role, "password123"); err != nil {
+			panic(err)
+		}
+		tokens, err := s.auth.Login(ctx, username, "password123")
+		if err != nil {
+			panic(err)
+		}
+		token = tokens.AccessToken
+		testTokens[name] = token
+	}
+
+	return http.Header{"Authorization": {"Bearer " + token}}
+}
+
+// authorize authenticates req as testWriter.
+func (s *TestSuite) authorize(req *http.Request) {
+	req.Header.Set("Authorization", s.as(testWriter).Get("Authorization"))
+}
+
+// runScheduler runs the publication scheduler until the test ends.
+func (s *TestSuite) runScheduler(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.scheduler.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+// send performs a request with payload encoded as JSON (when not nil) and
+// the given extra headers. Writes are sent as testWriter unless the headers
+// authenticate them otherwise, with a token or an API key.
+func (s *TestSuite) send(method, url string, payload interface{}, header http.Header) *httptest.ResponseRecorder {
+	body := &bytes.Buffer{}
+	if payload != nil {
+		encoded, _ := json.Marshal(payload)
+		body = bytes.NewBuffer(encoded)
+	}
+	req, _ := http.NewRequest(method, url, body)
+	req.Header.Set("Content-Type", "application/json")
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if method != "GET" && req.Header.Get("Authorization") == "" && req.Header.Get("X-API-Key") == "" {
+		s.authorize(req)
+	}
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+// createPost creates a post and returns its ID. New posts are drafts.
+func (s *TestSuite) createPost(t *testing.T, payload map[string]interface{}) int {
+	t.Helper()
+
+	w := s.send("POST", "/api/v1/posts", payload, nil)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	return int(created["id"].(float64))
+}
+
+// publish moves the post with the given ID through review to published, so
+// anonymous callers can read it.
+func (s *TestSuite) publish(t *testing.T, id int) {
+	t.Helper()
+
+	for _, status := range []string{"review", "published"} {
+		w := s.send("PUT", "/api/v1/posts/"+strconv.Itoa(id)+"/status", map[string]interface{}{"status": status}, nil)
+		require.Equal(t, http.StatusOK, w.Code)
 	}
 }
 
@@ -118,6 +280,7 @@ func TestAPI_CreatePost(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			jsonPayload, _ := json.Marshal(tc.payload)
 			req, _ := http.NewRequest("POST", "/api/v1/posts", bytes.NewBuffer(jsonPayload))
+			suite.authorize(req)
 			req.Header.Set("Content-Type", "application/json")
 
 			w := httptest.NewRecorder()
@@ -130,7 +293,7 @@ func TestAPI_CreatePost(t *testing.T) {
 			require.NoError(t, err)
 
 			if tc.expectError {
-				assert.Contains(t, response, "error")
+				assert.Equal(t, float64(tc.expectedStatus), response["status"])
 			} else {
 				assert.Contains(t, response, "id")
 				assert.Equal(t, tc.payload["title"], response["title"])
@@ -155,6 +318,7 @@ func TestAPI_GetPost(t *testing.T) {
 	}
 	jsonPayload, _ := json.Marshal(payload)
 	req, _ := http.NewRequest("POST", "/api/v1/posts", bytes.NewBuffer(jsonPayload))
+	suite.authorize(req)
 	req.Header.Set("Content-Type", "application/json")
 
 	w := httptest.NewRecorder()
@@ -169,6 +333,7 @@ func TestAPI_GetPost(t *testing.T) {
 	// Convert float64 to int for ID
 	postIDFloat := createdPost["id"].(float64)
 	postID := int(postIDFloat)
+	suite.publish(t, postID)
 
 	testsCases := []struct {
 		name           string
@@ -209,7 +374,7 @@ func TestAPI_GetPost(t *testing.T) {
 			require.NoError(t, err)
 
 			if tc.expectError {
-				assert.Contains(t, response, "error")
+				assert.Equal(t, float64(tc.expectedStatus), response["status"])
 			} else {
 				assert.Equal(t, float64(postID), response["id"])
 				assert.Equal(t, payload["title"], response["title"])
@@ -245,13 +410,7 @@ func TestAPI_GetAllPosts(t *testing.T) {
 	}
 
 	for _, post := range posts {
-		jsonPayload, _ := json.Marshal(post)
-		req, _ := http.NewRequest("POST", "/api/v1/posts", bytes.NewBuffer(jsonPayload))
-		req.Header.Set("Content-Type", "application/json")
-
-		w := httptest.NewRecorder()
-		suite.router.ServeHTTP(w, req)
-		require.Equal(t, http.StatusCreated, w.Code)
+		suite.publish(t, suite.createPost(t, post))
 	}
 
 	// Test populated list
@@ -269,6 +428,236 @@ func TestAPI_GetAllPosts(t *testing.T) {
 	assert.Len(t, postsArray, 3)
 }
 
+func TestAPI_Pagination(t *testing.T) {
+	suite := NewTestSuite()
+
+	create := func(title string) {
+		suite.publish(t, suite.createPost(t, map[string]interface{}{
+			"title":   title,
+			"content": "Content",
+			"author":  "Author",
+		}))
+	}
+	for i := 1; i <= 5; i++ {
+		create("Post " + strconv.Itoa(i))
+	}
+
+	type page struct {
+		Posts []map[string]interface{} `json:"posts"`
+		Total int                      `json:"total"`
+		Next  string                   `json:"next"`
+		Prev  string                   `json:"prev"`
+	}
+	get := func(url string) page {
+		w := suite.send("GET", url, nil, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var p page
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+		return p
+	}
+	titles := func(p page) []string {
+		var result []string
+		for _, post := range p.Posts {
+			result = append(result, post["title"].(string))
+		}
+		return result
+	}
+
+	first := get("/api/v1/posts?limit=2")
+	assert.Equal(t, []string{"Post 1", "Post 2"}, titles(first))
+	assert.Equal(t, 2, first.Total)
+	assert.Empty(t, first.Prev)
+	require.NotEmpty(t, first.Next)
+
+	// A post created while paging shows up at the end without shifting the
+	// pages already handed out.
+	create("Post 6")
+
+	second := get(first.Next)
+	assert.Equal(t, []string{"Post 3", "Post 4"}, titles(second))
+	require.NotEmpty(t, second.Prev)
+	require.NotEmpty(t, second.Next)
+
+	third := get(second.Next)
+	assert.Equal(t, []string{"Post 5", "Post 6"}, titles(third))
+	assert.Empty(t, third.Next)
+
+	back := get(third.Prev)
+	assert.Equal(t, titles(second), titles(back))
+	assert.Equal(t, titles(first), titles(get(back.Prev)))
+
+	all := get("/api/v1/posts")
+	assert.Len(t, all.Posts, 6)
+	assert.Empty(t, all.Next)
+	assert.Empty(t, all.Prev)
+
+	testCases := []struct {
+		name  string
+		query string
+	}{
+		{name: "non-numeric limit", query: "limit=abc"},
+		{name: "negative limit", query: "limit=-1"},
+		{name: "limit above maximum", query: "limit=101"},
+		{name: "malformed cursor", query: "cursor=not-a-cursor"},
+		{name: "cursor with unknown direction", query: "cursor=eyJkIjoic2lkZXdheXMiLCJpZCI6MX0"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := suite.send("GET", "/api/v1/posts?"+tc.query, nil, nil)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+}
+
+func TestAPI_FilterAndSort(t *testing.T) {
+	suite := NewTestSuite()
+
+	for _, post := range []map[string]interface{}{
+		{"title": "Go tips", "content": "Use gofmt", "author": "alice"},
+		{"title": "Rust tips", "content": "Use cargo fmt", "author": "bob"},
+		{"title": "Go tips", "content": "Use go vet", "author": "alicia"},
+		{"title": "About me", "content": "Hello", "author": "Aaron"},
+	} {
+		suite.publish(t, suite.createPost(t, post))
+	}
+
+	type page struct {
+		Posts []map[string]interface{} `json:"posts"`
+		Next  string                   `json:"next"`
+	}
+	ids := func(p page) []int {
+		result := make([]int, 0, len(p.Posts))
+		for _, post := range p.Posts {
+			result = append(result, int(post["id"].(float64)))
+		}
+		return result
+	}
+
+	testCases := []struct {
+		name        string
+		query       string
+		expectedIDs []int
+	}{
+		{name: "exact author", query: "author=alice", expectedIDs: []int{1}},
+		{name: "author prefix", query: "author_prefix=ali", expectedIDs: []int{1, 3}},
+		{name: "title substring", query: "title_contains=Go", expectedIDs: []int{1, 3}},
+		{name: "content substring", query: "content_contains=fmt", expectedIDs: []int{1, 2}},
+		{name: "ID range", query: "min_id=2&max_id=3", expectedIDs: []int{2, 3}},
+		{name: "sort by title with ID tiebreak", query: "sort=title", expectedIDs: []int{4, 1, 3, 2}},
+		{name: "sort by author", query: "sort=author", expectedIDs: []int{4, 1, 3, 2}},
+		{name: "newest first", query: "sort=-id", expectedIDs: []int{4, 3, 2, 1}},
+		{name: "filter and sort", query: "title_contains=tips&sort=-id", expectedIDs: []int{3, 2, 1}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := suite.send("GET", "/api/v1/posts?"+tc.query, nil, nil)
+			require.Equal(t, http.StatusOK, w.Code)
+			var p page
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+			assert.Equal(t, tc.expectedIDs, ids(p))
+		})
+	}
+
+	// Links keep the filter and the order.
+	w := suite.send("GET", "/api/v1/posts?sort=title&author_prefix=ali&limit=1", nil, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var first page
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &first))
+	assert.Equal(t, []int{1}, ids(first))
+	require.NotEmpty(t, first.Next)
+
+	w = suite.send("GET", first.Next, nil, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var second page
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &second))
+	assert.Equal(t, []int{3}, ids(second))
+	assert.Empty(t, second.Next)
+
+	cursor, err := url.Parse(first.Next)
+	require.NoError(t, err)
+
+	errorCases := []struct {
+		name  string
+		query string
+	}{
+		{name: "unknown sort", query: "sort=date"},
+		{name: "non-numeric ID bound", query: "min_id=abc"},
+		{name: "negative ID bound", query: "max_id=-1"},
+		{name: "inverted ID range", query: "min_id=3&max_id=2"},
+		{name: "cursor from another sort", query: "sort=author&cursor=" + cursor.Query().Get("cursor")},
+	}
+
+	for _, tc := range errorCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := suite.send("GET", "/api/v1/posts?"+tc.query, nil, nil)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+}
+
+func TestAPI_DateFilters(t *testing.T) {
+	suite := NewTestSuite()
+
+	// Posts 1, 2 and 3 are created a day apart; post 1 is then edited on
+	// the fourth day.
+	for _, title := range []string{"First", "Second", "Third"} {
+		suite.publish(t, suite.createPost(t, map[string]interface{}{
+			"title": title, "content": "Content", "author": "Author",
+		}))
+		suite.clock.Advance(24 * time.Hour)
+	}
+	w := suite.send("PUT", "/api/v1/posts/1", map[string]interface{}{
+		"title": "First, edited", "content": "Content", "author": "Author",
+	}, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var edited map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &edited))
+	assert.Equal(t, "2024-01-01T12:00:00Z", edited["created_at"])
+	assert.Equal(t, "2024-01-04T12:00:00Z", edited["updated_at"])
+
+	testCases := []struct {
+		name        string
+		query       string
+		expectedIDs []int
+	}{
+		{name: "created after", query: "created_after=2024-01-01T12:00:00Z", expectedIDs: []int{2, 3}},
+		{name: "created before", query: "created_before=2024-01-03T12:00:00Z", expectedIDs: []int{1, 2}},
+		{name: "creation window", query: "created_after=2024-01-01T00:00:00Z&created_before=2024-01-02T13:00:00%2B01:00", expectedIDs: []int{1}},
+		{name: "updated since", query: "updated_since=2024-01-03T12:00:00Z", expectedIDs: []int{1, 3}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := suite.send("GET", "/api/v1/posts?"+tc.query, nil, nil)
+			require.Equal(t, http.StatusOK, w.Code)
+
+			var response struct {
+				Posts []struct {
+					ID int `json:"id"`
+				} `json:"posts"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			ids := make([]int, 0, len(response.Posts))
+			for _, post := range response.Posts {
+				ids = append(ids, post.ID)
+			}
+			assert.Equal(t, tc.expectedIDs, ids)
+		})
+	}
+
+	for _, query := range []string{
+		"created_after=yesterday",
+		"updated_since=2024-01-01",
+		"created_after=2024-01-02T00:00:00Z&created_before=2024-01-01T00:00:00Z",
+	} {
+		w := suite.send("GET", "/api/v1/posts?"+query, nil, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
 func TestAPI_UpdatePost(t *testing.T) {
 	suite := NewTestSuite()
 
@@ -280,6 +669,7 @@ func TestAPI_UpdatePost(t *testing.T) {
 	}
 	jsonPayload, _ := json.Marshal(payload)
 	req, _ := http.NewRequest("POST", "/api/v1/posts", bytes.NewBuffer(jsonPayload))
+	suite.authorize(req)
 	req.Header.Set("Content-Type", "application/json")
 
 	w := httptest.NewRecorder()
@@ -294,6 +684,7 @@ func TestAPI_UpdatePost(t *testing.T) {
 	// Convert float64 to int for ID
 	postIDFloat := createdPost["id"].(float64)
 	postID := int(postIDFloat)
+	suite.publish(t, postID)
 
 	testsCases := []struct {
 		name           string
@@ -352,6 +743,7 @@ func TestAPI_UpdatePost(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			jsonPayload, _ := json.Marshal(tc.payload)
 			req, _ := http.NewRequest("PUT", "/api/v1/posts/"+tc.postID, bytes.NewBuffer(jsonPayload))
+			suite.authorize(req)
 			req.Header.Set("Content-Type", "application/json")
 
 			w := httptest.NewRecorder()
@@ -364,7 +756,7 @@ func TestAPI_UpdatePost(t *testing.T) {
 			require.NoError(t, err)
 
 			if tc.expectError {
-				assert.Contains(t, response, "error")
+				assert.Equal(t, float64(tc.expectedStatus), response["status"])
 			} else {
 				assert.Equal(t, float64(postID), response["id"])
 				assert.Equal(t, tc.payload["title"], response["title"])
@@ -386,6 +778,7 @@ func TestAPI_DeletePost(t *testing.T) {
 	}
 	jsonPayload, _ := json.Marshal(payload)
 	req, _ := http.NewRequest("POST", "/api/v1/posts", bytes.NewBuffer(jsonPayload))
+	suite.authorize(req)
 	req.Header.Set("Content-Type", "application/json")
 
 	w := httptest.NewRecorder()
@@ -400,6 +793,7 @@ func TestAPI_DeletePost(t *testing.T) {
 	// Convert float64 to int for ID
 	postIDFloat := createdPost["id"].(float64)
 	postID := int(postIDFloat)
+	suite.publish(t, postID)
 
 	testsCases := []struct {
 		name           string
@@ -430,6 +824,7 @@ func TestAPI_DeletePost(t *testing.T) {
 	for _, tc := range testsCases {
 		t.Run(tc.name, func(t *testing.T) {
 			req, _ := http.NewRequest("DELETE", "/api/v1/posts/"+tc.postID, nil)
+			suite.authorize(req)
 			w := httptest.NewRecorder()
 			suite.router.ServeHTTP(w, req)
 
@@ -439,7 +834,7 @@ func TestAPI_DeletePost(t *testing.T) {
 				var response map[string]interface{}
 				err := json.Unmarshal(w.Body.Bytes(), &response)
 				require.NoError(t, err)
-				assert.Contains(t, response, "error")
+				assert.Equal(t, float64(tc.expectedStatus), response["status"])
 			}
 		})
 	}
@@ -453,3 +848,1423 @@ func TestAPI_DeletePost(t *testing.T) {
 		assert.Equal(t, http.StatusNotFound, w.Code)
 	}
 }
+
+func TestAPI_RequestID(t *testing.T) {
+	suite := NewTestSuite()
+
+	req, _ := http.NewRequest("GET", "/health", nil)
+	req.Header.Set("X-Request-ID", "client-supplied-id")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(t, "client-supplied-id", w.Header().Get("X-Request-ID"))
+
+	req, _ = http.NewRequest("GET", "/health", nil)
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Len(t, w.Header().Get("X-Request-ID"), 32)
+}
+
+func TestAPI_CancelledRequest(t *testing.T) {
+	suite := NewTestSuite()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", "/api/v1/posts", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+func TestAPI_OptimisticConcurrency(t *testing.T) {
+	suite := NewTestSuite()
+
+	payload, _ := json.Marshal(map[string]interface{}{
+		"title":   "Test Title",
+		"content": "Test Content",
+		"author":  "Test Author",
+	})
+	req, _ := http.NewRequest("POST", "/api/v1/posts", bytes.NewBuffer(payload))
+	suite.authorize(req)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var created map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	postURL := "/api/v1/posts/" + strconv.Itoa(int(created["id"].(float64)))
+
+	// The post is still a draft, so it is read as its author.
+	req, _ = http.NewRequest("GET", postURL, nil)
+	req.Header.Set("Authorization", suite.as("Test Author").Get("Authorization"))
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	assert.Equal(t, `"1"`, etag)
+
+	update, _ := json.Marshal(map[string]interface{}{
+		"title":   "Updated Title",
+		"content": "Updated Content",
+		"author":  "Test Author",
+	})
+
+	testCases := []struct {
+		name           string
+		method         string
+		ifMatch        string
+		expectedStatus int
+		expectedETag   string
+	}{
+		{
+			name:           "update with current ETag",
+			method:         "PUT",
+			ifMatch:        etag,
+			expectedStatus: http.StatusOK,
+			expectedETag:   `"2"`,
+		},
+		{
+			name:           "update with stale ETag",
+			method:         "PUT",
+			ifMatch:        etag,
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:           "weak ETag never matches",
+			method:         "PUT",
+			ifMatch:        `W/"2"`,
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:           "delete with stale ETag",
+			method:         "DELETE",
+			ifMatch:        etag,
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:           "update with one of several ETags",
+			method:         "PUT",
+			ifMatch:        `"7", "2"`,
+			expectedStatus: http.StatusOK,
+			expectedETag:   `"3"`,
+		},
+		{
+			name:           "delete with any ETag",
+			method:         "DELETE",
+			ifMatch:        "*",
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "update of deleted post",
+			method:         "PUT",
+			ifMatch:        "*",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var body *bytes.Buffer
+			if tc.method == "PUT" {
+				body = bytes.NewBuffer(update)
+			} else {
+				body = &bytes.Buffer{}
+			}
+			req, _ := http.NewRequest(tc.method, postURL, body)
+			suite.authorize(req)
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("If-Match", tc.ifMatch)
+
+			w := httptest.NewRecorder()
+			suite.router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedETag != "" {
+				assert.Equal(t, tc.expectedETag, w.Header().Get("ETag"))
+			}
+		})
+	}
+}
+
+func TestAPI_Revisions(t *testing.T) {
+	suite := NewTestSuite()
+
+	send := suite.send
+	// The post stays a draft, so its history is read as its current author.
+	asAlice := suite.as("Alice")
+	asBob := suite.as("Bob")
+
+	w := send("POST", "/api/v1/posts", map[string]interface{}{
+		"title":   "Title",
+		"content": "one\ntwo\nthree\n",
+		"author":  "Alice",
+	}, nil)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	postURL := "/api/v1/posts/" + strconv.Itoa(int(created["id"].(float64)))
+
+	w = send("PUT", postURL, map[string]interface{}{
+		"title":   "Title",
+		"content": "one\n2\nthree\n",
+		"author":  "Bob",
+	}, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	w = send("PUT", postURL, map[string]interface{}{
+		"title":   "New Title",
+		"content": "one\n2\nthree\n",
+		"author":  "Bob",
+	}, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	t.Run("list", func(t *testing.T) {
+		w := send("GET", postURL+"/revisions", nil, asBob)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Revisions []struct {
+				Number   int      `json:"number"`
+				EditedBy string   `json:"edited_by"`
+				Changes  []string `json:"changes"`
+			} `json:"revisions"`
+			Total int `json:"total"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Equal(t, 3, response.Total)
+		assert.Equal(t, []string{"title", "content", "author"}, response.Revisions[0].Changes)
+		assert.Equal(t, testWriter, response.Revisions[1].EditedBy, "revisions credit the user who made the edit")
+		assert.Equal(t, []string{"content", "author"}, response.Revisions[1].Changes)
+		assert.Equal(t, []string{"title"}, response.Revisions[2].Changes)
+	})
+
+	t.Run("get", func(t *testing.T) {
+		w := send("GET", postURL+"/revisions/1", nil, asBob)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "one\ntwo\nthree\n", response["content"])
+		assert.Equal(t, "Alice", response["author"])
+
+		w = send("GET", postURL+"/revisions/9", nil, asBob)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		w = send("GET", "/api/v1/posts/999/revisions", nil, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("diff", func(t *testing.T) {
+		w := send("GET", postURL+"/revisions/2/diff", nil, asBob)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			From   int `json:"from"`
+			To     int `json:"to"`
+			Fields map[string][]struct {
+				Op   string `json:"op"`
+				Text string `json:"text"`
+			} `json:"fields"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 1, response.From)
+		assert.Equal(t, 2, response.To)
+		content := response.Fields["content"]
+		require.Len(t, content, 4)
+		assert.Equal(t, "delete", content[1].Op)
+		assert.Equal(t, "two\n", content[1].Text)
+		assert.Equal(t, "insert", content[2].Op)
+		assert.Equal(t, "2\n", content[2].Text)
+
+		w = send("GET", postURL+"/revisions/3/diff?from=1&mode=word", nil, asBob)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "insert", response.Fields["title"][0].Op)
+		assert.Equal(t, "New ", response.Fields["title"][0].Text)
+
+		w = send("GET", postURL+"/revisions/2/diff?mode=char", nil, asBob)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w = send("GET", postURL+"/revisions/1/diff", nil, asBob)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("restore", func(t *testing.T) {
+		w := send("POST", postURL+"/revisions/1/restore", nil, http.Header{"If-Match": {`"2"`}})
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+
+		w = send("POST", postURL+"/revisions/1/restore", nil, http.Header{"If-Match": {`"3"`}})
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"4"`, w.Header().Get("ETag"))
+
+		var post map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &post))
+		assert.Equal(t, "Title", post["title"])
+		assert.Equal(t, "one\ntwo\nthree\n", post["content"])
+		assert.Equal(t, "Alice", post["author"])
+
+		w = send("GET", postURL+"/revisions/4", nil, asAlice)
+		require.Equal(t, http.StatusOK, w.Code)
+		var revision map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &revision))
+		assert.Equal(t, float64(1), revision["restored_from"])
+
+		w = send("POST", postURL+"/revisions/9/restore", nil, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestAPI_EditorialWorkflow(t *testing.T) {
+	suite := NewTestSuite()
+
+	draftID := suite.createPost(t, map[string]interface{}{"title": "Draft", "content": "Unfinished gardening notes", "author": "alice"})
+	publishedID := suite.createPost(t, map[string]interface{}{"title": "Public", "content": "Content", "author": "bob"})
+	suite.publish(t, publishedID)
+
+	asAlice := suite.as("alice")
+	draftURL := "/api/v1/posts/" + strconv.Itoa(draftID)
+	statusURL := draftURL + "/status"
+
+	listIDs := func(query string, header http.Header) []int {
+		w := suite.send("GET", "/api/v1/posts"+query, nil, header)
+		require.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			Posts []struct {
+				ID     int    `json:"id"`
+				Status string `json:"status"`
+			} `json:"posts"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		ids := []int{}
+		for _, post := range response.Posts {
+			ids = append(ids, post.ID)
+		}
+		return ids
+	}
+
+	// Drafts are only visible to their author.
+	assert.Equal(t, http.StatusNotFound, suite.send("GET", draftURL, nil, nil).Code)
+	assert.Equal(t, http.StatusNotFound, suite.send("GET", draftURL, nil, suite.as("bob")).Code)
+	w := suite.send("GET", draftURL, nil, asAlice)
+	require.Equal(t, http.StatusOK, w.Code)
+	var draft map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &draft))
+	assert.Equal(t, "draft", draft["status"])
+
+	assert.Equal(t, []int{publishedID}, listIDs("", nil))
+	assert.Equal(t, []int{draftID, publishedID}, listIDs("", asAlice))
+	assert.Equal(t, []int{draftID}, listIDs("?status=draft", asAlice))
+	assert.Equal(t, []int{}, listIDs("?status=draft", nil))
+	assert.Equal(t, http.StatusBadRequest, suite.send("GET", "/api/v1/posts?status=deleted", nil, nil).Code)
+
+	testCases := []struct {
+		name           string
+		status         interface{}
+		header         http.Header
+		expectedStatus int
+		expectedETag   string
+	}{
+		{name: "publish without review", status: "published", expectedStatus: http.StatusConflict},
+		{name: "unknown status", status: "deleted", expectedStatus: http.StatusBadRequest},
+		{name: "missing status", status: nil, expectedStatus: http.StatusBadRequest},
+		{name: "stale ETag", status: "review", header: http.Header{"If-Match": {`"5"`}}, expectedStatus: http.StatusPreconditionFailed},
+		{name: "submit for review", status: "review", header: http.Header{"If-Match": {`"1"`}}, expectedStatus: http.StatusOK, expectedETag: `"2"`},
+		{name: "publish", status: "published", expectedStatus: http.StatusOK, expectedETag: `"3"`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			payload := map[string]interface{}{}
+			if tc.status != nil {
+				payload["status"] = tc.status
+			}
+			w := suite.send("PUT", statusURL, payload, tc.header)
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedETag != "" {
+				assert.Equal(t, tc.expectedETag, w.Header().Get("ETag"))
+			}
+		})
+	}
+
+	assert.Equal(t, http.StatusOK, suite.send("GET", draftURL, nil, nil).Code)
+	assert.Equal(t, []int{draftID, publishedID}, listIDs("", nil))
+
+	w = suite.send("GET", "/api/v1/search?q=gardening", nil, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var found map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &found))
+	assert.Equal(t, float64(1), found["total"])
+
+	w = suite.send("PUT", statusURL, map[string]interface{}{"status": "archived"}, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []int{publishedID}, listIDs("", nil))
+	assert.Equal(t, []int{draftID}, listIDs("?status=archived", asAlice))
+
+	assert.Equal(t, http.StatusNotFound, suite.send("PUT", "/api/v1/posts/999/status", map[string]interface{}{"status": "review"}, nil).Code)
+}
+
+func TestAPI_ScheduledPublishing(t *testing.T) {
+	suite := NewTestSuite()
+	suite.runScheduler(t)
+
+	id := suite.createPost(t, map[string]interface{}{"title": "Embargoed", "content": "Content", "author": "alice"})
+	postURL := "/api/v1/posts/" + strconv.Itoa(id)
+	statusURL := postURL + "/status"
+	publishAt := suite.clock.Now().Add(time.Hour)
+
+	schedule := func(status string, at time.Time) *httptest.ResponseRecorder {
+		return suite.send("PUT", statusURL, map[string]interface{}{"status": status, "publish_at": at.Format(time.RFC3339)}, nil)
+	}
+
+	assert.Equal(t, http.StatusConflict, schedule("published", publishAt).Code, "drafts must be reviewed first")
+	require.Equal(t, http.StatusOK, suite.send("PUT", statusURL, map[string]interface{}{"status": "review"}, nil).Code)
+
+	assert.Equal(t, http.StatusBadRequest, schedule("archived", publishAt).Code, "only publication can be scheduled")
+	assert.Equal(t, http.StatusBadRequest, schedule("published", suite.clock.Now().Add(-time.Minute)).Code, "schedules must be in the future")
+
+	w := schedule("published", publishAt)
+	require.Equal(t, http.StatusOK, w.Code)
+	var scheduled map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &scheduled))
+	assert.Equal(t, "review", scheduled["status"])
+	assert.Equal(t, publishAt.Format(time.RFC3339Nano), scheduled["publish_at"])
+	assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+
+	assert.Equal(t, http.StatusNotFound, suite.send("GET", postURL, nil, nil).Code, "not public before its time")
+
+	suite.clock.Advance(time.Hour)
+	require.Eventually(t, func() bool {
+		return suite.send("GET", postURL, nil, nil).Code == http.StatusOK
+	}, time.Second, time.Millisecond)
+
+	w = suite.send("GET", postURL, nil, nil)
+	var published map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &published))
+	assert.Equal(t, "published", published["status"])
+	assert.NotContains(t, published, "publish_at")
+	assert.Equal(t, `"4"`, w.Header().Get("ETag"))
+}
+
+func TestAPI_Tags(t *testing.T) {
+	suite := NewTestSuite()
+
+	goID := suite.createPost(t, map[string]interface{}{"title": "Go", "content": "Content", "author": "alice", "tags": []string{"Go", "Testing"}})
+	bothID := suite.createPost(t, map[string]interface{}{"title": "Both", "content": "Content", "author": "alice", "tags": []string{"go", "Machine Learning"}})
+	draftID := suite.createPost(t, map[string]interface{}{"title": "Draft", "content": "Content", "author": "alice", "tags": []string{"go"}})
+	suite.publish(t, goID)
+	suite.publish(t, bothID)
+
+	w := suite.send("POST", "/api/v1/posts", map[string]interface{}{"title": "Bad", "content": "Content", "author": "alice", "tags": []string{"a/b"}}, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = suite.send("GET", "/api/v1/posts/"+strconv.Itoa(bothID), nil, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var post map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &post))
+	assert.Equal(t, []interface{}{"go", "machine-learning"}, post["tags"])
+
+	tags := func() map[string]float64 {
+		w := suite.send("GET", "/api/v1/tags", nil, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			Tags []struct {
+				Tag   string  `json:"tag"`
+				Posts float64 `json:"posts"`
+			} `json:"tags"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		counts := map[string]float64{}
+		for _, tag := range response.Tags {
+			counts[tag.Tag] = tag.Posts
+		}
+		return counts
+	}
+	assert.Equal(t, map[string]float64{"go": 2, "testing": 1, "machine-learning": 1}, tags(), "drafts are not counted")
+
+	listIDs := func(query string) []int {
+		w := suite.send("GET", "/api/v1/posts"+query, nil, suite.as("alice"))
+		require.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			Posts []struct {
+				ID int `json:"id"`
+			} `json:"posts"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		ids := []int{}
+		for _, post := range response.Posts {
+			ids = append(ids, post.ID)
+		}
+		return ids
+	}
+	assert.Equal(t, []int{goID, bothID, draftID}, listIDs("?tag=GO"))
+	assert.Equal(t, []int{bothID}, listIDs("?tag="+url.QueryEscape("machine learning")))
+	assert.Equal(t, []int{}, listIDs("?tag=rust"))
+	assert.Equal(t, http.StatusBadRequest, suite.send("GET", "/api/v1/posts?tag=a/b", nil, nil).Code)
+
+	// Updates without tags keep them; an explicit list replaces them.
+	postURL := "/api/v1/posts/" + strconv.Itoa(goID)
+	w = suite.send("PUT", postURL, map[string]interface{}{"title": "Go", "content": "Edited", "author": "alice"}, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, map[string]float64{"go": 2, "testing": 1, "machine-learning": 1}, tags())
+
+	w = suite.send("PUT", postURL, map[string]interface{}{"title": "Go", "content": "Edited", "author": "alice", "tags": []string{"rust"}}, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, map[string]float64{"go": 1, "rust": 1, "machine-learning": 1}, tags())
+
+	require.Equal(t, http.StatusNoContent, suite.send("DELETE", postURL, nil, nil).Code)
+	assert.Equal(t, map[string]float64{"go": 1, "machine-learning": 1}, tags(), "trashed posts are not counted")
+}
+
+func TestAPI_Slugs(t *testing.T) {
+	suite := NewTestSuite()
+
+	getBySlug := func(slug string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		w := suite.send("GET", "/api/v1/posts/by-slug/"+slug, nil, nil)
+		var body map[string]interface{}
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		}
+		return w, body
+	}
+
+	firstID := suite.createPost(t, map[string]interface{}{"title": "Crème Brûlée!", "content": "Content", "author": "alice"})
+	secondID := suite.createPost(t, map[string]interface{}{"title": "Creme brulee", "content": "Content", "author": "alice"})
+
+	w, _ := getBySlug("creme-brulee")
+	assert.Equal(t, http.StatusNotFound, w.Code, "drafts stay hidden from other callers")
+
+	suite.publish(t, firstID)
+	suite.publish(t, secondID)
+
+	w, post := getBySlug("creme-brulee")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, float64(firstID), post["id"])
+	assert.Equal(t, "creme-brulee", post["slug"])
+	assert.NotEmpty(t, w.Header().Get("ETag"))
+
+	w, post = getBySlug("creme-brulee-2")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, float64(secondID), post["id"])
+
+	// Retitling moves the post to a new slug and redirects the old one.
+	w = suite.send("PUT", "/api/v1/posts/"+strconv.Itoa(secondID), map[string]interface{}{"title": "Tarte Tatin", "content": "Content", "author": "alice"}, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &post))
+	assert.Equal(t, "tarte-tatin", post["slug"])
+
+	w, _ = getBySlug("creme-brulee-2")
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "/api/v1/posts/by-slug/tarte-tatin", w.Header().Get("Location"))
+
+	// Retired slugs are not handed out again.
+	thirdID := suite.createPost(t, map[string]interface{}{"title": "Crème brûlée", "content": "Content", "author": "alice"})
+	w = suite.send("GET", "/api/v1/posts/"+strconv.Itoa(thirdID), nil, suite.as("alice"))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &post))
+	assert.Equal(t, "creme-brulee-3", post["slug"])
+
+	w, _ = getBySlug("missing")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	require.Equal(t, http.StatusNoContent, suite.send("DELETE", "/api/v1/posts/"+strconv.Itoa(firstID), nil, nil).Code)
+	w, _ = getBySlug("creme-brulee")
+	assert.Equal(t, http.StatusNotFound, w.Code, "trashed posts are not found by slug")
+}
+
+func TestAPI_ContentFormat(t *testing.T) {
+	suite := NewTestSuite()
+	asAlice := suite.as("alice")
+
+	get := func(url string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		w := suite.send("GET", url, nil, asAlice)
+		var body map[string]interface{}
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		}
+		return w, body
+	}
+
+	source := "# Hi\n\n<script>alert(1)</script> [x](javascript:alert(1)) *ok*"
+	id := suite.createPost(t, map[string]interface{}{"title": "Markdown", "content": source, "content_format": "markdown", "author": "alice"})
+	url := "/api/v1/posts/" + strconv.Itoa(id)
+
+	w, post := get(url)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "markdown", post["content_format"])
+	assert.NotContains(t, post, "content_html", "HTML is only rendered on request")
+
+	assert.Equal(t, `"1"`, w.Header().Get("ETag"))
+
+	w, post = get(url + "?render=html")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, source, post["content"], "the source comes alongside the HTML")
+	assert.Equal(t, "<h1>Hi</h1>\n<p>&lt;script&gt;alert(1)&lt;/script&gt; x <em>ok</em></p>\n", post["content_html"])
+	assert.Equal(t, `"1-html"`, w.Header().Get("ETag"), "the rendered form has a tag of its own")
+
+	w, _ = get(url + "?render=pdf")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Omitting the format on update keeps it; the new content is rendered.
+	// Either tag names the version for If-Match.
+	ifMatch := asAlice.Clone()
+	ifMatch.Set("If-Match", `"1-html"`)
+	w = suite.send("PUT", url, map[string]interface{}{"title": "Markdown", "content": "**bold**", "author": "alice"}, ifMatch)
+	require.Equal(t, http.StatusOK, w.Code)
+	_, post = get(url + "?render=html")
+	assert.Equal(t, "markdown", post["content_format"])
+	assert.Equal(t, "<p><strong>bold</strong></p>\n", post["content_html"])
+
+	w = suite.send("PUT", url, map[string]interface{}{"title": "Markdown", "content": "**bold**", "content_format": "plain", "author": "alice"}, asAlice)
+	require.Equal(t, http.StatusOK, w.Code)
+	_, post = get(url + "?render=html")
+	assert.Equal(t, "plain", post["content_format"])
+	assert.Equal(t, "<p>**bold**</p>\n", post["content_html"])
+
+	w = suite.send("POST", "/api/v1/posts", map[string]interface{}{"title": "HTML", "content": "<p>", "content_format": "html", "author": "alice"}, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Slug lookups render too, and redirects keep the query.
+	suite.publish(t, id)
+	w = suite.send("PUT", url, map[string]interface{}{"title": "Renamed", "content": "Content", "author": "alice"}, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	w, _ = get("/api/v1/posts/by-slug/markdown?render=html")
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "/api/v1/posts/by-slug/renamed?render=html", w.Header().Get("Location"))
+	w, post = get("/api/v1/posts/by-slug/renamed?render=html")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "<p>Content</p>\n", post["content_html"])
+}
+
+func TestAPI_Authors(t *testing.T) {
+	suite := NewTestSuite()
+
+	decode := func(w *httptest.ResponseRecorder) map[string]interface{} {
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return body
+	}
+
+	w := suite.send("POST", "/api/v1/authors", map[string]interface{}{"name": "  Author   1 ", "bio": "Writes things"}, nil)
+	require.Equal(t, http.StatusCreated, w.Code)
+	author := decode(w)
+	assert.Equal(t, "Author 1", author["name"])
+	assert.Equal(t, "Writes things", author["bio"])
+	authorID := int(author["id"].(float64))
+
+	w = suite.send("POST", "/api/v1/authors", map[string]interface{}{"name": "author 1"}, nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = suite.send("POST", "/api/v1/authors", map[string]interface{}{"name": ""}, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Posts name their author by ID or by a name matching up to case and
+	// spacing; an unknown name adds an author.
+	byID := suite.createPost(t, map[string]interface{}{"title": "By ID", "content": "Content", "author_id": authorID})
+	suite.createPost(t, map[string]interface{}{"title": "By name", "content": "Content", "author": "AUTHOR 1"})
+	suite.createPost(t, map[string]interface{}{"title": "Other", "content": "Content", "author": "Author 2"})
+	w = suite.send("POST", "/api/v1/posts", map[string]interface{}{"title": "Unknown", "content": "Content", "author_id": 99}, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = suite.send("GET", "/api/v1/authors", nil, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, float64(2), decode(w)["total"])
+
+	w = suite.send("GET", "/api/v1/posts?author_id="+strconv.Itoa(authorID), nil, suite.as("Author 1"))
+	require.Equal(t, http.StatusOK, w.Code)
+	posts := decode(w)["posts"].([]interface{})
+	require.Len(t, posts, 2)
+	for _, post := range posts {
+		assert.Equal(t, "Author 1", post.(map[string]interface{})["author"])
+		assert.Equal(t, float64(authorID), post.(map[string]interface{})["author_id"])
+	}
+
+	// Renaming an author carries the new name over to their posts.
+	w = suite.send("PUT", "/api/v1/authors/"+strconv.Itoa(authorID), map[string]interface{}{"name": "Renamed", "bio": ""}, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "Renamed", decode(w)["name"])
+
+	w = suite.send("GET", "/api/v1/posts/"+strconv.Itoa(byID), nil, suite.as("Renamed"))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "Renamed", decode(w)["author"])
+
+	w = suite.send("PUT", "/api/v1/authors/"+strconv.Itoa(authorID), map[string]interface{}{"name": "author 2"}, nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// Authors with posts cannot be deleted.
+	w = suite.send("DELETE", "/api/v1/authors/"+strconv.Itoa(authorID), nil, nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = suite.send("POST", "/api/v1/authors", map[string]interface{}{"name": "Idle"}, nil)
+	require.Equal(t, http.StatusCreated, w.Code)
+	idleID := strconv.Itoa(int(decode(w)["id"].(float64)))
+	assert.Equal(t, http.StatusNoContent, suite.send("DELETE", "/api/v1/authors/"+idleID, nil, nil).Code)
+	assert.Equal(t, http.StatusNotFound, suite.send("GET", "/api/v1/authors/"+idleID, nil, nil).Code)
+	assert.Equal(t, http.StatusBadRequest, suite.send("GET", "/api/v1/authors/abc", nil, nil).Code)
+}
+
+func TestAPI_Comments(t *testing.T) {
+	suite := NewTestSuite()
+
+	type comment struct {
+		ID       int       `json:"id"`
+		ParentID int       `json:"parent_id"`
+		Content  string    `json:"content"`
+		Replies  []comment `json:"replies"`
+	}
+	type page struct {
+		Comments []comment `json:"comments"`
+		Total    int       `json:"total"`
+		Next     string    `json:"next"`
+	}
+
+	postID := suite.createPost(t, map[string]interface{}{"title": "Post", "content": "Content", "author": "alice"})
+	commentsURL := "/api/v1/posts/" + strconv.Itoa(postID) + "/comments"
+	addComment := func(parentID int, content string) int {
+		w := suite.send("POST", commentsURL, map[string]interface{}{"parent_id": parentID, "content": content}, nil)
+		require.Equal(t, http.StatusCreated, w.Code)
+		var created comment
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		return created.ID
+	}
+
+	w := suite.send("POST", commentsURL, map[string]interface{}{"content": "Too early"}, suite.as("alice"))
+	assert.Equal(t, http.StatusConflict, w.Code, "drafts take no comments")
+
+	suite.publish(t, postID)
+	first := addComment(0, "First")
+	reply := addComment(first, "Reply")
+	addComment(reply, "Nested reply")
+	second := addComment(0, "Second")
+	third := addComment(0, "Third")
+
+	w = suite.send("POST", commentsURL, map[string]interface{}{"parent_id": 99, "content": "Orphan"}, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = suite.send("POST", commentsURL, map[string]interface{}{"parent_id": 0}, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = suite.send("GET", commentsURL+"?limit=2", nil, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var listing page
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listing))
+	require.Len(t, listing.Comments, 2)
+	assert.Equal(t, 2, listing.Total)
+	assert.Equal(t, first, listing.Comments[0].ID)
+	require.Len(t, listing.Comments[0].Replies, 1)
+	assert.Equal(t, reply, listing.Comments[0].Replies[0].ID)
+	require.Len(t, listing.Comments[0].Replies[0].Replies, 1)
+	assert.Equal(t, "Nested reply", listing.Comments[0].Replies[0].Replies[0].Content)
+	assert.Equal(t, second, listing.Comments[1].ID)
+	require.NotEmpty(t, listing.Next)
+
+	w = suite.send("GET", listing.Next, nil, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	listing = page{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listing))
+	require.Len(t, listing.Comments, 1)
+	assert.Equal(t, third, listing.Comments[0].ID)
+	assert.Empty(t, listing.Next)
+
+	w = suite.send("PUT", commentsURL+"/"+strconv.Itoa(second), map[string]interface{}{"content": "Edited"}, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var edited comment
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &edited))
+	assert.Equal(t, "Edited", edited.Content)
+
+	// Deleting a comment takes the replies below it along.
+	assert.Equal(t, http.StatusNoContent, suite.send("DELETE", commentsURL+"/"+strconv.Itoa(first), nil, nil).Code)
+	assert.Equal(t, http.StatusNotFound, suite.send("PUT", commentsURL+"/"+strconv.Itoa(reply), map[string]interface{}{"content": "Gone"}, nil).Code)
+
+	w = suite.send("GET", commentsURL, nil, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	listing = page{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listing))
+	assert.Equal(t, 2, listing.Total)
+
+	// Comments follow their post into the trash and back.
+	require.Equal(t, http.StatusNoContent, suite.send("DELETE", "/api/v1/posts/"+strconv.Itoa(postID), nil, nil).Code)
+	assert.Equal(t, http.StatusNotFound, suite.send("GET", commentsURL, nil, nil).Code)
+	require.Equal(t, http.StatusOK, suite.send("POST", "/api/v1/trash/"+strconv.Itoa(postID)+"/restore", nil, nil).Code)
+	w = suite.send("GET", commentsURL, nil, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	listing = page{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listing))
+	assert.Equal(t, 2, listing.Total)
+
+	assert.Equal(t, http.StatusBadRequest, suite.send("GET", commentsURL+"?cursor=bogus", nil, nil).Code)
+	assert.Equal(t, http.StatusNotFound, suite.send("GET", "/api/v1/posts/999/comments", nil, nil).Code)
+}
+
+func TestAPI_Trash(t *testing.T) {
+	suite := NewTestSuite()
+
+	var ids []string
+	for _, title := range []string{"Trashed", "Kept"} {
+		id := suite.createPost(t, map[string]interface{}{
+			"title":   title,
+			"content": "Content",
+			"author":  "Author",
+		})
+		suite.publish(t, id)
+		ids = append(ids, strconv.Itoa(id))
+	}
+
+	w := suite.send("DELETE", "/api/v1/posts/"+ids[0], nil, nil)
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	w = suite.send("GET", "/api/v1/posts", nil, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var listing map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listing))
+	assert.Equal(t, float64(1), listing["total"])
+
+	w = suite.send("GET", "/api/v1/trash", nil, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var trash struct {
+		Posts []map[string]interface{} `json:"posts"`
+		Total int                      `json:"total"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &trash))
+	require.Equal(t, 1, trash.Total)
+	assert.Equal(t, "Trashed", trash.Posts[0]["title"])
+	assert.NotEmpty(t, trash.Posts[0]["deleted_at"])
+
+	// Trashed drafts stay hidden from those who could not read them before.
+	draftID := suite.createPost(t, map[string]interface{}{"title": "Trashed draft", "content": "Content", "author": "Author"})
+	require.Equal(t, http.StatusNoContent, suite.send("DELETE", "/api/v1/posts/"+strconv.Itoa(draftID), nil, nil).Code)
+	w = suite.send("GET", "/api/v1/trash", nil, nil)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &trash))
+	assert.Equal(t, 1, trash.Total, "anonymous callers do not see trashed drafts")
+	w = suite.send("GET", "/api/v1/trash", nil, suite.as(testWriter))
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &trash))
+	assert.Equal(t, 2, trash.Total)
+
+	testCases := []struct {
+		name           string
+		postID         string
+		expectedStatus int
+	}{
+		{name: "restore trashed post", postID: ids[0], expectedStatus: http.StatusOK},
+		{name: "restore post that is not trashed", postID: ids[1], expectedStatus: http.StatusNotFound},
+		{name: "restore non-existent post", postID: "999", expectedStatus: http.StatusNotFound},
+		{name: "invalid post ID format", postID: "invalid", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := suite.send("POST", "/api/v1/trash/"+tc.postID+"/restore", nil, nil)
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+
+	w = suite.send("GET", "/api/v1/posts/"+ids[0], nil, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var restored map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &restored))
+	assert.Equal(t, "Trashed", restored["title"])
+	assert.NotContains(t, restored, "deleted_at")
+}
+
+func TestAPI_Search(t *testing.T) {
+	suite := NewTestSuite()
+
+	var ids []string
+	for _, post := range []map[string]interface{}{
+		{"title": "Cooking pasta", "content": "Boil the pasta in salted water.", "author": "Chef"},
+		{"title": "Go concurrency", "content": "Goroutines make cooking dinner and coding at once easy.", "author": "Gopher"},
+		{"title": "Gardening", "content": "Tomatoes need sun.", "author": "Gardener"},
+	} {
+		id := suite.createPost(t, post)
+		suite.publish(t, id)
+		ids = append(ids, strconv.Itoa(id))
+	}
+
+	type searchResponse struct {
+		Query string `json:"query"`
+		Hits  []struct {
+			Post       map[string]interface{} `json:"post"`
+			Score      float64                `json:"score"`
+			Highlights struct {
+				Title   string `json:"title"`
+				Content string `json:"content"`
+			} `json:"highlights"`
+		} `json:"hits"`
+		Total int `json:"total"`
+	}
+	searchFor := func(q string) searchResponse {
+		w := suite.send("GET", "/api/v1/search?q="+url.QueryEscape(q), nil, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var response searchResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+
+	response := searchFor("cooked")
+	assert.Equal(t, "cooked", response.Query)
+	require.Equal(t, 2, response.Total)
+	assert.Equal(t, "Cooking pasta", response.Hits[0].Post["title"], "title matches rank first")
+	assert.Equal(t, "<mark>Cooking</mark> pasta", response.Hits[0].Highlights.Title)
+	assert.Equal(t, "Goroutines make <mark>cooking</mark> dinner and coding at once easy.", response.Hits[1].Highlights.Content)
+	assert.Greater(t, response.Hits[0].Score, response.Hits[1].Score)
+
+	// The index follows updates and deletes.
+	w := suite.send("PUT", "/api/v1/posts/"+ids[2], map[string]interface{}{
+		"title":   "Gardening",
+		"content": "Tomatoes and basil for the pasta sauce.",
+		"author":  "Gardener",
+	}, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 2, searchFor("pasta").Total)
+
+	w = suite.send("DELETE", "/api/v1/posts/"+ids[0], nil, nil)
+	require.Equal(t, http.StatusNoContent, w.Code)
+	response = searchFor("pasta")
+	require.Equal(t, 1, response.Total)
+	assert.Equal(t, "Gardening", response.Hits[0].Post["title"])
+
+	w = suite.send("POST", "/api/v1/trash/"+ids[0]+"/restore", nil, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 2, searchFor("pasta").Total)
+
+	assert.Equal(t, 0, searchFor("the").Total, "stopwords match nothing")
+
+	testCases := []struct {
+		name  string
+		query string
+	}{
+		{name: "missing query", query: ""},
+		{name: "blank query", query: "q=%20"},
+		{name: "invalid limit format", query: "q=pasta&limit=abc"},
+		{name: "limit above maximum", query: "q=pasta&limit=101"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := suite.send("GET", "/api/v1/search?"+tc.query, nil, nil)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+}
+
+func TestAPI_ValidationErrors(t *testing.T) {
+	suite := NewTestSuite()
+
+	type detail struct {
+		Field   string                 `json:"field"`
+		Code    string                 `json:"code"`
+		Message string                 `json:"message"`
+		Params  map[string]interface{} `json:"params"`
+	}
+	detailsOf := func(w *httptest.ResponseRecorder) []detail {
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		var response struct {
+			Type    string   `json:"type"`
+			Details []detail `json:"details"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "/problems/validation-error", response.Type)
+		return response.Details
+	}
+
+	// Every missing field is reported at once.
+	details := detailsOf(suite.send("POST", "/api/v1/posts", map[string]interface{}{}, nil))
+	require.Len(t, details, 3)
+	for i, field := range []string{"title", "content", "author"} {
+		assert.Equal(t, field, details[i].Field)
+		assert.Equal(t, "required", details[i].Code)
+	}
+
+	details = detailsOf(suite.send("POST", "/api/v1/posts", map[string]interface{}{
+		"title": strings.Repeat("a", 256), "content": "Content", "author": "Author", "content_format": "html",
+	}, nil))
+	require.Len(t, details, 2)
+	assert.Equal(t, detail{Field: "title", Code: "too_long", Message: "title must be at most 255 characters",
+		Params: map[string]interface{}{"max": float64(255)}}, details[0])
+	assert.Equal(t, "content_format", details[1].Field)
+	assert.Equal(t, "one_of", details[1].Code)
+	assert.Equal(t, []interface{}{"plain", "markdown"}, details[1].Params["allowed"])
+
+	details = detailsOf(suite.send("POST", "/api/v1/posts", map[string]interface{}{
+		"title": 5, "content": "Content", "author": "Author",
+	}, nil))
+	require.Len(t, details, 1)
+	assert.Equal(t, detail{Field: "title", Code: "invalid_type", Message: "title must be of type string",
+		Params: map[string]interface{}{"type": "string"}}, details[0])
+
+	// Blank values pass binding and are caught by the entity, with the same codes.
+	details = detailsOf(suite.send("POST", "/api/v1/posts", map[string]interface{}{
+		"title": "   ", "content": "   ", "author": "Author",
+	}, nil))
+	require.Len(t, details, 2)
+	assert.Equal(t, "title", details[0].Field)
+	assert.Equal(t, "required", details[0].Code)
+	assert.Equal(t, "content", details[1].Field)
+	assert.Equal(t, "required", details[1].Code)
+
+	id := suite.createPost(t, map[string]interface{}{"title": "Title", "content": "Content", "author": "Author"})
+	details = detailsOf(suite.send("PUT", "/api/v1/posts/"+strconv.Itoa(id), map[string]interface{}{
+		"title": "Title", "content": "Content", "author": "Author", "tags": []string{"c#"},
+	}, nil))
+	require.Len(t, details, 1)
+	assert.Equal(t, "tags", details[0].Field)
+	assert.Equal(t, "invalid", details[0].Code)
+
+	details = detailsOf(suite.send("POST", "/api/v1/authors", map[string]interface{}{"name": " "}, nil))
+	require.Len(t, details, 1)
+	assert.Equal(t, "name", details[0].Field)
+	assert.Equal(t, "required", details[0].Code)
+
+	// Errors that are not about a field carry no details.
+	w := suite.send("POST", "/api/v1/posts", "not an object", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.NotContains(t, w.Body.String(), "details")
+}
+
+func TestAPI_Problems(t *testing.T) {
+	suite := NewTestSuite()
+	id := suite.createPost(t, map[string]interface{}{"title": "Title", "content": "Content", "author": "Author"})
+	postURL := "/api/v1/posts/" + strconv.Itoa(id)
+
+	problemOf := func(w *httptest.ResponseRecorder, status int) map[string]interface{} {
+		require.Equal(t, status, w.Code)
+		assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+		var problem map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+		assert.Equal(t, float64(status), problem["status"])
+		assert.NotEmpty(t, problem["title"])
+		return problem
+	}
+
+	problem := problemOf(suite.send("GET", "/api/v1/posts/999", nil, nil), http.StatusNotFound)
+	assert.Equal(t, "/problems/not-found", problem["type"])
+	assert.Equal(t, "post not found", problem["detail"])
+	assert.Equal(t, "/api/v1/posts/999", problem["instance"])
+
+	problem = problemOf(suite.send("GET", "/api/v1/nothing-here", nil, nil), http.StatusNotFound)
+	assert.Equal(t, "/problems/not-found", problem["type"])
+
+	problem = problemOf(suite.send("PUT", postURL, map[string]interface{}{"title": "New", "content": "Content", "author": "Author"},
+		http.Header{"If-Match": {`"7"`}}), http.StatusPreconditionFailed)
+	assert.Equal(t, "/problems/precondition-failed", problem["type"])
+
+	problem = problemOf(suite.send("PUT", postURL+"/status", map[string]interface{}{"status": "archived"}, nil), http.StatusConflict)
+	assert.Equal(t, "/problems/conflict", problem["type"])
+	assert.Equal(t, "post cannot move to the requested status from its current one", problem["detail"])
+
+	require.Equal(t, http.StatusCreated, suite.send("POST", "/api/v1/authors", map[string]interface{}{"name": "Ada"}, nil).Code)
+	problem = problemOf(suite.send("POST", "/api/v1/authors", map[string]interface{}{"name": "Ada"}, nil), http.StatusConflict)
+	assert.Equal(t, "author already exists", problem["detail"])
+
+	problem = problemOf(suite.send("GET", "/api/v1/posts?limit=x", nil, nil), http.StatusBadRequest)
+	assert.Equal(t, "/problems/validation-error", problem["type"])
+	assert.Equal(t, "invalid limit format", problem["detail"])
+
+	// Requests cut short by their client or by a deadline are not internal
+	// errors, and do not leak their message either.
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	for _, ctx := range []context.Context{cancelled, expired} {
+		req, _ := http.NewRequestWithContext(ctx, "GET", "/api/v1/posts", nil)
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		problem = problemOf(w, http.StatusServiceUnavailable)
+		assert.Equal(t, "/problems/unavailable", problem["type"])
+		assert.Equal(t, "The request could not be completed", problem["detail"])
+	}
+}
+
+func TestAPI_Auth(t *testing.T) {
+	suite := NewTestSuite()
+	if _, err := suite.auth.CreateUser(context.Background(), "signin", "Sign In", entities.RoleAuthor, "correct horse"); err != nil {
+		require.ErrorIs(t, err, domain_repositories.ErrUserExists, "users are shared with earlier runs")
+	}
+	post := map[string]interface{}{"title": "Title", "content": "Content", "author": "Sign In"}
+
+	req, _ := http.NewRequest("POST", "/api/v1/posts", bytes.NewBufferString(`{}`))
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusUnauthorized, w.Code, "writes need authentication")
+	assert.Contains(t, w.Header().Get("WWW-Authenticate"), "Bearer")
+	assert.Contains(t, w.Body.String(), "/problems/unauthenticated")
+
+	w = suite.send("POST", "/api/v1/auth/login", map[string]interface{}{"username": "signin", "password": "wrong password"}, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = suite.send("POST", "/api/v1/auth/login", map[string]interface{}{"username": "signin"}, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = suite.send("POST", "/api/v1/auth/login", map[string]interface{}{"username": "SignIn", "password": "correct horse"}, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	var tokens struct {
+		AccessToken  string `json:"access_token"`
+		TokenType    string `json:"token_type"`
+		ExpiresIn    int    `json:"expires_in"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tokens))
+	assert.Equal(t, "Bearer", tokens.TokenType)
+	assert.Equal(t, int(testTokenTTL.Seconds()), tokens.ExpiresIn)
+	bearer := func(token string) http.Header {
+		return http.Header{"Authorization": {"Bearer " + token}}
+	}
+
+	w = suite.send("POST", "/api/v1/posts", post, bearer(tokens.AccessToken))
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	draftURL := "/api/v1/posts/" + strconv.Itoa(int(created["id"].(float64)))
+	assert.Equal(t, http.StatusOK, suite.send("GET", draftURL, nil, bearer(tokens.AccessToken)).Code, "the token names the caller")
+	assert.Equal(t, http.StatusNotFound, suite.send("GET", draftURL, nil, nil).Code)
+
+	// A token that does not authenticate fails reads too, rather than
+	// falling back to an anonymous request.
+	assert.Equal(t, http.StatusUnauthorized, suite.send("GET", "/api/v1/posts", nil, bearer("garbage")).Code)
+	assert.Equal(t, http.StatusUnauthorized, suite.send("GET", "/api/v1/posts", nil, http.Header{"Authorization": {"Basic c2lnbmluOnB3"}}).Code)
+	assert.Equal(t, http.StatusUnauthorized, suite.send("GET", "/api/v1/posts", nil, bearer(tokens.RefreshToken)).Code)
+
+	w = suite.send("POST", "/api/v1/auth/refresh", map[string]interface{}{"refresh_token": tokens.AccessToken}, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = suite.send("POST", "/api/v1/auth/refresh", map[string]interface{}{"refresh_token": tokens.RefreshToken}, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var refreshed struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &refreshed))
+	assert.Equal(t, http.StatusOK, suite.send("GET", draftURL, nil, bearer(refreshed.AccessToken)).Code)
+	w = suite.send("POST", "/api/v1/auth/refresh", map[string]interface{}{"refresh_token": tokens.RefreshToken}, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "refresh tokens are used once")
+
+	// Signing out revokes the refresh token.
+	w = suite.send("POST", "/api/v1/auth/logout", map[string]interface{}{"refresh_token": refreshed.RefreshToken}, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = suite.send("POST", "/api/v1/auth/refresh", map[string]interface{}{"refresh_token": refreshed.RefreshToken}, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	suite.clock.Advance(testTokenTTL)
+	assert.Equal(t, http.StatusUnauthorized, suite.send("GET", draftURL, nil, bearer(tokens.AccessToken)).Code, "tokens expire")
+}
+
+func TestAPI_APIKeys(t *testing.T) {
+	suite := NewTestSuite()
+	admin := suite.as(testWriter)
+
+	decode := func(w *httptest.ResponseRecorder) map[string]interface{} {
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return body
+	}
+	issue := func(scopes ...string) (string, string) {
+		w := suite.send("POST", "/api/v1/api-keys", map[string]interface{}{"name": "Importer", "scopes": scopes}, admin)
+		require.Equal(t, http.StatusCreated, w.Code)
+		created := decode(w)
+		return created["key"].(string), strconv.Itoa(int(created["id"].(float64)))
+	}
+	withKey := func(key string) http.Header {
+		header := http.Header{}
+		header.Set("X-API-Key", key)
+		return header
+	}
+
+	w := suite.send("POST", "/api/v1/api-keys", map[string]interface{}{"name": "Importer", "scopes": []string{"posts:write", "posts:read"}}, admin)
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	created := decode(w)
+	writerKey := created["key"].(string)
+	writerURL := "/api/v1/api-keys/" + strconv.Itoa(int(created["id"].(float64)))
+	assert.True(t, strings.HasPrefix(writerKey, created["prefix"].(string)+"_"))
+	assert.Equal(t, []interface{}{"posts:read", "posts:write"}, created["scopes"])
+	assert.Nil(t, created["last_used_at"])
+
+	w = suite.send("POST", "/api/v1/api-keys", map[string]interface{}{"name": "Importer", "scopes": []string{"posts:everything"}}, admin)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"field":"scopes"`)
+	past := suite.clock.Now().Add(-time.Hour)
+	w = suite.send("POST", "/api/v1/api-keys", map[string]interface{}{"name": "Old", "scopes": []string{"posts:read"}, "expires_at": past}, admin)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Keys act for the user who issued them, within their scopes.
+	w = suite.send("POST", "/api/v1/posts", map[string]interface{}{"title": "Imported", "content": "Content", "author": testWriter}, withKey(writerKey))
+	require.Equal(t, http.StatusCreated, w.Code)
+	postURL := "/api/v1/posts/" + strconv.Itoa(int(decode(w)["id"].(float64)))
+	assert.Equal(t, http.StatusOK, suite.send("GET", postURL, nil, withKey(writerKey)).Code)
+	w = suite.send("DELETE", postURL, nil, withKey(writerKey))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "posts:delete")
+
+	readerKey, _ := issue("posts:read")
+	assert.Equal(t, http.StatusOK, suite.send("GET", postURL, nil, withKey(readerKey)).Code)
+	assert.Equal(t, http.StatusForbidden, suite.send("PUT", postURL+"/status", map[string]interface{}{"status": "review"}, withKey(readerKey)).Code)
+	deleterKey, _ := issue("posts:delete")
+	assert.Equal(t, http.StatusForbidden, suite.send("GET", postURL, nil, withKey(deleterKey)).Code, "keys need posts:read to read")
+	assert.Equal(t, http.StatusNoContent, suite.send("DELETE", postURL, nil, withKey(deleterKey)).Code)
+
+	// Managing keys needs the admin scope.
+	assert.Equal(t, http.StatusUnauthorized, suite.send("GET", "/api/v1/api-keys", nil, nil).Code)
+	assert.Equal(t, http.StatusForbidden, suite.send("GET", "/api/v1/api-keys", nil, withKey(writerKey)).Code)
+	adminKey, _ := issue("admin", "posts:read")
+	w = suite.send("POST", "/api/v1/api-keys", map[string]interface{}{"name": "Escalated", "scopes": []string{"posts:write"}}, withKey(adminKey))
+	assert.Equal(t, http.StatusForbidden, w.Code, "keys cannot grant scopes they lack")
+
+	suite.clock.Advance(time.Minute)
+	assert.Equal(t, http.StatusOK, suite.send("GET", "/api/v1/posts", nil, withKey(writerKey)).Code)
+	w = suite.send("GET", "/api/v1/api-keys", nil, withKey(adminKey))
+	require.Equal(t, http.StatusOK, w.Code)
+	listed := decode(w)
+	assert.Equal(t, float64(4), listed["total"])
+	first := listed["api_keys"].([]interface{})[0].(map[string]interface{})
+	assert.NotContains(t, first, "key", "listings never show the key")
+	assert.Equal(t, suite.clock.Now().Format(time.RFC3339), first["last_used_at"])
+
+	// Revoked, expired and unknown keys fail every request.
+	w = suite.send("DELETE", writerURL, nil, admin)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, http.StatusUnauthorized, suite.send("GET", "/api/v1/posts", nil, withKey(writerKey)).Code)
+	w = suite.send("GET", writerURL, nil, admin)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotNil(t, decode(w)["revoked_at"])
+	assert.Equal(t, http.StatusNotFound, suite.send("DELETE", "/api/v1/api-keys/99", nil, admin).Code)
+
+	expiresAt := suite.clock.Now().Add(time.Hour)
+	w = suite.send("POST", "/api/v1/api-keys", map[string]interface{}{"name": "Temporary", "scopes": []string{"posts:read"}, "expires_at": expiresAt}, admin)
+	require.Equal(t, http.StatusCreated, w.Code)
+	temporaryKey := decode(w)["key"].(string)
+	assert.Equal(t, http.StatusOK, suite.send("GET", "/api/v1/posts", nil, withKey(temporaryKey)).Code)
+	suite.clock.Advance(time.Hour)
+	assert.Equal(t, http.StatusUnauthorized, suite.send("GET", "/api/v1/posts", nil, withKey(temporaryKey)).Code)
+
+	assert.Equal(t, http.StatusUnauthorized, suite.send("GET", "/api/v1/posts", nil, withKey("rk_unknown_key")).Code)
+	header := withKey(readerKey)
+	header.Set("Authorization", admin.Get("Authorization"))
+	assert.Equal(t, http.StatusUnauthorized, suite.send("GET", "/api/v1/posts", nil, header).Code, "requests carry one credential")
+}
+
+func TestAPI_Authorization(t *testing.T) {
+	suite := NewTestSuite()
+	owner, other := suite.as("Policy Owner"), suite.as("Policy Other")
+	editor := suite.asRole("Policy Editor", entities.RoleEditor)
+	reader := suite.asRole("Policy Reader", entities.RoleReader)
+	post := func(author string) map[string]interface{} {
+		return map[string]interface{}{"title": "Title", "content": "Content", "author": author}
+	}
+
+	w := suite.send("POST", "/api/v1/posts", post("Policy Owner"), owner)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	postURL := "/api/v1/posts/" + strconv.Itoa(int(created["id"].(float64)))
+
+	w = suite.send("POST", "/api/v1/posts", post("Policy Reader"), reader)
+	assert.Equal(t, http.StatusForbidden, w.Code, "readers write no posts")
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "/problems/forbidden")
+	assert.Equal(t, http.StatusForbidden, suite.send("POST", "/api/v1/posts", post("Policy Other"), owner).Code, "authors write under their own name")
+
+	// Authors only change their own posts.
+	assert.Equal(t, http.StatusForbidden, suite.send("PUT", postURL, post("Policy Other"), other).Code)
+	assert.Equal(t, http.StatusForbidden, suite.send("PUT", postURL+"/status", map[string]interface{}{"status": "review"}, other).Code)
+	assert.Equal(t, http.StatusForbidden, suite.send("DELETE", postURL, nil, other).Code)
+	assert.Equal(t, http.StatusForbidden, suite.send("PUT", postURL, post("Policy Other"), owner).Code, "authors cannot give their posts away")
+	w = suite.send("PUT", postURL, post("Policy Owner"), owner)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// Editors change every post.
+	w = suite.send("PUT", postURL, map[string]interface{}{"title": "Edited", "content": "Content", "author": "Policy Owner"}, editor)
+	require.Equal(t, http.StatusOK, w.Code)
+	suite.publish(t, int(created["id"].(float64)))
+
+	// Readers still comment, under their own name, and only editors change
+	// the comments of others.
+	w = suite.send("POST", postURL+"/comments", map[string]interface{}{"author": "Policy Other", "content": "Nice"}, reader)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var comment map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &comment))
+	assert.Equal(t, "Policy Reader", comment["author"])
+	commentURL := postURL + "/comments/" + strconv.Itoa(int(comment["id"].(float64)))
+	assert.Equal(t, http.StatusForbidden, suite.send("PUT", commentURL, map[string]interface{}{"content": "Taken"}, other).Code)
+	assert.Equal(t, http.StatusForbidden, suite.send("PUT", commentURL, map[string]interface{}{"content": "Taken"}, owner).Code, "posts do not own their comments")
+	assert.Equal(t, http.StatusForbidden, suite.send("DELETE", commentURL, nil, other).Code)
+	assert.Equal(t, http.StatusOK, suite.send("PUT", commentURL, map[string]interface{}{"content": "Nicer"}, reader).Code)
+	assert.Equal(t, http.StatusOK, suite.send("PUT", commentURL, map[string]interface{}{"content": "Moderated"}, editor).Code)
+
+	assert.Equal(t, http.StatusForbidden, suite.send("DELETE", postURL, nil, other).Code)
+	assert.Equal(t, http.StatusNoContent, suite.send("DELETE", postURL, nil, owner).Code)
+	assert.Equal(t, http.StatusForbidden, suite.send("POST", "/api/v1/trash/"+strconv.Itoa(int(created["id"].(float64)))+"/restore", nil, other).Code)
+	assert.Equal(t, http.StatusOK, suite.send("POST", "/api/v1/trash/"+strconv.Itoa(int(created["id"].(float64)))+"/restore", nil, owner).Code)
+
+	// Authors cannot take over posts by renaming their author.
+	authorURL := "/api/v1/authors/" + strconv.Itoa(int(created["author_id"].(float64)))
+	assert.Equal(t, http.StatusForbidden, suite.send("PUT", authorURL, map[string]interface{}{"name": "Policy Other"}, other).Code)
+	assert.Equal(t, http.StatusForbidden, suite.send("PUT", authorURL, map[string]interface{}{"name": "Policy Other"}, reader).Code)
+	assert.Equal(t, http.StatusForbidden, suite.send("DELETE", authorURL, nil, other).Code)
+	assert.Equal(t, http.StatusForbidden, suite.send("PUT", postURL, post("Policy Other"), other).Code)
+	assert.Equal(t, http.StatusOK, suite.send("PUT", authorURL, map[string]interface{}{"name": "Policy Owner", "bio": "Owns things"}, owner).Code)
+
+	// Only admins manage API keys.
+	assert.Equal(t, http.StatusForbidden, suite.send("GET", "/api/v1/api-keys", nil, editor).Code)
+}
+
+func TestAPI_RateLimit(t *testing.T) {
+	suite := newTestSuite(3, 2)
+	fromIP := func(remoteAddr, forwardedFor string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/api/v1/posts", nil)
+		req.RemoteAddr = remoteAddr
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Anonymous callers are told apart by IP address.
+	for remaining := 2; remaining >= 0; remaining-- {
+		w := fromIP("10.0.0.1:1234", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "3", w.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, strconv.Itoa(remaining), w.Header().Get("RateLimit-Remaining"))
+		assert.Empty(t, w.Header().Get("Retry-After"))
+	}
+	w := fromIP("10.0.0.1:5678", "")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "/problems/rate-limited")
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "60", w.Header().Get("RateLimit-Reset"))
+	assert.Equal(t, "20", w.Header().Get("Retry-After"))
+	assert.Equal(t, http.StatusOK, fromIP("10.0.0.2:1234", "").Code)
+
+	// X-Forwarded-For only counts when sent by a trusted proxy.
+	assert.Equal(t, http.StatusTooManyRequests, fromIP("10.0.0.1:1234", "10.0.0.9").Code)
+	require.NoError(t, suite.router.SetTrustedProxies([]string{"10.0.0.1"}))
+	assert.Equal(t, http.StatusOK, fromIP("10.0.0.1:1234", "10.0.0.9").Code)
+
+	req, _ := http.NewRequest("GET", "/health", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code, "the health check is not limited")
+	assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+
+	// Failed credentials count against the address they come from, so
+	// tokens and keys cannot be guessed at will.
+	guess := func(token string) int {
+		req, _ := http.NewRequest("GET", "/api/v1/posts", nil)
+		req.RemoteAddr = "10.0.0.3:1234"
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		return w.Code
+	}
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusUnauthorized, guess("guess-"+strconv.Itoa(i)))
+	}
+	assert.Equal(t, http.StatusTooManyRequests, guess("guess-3"))
+
+	// Signed-in users and API keys have budgets of their own, with reads
+	// and writes counted apart.
+	user := suite.as(testWriter)
+	w = suite.send("POST", "/api/v1/api-keys", map[string]interface{}{"name": "Importer", "scopes": []string{"posts:write"}}, user)
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	var created map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	key := http.Header{}
+	key.Set("X-API-Key", created["key"].(string))
+
+	post := map[string]interface{}{"title": "Title", "content": "Content", "author": testWriter}
+	assert.Equal(t, http.StatusCreated, suite.send("POST", "/api/v1/posts", post, user).Code)
+	w = suite.send("POST", "/api/v1/posts", post, user)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "30", w.Header().Get("Retry-After"))
+	assert.Equal(t, http.StatusOK, suite.send("GET", "/api/v1/posts", nil, user).Code, "reads have a budget of their own")
+	assert.Equal(t, http.StatusCreated, suite.send("POST", "/api/v1/posts", post, key).Code)
+
+	// Budgets refill over time.
+	suite.clock.Advance(30 * time.Second)
+	w = suite.send("POST", "/api/v1/posts", post, user)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+}
+
+func TestAPI_IdempotentCreate(t *testing.T) {
+	suite := NewTestSuite()
+	withKey := func(header http.Header, key string) http.Header {
+		keyed := header.Clone()
+		keyed.Set("Idempotency-Key", key)
+		return keyed
+	}
+	writer := withKey(suite.as(testWriter), "import-1")
+	post := map[string]interface{}{"title": "Imported", "content": "Content", "author": testWriter}
+	postID := func(w *httptest.ResponseRecorder) int {
+		var created map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		return int(created["id"].(float64))
+	}
+
+	first := suite.send("POST", "/api/v1/posts", post, writer)
+	require.Equal(t, http.StatusCreated, first.Code)
+	assert.Empty(t, first.Header().Get("Idempotent-Replayed"))
+
+	// Retries get the original response, and create nothing.
+	retry := suite.send("POST", "/api/v1/posts", post, writer)
+	require.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	assert.Equal(t, first.Header().Get("ETag"), retry.Header().Get("ETag"))
+	assert.Equal(t, first.Header().Get("Content-Type"), retry.Header().Get("Content-Type"))
+	assert.NotEqual(t, postID(first), postID(suite.send("POST", "/api/v1/posts", post, nil)), "requests without a key are not deduplicated")
+
+	w := suite.send("POST", "/api/v1/posts", map[string]interface{}{"title": "Other", "content": "Content", "author": testWriter}, writer)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "/problems/idempotency-key-reused")
+
+	// Keys belong to the client that sent them.
+	other := suite.send("POST", "/api/v1/posts", map[string]interface{}{"title": "Imported", "content": "Content", "author": "Idempotent Other"}, withKey(suite.as("Idempotent Other"), "import-1"))
+	require.Equal(t, http.StatusCreated, other.Code)
+	assert.NotEqual(t, postID(first), postID(other))
+
+	// Failed requests are not kept, so their retries run again.
+	failing := withKey(suite.as(testWriter), "import-2")
+	assert.Equal(t, http.StatusBadRequest, suite.send("POST", "/api/v1/posts", map[string]interface{}{"title": "Imported"}, failing).Code)
+	assert.Equal(t, http.StatusCreated, suite.send("POST", "/api/v1/posts", post, failing).Code)
+
+	assert.Equal(t, http.StatusBadRequest, suite.send("POST", "/api/v1/posts", post, withKey(suite.as(testWriter), strings.Repeat("k", 256))).Code)
+
+	// Bodies are read within the limit of the handlers before anything is
+	// stored for the key.
+	oversized := withKey(suite.as(testWriter), "import-oversized")
+	huge := map[string]interface{}{"title": "Huge", "content": strings.Repeat("a", 1<<20), "author": testWriter}
+	w = suite.send("POST", "/api/v1/posts", huge, oversized)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Contains(t, w.Body.String(), "/problems/body-too-large")
+	assert.Equal(t, http.StatusRequestEntityTooLarge, suite.send("POST", "/api/v1/posts", huge, nil).Code)
+	assert.Equal(t, http.StatusCreated, suite.send("POST", "/api/v1/posts", post, oversized).Code)
+
+	// Concurrent duplicates create a single post.
+	concurrent := withKey(suite.as(testWriter), "import-3")
+	ids := make([]int, 10)
+	var wg sync.WaitGroup
+	for i := range ids {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			w := suite.send("POST", "/api/v1/posts", post, concurrent)
+			if assert.Equal(t, http.StatusCreated, w.Code) {
+				ids[i] = postID(w)
+			}
+		}(i)
+	}
+	wg.Wait()
+	for _, id := range ids {
+		assert.Equal(t, ids[0], id)
+	}
+
+	// Keys can be used again once their response expires.
+	suite.clock.Advance(time.Hour)
+	w = suite.send("POST", "/api/v1/posts", post, writer)
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.NotEqual(t, postID(first), postID(w))
+}